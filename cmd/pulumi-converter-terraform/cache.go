@@ -0,0 +1,318 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	tfconvert "github.com/pulumi/pulumi-converter-terraform/pkg/convert"
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/terraform/pkg/addrs"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/spf13/afero"
+)
+
+// Directories of a workspace that aren't part of its cache key: version control, and the providers installed by
+// `terraform init`, which can be large and don't change the conversion.
+var uncachedDirectories = map[string]bool{
+	".git":                                   true,
+	filepath.Join(".terraform", "providers"): true,
+}
+
+// conversionCache stores the results of conversions on disk, keyed by a hash of the workspace, the converter version
+// and the options, so that repeated conversions of the same workspace, like docs builds and CI retries, return the
+// result of the first one. Each result records the versions of the providers it used and is only reused while they
+// resolve to the same versions. Workspaces using remote modules shouldn't be cached, see usesRemoteModules.
+type conversionCache struct {
+	directory string
+}
+
+// cachedConversion is the result of a conversion in the cache.
+type cachedConversion struct {
+	// The converted files keyed by their slash separated path in the output.
+	Files       map[string]string    `json:"files"`
+	Diagnostics []cachedDiagnostic   `json:"diagnostics"`
+	Statistics  tfconvert.Statistics `json:"statistics"`
+	// The versions of the providers the conversion used, keyed by the name of the Terraform provider. This is empty
+	// for providers that had no mapping.
+	Providers map[string]string `json:"providers"`
+}
+
+// cachedDiagnostic is a diagnostic in the cache. This only has the fields of hcl.Diagnostic that can be read back
+// from JSON.
+type cachedDiagnostic struct {
+	Severity hcl.DiagnosticSeverity `json:"severity"`
+	Summary  string                 `json:"summary"`
+	Detail   string                 `json:"detail"`
+	Subject  *hcl.Range             `json:"subject,omitempty"`
+	Context  *hcl.Range             `json:"context,omitempty"`
+}
+
+// key returns the cache key for converting directory of fs with the given options. The conversion is written to
+// target, which isn't part of the key, otherwise the output of one conversion would change the key of the next. If
+// target is inside directory it's skipped, and if it is directory the files a conversion writes, .pp files and
+// Pulumi.yaml, are skipped. target may be empty if the conversion isn't written to fs.
+func (c *conversionCache) key(fs afero.Fs, directory, target string, options ...string) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%d\x00", version.Version, len(options))
+	for _, option := range options {
+		fmt.Fprintf(hash, "%s\x00", option)
+	}
+
+	var relTarget string
+	if target != "" {
+		rel, err := filepath.Rel(directory, target)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			relTarget = rel
+		}
+	}
+
+	// Walk visits files in lexical order, so the same workspace always hashes the same.
+	err := afero.Walk(fs, directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if uncachedDirectories[rel] || (rel == relTarget && rel != ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if relTarget == "." && (filepath.Ext(rel) == ".pp" || filepath.Base(rel) == "Pulumi.yaml") {
+			return nil
+		}
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		hash.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("hash workspace: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// load returns the cached conversion for key, or false if there isn't one or the providers it used resolve to other
+// versions in info, e.g. because a plugin has been upgraded. Entries that can't be read are treated as missing,
+// they'll be overwritten by the next conversion.
+func (c *conversionCache) load(key string, info il.ProviderInfoSource) (*cachedConversion, bool) {
+	data, err := os.ReadFile(filepath.Join(c.directory, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedConversion
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	for name, version := range cached.Providers {
+		if resolveProviderVersion(info, name) != version {
+			return nil, false
+		}
+	}
+	return &cached, true
+}
+
+// store saves the result of a conversion under key, with the versions of the providers it used.
+func (c *conversionCache) store(
+	key string, files map[string]string, diagnostics hcl.Diagnostics, statistics tfconvert.Statistics,
+	providers *providerVersions,
+) error {
+	cached := cachedConversion{
+		Files:       files,
+		Diagnostics: []cachedDiagnostic{},
+		Statistics:  statistics,
+		Providers:   providers.recorded(),
+	}
+	for _, d := range diagnostics {
+		cached.Diagnostics = append(cached.Diagnostics, cachedDiagnostic{
+			Severity: d.Severity,
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+			Subject:  d.Subject,
+			Context:  d.Context,
+		})
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	err = os.MkdirAll(c.directory, 0o755)
+	if err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+	// Write the entry under a temporary name first so concurrent conversions never read a partial entry.
+	f, err := os.CreateTemp(c.directory, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	err = os.Rename(f.Name(), filepath.Join(c.directory, key+".json"))
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return nil
+}
+
+// providerVersions is a provider info source that records the version of each provider looked up through it, so a
+// conversion can be cached with the versions of the providers it used.
+type providerVersions struct {
+	il.ProviderInfoSource
+
+	lock     sync.Mutex
+	versions map[string]string
+}
+
+func newProviderVersions(info il.ProviderInfoSource) *providerVersions {
+	return &providerVersions{ProviderInfoSource: info, versions: map[string]string{}}
+}
+
+func (p *providerVersions) GetProviderInfo(
+	registry, namespace, name, version string,
+) (*tfbridge.ProviderInfo, error) {
+	info, err := p.ProviderInfoSource.GetProviderInfo(registry, namespace, name, version)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.versions[name] = providerVersion(info, err)
+	return info, err
+}
+
+// recorded returns the versions of the providers looked up so far, keyed by name.
+func (p *providerVersions) recorded() map[string]string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	versions := make(map[string]string, len(p.versions))
+	for name, version := range p.versions {
+		versions[name] = version
+	}
+	return versions
+}
+
+// resolveProviderVersion returns the version the named provider resolves to in info.
+func resolveProviderVersion(info il.ProviderInfoSource, name string) string {
+	return providerVersion(info.GetProviderInfo("", "", name, ""))
+}
+
+// providerVersion returns the version of a provider as the version of its Pulumi provider and the Terraform provider
+// it's built from, or an empty string if it couldn't be looked up.
+func providerVersion(info *tfbridge.ProviderInfo, err error) string {
+	if err != nil || info == nil {
+		return ""
+	}
+	return info.Version + "/" + info.TFProviderVersion
+}
+
+// usesRemoteModules returns true if the module in directory, or any local module it calls, calls a module from a
+// registry or other remote source. Those are downloaded by the conversion and can change without the workspace
+// changing, so conversions of workspaces that use them aren't cached.
+func usesRemoteModules(fs afero.Fs, directory string) (bool, error) {
+	seen := map[string]bool{}
+	var visit func(directory string) (bool, error)
+	visit = func(directory string) (bool, error) {
+		if seen[directory] {
+			return false, nil
+		}
+		seen[directory] = true
+
+		module, diags := configs.NewParser(fs).LoadConfigDir(directory)
+		if diags.HasErrors() {
+			return false, diags
+		}
+		for _, call := range module.ModuleCalls {
+			local, ok := call.SourceAddr.(addrs.ModuleSourceLocal)
+			if !ok {
+				return true, nil
+			}
+			remote, err := visit(filepath.Join(directory, filepath.FromSlash(string(local))))
+			if remote || err != nil {
+				return remote, err
+			}
+		}
+		return false, nil
+	}
+	return visit(directory)
+}
+
+// diagnostics returns the diagnostics of the cached conversion.
+func (c *cachedConversion) diagnostics() hcl.Diagnostics {
+	diagnostics := hcl.Diagnostics{}
+	for _, d := range c.Diagnostics {
+		diagnostics = append(diagnostics, &hcl.Diagnostic{
+			Severity: d.Severity,
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+			Subject:  d.Subject,
+			Context:  d.Context,
+		})
+	}
+	return diagnostics
+}
+
+// readFiles returns the files in fs keyed by their slash separated path.
+func readFiles(fs afero.Fs) (map[string]string, error) {
+	files := map[string]string{}
+	err := afero.Walk(fs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimPrefix(filepath.ToSlash(path), "/")] = string(data)
+		return nil
+	})
+	return files, err
+}
+
+// writeFiles writes files, keyed by their slash separated path, to fs.
+func writeFiles(fs afero.Fs, files map[string]string) error {
+	for path, data := range files {
+		fullpath := filepath.Join("/", filepath.FromSlash(path))
+		err := fs.MkdirAll(filepath.Dir(fullpath), 0o755)
+		if err != nil {
+			return err
+		}
+		err = afero.WriteFile(fs, fullpath, []byte(data), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	tfconvert "github.com/pulumi/pulumi-converter-terraform/pkg/convert"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// providerInfoVersions is a provider info source with empty mappings for the providers it has versions for.
+type providerInfoVersions map[string]string
+
+func (p providerInfoVersions) GetProviderInfo(
+	registry, namespace, name, version string,
+) (*tfbridge.ProviderInfo, error) {
+	v, ok := p[name]
+	if !ok {
+		return nil, fmt.Errorf("no mapping for %s", name)
+	}
+	return &tfbridge.ProviderInfo{Name: name, Version: v}, nil
+}
+
+func TestConversionCache(t *testing.T) {
+	t.Parallel()
+
+	cache := &conversionCache{directory: t.TempDir()}
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/workspace/main.tf", []byte(`variable "name" {}`), 0o644))
+	key, err := cache.key(fs, "/workspace", "", "--remove-unused")
+	require.NoError(t, err)
+
+	// Version control and installed providers aren't part of the key.
+	require.NoError(t, afero.WriteFile(fs, "/workspace/.git/HEAD", []byte("ref: refs/heads/main"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/workspace/.terraform/providers/aws", []byte("binary"), 0o644))
+	sameKey, err := cache.key(fs, "/workspace", "", "--remove-unused")
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	otherKey, err := cache.key(fs, "/workspace", "", "--fold-constants")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+
+	require.NoError(t, afero.WriteFile(fs, "/workspace/main.tf", []byte(`variable "names" {}`), 0o644))
+	otherKey, err = cache.key(fs, "/workspace", "", "--remove-unused")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+
+	info := providerInfoVersions{"aws": "6.0.0"}
+	_, ok := cache.load(key, info)
+	assert.False(t, ok)
+
+	subject := hcl.Range{Filename: "main.tf", Start: hcl.InitialPos, End: hcl.InitialPos}
+	diagnostics := hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Unused variable",
+		Detail:   "The variable name isn't used",
+		Subject:  &subject,
+	}}
+	files := map[string]string{"main.pp": "config \"name\" {\n}\n"}
+	statistics := tfconvert.Statistics{
+		Resources:         1,
+		NotImplemented:    map[string]int{"function:templatefile": 1},
+		UnmappedResources: map[string]int{},
+	}
+	providers := newProviderVersions(info)
+	_, err = providers.GetProviderInfo("", "", "aws", "")
+	require.NoError(t, err)
+	_, err = providers.GetProviderInfo("", "", "google", "")
+	require.Error(t, err)
+	require.NoError(t, cache.store(key, files, diagnostics, statistics, providers))
+
+	cached, ok := cache.load(key, info)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"aws": "6.0.0/", "google": ""}, cached.Providers)
+	assert.Equal(t, files, cached.Files)
+	assert.Equal(t, diagnostics, cached.diagnostics())
+	assert.Equal(t, statistics, cached.Statistics)
+
+	dst := afero.NewMemMapFs()
+	require.NoError(t, writeFiles(dst, cached.Files))
+	written, err := readFiles(dst)
+	require.NoError(t, err)
+	assert.Equal(t, files, written)
+
+	// The conversion isn't reused once a provider it used is upgraded, or one it had no mapping for is installed.
+	_, ok = cache.load(key, providerInfoVersions{"aws": "6.1.0"})
+	assert.False(t, ok)
+	_, ok = cache.load(key, providerInfoVersions{"aws": "6.0.0", "google": "7.0.0"})
+	assert.False(t, ok)
+}
+
+func TestConversionCacheKeyTarget(t *testing.T) {
+	t.Parallel()
+
+	cache := &conversionCache{directory: t.TempDir()}
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/workspace/main.tf", []byte(`variable "name" {}`), 0o644))
+	key, err := cache.key(fs, "/workspace", "/workspace")
+	require.NoError(t, err)
+
+	// Converting into the workspace itself writes the program next to its source.
+	require.NoError(t, afero.WriteFile(fs, "/workspace/main.pp", []byte(`config "name" {}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/workspace/modules/vpc/main.pp", []byte(``), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/workspace/Pulumi.yaml", []byte(`name: workspace`), 0o644))
+	sameKey, err := cache.key(fs, "/workspace", "/workspace")
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	// A target inside the workspace is skipped entirely.
+	key, err = cache.key(fs, "/workspace", "/workspace/out")
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, "/workspace/out/data.json", []byte(`{}`), 0o644))
+	sameKey, err = cache.key(fs, "/workspace", "/workspace/out")
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	// Otherwise the output of a conversion is part of the workspace.
+	otherKey, err := cache.key(fs, "/workspace", "/converted")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+}
+
+func TestUsesRemoteModules(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/workspace/main.tf", []byte(`
+module "network" {
+    source = "./modules/network"
+}
+`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/workspace/modules/network/main.tf", []byte(`
+variable "cidr" {}
+`), 0o644))
+	remote, err := usesRemoteModules(fs, "/workspace")
+	require.NoError(t, err)
+	assert.False(t, remote)
+
+	// Remote modules called by local modules count too.
+	require.NoError(t, afero.WriteFile(fs, "/workspace/modules/network/vpc.tf", []byte(`
+module "vpc" {
+    source  = "terraform-aws-modules/vpc/aws"
+    version = "5.0.0"
+}
+`), 0o644))
+	remote, err = usesRemoteModules(fs, "/workspace")
+	require.NoError(t, err)
+	assert.True(t, remote)
+}
@@ -0,0 +1,38 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	tfconvert "github.com/pulumi/pulumi-converter-terraform/pkg/convert"
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+)
+
+// printFeatures writes the version of the converter and its optional features, with the flag that turns each on, to
+// w. Generated files list the features that were on in their header, so this says what each of them does.
+func printFeatures(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "pulumi-converter-terraform %s\n\n", version.Version); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, feature := range tfconvert.Features {
+		if _, err := fmt.Fprintf(tw, "--%s\t%s\n", feature.Name, feature.Description); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,34 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+)
+
+func TestPrintFeatures(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	require.NoError(t, printFeatures(&buffer))
+	assert.Contains(t, buffer.String(), "pulumi-converter-terraform "+version.Version+"\n")
+	assert.Regexp(t, `(?m)^--fold-constants\s+replace function calls`, buffer.String())
+	assert.Regexp(t, `(?m)^--rules-file\s+rename, retype`, buffer.String())
+}
@@ -0,0 +1,39 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	logger, err := newLogger(&buffer, "warn", "json")
+	require.NoError(t, err)
+	logger.Info("dropped")
+	logger.Warn("kept", "provider", "aws")
+	assert.NotContains(t, buffer.String(), "dropped")
+	assert.Contains(t, buffer.String(), `"msg":"kept","provider":"aws"`)
+
+	_, err = newLogger(&buffer, "loud", "text")
+	assert.ErrorContains(t, err, `invalid log level "loud"`)
+	_, err = newLogger(&buffer, "info", "xml")
+	assert.ErrorContains(t, err, `invalid log format "xml"`)
+}
@@ -17,8 +17,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -26,6 +29,7 @@ import (
 	tfconvert "github.com/pulumi/pulumi-converter-terraform/pkg/convert"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/convert"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
 	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
@@ -49,12 +53,21 @@ func (*tfConverter) ConvertState(_ context.Context,
 	}
 	providerInfoSource := il.NewMapperProviderInfoSource(mapper)
 
-	if len(req.Args) != 1 {
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	instanceNaming := flags.String("instance-names", string(tfconvert.InstanceNamingKey),
+		"how to name the instances of resources using count or for_each: key, index or hash")
+	err = flags.Parse(req.Args)
+	if err != nil {
+		return nil, fmt.Errorf("parse args: %w", err)
+	}
+
+	if flags.NArg() != 1 {
 		return nil, fmt.Errorf("expected exactly one argument")
 	}
-	path := req.Args[0]
+	path := flags.Arg(0)
 
-	return tfconvert.TranslateState(providerInfoSource, path)
+	return tfconvert.TranslateState(providerInfoSource, path,
+		tfconvert.WithInstanceNaming(tfconvert.InstanceNaming(*instanceNaming)))
 }
 
 type translatedExample struct {
@@ -62,15 +75,98 @@ type translatedExample struct {
 	Diagnostics hcl.Diagnostics `json:"diagnostics"`
 }
 
-func (*tfConverter) ConvertProgram(_ context.Context,
+func (*tfConverter) ConvertProgram(ctx context.Context,
 	req *plugin.ConvertProgramRequest,
 ) (*plugin.ConvertProgramResponse, error) {
 	flags := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
 	convertExamples := flags.String("convert-examples", "", "path to a terraform bridge example file to convert")
+	root := flags.String("root", "",
+		"directory that local module sources may be loaded from, relative paths are resolved against the source directory")
+	useLockfile := flags.Bool("use-lockfile", false,
+		"use the modules installed by `terraform init` in .terraform/modules rather than downloading them")
+	moduleLayout := flags.String("module-layout", "",
+		"directory to write modules to relative to the output directory, {module} is replaced with the module's name")
+	interfaceOnly := flags.Bool("interface-only", false,
+		"only convert the variables and outputs of the program, skipping resources, data sources and modules")
+	foldConstants := flags.Bool("fold-constants", false,
+		"replace function calls that only use literals, locals and variable defaults with their value")
+	removeUnused := flags.Bool("remove-unused", false,
+		"remove locals, data sources and variables that aren't used by any resource, module, provider or output")
+	keepVariables := flags.Bool("keep-variables", false,
+		"convert every variable to config even if it's unused, including with --remove-unused")
+	extractFiles := flags.Bool("extract-files", false,
+		"write long strings and heredocs, like the content of repository files, to files rather than string literals")
+	singleFile := flags.Bool("single-file", false,
+		"write each module to a single main.pp, ordered so everything comes after what it refers to")
+	kubeconfigTemplate := flags.Bool("kubeconfig-template", false,
+		"give kubernetes and helm providers configured from a cluster's outputs a templated kubeconfig string")
+	archive := flags.String("archive", "",
+		"zip, tar or tar.gz archive of the terraform workspace to convert instead of the source directory, - for stdin")
+	verboseDiagnostics := flags.Bool("verbose-diagnostics", false,
+		"report every diagnostic, rather than just the first few occurrences of the same diagnostic")
+	summary := flags.Bool("summary", false,
+		"replace warnings with a summary of the resources converted, warnings, errors and coverage")
+	minCoverage := flags.Float64("min-coverage", 0,
+		"fail if the percentage of resources that are mapped to Pulumi types is below this")
+	statisticsFile := flags.String("statistics-file", "",
+		"write counts of the constructs and resource types that couldn't be converted to this JSON file")
+	bootstrapProject := flags.String("bootstrap-project", "",
+		"directory to write a separate project for the bucket and lock table of the s3 state backend to")
+	backendConfig := flags.StringArray("backend-config", nil,
+		"partial backend configuration as passed to `terraform init`, a key=value pair or a .tfbackend file")
+	tfcWorkspace := flags.String("tfc-workspace", "",
+		"organization/workspace in Terraform Cloud to write stack config for from the workspace's variables")
+	tfcHostname := flags.String("tfc-hostname", "app.terraform.io",
+		"hostname of Terraform Cloud or Terraform Enterprise to read --tfc-workspace from")
+	rulesFile := flags.String("rules-file", "",
+		"YAML file of rules to rename, retype, protect or exclude resources with")
+	retainOnDelete := flags.StringSlice("retain-on-delete", nil,
+		"set retainOnDelete on resources of these types, or on common stateful types such as databases, buckets "+
+			"and keys if no types are given")
+	flags.Lookup("retain-on-delete").NoOptDefVal = strings.Join(tfconvert.DefaultRetainOnDeleteTypes, ",")
+	inlineFunctions := flags.StringSlice("inline-functions", nil,
+		"write functions of these categories as PCL builtins or expressions rather than std invokes where they can "+
+			"be, or of every category if none are given: numeric, string, collection, encoding, filesystem, hash "+
+			"or type")
+	var allFunctionCategories []string
+	for _, category := range tfconvert.FunctionCategories {
+		allFunctionCategories = append(allFunctionCategories, string(category))
+	}
+	flags.Lookup("inline-functions").NoOptDefVal = strings.Join(allFunctionCategories, ",")
+	stabilizeNames := flags.String("stabilize-names", "",
+		"replace timestamps in resource names with a suffix from config, or from a random_id with \"random\"")
+	flags.Lookup("stabilize-names").NoOptDefVal = string(tfconvert.NameStabilizationConfig)
+	localNaming := flags.String("local-naming", "",
+		"how to name the variables locals are converted to: camel (vpcCidr, the default), output (vpcCidrOutput), "+
+			"underscore (vpcCidr_) or original (vpc_cidr)")
+	convertWaits := flags.Bool("convert-waits", false,
+		"convert time_sleep resources to commands that sleep, and make resources depend on the provisioners that "+
+			"sleep or retry of the resources they refer to")
+	syncedFolders := flags.Bool("synced-folders", false,
+		"convert aws_s3_object resources that upload every file in a directory to a synced folder component")
+	logLevel := flags.String("log-level", "",
+		"log the progress of the conversion to stderr at this level: debug, info, warn or error")
+	logFormat := flags.String("log-format", "text", "format of logs: text or json")
+	stackDependenciesFile := flags.String("stack-dependencies-file", "",
+		"write the stacks the program depends on, from remote state and --tfc-workspace run triggers, to this JSON file")
+	emitGraph := flags.String("emit-graph", "",
+		"write the dependency graph of the converted program to this file, as Mermaid if it ends in .mmd or "+
+			".mermaid and DOT otherwise")
+	bugReportFile := flags.String("bug-report-file", "",
+		"if the converter hits internal errors, write a report of them to attach to an issue to this JSON file")
+	timeout := flags.Duration("timeout", 0, "stop the conversion if it takes longer than this, e.g. 10m")
+	cacheDir := flags.String("cache-dir", "",
+		"directory to cache the results of conversions in, so converting the same workspace with the same options "+
+			"again reuses the first result")
 	err := flags.Parse(req.Args)
 	if err != nil {
 		return nil, fmt.Errorf("parse args: %w", err)
 	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
 	mapper, err := convert.NewMapperClient(req.MapperTarget)
 	if err != nil {
@@ -100,7 +196,7 @@ func (*tfConverter) ConvertProgram(_ context.Context,
 			}
 
 			dst := afero.NewMemMapFs()
-			diags := tfconvert.TranslateModule(src, "/", dst, providerInfoSource)
+			diags := tfconvert.TranslateModule(src, "/", dst, providerInfoSource, tfconvert.WithContext(ctx))
 
 			pcl, err := afero.ReadFile(dst, "/"+safename+".pp")
 			if err != nil && !os.IsNotExist(err) {
@@ -136,13 +232,420 @@ func (*tfConverter) ConvertProgram(_ context.Context,
 	fs := afero.NewOsFs()
 	dst := afero.NewBasePathFs(fs, req.TargetDirectory)
 
-	diags := tfconvert.TranslateModule(fs, req.SourceDirectory, dst, providerInfoSource)
+	src, sourceDirectory := afero.Fs(fs), req.SourceDirectory
+	if *archive != "" {
+		var r io.Reader = os.Stdin
+		if *archive != "-" {
+			archivePath := *archive
+			if !filepath.IsAbs(archivePath) {
+				archivePath = filepath.Join(req.SourceDirectory, archivePath)
+			}
+			f, err := os.Open(archivePath)
+			if err != nil {
+				return nil, fmt.Errorf("open archive: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		src, sourceDirectory, err = tfconvert.ReadArchive(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []tfconvert.TranslateOption{tfconvert.WithContext(ctx), tfconvert.WithHeader(req.Args)}
+	if req.LoaderTarget != "" {
+		loader, err := schema.NewLoaderClient(req.LoaderTarget)
+		if err != nil {
+			return nil, fmt.Errorf("create loader: %w", err)
+		}
+		opts = append(opts, tfconvert.WithSchemaLoader(loader))
+	}
+	if *root != "" {
+		rootPath := *root
+		if !filepath.IsAbs(rootPath) {
+			rootPath = filepath.Join(sourceDirectory, rootPath)
+		}
+		opts = append(opts, tfconvert.WithRoot(rootPath))
+	}
+	if *useLockfile {
+		opts = append(opts, tfconvert.WithUseLockfile())
+	}
+	if *moduleLayout != "" {
+		opts = append(opts, tfconvert.WithModuleLayout(*moduleLayout))
+	}
+	if *interfaceOnly {
+		opts = append(opts, tfconvert.WithInterfaceOnly())
+	}
+	if *foldConstants {
+		opts = append(opts, tfconvert.WithConstantFolding())
+	}
+	if *removeUnused {
+		opts = append(opts, tfconvert.WithRemoveUnused())
+	}
+	if *keepVariables {
+		opts = append(opts, tfconvert.WithKeepVariables())
+	}
+	if *extractFiles {
+		opts = append(opts, tfconvert.WithExtractFiles())
+	}
+	if *kubeconfigTemplate {
+		opts = append(opts, tfconvert.WithKubeconfigTemplate())
+	}
+	if *singleFile {
+		opts = append(opts, tfconvert.WithSingleFile())
+	}
+	if *verboseDiagnostics {
+		opts = append(opts, tfconvert.WithVerboseDiagnostics())
+	}
+	if *summary {
+		opts = append(opts, tfconvert.WithSummary())
+	}
+	if *minCoverage > 0 {
+		opts = append(opts, tfconvert.WithMinimumCoverage(*minCoverage))
+	}
+	if *bootstrapProject != "" {
+		opts = append(opts, tfconvert.WithBootstrapProject())
+	}
+	if *logLevel != "" {
+		logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, tfconvert.WithLogger(logger))
+	}
+	if len(*backendConfig) > 0 {
+		opts = append(opts, tfconvert.WithBackendConfig(*backendConfig...))
+	}
+	if len(*inlineFunctions) > 0 {
+		var categories []tfconvert.FunctionCategory
+		for _, category := range *inlineFunctions {
+			categories = append(categories, tfconvert.FunctionCategory(category))
+		}
+		opts = append(opts, tfconvert.WithInlineFunctions(categories...))
+	}
+	if *convertWaits {
+		opts = append(opts, tfconvert.WithWaits())
+	}
+	if *stabilizeNames != "" {
+		opts = append(opts, tfconvert.WithStableNames(tfconvert.NameStabilization(*stabilizeNames)))
+	}
+	if *localNaming != "" {
+		opts = append(opts, tfconvert.WithLocalNaming(tfconvert.LocalNaming(*localNaming)))
+	}
+	if *syncedFolders {
+		opts = append(opts, tfconvert.WithSyncedFolders())
+	}
+	if len(*retainOnDelete) > 0 {
+		opts = append(opts, tfconvert.WithRetainOnDelete(*retainOnDelete))
+	}
+	var rulesBytes []byte
+	if *rulesFile != "" {
+		rulesPath := *rulesFile
+		if !filepath.IsAbs(rulesPath) {
+			rulesPath = filepath.Join(req.SourceDirectory, rulesPath)
+		}
+		rulesBytes, err = os.ReadFile(rulesPath)
+		if err != nil {
+			return nil, fmt.Errorf("read rules: %w", err)
+		}
+		rules, err := tfconvert.ParseRules(rulesBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", rulesPath, err)
+		}
+		opts = append(opts, tfconvert.WithRules(rules))
+	}
+	var runTriggers []string
+	if *tfcWorkspace != "" {
+		token, err := terraformCloudToken(*tfcHostname)
+		if err != nil {
+			return nil, err
+		}
+		variables, err := tfconvert.ReadTerraformCloudVariables(ctx, http.DefaultClient,
+			"https://"+*tfcHostname, token, *tfcWorkspace)
+		if err != nil {
+			return nil, fmt.Errorf("read terraform cloud variables: %w", err)
+		}
+		opts = append(opts, tfconvert.WithTerraformCloudVariables(*tfcWorkspace, variables))
+
+		if *stackDependenciesFile != "" {
+			runTriggers, err = tfconvert.ReadTerraformCloudRunTriggers(ctx, http.DefaultClient,
+				"https://"+*tfcHostname, token, *tfcWorkspace)
+			if err != nil {
+				return nil, fmt.Errorf("read terraform cloud run triggers: %w", err)
+			}
+		}
+	}
+
+	var statistics tfconvert.Statistics
+	if *statisticsFile != "" {
+		opts = append(opts, tfconvert.WithStatistics(func(s tfconvert.Statistics) {
+			statistics = s
+		}))
+	}
+	var stackDependencies []tfconvert.StackDependency
+	if *stackDependenciesFile != "" {
+		opts = append(opts, tfconvert.WithStackDependencies(func(d []tfconvert.StackDependency) {
+			stackDependencies = d
+		}))
+	}
+	var graph tfconvert.Graph
+	if *emitGraph != "" {
+		opts = append(opts, tfconvert.WithGraph(func(g tfconvert.Graph) {
+			graph = g
+		}))
+	}
+	var bugReport tfconvert.BugReport
+	opts = append(opts, tfconvert.WithBugReport(func(r tfconvert.BugReport) {
+		bugReport = r
+	}))
+
+	// Conversions that write files other than the program, or read from Terraform Cloud, aren't cached.
+	var cache *conversionCache
+	if *cacheDir != "" && *statisticsFile == "" && *stackDependenciesFile == "" && *bugReportFile == "" &&
+		*emitGraph == "" && *tfcWorkspace == "" {
+		cache = &conversionCache{directory: *cacheDir}
+	}
+	// Nor are conversions using remote modules, which can change without the workspace changing, or of workspaces
+	// that fail to load.
+	if cache != nil {
+		if remote, err := usesRemoteModules(src, sourceDirectory); err != nil || remote {
+			cache = nil
+		}
+	}
+	var diags hcl.Diagnostics
+	var cacheKey string
+	var cached *cachedConversion
+	var providers *providerVersions
+	if cache != nil {
+		// The rules file can be outside the workspace, so its content is part of the key as well as its path.
+		options := append(append([]string{}, req.Args...), string(rulesBytes))
+		// Conversions of archives aren't written next to their source.
+		target := req.TargetDirectory
+		if *archive != "" {
+			target = ""
+		}
+		cacheKey, err = cache.key(src, sourceDirectory, target, options...)
+		if err != nil {
+			return nil, err
+		}
+		cached, _ = cache.load(cacheKey, providerInfoSource)
+		providers = newProviderVersions(providerInfoSource)
+	}
+	if cached != nil {
+		diags = cached.diagnostics()
+		err = writeFiles(dst, cached.Files)
+		if err != nil {
+			return nil, fmt.Errorf("write cached conversion: %w", err)
+		}
+	} else {
+		var info il.ProviderInfoSource = providerInfoSource
+		if providers != nil {
+			info = providers
+		}
+		diags = tfconvert.TranslateModule(src, sourceDirectory, dst, info, opts...)
+		// Failed conversions aren't cached, they may have failed for reasons that won't last, like a timeout.
+		if cache != nil && !diags.HasErrors() && len(bugReport.Errors) == 0 {
+			files, err := readFiles(dst)
+			if err != nil {
+				return nil, fmt.Errorf("read converted files: %w", err)
+			}
+			err = cache.store(cacheKey, files, diags, tfconvert.Statistics{}, providers)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Only the converted program is generated by `pulumi convert`, so the bootstrap project has to be moved out of
+	// the target directory to be kept.
+	if *bootstrapProject != "" {
+		bootstrapPath := *bootstrapProject
+		if !filepath.IsAbs(bootstrapPath) {
+			bootstrapPath = filepath.Join(req.SourceDirectory, bootstrapPath)
+		}
+		err = movePath(dst, "/bootstrap", bootstrapPath)
+		if err != nil {
+			return nil, fmt.Errorf("write bootstrap project: %w", err)
+		}
+	}
+	// Like the bootstrap project the stack config is written next to the source, which is where `pulumi convert`
+	// writes the converted program by default.
+	if *tfcWorkspace != "" {
+		name := "Pulumi." + path.Base(*tfcWorkspace) + ".yaml"
+		err = movePath(dst, "/"+name, filepath.Join(req.SourceDirectory, name))
+		if err != nil {
+			return nil, fmt.Errorf("write stack config: %w", err)
+		}
+	}
+
+	if *statisticsFile != "" {
+		statisticsPath := *statisticsFile
+		if !filepath.IsAbs(statisticsPath) {
+			statisticsPath = filepath.Join(req.SourceDirectory, statisticsPath)
+		}
+		statisticsBytes, err := json.MarshalIndent(statistics, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal statistics: %w", err)
+		}
+		err = os.WriteFile(statisticsPath, statisticsBytes, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("write statistics: %w", err)
+		}
+	}
+
+	if *stackDependenciesFile != "" {
+		// Workspaces triggering this one are assumed to have been converted to stacks named like remote state.
+		for _, source := range runTriggers {
+			stackDependencies = append(stackDependencies, tfconvert.StackDependency{
+				Kind:   "run_trigger",
+				Source: source,
+				Stack:  source + "/" + path.Base(source),
+			})
+		}
+		stackDependenciesPath := *stackDependenciesFile
+		if !filepath.IsAbs(stackDependenciesPath) {
+			stackDependenciesPath = filepath.Join(req.SourceDirectory, stackDependenciesPath)
+		}
+		stackDependenciesBytes, err := json.MarshalIndent(stackDependencies, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal stack dependencies: %w", err)
+		}
+		err = os.WriteFile(stackDependenciesPath, stackDependenciesBytes, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("write stack dependencies: %w", err)
+		}
+	}
+
+	if *emitGraph != "" {
+		graphPath := *emitGraph
+		if !filepath.IsAbs(graphPath) {
+			graphPath = filepath.Join(req.SourceDirectory, graphPath)
+		}
+		graphText := graph.DOT()
+		if ext := filepath.Ext(graphPath); ext == ".mmd" || ext == ".mermaid" {
+			graphText = graph.Mermaid()
+		}
+		err = os.WriteFile(graphPath, []byte(graphText), 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("write graph: %w", err)
+		}
+	}
+
+	if len(bugReport.Errors) > 0 {
+		if *bugReportFile == "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Write a bug report",
+				Detail: "The converter hit internal errors. Run the conversion again with --bug-report-file to write " +
+					"a report of them, with the program's string literals redacted, to attach to an issue.",
+			})
+		} else {
+			bugReportPath := *bugReportFile
+			if !filepath.IsAbs(bugReportPath) {
+				bugReportPath = filepath.Join(req.SourceDirectory, bugReportPath)
+			}
+			bugReportBytes, err := json.MarshalIndent(bugReport, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("marshal bug report: %w", err)
+			}
+			err = os.WriteFile(bugReportPath, bugReportBytes, 0o600)
+			if err != nil {
+				return nil, fmt.Errorf("write bug report: %w", err)
+			}
+		}
+	}
+
 	return &plugin.ConvertProgramResponse{
 		Diagnostics: diags,
 	}, nil
 }
 
+// terraformCloudToken returns the API token for the given Terraform Cloud hostname. Like terraform this is read from
+// the TF_TOKEN_<hostname> environment variable, or the credentials file written by `terraform login`.
+func terraformCloudToken(hostname string) (string, error) {
+	// Dots in the hostname are replaced with underscores, and dashes with double underscores.
+	env := "TF_TOKEN_" + strings.NewReplacer(".", "_", "-", "__").Replace(hostname)
+	if token := os.Getenv(env); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	credentialsBytes, err := os.ReadFile(filepath.Join(home, ".terraform.d", "credentials.tfrc.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read terraform credentials: %w", err)
+	}
+	var credentials struct {
+		Credentials map[string]struct {
+			Token string `json:"token"`
+		} `json:"credentials"`
+	}
+	if err == nil {
+		if err := json.Unmarshal(credentialsBytes, &credentials); err != nil {
+			return "", fmt.Errorf("parse terraform credentials: %w", err)
+		}
+	}
+	if token := credentials.Credentials[hostname].Token; token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("no API token for %s, set %s or run `terraform login`", hostname, env)
+}
+
+// movePath moves the file or directory at path in fs to target on disk, it does nothing if path doesn't exist.
+func movePath(fs afero.Fs, path, target string) error {
+	exists, err := afero.Exists(fs, path)
+	if err != nil || !exists {
+		return err
+	}
+	err = afero.Walk(fs, path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(target, rel), 0o755)
+		}
+		data, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(target, rel), data, 0o600)
+	})
+	if err != nil {
+		return err
+	}
+	return fs.RemoveAll(path)
+}
+
 func main() {
+	// When run as `pulumi-converter-terraform serve` we're a standalone conversion service, not a plugin.
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := serve(os.Args[2:]); err != nil {
+			log.Fatalf("fatal: %v", err)
+		}
+		return
+	}
+	// `pulumi-converter-terraform --features` lists the optional parts of the conversion.
+	if len(os.Args) > 1 && os.Args[1] == "--features" {
+		if err := printFeatures(os.Stdout); err != nil {
+			log.Fatalf("fatal: %v", err)
+		}
+		return
+	}
+	// `pulumi-converter-terraform verify` compares a converted project with a plan of the original program.
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := verify(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("fatal: %v", err)
+		}
+		return
+	}
+
 	// Fire up a gRPC server, letting the kernel choose a free port for us.
 	handle, err := rpcutil.ServeWithOptions(rpcutil.ServeOptions{
 		Init: func(srv *grpc.Server) error {
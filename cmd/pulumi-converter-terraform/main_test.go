@@ -22,6 +22,7 @@ import (
 
 	"github.com/pulumi/pulumi/pkg/v3/codegen/convert"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
 
@@ -101,3 +102,58 @@ func TestExamplesJson(t *testing.T) {
 	}
 	require.Equal(t, expectedJSON, resultJSON)
 }
+
+func TestMovePath(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/main.pp", []byte("main"), 0o600))
+	require.NoError(t, afero.WriteFile(fs, "/bootstrap/main.pp", []byte("bootstrap"), 0o600))
+	require.NoError(t, afero.WriteFile(fs, "/bootstrap/nested/Pulumi.yaml", []byte("project"), 0o600))
+
+	target := filepath.Join(t.TempDir(), "bootstrap")
+	err := movePath(fs, "/bootstrap", target)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(target, "main.pp"))
+	require.NoError(t, err)
+	require.Equal(t, "bootstrap", string(data))
+	data, err = os.ReadFile(filepath.Join(target, "nested", "Pulumi.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "project", string(data))
+
+	exists, err := afero.DirExists(fs, "/bootstrap")
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = afero.Exists(fs, "/main.pp")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Nothing to move is fine.
+	err = movePath(fs, "/bootstrap", target)
+	require.NoError(t, err)
+}
+
+//nolint:paralleltest // Sets environment variables.
+func TestTerraformCloudToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TF_TOKEN_tfe_example__corp_com", "")
+
+	_, err := terraformCloudToken("tfe.example-corp.com")
+	require.ErrorContains(t, err, "set TF_TOKEN_tfe_example__corp_com or run `terraform login`")
+
+	err = os.MkdirAll(filepath.Join(home, ".terraform.d"), 0o700)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(home, ".terraform.d", "credentials.tfrc.json"),
+		[]byte(`{"credentials": {"tfe.example-corp.com": {"token": "from-file"}}}`), 0o600)
+	require.NoError(t, err)
+	token, err := terraformCloudToken("tfe.example-corp.com")
+	require.NoError(t, err)
+	require.Equal(t, "from-file", token)
+
+	t.Setenv("TF_TOKEN_tfe_example__corp_com", "from-env")
+	token, err = terraformCloudToken("tfe.example-corp.com")
+	require.NoError(t, err)
+	require.Equal(t, "from-env", token)
+}
@@ -0,0 +1,248 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/blang/semver"
+	"github.com/hashicorp/hcl/v2"
+	tfconvert "github.com/pulumi/pulumi-converter-terraform/pkg/convert"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/convert"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slog"
+)
+
+// maxRequestSize is the largest archive that can be sent to the convert endpoint.
+const maxRequestSize = 256 << 20
+
+type convertResponse struct {
+	// The generated PCL files keyed by their path in the output.
+	Files       map[string]string `json:"files"`
+	Diagnostics hcl.Diagnostics   `json:"diagnostics"`
+	// Counts of what couldn't be converted.
+	Statistics tfconvert.Statistics `json:"statistics"`
+}
+
+// queryOptions reads the options for a conversion from the query string of a request, these match the flags that
+// can be passed to `pulumi convert`.
+func queryOptions(query url.Values, sourceDirectory string) ([]tfconvert.TranslateOption, error) {
+	var opts []tfconvert.TranslateOption
+	if root := query.Get("root"); root != "" {
+		// The root has to be in the archive.
+		opts = append(opts, tfconvert.WithRoot(filepath.Join(sourceDirectory, root)))
+	}
+	if value := query.Get("use-lockfile"); value != "" {
+		useLockfile, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid use-lockfile %q: %w", value, err)
+		}
+		if useLockfile {
+			opts = append(opts, tfconvert.WithUseLockfile())
+		}
+	}
+	if moduleLayout := query.Get("module-layout"); moduleLayout != "" {
+		opts = append(opts, tfconvert.WithModuleLayout(moduleLayout))
+	}
+	if value := query.Get("interface-only"); value != "" {
+		interfaceOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interface-only %q: %w", value, err)
+		}
+		if interfaceOnly {
+			opts = append(opts, tfconvert.WithInterfaceOnly())
+		}
+	}
+	if value := query.Get("verbose-diagnostics"); value != "" {
+		verboseDiagnostics, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verbose-diagnostics %q: %w", value, err)
+		}
+		if verboseDiagnostics {
+			opts = append(opts, tfconvert.WithVerboseDiagnostics())
+		}
+	}
+	return append(opts, tfconvert.WithHeader(queryArgs(query))), nil
+}
+
+// queryArgs returns the options in query as the flags they match, for the header of the generated files.
+func queryArgs(query url.Values) []string {
+	var args []string
+	for key, values := range query {
+		for _, value := range values {
+			args = append(args, "--"+key+"="+value)
+		}
+	}
+	sort.Strings(args)
+	return args
+}
+
+// convertHandler returns a handler that converts the terraform workspace archive POSTed to it, responding with the
+// generated PCL files, diagnostics and statistics as JSON. The same provider info source is used for every request so
+// mappings only need to be loaded once. The progress of each conversion is logged to logger. If cache isn't nil the
+// results of conversions are cached in it.
+func convertHandler(info il.ProviderInfoSource, logger *slog.Logger, cache *conversionCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "expected a POST of a terraform workspace archive", http.StatusMethodNotAllowed)
+			return
+		}
+
+		src, sourceDirectory, err := tfconvert.ReadArchive(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts, err := queryOptions(r.URL.Query(), sourceDirectory)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// The provider versions a conversion uses are recorded for the cache, in a source just for this request.
+		requestInfo := info
+		var cacheKey string
+		var providers *providerVersions
+		useCache := cache != nil
+		if useCache {
+			remote, err := usesRemoteModules(src, sourceDirectory)
+			useCache = err == nil && !remote
+		}
+		if useCache {
+			cacheKey, err = cache.key(src, sourceDirectory, "", r.URL.Query().Encode())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if cached, ok := cache.load(cacheKey, info); ok {
+				logger.Debug("using cached conversion", "remote", r.RemoteAddr, "key", cacheKey)
+				writeResponse(w, logger, convertResponse{
+					Files:       cached.Files,
+					Diagnostics: cached.diagnostics(),
+					Statistics:  cached.Statistics,
+				})
+				return
+			}
+			providers = newProviderVersions(info)
+			requestInfo = providers
+		}
+
+		var response convertResponse
+		opts = append(opts, tfconvert.WithStatistics(func(s tfconvert.Statistics) {
+			response.Statistics = s
+		}), tfconvert.WithLogger(logger.With("remote", r.RemoteAddr)), tfconvert.WithContext(r.Context()))
+
+		dst := afero.NewMemMapFs()
+		response.Diagnostics = tfconvert.TranslateModule(src, sourceDirectory, dst, requestInfo, opts...)
+		response.Files, err = readFiles(dst)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("read converted files: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Failed conversions aren't cached, they may have failed for reasons that won't last, like a timeout.
+		if useCache && !response.Diagnostics.HasErrors() {
+			err = cache.store(cacheKey, response.Files, response.Diagnostics, response.Statistics, providers)
+			if err != nil {
+				logger.Warn("failed to cache conversion", "error", err)
+			}
+		}
+
+		writeResponse(w, logger, response)
+	})
+}
+
+// writeResponse writes the result of a conversion as JSON.
+func writeResponse(w http.ResponseWriter, logger *slog.Logger, response convertResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		logger.Error("failed to write response", "error", err)
+	}
+}
+
+// installedProviderInfo returns a source of provider mappings read from the installed resource plugins, and a
+// function to call once it's no longer needed. Plugins aren't installed on demand, only what's already installed is
+// used.
+func installedProviderInfo() (il.ProviderInfoSource, func(), error) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get working directory: %w", err)
+	}
+	sink := diag.DefaultSink(os.Stderr, os.Stderr, diag.FormatOptions{Color: colors.Never})
+	pctx, err := plugin.NewContext(sink, sink, nil, nil, pwd, nil, false, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create plugin context: %w", err)
+	}
+
+	installProvider := func(tokens.Package) *semver.Version { return nil }
+	mapper, err := convert.NewPluginMapper(
+		convert.DefaultWorkspace(), convert.ProviderFactoryFromHost(pctx.Host),
+		"terraform", nil, installProvider)
+	if err != nil {
+		pctx.Close()
+		return nil, nil, fmt.Errorf("create provider mapper: %w", err)
+	}
+	closeInfo := func() {
+		pctx.Close()
+	}
+	return il.NewCachingProviderInfoSource(il.NewMapperProviderInfoSource(mapper)), closeInfo, nil
+}
+
+// serve runs the converter as a long running HTTP service, rather than as a plugin for the Pulumi CLI. Mappings are
+// read from the installed resource plugins, as `pulumi convert` does.
+func serve(args []string) error {
+	flags := pflag.NewFlagSet("serve", pflag.ContinueOnError)
+	address := flags.String("address", "localhost:8080", "address to listen on")
+	logLevel := flags.String("log-level", "info", "level to log at: debug, info, warn or error")
+	logFormat := flags.String("log-format", "text", "format of logs: text or json")
+	cacheDir := flags.String("cache-dir", "", "directory to cache the results of conversions in")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse args: %w", err)
+	}
+	logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		return err
+	}
+
+	info, closeInfo, err := installedProviderInfo()
+	if err != nil {
+		return err
+	}
+	defer closeInfo()
+
+	var cache *conversionCache
+	if *cacheDir != "" {
+		cache = &conversionCache{directory: *cacheDir}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/convert", convertHandler(info, logger, cache))
+	logger.Info("listening", "address", *address)
+	return http.ListenAndServe(*address, mux)
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+)
+
+func TestConvertHandler(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	source := `variable "name" {
+  type = string
+}
+
+output "greeting" {
+  value = "Hello ${var.name}"
+}
+`
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "main.tf", Mode: 0o644, Size: int64(len(source))}))
+	_, err := tw.Write([]byte(source))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	logger, err := newLogger(io.Discard, "debug", "text")
+	require.NoError(t, err)
+	handler := convertHandler(il.NewMapperProviderInfoSource(&testMapper{}), logger, nil)
+
+	t.Run("convert", func(t *testing.T) {
+		t.Parallel()
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(archive.Bytes())))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var result convertResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Empty(t, result.Diagnostics)
+		assert.Equal(t, map[string]string{
+			"main.pp": "// Converted from Terraform by pulumi-converter-terraform " + version.Version + ".\n" +
+				"// Options: none\n// Features: none\n\n" + `config "name" "string" {
+}
+
+output "greeting" {
+  value = "Hello ${name}"
+}
+`,
+		}, result.Files)
+	})
+
+	t.Run("header", func(t *testing.T) {
+		t.Parallel()
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/convert?interface-only=true",
+			bytes.NewReader(archive.Bytes())))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var result convertResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Contains(t, result.Files["main.pp"], "// Options: --interface-only=true\n// Features: interface-only\n")
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		t.Parallel()
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/convert?interface-only=maybe",
+			bytes.NewReader(archive.Bytes())))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("not a post", func(t *testing.T) {
+		t.Parallel()
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/convert", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	})
+}
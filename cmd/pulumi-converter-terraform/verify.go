@@ -0,0 +1,134 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	tfconvert "github.com/pulumi/pulumi-converter-terraform/pkg/convert"
+	"github.com/spf13/pflag"
+)
+
+// verify compares a `terraform plan` of the original program with a `pulumi preview` of the converted project, and
+// reports what the conversion changed. The project is previewed with the stack's state as it is, so resources
+// should already have been imported from the Terraform state for updates to show as differences.
+func verify(args []string, stdout io.Writer) error {
+	flags := pflag.NewFlagSet("verify", pflag.ContinueOnError)
+	planFile := flags.String("plan", "", "output of `terraform show -json` for a plan of the original program")
+	previewFile := flags.String("preview", "",
+		"output of `pulumi preview --json` for the converted project, rather than running a preview")
+	stack := flags.String("stack", "", "stack of the converted project to preview")
+	jsonOutput := flags.Bool("json", false, "write the comparison as JSON rather than text")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("parse args: %w", err)
+	}
+	if *planFile == "" {
+		return fmt.Errorf("--plan is required")
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("expected at most one argument, the directory of the converted project")
+	}
+	projectDirectory := "."
+	if flags.NArg() == 1 {
+		projectDirectory = flags.Arg(0)
+	}
+
+	plan, err := os.Open(*planFile)
+	if err != nil {
+		return fmt.Errorf("read plan: %w", err)
+	}
+	defer plan.Close()
+
+	var preview io.Reader
+	if *previewFile != "" {
+		file, err := os.Open(*previewFile)
+		if err != nil {
+			return fmt.Errorf("read preview: %w", err)
+		}
+		defer file.Close()
+		preview = file
+	} else {
+		previewBytes, err := runPreview(projectDirectory, *stack)
+		if err != nil {
+			return err
+		}
+		preview = bytes.NewReader(previewBytes)
+	}
+
+	info, closeInfo, err := installedProviderInfo()
+	if err != nil {
+		return err
+	}
+	defer closeInfo()
+
+	comparison, err := tfconvert.ComparePlan(info, plan, preview)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(comparison); err != nil {
+			return fmt.Errorf("write comparison: %w", err)
+		}
+	} else {
+		writeComparison(stdout, comparison)
+	}
+	if len(comparison.Differences) > 0 {
+		return fmt.Errorf("the converted project differs from the plan in %d places", len(comparison.Differences))
+	}
+	return nil
+}
+
+// runPreview runs `pulumi preview --json` for the project in directory and returns its output.
+func runPreview(directory, stack string) ([]byte, error) {
+	args := []string{"preview", "--json", "--non-interactive"}
+	if stack != "" {
+		args = append(args, "--stack", stack)
+	}
+	cmd := exec.Command("pulumi", args...)
+	cmd.Dir = directory
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pulumi preview: %w: %s", err, stderr.String())
+	}
+	return output, nil
+}
+
+// writeComparison writes a comparison for people to read.
+func writeComparison(w io.Writer, comparison *tfconvert.PlanComparison) {
+	fmt.Fprintf(w, "%d resources matched, %d differences\n", comparison.Resources, len(comparison.Differences))
+	for _, difference := range comparison.Differences {
+		switch {
+		case difference.URN == "":
+			fmt.Fprintf(w, "- %s: not in the preview\n", difference.Address)
+		case difference.Address == "":
+			fmt.Fprintf(w, "+ %s: not in the plan\n", difference.URN)
+		default:
+			tf, _ := json.Marshal(difference.Terraform)
+			pulumi, _ := json.Marshal(difference.Pulumi)
+			fmt.Fprintf(w, "~ %s: %s is %s in the plan and %s in the preview\n",
+				difference.Address, difference.Property, tf, pulumi)
+		}
+	}
+}
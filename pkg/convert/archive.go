@@ -0,0 +1,193 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// maxArchiveSize is the most data we'll read out of an archive, this guards against archives that decompress to far
+// more than their own size.
+const maxArchiveSize = 1 << 30
+
+// ReadArchive reads a zip, tar or gzipped tar archive of a terraform workspace into an in memory filesystem. Nothing
+// is written to disk, entries that would be outside of the archive root are rejected and links and other special
+// files are skipped. The returned directory is the one to convert, this is the root of the archive unless everything
+// in it is in a single directory (as is common for archives of repositories), in which case it is that directory.
+func ReadArchive(r io.Reader) (afero.Fs, string, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, "", fmt.Errorf("read archive: %w", err)
+	}
+
+	fs := afero.NewMemMapFs()
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		// zip needs random access so we have to read the whole thing into memory first.
+		data, err := io.ReadAll(io.LimitReader(br, maxArchiveSize+1))
+		if err != nil {
+			return nil, "", fmt.Errorf("read archive: %w", err)
+		}
+		if len(data) > maxArchiveSize {
+			return nil, "", fmt.Errorf("archive is larger than %d bytes", maxArchiveSize)
+		}
+		err = readZip(fs, data)
+		if err != nil {
+			return nil, "", err
+		}
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, "", fmt.Errorf("read archive: %w", err)
+		}
+		defer gz.Close()
+		err = readTar(fs, gz)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		err = readTar(fs, br)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// If everything is in one top level directory then that's the workspace to convert.
+	infos, err := afero.ReadDir(fs, "/")
+	if err != nil {
+		return nil, "", fmt.Errorf("read archive: %w", err)
+	}
+	if len(infos) == 1 && infos[0].IsDir() {
+		return fs, "/" + infos[0].Name(), nil
+	}
+	return fs, "/", nil
+}
+
+// archivePath returns the path in the filesystem to write the named archive entry to, or an error if the entry would
+// be outside of the archive root.
+func archivePath(name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+		return "", fmt.Errorf("archive entry %q is outside of the archive", name)
+	}
+	return path.Join("/", clean), nil
+}
+
+// writeArchiveFile copies an archive entry into fs, returning the number of bytes written.
+func writeArchiveFile(fs afero.Fs, name string, r io.Reader, remaining int64) (int64, error) {
+	dest, err := archivePath(name)
+	if err != nil {
+		return 0, err
+	}
+	err = fs.MkdirAll(path.Dir(dest), 0o755)
+	if err != nil {
+		return 0, fmt.Errorf("write archive entry %q: %w", name, err)
+	}
+	f, err := fs.Create(dest)
+	if err != nil {
+		return 0, fmt.Errorf("write archive entry %q: %w", name, err)
+	}
+	defer f.Close()
+	n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	if err != nil {
+		return 0, fmt.Errorf("read archive entry %q: %w", name, err)
+	}
+	if n > remaining {
+		return 0, fmt.Errorf("archive is larger than %d bytes", maxArchiveSize)
+	}
+	return n, nil
+}
+
+func readTar(fs afero.Fs, r io.Reader) error {
+	remaining := int64(maxArchiveSize)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			dest, err := archivePath(header.Name)
+			if err != nil {
+				return err
+			}
+			err = fs.MkdirAll(dest, 0o755)
+			if err != nil {
+				return fmt.Errorf("write archive entry %q: %w", header.Name, err)
+			}
+		case tar.TypeReg:
+			n, err := writeArchiveFile(fs, header.Name, tr, remaining)
+			if err != nil {
+				return err
+			}
+			remaining -= n
+		}
+		// Everything else (links, devices, etc) is skipped, we never want to follow links out of the archive.
+	}
+}
+
+func readZip(fs afero.Fs, data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+
+	remaining := int64(maxArchiveSize)
+	for _, file := range zr.File {
+		mode := file.Mode()
+		if mode.IsDir() {
+			dest, err := archivePath(file.Name)
+			if err != nil {
+				return err
+			}
+			err = fs.MkdirAll(dest, 0o755)
+			if err != nil {
+				return fmt.Errorf("write archive entry %q: %w", file.Name, err)
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("read archive entry %q: %w", file.Name, err)
+		}
+		n, err := writeArchiveFile(fs, file.Name, rc, remaining)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+	link    string
+}
+
+func makeTar(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content))}
+		if entry.link != "" {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.link
+			header.Size = 0
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		if entry.link == "" {
+			_, err := tw.Write([]byte(entry.content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestReadArchive(t *testing.T) {
+	t.Parallel()
+
+	entries := []archiveEntry{
+		{name: "main.tf", content: "# main"},
+		{name: "modules/vpc/main.tf", content: "# vpc"},
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"tar", makeTar(t, entries)},
+		{"tar.gz", gzipBytes(t, makeTar(t, entries))},
+		{"zip", makeZip(t, entries)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, dir, err := ReadArchive(bytes.NewReader(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, "/", dir)
+
+			main, err := afero.ReadFile(fs, "/main.tf")
+			require.NoError(t, err)
+			assert.Equal(t, "# main", string(main))
+			vpc, err := afero.ReadFile(fs, "/modules/vpc/main.tf")
+			require.NoError(t, err)
+			assert.Equal(t, "# vpc", string(vpc))
+		})
+	}
+
+	t.Run("single directory", func(t *testing.T) {
+		t.Parallel()
+
+		data := makeZip(t, []archiveEntry{
+			{name: "repo-main/main.tf", content: "# main"},
+			{name: "repo-main/modules/vpc/main.tf", content: "# vpc"},
+		})
+		fs, dir, err := ReadArchive(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "/repo-main", dir)
+		_, err = afero.ReadFile(fs, "/repo-main/main.tf")
+		require.NoError(t, err)
+	})
+
+	t.Run("links are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		data := makeTar(t, []archiveEntry{
+			{name: "main.tf", content: "# main"},
+			{name: "passwd.tf", link: "/etc/passwd"},
+		})
+		fs, _, err := ReadArchive(bytes.NewReader(data))
+		require.NoError(t, err)
+		exists, err := afero.Exists(fs, "/passwd.tf")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	for _, name := range []string{"../main.tf", "modules/../../main.tf"} {
+		name := name
+		t.Run("escaping "+name, func(t *testing.T) {
+			t.Parallel()
+
+			data := makeTar(t, []archiveEntry{{name: name, content: "# main"}})
+			_, _, err := ReadArchive(bytes.NewReader(data))
+			assert.EqualError(t, err, `archive entry "`+name+`" is outside of the archive`)
+		})
+	}
+}
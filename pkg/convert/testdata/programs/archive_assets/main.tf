@@ -0,0 +1,58 @@
+data "archive_file" "dir" {
+    type = "zip"
+    source_dir = "src"
+    output_path = "dir.zip"
+}
+
+# Archives only used for a function's code are converted to assets, and the hash of the archive isn't needed.
+resource "aws_lambda_function" "dir" {
+    function_name = "dir"
+    role = "role"
+    filename = data.archive_file.dir.output_path
+    source_code_hash = data.archive_file.dir.output_base64sha256
+}
+
+data "archive_file" "file" {
+    type = "zip"
+    source_file = "src/index.js"
+    output_path = "file.zip"
+}
+
+resource "aws_lambda_function" "file" {
+    function_name = "file"
+    role = "role"
+    filename = data.archive_file.file.output_path
+}
+
+data "archive_file" "sources" {
+    type = "zip"
+    output_path = "sources.zip"
+
+    source {
+        content = "exports.handler = async () => {}"
+        filename = "index.js"
+    }
+}
+
+resource "aws_lambda_function" "sources" {
+    function_name = "sources"
+    role = "role"
+    filename = data.archive_file.sources.output_path
+}
+
+# An archive that's used for more than its code is still invoked.
+data "archive_file" "shared" {
+    type = "zip"
+    source_dir = "src"
+    output_path = "shared.zip"
+}
+
+resource "aws_lambda_function" "shared" {
+    function_name = "shared"
+    role = "role"
+    filename = data.archive_file.shared.output_path
+}
+
+output "size" {
+    value = data.archive_file.shared.output_size
+}
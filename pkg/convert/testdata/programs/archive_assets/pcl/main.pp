@@ -0,0 +1,46 @@
+
+
+# Archives only used for a function's code are converted to assets, and the hash of the archive isn't needed.
+resource "dirFunction" "aws:lambda/function:Function" {
+  __logicalName = "dir"
+  functionName  = "dir"
+  role          = "role"
+  code          = fileArchive("src")
+}
+
+resource "fileFunction" "aws:lambda/function:Function" {
+  __logicalName = "file"
+  functionName  = "file"
+  role          = "role"
+  code = assetArchive({
+    "index.js" = fileAsset("src/index.js")
+  })
+}
+
+resource "sourcesFunction" "aws:lambda/function:Function" {
+  __logicalName = "sources"
+  functionName  = "sources"
+  role          = "role"
+  code = assetArchive({
+    "index.js" = stringAsset("exports.handler = async () => {}")
+  })
+}
+
+
+# An archive that's used for more than its code is still invoked.
+shared = invoke("archive:index/getFile:getFile", {
+  type       = "zip"
+  sourceDir  = "src"
+  outputPath = "shared.zip"
+})
+
+resource "sharedFunction" "aws:lambda/function:Function" {
+  __logicalName = "shared"
+  functionName  = "shared"
+  role          = "role"
+  code          = fileArchive(shared.outputPath)
+}
+
+output "size" {
+  value = shared.outputSize
+}
@@ -0,0 +1,40 @@
+data "aws_availability_zones" "available" {
+  state = "available"
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+# A subnet in each availability zone
+resource "aws_subnet" "public" {
+  count = length(data.aws_availability_zones.available.names)
+
+  vpc_id            = aws_vpc.main.id
+  cidr_block        = cidrsubnet(aws_vpc.main.cidr_block, 8, count.index)
+  availability_zone = data.aws_availability_zones.available.names[count.index]
+  tags = {
+    Name = "public-${data.aws_availability_zones.available.names[count.index]}"
+  }
+}
+
+resource "aws_subnet" "private" {
+  count = length(data.aws_availability_zones.available.names)
+
+  vpc_id            = aws_vpc.main.id
+  cidr_block        = cidrsubnet(aws_vpc.main.cidr_block, 8, count.index + 10)
+  availability_zone = element(data.aws_availability_zones.available.names, count.index)
+}
+
+# Only two of the availability zones, so this can't loop over them
+resource "aws_subnet" "database" {
+  count = 2
+
+  vpc_id            = aws_vpc.main.id
+  cidr_block        = cidrsubnet(aws_vpc.main.cidr_block, 8, count.index + 20)
+  availability_zone = data.aws_availability_zones.available.names[count.index]
+}
+
+output "public_subnet_ids" {
+  value = aws_subnet.public[*].id
+}
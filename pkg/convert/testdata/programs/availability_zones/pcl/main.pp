@@ -0,0 +1,58 @@
+available = invoke("aws:index/getAvailabilityZones:getAvailabilityZones", {
+  state = "available"
+})
+
+resource "main" "aws:ec2/vpc:Vpc" {
+  cidrBlock = "10.0.0.0/16"
+}
+
+
+# A subnet in each availability zone
+resource "public" "aws:ec2/subnet:Subnet" {
+  options {
+    range = available.names
+  }
+  vpcId = main.id
+  cidrBlock = invoke("std:index:cidrsubnet", {
+    input   = main.cidrBlock
+    newbits = 8
+    netnum  = range.key
+  }).result
+  availabilityZone = range.value
+  tags = {
+    Name = "public-${range.value}"
+  }
+}
+
+resource "private" "aws:ec2/subnet:Subnet" {
+  options {
+    range = available.names
+  }
+  vpcId = main.id
+  cidrBlock = invoke("std:index:cidrsubnet", {
+    input   = main.cidrBlock
+    newbits = 8
+    netnum  = range.key + 10
+  }).result
+  availabilityZone = range.value
+}
+
+
+# Only two of the availability zones, so this can't loop over them
+resource "database" "aws:ec2/subnet:Subnet" {
+  options {
+    range = 2
+
+  }
+  vpcId = main.id
+  cidrBlock = invoke("std:index:cidrsubnet", {
+    input   = main.cidrBlock
+    newbits = 8
+    netnum  = range.value + 20
+  }).result
+  availabilityZone = available.names[range.value]
+}
+
+output "publicSubnetIds" {
+  value = public[*].id
+}
@@ -0,0 +1,58 @@
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  owners      = ["099720109477", "amazon"]
+
+  filter {
+    name   = "name"
+    values = ["ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*"]
+  }
+
+  filter {
+    name   = "virtualization-type"
+    values = ["hvm"]
+  }
+}
+
+data "aws_ami" "single_filter" {
+  owners = ["self"]
+
+  filter {
+    name   = "tag:Role"
+    values = ["web", "api"]
+  }
+}
+
+output "ubuntu_image_id" {
+  value = data.aws_ami.ubuntu.image_id
+}
+
+output "single_filter_arn" {
+  value = data.aws_ami.single_filter.arn
+}
+
+variable "extra_filters" {
+  type    = map(list(string))
+  default = {}
+}
+
+data "aws_ami" "with_dynamic_filters" {
+  most_recent = true
+  owners      = ["self"]
+
+  filter {
+    name   = "state"
+    values = ["available"]
+  }
+
+  dynamic "filter" {
+    for_each = var.extra_filters
+    content {
+      name   = filter.key
+      values = filter.value
+    }
+  }
+}
+
+output "with_dynamic_filters_id" {
+  value = data.aws_ami.with_dynamic_filters.image_id
+}
@@ -0,0 +1,49 @@
+ubuntu = invoke("aws:ec2/getAmi:getAmi", {
+  mostRecent = true
+  owners     = ["099720109477", "amazon"]
+  filters = [{
+    name   = "name"
+    values = ["ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*"]
+    }, {
+    name   = "virtualization-type"
+    values = ["hvm"]
+  }]
+})
+
+singleFilter = invoke("aws:ec2/getAmi:getAmi", {
+  owners = ["self"]
+  filters = [{
+    name   = "tag:Role"
+    values = ["web", "api"]
+  }]
+})
+
+output "ubuntuImageId" {
+  value = ubuntu.imageId
+}
+
+output "singleFilterArn" {
+  value = singleFilter.arn
+}
+
+config "extraFilters" "map(list(string))" {
+  default = {}
+}
+
+withDynamicFilters = invoke("aws:ec2/getAmi:getAmi", {
+  mostRecent = true
+  owners     = ["self"]
+  filters = invoke("std:index:concat", {
+    input = [[{
+      name   = "state"
+      values = ["available"]
+      }], [for entry in entries(extraFilters) : {
+      name   = entry.key
+      values = entry.value
+    }]]
+  }).result
+})
+
+output "withDynamicFiltersId" {
+  value = withDynamicFilters.imageId
+}
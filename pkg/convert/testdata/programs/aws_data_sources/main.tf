@@ -0,0 +1,40 @@
+data "aws_caller_identity" "current" {}
+
+data "aws_partition" "current" {}
+
+data "aws_region" "current" {}
+
+locals {
+  account_id = data.aws_caller_identity.current.account_id
+  region     = data.aws_region.current.name
+}
+
+resource "aws_iam_role" "example" {
+  name = "example-${local.region}"
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Effect = "Allow"
+      Action = "sts:AssumeRole"
+      Principal = {
+        Service = "ec2.${data.aws_partition.current.dns_suffix}"
+      }
+    }]
+  })
+}
+
+output "account_root_arn" {
+  value = "arn:${data.aws_partition.current.partition}:iam::${local.account_id}:root"
+}
+
+output "log_group_arn" {
+  value = "arn:${data.aws_partition.current.partition}:logs:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:log-group:*"
+}
+
+output "caller_arn" {
+  value = data.aws_caller_identity.current.arn
+}
+
+output "role_arn" {
+  value = join(":", ["arn", data.aws_partition.current.partition, "iam", "", local.account_id, "role/${aws_iam_role.example.name}"])
+}
@@ -0,0 +1,37 @@
+current = invoke("aws:index/getCallerIdentity:getCallerIdentity", {})
+
+currentGetPartition = invoke("aws:index/getPartition:getPartition", {})
+
+currentGetRegion = invoke("aws:index/getRegion:getRegion", {})
+accountId        = current.accountId
+region           = currentGetRegion.name
+
+resource "example" "aws:iam/role:Role" {
+  name = "example-${region}"
+  assumeRolePolicy = toJSON({
+    "Version" = "2012-10-17"
+    "Statement" = [{
+      "Effect" = "Allow"
+      "Action" = "sts:AssumeRole"
+      "Principal" = {
+        "Service" = "ec2.${currentGetPartition.dnsSuffix}"
+      }
+    }]
+  })
+}
+
+output "accountRootArn" {
+  value = "arn:${currentGetPartition.partition}:iam::${accountId}:root"
+}
+
+output "logGroupArn" {
+  value = "arn:${currentGetPartition.partition}:logs:${currentGetRegion.name}:${current.accountId}:log-group:*"
+}
+
+output "callerArn" {
+  value = current.arn
+}
+
+output "roleArn" {
+  value = "arn:${currentGetPartition.partition}:iam::${accountId}:role/${example.name}"
+}
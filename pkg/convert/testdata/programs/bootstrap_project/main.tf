@@ -0,0 +1,32 @@
+terraform {
+    backend "s3" {
+        bucket = "my-state"
+        key = "prod/terraform.tfstate"
+        dynamodb_table = "my-locks"
+    }
+}
+
+provider "aws" {
+    region = "us-west-2"
+}
+
+resource "aws_s3_bucket" "state" {
+    bucket = "my-state"
+}
+
+resource "aws_s3_bucket_versioning" "state" {
+    bucket = aws_s3_bucket.state.id
+    versioning_configuration {
+        status = "Enabled"
+    }
+}
+
+resource "aws_dynamodb_table" "locks" {
+    name = "my-locks"
+    hash_key = "LockID"
+}
+
+# The state bucket, its versioning and the lock table are moved to the bootstrap project, this bucket stays.
+resource "aws_s3_bucket" "data" {
+    bucket = "my-data"
+}
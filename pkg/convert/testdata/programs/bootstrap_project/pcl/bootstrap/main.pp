@@ -0,0 +1,17 @@
+
+resource "state" "aws:s3/bucket:Bucket" {
+  bucket = "my-state"
+}
+
+resource "stateBucketVersioningV2" "aws:s3/bucketVersioningV2:BucketVersioningV2" {
+  __logicalName = "state"
+  bucket        = state.id
+  versioningConfiguration = {
+    status = "Enabled"
+  }
+}
+
+resource "locks" "aws:dynamodb/table:Table" {
+  name    = "my-locks"
+  hashKey = "LockID"
+}
@@ -0,0 +1,6 @@
+
+
+# The state bucket, its versioning and the lock table are moved to the bootstrap project, this bucket stays.
+resource "data" "aws:s3/bucket:Bucket" {
+  bucket = "my-data"
+}
@@ -0,0 +1,10 @@
+terraform {
+    backend "local" {
+        path = "terraform.tfstate"
+    }
+}
+
+# Only the state of the s3 backend is bootstrapped, so this bucket stays in the program.
+resource "aws_s3_bucket" "state" {
+    bucket = "my-state"
+}
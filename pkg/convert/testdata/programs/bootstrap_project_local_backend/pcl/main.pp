@@ -0,0 +1,6 @@
+
+
+# Only the state of the s3 backend is bootstrapped, so this bucket stays in the program.
+resource "state" "aws:s3/bucket:Bucket" {
+  bucket = "my-state"
+}
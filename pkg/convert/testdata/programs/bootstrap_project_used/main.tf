@@ -0,0 +1,15 @@
+terraform {
+    backend "s3" {
+        bucket = "my-state"
+        key = "prod/terraform.tfstate"
+    }
+}
+
+resource "aws_s3_bucket" "state" {
+    bucket = "my-state"
+}
+
+# The program uses the state bucket, so it can't be moved to a bootstrap project.
+output "state_bucket" {
+    value = aws_s3_bucket.state.arn
+}
@@ -0,0 +1,10 @@
+
+resource "state" "aws:s3/bucket:Bucket" {
+  bucket = "my-state"
+}
+
+
+# The program uses the state bucket, so it can't be moved to a bootstrap project.
+output "stateBucket" {
+  value = state.arn
+}
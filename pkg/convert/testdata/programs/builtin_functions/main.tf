@@ -483,6 +483,9 @@ output "funcJoin1" {
 output "funcJoin2" {
   value = join(", ", ["foo"])
 }
+output "funcJoin3" {
+  value = join(", ", split(",", "foo,bar"))
+}
 
 
 # Examples for jsondecode
@@ -162,13 +162,13 @@ output "funcCan0" {
   value = foo
 }
 output "funcCan1" {
-  value = notImplemented("can(local.foo.bar)")
+  value = true
 }
 output "funcCan2" {
-  value = notImplemented("can(local.foo.boop)")
+  value = false
 }
 output "funcCan3" {
-  value = notImplemented("can(local.nonexist)")
+  value = false
 }
 
 
@@ -286,35 +286,121 @@ output "funcCidrsubnet4" {
 
 # Examples for cidrsubnets
 output "funcCidrsubnets0" {
-  value = notImplemented("cidrsubnets(\"10.1.0.0/16\",4,4,8,4)")
+  value = [
+    invoke("std:index:cidrsubnet", {
+      input   = "10.1.0.0/16"
+      newbits = 4
+      netnum  = 0
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "10.1.0.0/16"
+      newbits = 4
+      netnum  = 1
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "10.1.0.0/16"
+      newbits = 8
+      netnum  = 32
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "10.1.0.0/16"
+      newbits = 4
+      netnum  = 3
+    }).result,
+  ]
 }
 output "funcCidrsubnets1" {
-  value = notImplemented("cidrsubnets(\"fd00:fd12:3456:7890::/56\",16,16,16,32)")
+  value = [
+    invoke("std:index:cidrsubnet", {
+      input   = "fd00:fd12:3456:7890::/56"
+      newbits = 16
+      netnum  = 0
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "fd00:fd12:3456:7890::/56"
+      newbits = 16
+      netnum  = 1
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "fd00:fd12:3456:7890::/56"
+      newbits = 16
+      netnum  = 2
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "fd00:fd12:3456:7890::/56"
+      newbits = 32
+      netnum  = 196608
+    }).result,
+  ]
 }
 output "funcCidrsubnets2" {
-  value = [for cidrBlock in notImplemented("cidrsubnets(\"10.0.0.0/8\",8,8,8,8)") : notImplemented("cidrsubnets(cidr_block,4,4)")]
+  value = [for cidrBlock in [
+    invoke("std:index:cidrsubnet", {
+      input   = "10.0.0.0/8"
+      newbits = 8
+      netnum  = 0
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "10.0.0.0/8"
+      newbits = 8
+      netnum  = 1
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "10.0.0.0/8"
+      newbits = 8
+      netnum  = 2
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = "10.0.0.0/8"
+      newbits = 8
+      netnum  = 3
+    }).result,
+    ] : [
+    invoke("std:index:cidrsubnet", {
+      input   = cidrBlock
+      newbits = 4
+      netnum  = 0
+    }).result,
+    invoke("std:index:cidrsubnet", {
+      input   = cidrBlock
+      newbits = 4
+      netnum  = 1
+    }).result,
+  ]]
 }
 
 
 
 # Examples for coalesce
 output "funcCoalesce0" {
-  value = notImplemented("coalesce(\"a\",\"b\")")
+  value = invoke("std:index:coalesce", {
+    input = ["a", "b"]
+  }).result
 }
 output "funcCoalesce1" {
-  value = notImplemented("coalesce(\"\",\"b\")")
+  value = invoke("std:index:coalesce", {
+    input = ["", "b"]
+  }).result
 }
 output "funcCoalesce2" {
-  value = notImplemented("coalesce(1,2)")
+  value = invoke("std:index:coalesce", {
+    input = [1, 2]
+  }).result
 }
 output "funcCoalesce3" {
-  value = notImplemented("coalesce([\"\",\"b\"]...)")
+  value = invoke("std:index:coalesce", {
+    input = ["", "b"]
+  }).result
 }
 output "funcCoalesce4" {
-  value = notImplemented("coalesce(1,\"hello\")")
+  value = invoke("std:index:coalesce", {
+    input = [1, "hello"]
+  }).result
 }
 output "funcCoalesce5" {
-  value = notImplemented("coalesce(true,\"hello\")")
+  value = invoke("std:index:coalesce", {
+    input = [true, "hello"]
+  }).result
 }
 output "funcCoalesce6" {
   value = notImplemented("coalesce({},\"hello\")")
@@ -324,20 +410,28 @@ output "funcCoalesce6" {
 
 # Examples for coalescelist
 output "funcCoalescelist0" {
-  value = notImplemented("coalescelist([\"a\",\"b\"],[\"c\",\"d\"])")
+  value = invoke("std:index:coalescelist", {
+    input = [["a", "b"], ["c", "d"]]
+  }).result
 }
 output "funcCoalescelist1" {
-  value = notImplemented("coalescelist([],[\"c\",\"d\"])")
+  value = invoke("std:index:coalescelist", {
+    input = [[], ["c", "d"]]
+  }).result
 }
 output "funcCoalescelist2" {
-  value = notImplemented("coalescelist([[],[\"c\",\"d\"]]...)")
+  value = invoke("std:index:coalescelist", {
+    input = [[], ["c", "d"]]
+  }).result
 }
 
 
 
 # Examples for compact
 output "funcCompact" {
-  value = notImplemented("compact([\"a\",\"\",\"b\",null,\"c\"])")
+  value = invoke("std:index:compact", {
+    input = ["a", "", "b", "c"]
+  }).result
 }
 
 
@@ -381,7 +475,9 @@ output "funcDirname" {
 
 # Examples for distinct
 output "funcDistinct" {
-  value = notImplemented("distinct([\"a\",\"b\",\"a\",\"c\",\"d\",\"b\"])")
+  value = invoke("std:index:distinct", {
+    input = ["a", "b", "a", "c", "d", "b"]
+  }).result
 }
 
 
@@ -514,10 +610,10 @@ output "funcFilesha512" {
 
 # Examples for flatten
 output "funcFlatten0" {
-  value = notImplemented("flatten([[\"a\",\"b\"],[],[\"c\"]])")
+  value = ["a", "b", "c"]
 }
 output "funcFlatten1" {
-  value = notImplemented("flatten([[[\"a\",\"b\"],[]],[\"c\"]])")
+  value = ["a", "b", "c"]
 }
 
 
@@ -538,34 +634,37 @@ output "funcFloor1" {
 
 # Examples for format
 output "funcFormat0" {
-  value = notImplemented("format(\"Hello, %s!\",\"Ander\")")
+  value = "Hello, Ander!"
 }
 output "funcFormat1" {
-  value = notImplemented("format(\"There are %d lights\",4)")
+  value = "There are 4 lights"
 }
 output "funcFormat2" {
-  value = notImplemented("format(\"Hello, %s!\",var.name)")
+  value = "Hello, ${name}!"
 }
 output "funcFormat3" {
   value = "Hello, ${name}!"
 }
 output "funcFormat4" {
-  value = notImplemented("format(\"%#v\",\"hello\")")
+  value = "\"hello\""
 }
 output "funcFormat5" {
-  value = notImplemented("format(\"%#v\",true)")
+  value = "true"
 }
 output "funcFormat6" {
-  value = notImplemented("format(\"%#v\",1)")
+  value = "1"
 }
 output "funcFormat7" {
-  value = notImplemented("format(\"%#v\",{a=1})")
+  value = "{\"a\":1}"
 }
 output "funcFormat8" {
-  value = notImplemented("format(\"%#v\",[true])")
+  value = "[true]"
 }
 output "funcFormat9" {
-  value = notImplemented("format(\"%#v\",null)")
+  value = invoke("std:index:format", {
+    input = "%#v"
+    args  = [null]
+  }).result
 }
 
 
@@ -597,10 +696,10 @@ output "funcFormatdate6" {
 
 # Examples for formatlist
 output "funcFormatlist0" {
-  value = notImplemented("formatlist(\"Hello, %s!\",[\"Valentina\",\"Ander\",\"Olivia\",\"Sam\"])")
+  value = ["Hello, Valentina!", "Hello, Ander!", "Hello, Olivia!", "Hello, Sam!"]
 }
 output "funcFormatlist1" {
-  value = notImplemented("formatlist(\"%s, %s!\",\"Salutations\",[\"Valentina\",\"Ander\",\"Olivia\",\"Sam\"])")
+  value = ["Salutations, Valentina!", "Salutations, Ander!", "Salutations, Olivia!", "Salutations, Sam!"]
 }
 
 
@@ -624,21 +723,21 @@ output "funcIndex" {
 
 # Examples for join
 output "funcJoin0" {
-  value = invoke("std:index:join", {
-    separator = "-"
-    input     = ["foo", "bar", "baz"]
-  }).result
+  value = "foo-bar-baz"
 }
 output "funcJoin1" {
-  value = invoke("std:index:join", {
-    separator = ", "
-    input     = ["foo", "bar", "baz"]
-  }).result
+  value = "foo, bar, baz"
 }
 output "funcJoin2" {
+  value = "foo"
+}
+output "funcJoin3" {
   value = invoke("std:index:join", {
     separator = ", "
-    input     = ["foo"]
+    input = invoke("std:index:split", {
+      separator = ","
+      text      = "foo,bar"
+    }).result
   }).result
 }
 
@@ -646,10 +745,14 @@ output "funcJoin2" {
 
 # Examples for jsondecode
 output "funcJsondecode0" {
-  value = notImplemented("jsondecode(\"{\\\"hello\\\": \\\"world\\\"}\")")
+  value = invoke("std:index:jsondecode", {
+    input = "{\"hello\": \"world\"}"
+  }).result
 }
 output "funcJsondecode1" {
-  value = notImplemented("jsondecode(\"true\")")
+  value = invoke("std:index:jsondecode", {
+    input = "true"
+  }).result
 }
 
 
@@ -820,13 +923,38 @@ output "funcMd5" {
 
 # Examples for merge
 output "funcMerge0" {
-  value = notImplemented("merge({a=\"b\",c=\"d\"},{e=\"f\",c=\"z\"})")
+  value = invoke("std:index:merge", {
+    input = [{
+      a = "b"
+      c = "d"
+      }, {
+      e = "f"
+      c = "z"
+    }]
+  }).result
 }
 output "funcMerge1" {
-  value = notImplemented("merge({a=\"b\"},{a=[1,2],c=\"z\"},{d=3})")
+  value = invoke("std:index:merge", {
+    input = [{
+      a = "b"
+      }, {
+      a = [1, 2]
+      c = "z"
+      }, {
+      d = 3
+    }]
+  }).result
 }
 output "funcMerge2" {
-  value = notImplemented("merge([{a=\"b\",c=\"d\"},{},{e=\"f\",c=\"z\"}]...)")
+  value = invoke("std:index:merge", {
+    input = [{
+      a = "b"
+      c = "d"
+      }, {}, {
+      e = "f"
+      c = "z"
+    }]
+  }).result
 }
 
 
@@ -1016,13 +1144,16 @@ output "funcRange5" {
 
 # Examples for regex
 output "funcRegex0" {
-  value = notImplemented("regex(\"[a-z]+\",\"53453453.345345aaabbbccc23454\")")
+  value = "aaabbbccc"
 }
 output "funcRegex1" {
-  value = notImplemented("regex(\"(\\\\d\\\\d\\\\d\\\\d)-(\\\\d\\\\d)-(\\\\d\\\\d)\",\"2019-02-01\")")
+  value = ["2019", "02", "01"]
 }
 output "funcRegex2" {
-  value = notImplemented("regex(\"^(?:(?P<scheme>[^:/?#]+):)?(?://(?P<authority>[^/?#]*))?\",\"https://terraform.io/docs/\")")
+  value = {
+    authority = "terraform.io"
+    scheme    = "https"
+  }
 }
 output "funcRegex3" {
   value = notImplemented("regex(\"[a-z]+\",\"53453453.34534523454\")")
@@ -1032,13 +1163,13 @@ output "funcRegex3" {
 
 # Examples for regexall
 output "funcRegexall0" {
-  value = notImplemented("regexall(\"[a-z]+\",\"1234abcd5678efgh9\")")
+  value = ["abcd", "efgh"]
 }
 output "funcRegexall1" {
-  value = length(notImplemented("regexall(\"[a-z]+\",\"1234abcd5678efgh9\")"))
+  value = length(["abcd", "efgh"])
 }
 output "funcRegexall2" {
-  value = length(notImplemented("regexall(\"[a-z]+\",\"123456789\")")) > 0
+  value = length([]) > 0
 }
 
 
@@ -1104,13 +1235,13 @@ output "funcSetintersection" {
 
 # Examples for setproduct
 output "funcSetproduct0" {
-  value = notImplemented("setproduct([\"development\",\"staging\",\"production\"],[])")
+  value = []
 }
 output "funcSetproduct1" {
-  value = notImplemented("setproduct([\"a\"],[\"b\"])")
+  value = [["a", "b"]]
 }
 output "funcSetproduct2" {
-  value = notImplemented("setproduct([\"staging\",\"production\"],[\"a\",2])")
+  value = [["staging", "a"], ["staging", "2"], ["production", "a"], ["production", "2"]]
 }
 
 
@@ -1377,19 +1508,29 @@ output "funcTitle" {
 
 # Examples for tobool
 output "funcTobool0" {
-  value = notImplemented("tobool(true)")
+  value = invoke("std:index:tobool", {
+    input = true
+  }).result
 }
 output "funcTobool1" {
-  value = notImplemented("tobool(\"true\")")
+  value = invoke("std:index:tobool", {
+    input = "true"
+  }).result
 }
 output "funcTobool2" {
-  value = notImplemented("tobool(null)")
+  value = invoke("std:index:tobool", {
+    input = null
+  }).result
 }
 output "funcTobool3" {
-  value = notImplemented("tobool(\"no\")")
+  value = invoke("std:index:tobool", {
+    input = "no"
+  }).result
 }
 output "funcTobool4" {
-  value = notImplemented("tobool(1)")
+  value = invoke("std:index:tobool", {
+    input = 1
+  }).result
 }
 
 
@@ -1416,29 +1557,43 @@ output "funcTomap1" {
 
 # Examples for tonumber
 output "funcTonumber0" {
-  value = notImplemented("tonumber(1)")
+  value = invoke("std:index:tonumber", {
+    input = 1
+  }).result
 }
 output "funcTonumber1" {
-  value = notImplemented("tonumber(\"1\")")
+  value = invoke("std:index:tonumber", {
+    input = "1"
+  }).result
 }
 output "funcTonumber2" {
-  value = notImplemented("tonumber(null)")
+  value = invoke("std:index:tonumber", {
+    input = null
+  }).result
 }
 output "funcTonumber3" {
-  value = notImplemented("tonumber(\"no\")")
+  value = invoke("std:index:tonumber", {
+    input = "no"
+  }).result
 }
 
 
 
 # Examples for toset
 output "funcToset0" {
-  value = notImplemented("toset([\"a\",\"b\",\"c\"])")
+  value = invoke("std:index:toset", {
+    input = ["a", "b", "c"]
+  }).result
 }
 output "funcToset1" {
-  value = notImplemented("toset([\"a\",\"b\",3])")
+  value = invoke("std:index:toset", {
+    input = ["a", "b", 3]
+  }).result
 }
 output "funcToset2" {
-  value = notImplemented("toset([\"c\",\"b\",\"b\"])")
+  value = invoke("std:index:toset", {
+    input = ["c", "b", "b"]
+  }).result
 }
 
 
@@ -1536,13 +1691,13 @@ output "funcTry0" {
   value = foo
 }
 output "funcTry1" {
-  value = notImplemented("try(local.foo.bar,\"fallback\")")
+  value = foo.bar
 }
 output "funcTry2" {
-  value = notImplemented("try(local.foo.boop,\"fallback\")")
+  value = "fallback"
 }
 output "funcTry3" {
-  value = notImplemented("try(local.nonexist,\"fallback\")")
+  value = "fallback"
 }
 
 
@@ -1628,13 +1783,18 @@ output "funcValues" {
 
 # Examples for yamldecode
 output "funcYamldecode0" {
-  value = notImplemented("yamldecode(\"hello: world\")")
+  value = {
+    hello = "world"
+  }
 }
 output "funcYamldecode1" {
-  value = notImplemented("yamldecode(\"true\")")
+  value = true
 }
 output "funcYamldecode2" {
-  value = notImplemented("yamldecode(\"{a: &foo [1, 2, 3], b: *foo}\")")
+  value = {
+    a = [1, 2, 3]
+    b = [1, 2, 3]
+  }
 }
 output "funcYamldecode3" {
   value = notImplemented("yamldecode(\"{a: &foo [1, *foo, 3]}\")")
@@ -1647,18 +1807,21 @@ output "funcYamldecode4" {
 
 # Examples for yamlencode
 output "funcYamlencode0" {
-  value = notImplemented("yamlencode({\"a\":\"b\",\"c\":\"d\"})")
+  value = "\"a\": \"b\"\n\"c\": \"d\"\n"
 }
 output "funcYamlencode1" {
-  value = notImplemented("yamlencode({\"foo\":[1,2,3],\"bar\":\"baz\"})")
+  value = "\"bar\": \"baz\"\n\"foo\":\n- 1\n- 2\n- 3\n"
 }
 output "funcYamlencode2" {
-  value = notImplemented("yamlencode({\"foo\":[1,{\"a\":\"b\",\"c\":\"d\"},3],\"bar\":\"baz\"})")
+  value = "\"bar\": \"baz\"\n\"foo\":\n- 1\n- \"a\": \"b\"\n  \"c\": \"d\"\n- 3\n"
 }
 
 
 
 # Examples for zipmap
 output "funcZipmap" {
-  value = notImplemented("zipmap([\"a\",\"b\"],[1,2])")
+  value = invoke("std:index:zipmap", {
+    keys   = ["a", "b"]
+    values = [1, 2]
+  }).result
 }
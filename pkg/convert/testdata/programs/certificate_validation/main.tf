@@ -0,0 +1,29 @@
+resource "aws_acm_certificate" "cert" {
+    domain_name = "example.com"
+    subject_alternative_names = ["www.example.com"]
+    validation_method = "DNS"
+}
+
+# The records range over the certificate's domains, which are known, rather than its validation options, which aren't
+# known until the certificate is created.
+resource "aws_route53_record" "validation" {
+    for_each = {
+        for dvo in aws_acm_certificate.cert.domain_validation_options : dvo.domain_name => {
+            name = dvo.resource_record_name
+            record = dvo.resource_record_value
+            type = dvo.resource_record_type
+        }
+    }
+
+    allow_overwrite = true
+    name = each.value.name
+    records = [each.value.record]
+    ttl = 60
+    type = each.value.type
+    zone_id = "zone"
+}
+
+resource "aws_acm_certificate_validation" "cert" {
+    certificate_arn = aws_acm_certificate.cert.arn
+    validation_record_fqdns = [for record in aws_route53_record.validation : record.fqdn]
+}
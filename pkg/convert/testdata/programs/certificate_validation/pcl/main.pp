@@ -0,0 +1,26 @@
+resource "cert" "aws:acm/certificate:Certificate" {
+  domainName              = "example.com"
+  subjectAlternativeNames = ["www.example.com"]
+  validationMethod        = "DNS"
+}
+
+
+# The records range over the certificate's domains, which are known, rather than its validation options, which aren't
+# known until the certificate is created.
+resource "validation" "aws:route53/record:Record" {
+  options {
+    range = { for __key in ["example.com", "www.example.com"] : __key => __key }
+  }
+  allowOverwrite = true
+  name           = [for dvo in cert.domainValidationOptions : dvo if dvo.domainName == range.key][0].resourceRecordName
+  records        = [[for dvo in cert.domainValidationOptions : dvo if dvo.domainName == range.key][0].resourceRecordValue]
+  ttl            = 60
+  type           = [for dvo in cert.domainValidationOptions : dvo if dvo.domainName == range.key][0].resourceRecordType
+  zoneId         = "zone"
+}
+
+resource "certCertificateValidation" "aws:acm/certificateValidation:CertificateValidation" {
+  __logicalName         = "cert"
+  certificateArn        = cert.arn
+  validationRecordFqdns = [for record in validation : record.fqdn]
+}
@@ -0,0 +1,72 @@
+variable "settings" {
+  type = object({
+    instance_type = string
+    tags          = optional(map(string), {})
+    disk = optional(object({
+      size_gb = number
+      kind    = optional(string, "ssd")
+    }))
+  })
+  default = {
+    instance_type = "t2.micro"
+  }
+}
+
+variable "untyped_nested" {
+  default = {
+    first_level = {
+      second_level = ["a", "b"]
+    }
+    enabled = true
+  }
+}
+
+variable "typed_list" {
+  type = list(object({
+    name  = string
+    count = optional(number, 1)
+  }))
+  default = [
+    { name = "a" },
+    { name = "b", count = 2 },
+  ]
+}
+
+variable "maybe_name" {
+  type    = string
+  default = null
+}
+
+variable "empty_map" {
+  type    = map(string)
+  default = {}
+}
+
+locals {
+  name = coalesce(var.maybe_name, "default-name")
+}
+
+output "settings_instance_type" {
+  value = var.settings.instance_type
+}
+
+output "untyped_nested" {
+  value = var.untyped_nested.first_level.second_level
+}
+
+output "name" {
+  value = local.name
+}
+
+variable "maybe_list" {
+  type    = list(string)
+  default = null
+}
+
+locals {
+  list = coalescelist(var.maybe_list, ["a", "b"])
+}
+
+output "list" {
+  value = local.list
+}
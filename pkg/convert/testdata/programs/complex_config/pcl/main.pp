@@ -0,0 +1,60 @@
+config "settings" "object({disk=object({kind=string, sizeGb=number}), instanceType=string, tags=map(string)})" {
+  default = {
+    disk         = null
+    instanceType = "t2.micro"
+    tags         = {}
+  }
+}
+
+config "untypedNested" {
+  default = {
+    enabled = true
+    firstLevel = {
+      secondLevel = ["a", "b"]
+    }
+  }
+}
+
+config "typedList" "list(object({count=number, name=string}))" {
+  default = [{
+    count = 1
+    name  = "a"
+    }, {
+    count = 2
+    name  = "b"
+  }]
+}
+
+config "maybeName" "string" {
+  default = null
+}
+
+config "emptyMap" "map(string)" {
+  default = {}
+}
+name = invoke("std:index:coalesce", {
+  input = [maybeName, "default-name"]
+}).result
+
+output "settingsInstanceType" {
+  value = settings.instanceType
+}
+
+output "untypedNested" {
+  value = untypedNested.firstLevel.secondLevel
+}
+
+output "name" {
+  value = name
+}
+
+config "maybeList" "list(string)" {
+  default = null
+}
+list = invoke("std:index:coalescelist", {
+  input = [maybeList, ["a", "b"]]
+}).result
+
+output "list" {
+  value = list
+}
@@ -0,0 +1,10 @@
+module "described" {
+    source = "./mod"
+
+    name = "example"
+}
+
+output "result" {
+    description = "The result of the described module"
+    value = module.described.result
+}
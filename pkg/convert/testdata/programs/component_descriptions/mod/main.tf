@@ -0,0 +1,19 @@
+variable "name" {
+    type = string
+    description = "The name to give the resource"
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = var.name
+    input_two = 1
+}
+
+# The resource's result
+output "result" {
+    description = <<-EOT
+    The result of the resource.
+
+    This spans multiple lines.
+    EOT
+    value = simple_resource.a_resource.result
+}
@@ -0,0 +1,8 @@
+component "described" "./mod" {
+  name = "example"
+}
+
+# The result of the described module
+output "result" {
+  value = described.result
+}
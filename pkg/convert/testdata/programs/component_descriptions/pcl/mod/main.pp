@@ -0,0 +1,18 @@
+config "name" "string" {
+  description = "The name to give the resource"
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = name
+  inputTwo      = 1
+}
+
+
+# The resource's result
+# The result of the resource.
+#
+# This spans multiple lines.
+output "result" {
+  value = aResource.result
+}
@@ -0,0 +1,31 @@
+variable "enabled" {
+    type = bool
+}
+
+data "simple_data_source" "lookup" {
+    count     = var.enabled ? 1 : 0
+    input_one = "hello"
+}
+
+data "simple_data_source" "fallback" {
+    count     = var.enabled ? 0 : 1
+    input_one = "world"
+}
+
+# Data sources with any other count are still lists.
+data "simple_data_source" "counted" {
+    count     = 2
+    input_one = "counted"
+}
+
+output "result" {
+    value = var.enabled ? data.simple_data_source.lookup[0].result : data.simple_data_source.fallback[0].result
+}
+
+output "results" {
+    value = data.simple_data_source.lookup[*].result
+}
+
+output "second" {
+    value = data.simple_data_source.counted[1].result
+}
@@ -0,0 +1,28 @@
+config "enabled" "bool" {
+}
+
+lookup = enabled ? invoke("simple:index:dataSource", {
+  inputOne = "hello"
+}) : null
+
+fallback = enabled ? null : invoke("simple:index:dataSource", {
+  inputOne = "world"
+})
+
+
+# Data sources with any other count are still lists.
+counted = [for __index in range(2) : invoke("simple:index:dataSource", {
+  inputOne = "counted"
+})]
+
+output "result" {
+  value = enabled ? (lookup == null ? null : lookup.result) : (fallback == null ? null : fallback.result)
+}
+
+output "results" {
+  value = (lookup == null ? [] : [lookup])[*].result
+}
+
+output "second" {
+  value = counted[1].result
+}
@@ -0,0 +1,21 @@
+variable "vpc_cidr" {
+    type    = string
+    default = "10.0.0.0/16"
+}
+
+variable "name" {
+    type = string
+}
+
+locals {
+    subnet_bits = 8
+}
+
+# The call using a variable with a default is folded, the call using a variable without one isn't.
+resource "simple_resource" "a_resource" {
+    input_one = cidrsubnet(var.vpc_cidr, local.subnet_bits, 1)
+}
+
+resource "simple_resource" "b_resource" {
+    input_one = upper(var.name)
+}
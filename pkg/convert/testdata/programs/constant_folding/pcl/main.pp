@@ -0,0 +1,21 @@
+config "vpcCidr" "string" {
+  default = "10.0.0.0/16"
+}
+
+config "name" "string" {
+}
+subnetBits = 8
+
+
+# The call using a variable with a default is folded, the call using a variable without one isn't.
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = /* cidrsubnet(var.vpc_cidr, local.subnet_bits, 1) */ "10.0.1.0/24"
+}
+
+resource "bResource" "simple:index:resource" {
+  __logicalName = "b_resource"
+  inputOne = invoke("std:index:upper", {
+    input = name
+  }).result
+}
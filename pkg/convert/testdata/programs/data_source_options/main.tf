@@ -0,0 +1,22 @@
+provider "simple" {
+    alias = "other"
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = "hello"
+    input_two = true
+}
+
+data "simple_data_source" "with_provider" {
+    provider = simple.other
+
+    input_one = "hello"
+    input_two = true
+}
+
+data "simple_data_source" "with_depends_on" {
+    depends_on = [simple_resource.a_resource]
+
+    input_one = "world"
+    input_two = false
+}
@@ -0,0 +1,18 @@
+resource "other" "pulumi:providers:simple" {
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "hello"
+  inputTwo      = true
+}
+
+withProvider = invoke("simple:index:dataSource", {
+  inputOne = "hello"
+  inputTwo = true
+})
+
+withDependsOn = invoke("simple:index:dataSource", {
+  inputOne = "world"
+  inputTwo = false
+})
@@ -0,0 +1,55 @@
+variable "team" {
+    type = string
+}
+
+# Templates are read with format, so their literal % are escaped.
+resource "simple_resource" "codeowners" {
+    input_one = <<EOT
+* @org/${var.team}
+100% owned
+line
+line
+line
+line
+line
+line
+line
+line
+line
+line
+EOT
+    input_two = <<EOT
+line
+line
+line
+line
+line
+line
+line
+line
+line
+line
+EOT
+}
+
+resource "assets_resource" "a_resource" {
+    source = <<EOT
+line
+line
+line
+line
+line
+line
+line
+line
+line
+line
+EOT
+}
+
+# Short strings aren't extracted.
+resource "simple_another_resource" "short" {
+    input_one = <<EOT
+not extracted
+EOT
+}
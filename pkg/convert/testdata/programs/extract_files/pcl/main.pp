@@ -0,0 +1,23 @@
+config "team" "string" {
+}
+
+
+# Templates are read with format, so their literal % are escaped.
+resource "codeowners" "simple:index:resource" {
+  inputOne = invoke("std:index:format", {
+    input = readFile("files/simple_resource.codeowners/input_one")
+    args  = [team]
+  }).result
+  inputTwo = readFile("files/simple_resource.codeowners/input_two")
+}
+
+resource "aResource" "assets:index:resource" {
+  __logicalName = "a_resource"
+  source        = fileAsset("files/assets_resource.a_resource/source")
+}
+
+
+# Short strings aren't extracted.
+resource "short" "simple:index:anotherResource" {
+  inputOne = "not extracted\n"
+}
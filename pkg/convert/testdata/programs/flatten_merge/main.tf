@@ -0,0 +1,23 @@
+variable "rules" {
+    type = map(object({ ports = list(number), cidrs = list(string) }))
+}
+
+variable "extra_tags" {
+    type = map(string)
+}
+
+locals {
+    # Locals merged with a map keep their keys, like the map does. v.cidrs is a list, so flattening it has to be
+    # done as terraform would.
+    common_tags = { team_name = "platform" }
+    env_tags = { cost_center = "123" }
+    tags = merge(local.common_tags, local.env_tags, var.extra_tags)
+    rule_ports = flatten([for k, v in var.rules : [for p in v.ports : { rule = k, port = p }]])
+    rule_cidrs = flatten([for k, v in var.rules : [for p in v.ports : [for c in v.cidrs : "${c}:${p}"]]])
+    cidrs = flatten([for k, v in var.rules : v.cidrs])
+}
+
+# The objects in the flattened list are renamed by the schema of the list they're assigned to.
+resource "renames_resource" "a_resource" {
+    a_list = flatten([for k, v in var.rules : [for p in v.ports : { inner_number = p }]])
+}
@@ -0,0 +1,42 @@
+config "rules" "map(object({cidrs=list(string), ports=list(number)}))" {
+}
+
+config "extraTags" "map(string)" {
+}
+
+# Locals merged with a map keep their keys, like the map does. v.cidrs is a list, so flattening it has to be
+# done as terraform would.
+commonTags = {
+  "team_name" = "platform"
+}
+envTags = {
+  "cost_center" = "123"
+}
+tags = invoke("std:index:merge", {
+  input = [commonTags, envTags, extraTags]
+}).result
+rulePorts = invoke("std:index:concat", {
+  input = [for k, v in rules : [for p in v.ports : {
+    rule = k
+    port = p
+  }]]
+}).result
+ruleCidrs = invoke("std:index:concat", {
+  input = [for k, v in rules : invoke("std:index:concat", {
+    input = [for p in v.ports : [for c in v.cidrs : "${c}:${p}"]]
+  }).result]
+}).result
+cidrs = invoke("std:index:flatten", {
+  input = [for k, v in rules : v.cidrs]
+}).result
+
+
+# The objects in the flattened list are renamed by the schema of the list they're assigned to.
+resource "aResource" "renames:index/index:resource" {
+  __logicalName = "a_resource"
+  theList = invoke("std:index:concat", {
+    input = [for k, v in rules : [for p in v.ports : {
+      number = p
+    }]]
+  }).result
+}
@@ -0,0 +1,24 @@
+locals {
+    mime_types = {
+        ".html" = "text/html"
+        ".css" = "text/css"
+    }
+}
+
+# Uploads of the files directly in a folder range over the folder, with content types looked up by extension.
+resource "aws_s3_object" "pages" {
+    for_each = fileset("pages", "*")
+    bucket = "site"
+    key = each.value
+    source = "pages/${each.value}"
+    content_type = lookup(local.mime_types, regex("\\.[^.]+$", each.value), null)
+}
+
+# Uploads of every file in a folder need a synced folder.
+resource "aws_s3_object" "site" {
+    for_each = fileset("www", "**")
+    bucket = "site"
+    key = each.key
+    source = "www/${each.value}"
+    etag = filemd5("www/${each.value}")
+}
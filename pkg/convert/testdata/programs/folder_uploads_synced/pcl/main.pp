@@ -0,0 +1,27 @@
+mimeTypes = {
+  ".html" = "text/html"
+  ".css"  = "text/css"
+}
+
+
+# Uploads of the files directly in a folder range over the folder, with content types looked up by extension.
+// Converted from an aws_s3_object for each file in fileset("pages","*").
+resource "pages" "aws:s3/bucketObjectv2:BucketObjectv2" {
+  options {
+    range = { for __key in readDir("pages") : __key => __key }
+  }
+  bucket      = "site"
+  key         = range.value
+  source      = "pages/${range.value}"
+  contentType = mimeType("pages/${range.value}")
+}
+
+
+# Uploads of every file in a folder need a synced folder.
+// Converted from an aws_s3_object for each file in fileset("www","**").
+// The synced folder sets etag, key, source of each object itself.
+resource "site" "synced-folder:index:S3BucketFolder" {
+  path       = "www"
+  bucketName = "site"
+  acl        = "private"
+}
@@ -0,0 +1,26 @@
+resource "aws_vpc" "main" {
+    cidr_block = "10.0.0.0/16"
+}
+
+data "aws_subnet_ids" "static" {
+    vpc_id = "vpc-123"
+}
+
+locals {
+    vpc_id = aws_vpc.main.id
+}
+
+data "aws_subnet_ids" "private" {
+    vpc_id = local.vpc_id
+}
+
+# The ids are a set, so each.key is the id rather than its index.
+resource "aws_subnet" "static" {
+    for_each = data.aws_subnet_ids.static.ids
+    vpc_id   = each.key
+}
+
+resource "aws_subnet" "private" {
+    for_each = data.aws_subnet_ids.private.ids
+    vpc_id   = each.value
+}
@@ -0,0 +1,32 @@
+resource "main" "aws:ec2/vpc:Vpc" {
+  cidrBlock = "10.0.0.0/16"
+}
+
+static = invoke("aws:ec2/getSubnetIds:getSubnetIds", {
+  vpcId = "vpc-123"
+})
+vpcId = main.id
+
+private = invoke("aws:ec2/getSubnetIds:getSubnetIds", {
+  vpcId = vpcId
+})
+
+
+# The ids are a set, so each.key is the id rather than its index.
+resource "staticSubnet" "aws:ec2/subnet:Subnet" {
+  __logicalName = "static"
+  options {
+    range = { for __key in static.ids : __key => __key }
+  }
+  vpcId = range.key
+}
+
+// The for_each of this resource uses data.aws_subnet_ids.private, which is read once the resources its arguments
+// refer to are created, so these resources are created for its results in an apply.
+resource "privateSubnet" "aws:ec2/subnet:Subnet" {
+  __logicalName = "private"
+  options {
+    range = { for __key in private.ids : __key => __key }
+  }
+  vpcId = range.value
+}
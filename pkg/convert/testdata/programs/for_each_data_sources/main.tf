@@ -0,0 +1,29 @@
+variable "ids" {
+    type = set(string)
+}
+
+data "simple_data_source" "selected" {
+    for_each = var.ids
+    input_one = each.key
+    input_two = true
+}
+
+data "simple_data_source" "sets" {
+    for_each = toset(["a", "b"])
+    input_one = each.value
+    input_two = true
+}
+
+resource "simple_resource" "per_selected" {
+    for_each = data.simple_data_source.selected
+    input_one = each.value.result
+    input_two = 1
+}
+
+output "results" {
+    value = [for s in data.simple_data_source.selected : s.result]
+}
+
+output "by_key" {
+    value = { for k, s in data.simple_data_source.sets : k => s.result }
+}
@@ -0,0 +1,29 @@
+config "ids" "list(string)" {
+}
+
+selected = { for __key, __value in { for __key in ids : __key => __key } : __key => invoke("simple:index:dataSource", {
+  inputOne = __key
+  inputTwo = true
+}) }
+
+sets = { for __key, __value in { for __key in ["a", "b"] : __key => __key } : __key => invoke("simple:index:dataSource", {
+  inputOne = __value
+  inputTwo = true
+}) }
+
+resource "perSelected" "simple:index:resource" {
+  __logicalName = "per_selected"
+  options {
+    range = selected
+  }
+  inputOne = range.value.result
+  inputTwo = 1
+}
+
+output "results" {
+  value = [for s in selected : s.result]
+}
+
+output "byKey" {
+  value = { for k, s in sets : k => s.result }
+}
@@ -0,0 +1,33 @@
+variable "names" {
+  type    = list(string)
+  default = ["a", "", "b"]
+}
+
+variable "extra_names" {
+  type    = list(string)
+  default = []
+}
+
+resource "simple_resource" "for_each_pipeline" {
+  for_each  = toset(compact(concat(var.names, var.extra_names)))
+  input_one = each.key
+}
+
+resource "simple_resource" "count_pipeline" {
+  count     = length(distinct(compact(var.names)))
+  input_one = "${count.index}"
+}
+
+resource "simple_resource" "for_each_map" {
+  for_each  = { for name in compact(var.names) : name => upper(name) }
+  input_one = each.value
+}
+
+data "simple_data_source" "for_each_set" {
+  for_each  = toset(var.names)
+  input_one = each.key
+}
+
+output "set_result" {
+  value = data.simple_data_source.for_each_set["a"].result
+}
@@ -0,0 +1,51 @@
+config "names" "list(string)" {
+  default = ["a", "", "b"]
+}
+
+config "extraNames" "list(string)" {
+  default = []
+}
+
+resource "forEachPipeline" "simple:index:resource" {
+  __logicalName = "for_each_pipeline"
+  options {
+    range = { for __key in invoke("std:index:compact", {
+      input = invoke("std:index:concat", {
+        input = [names, extraNames]
+      }).result
+    }).result : __key => __key }
+  }
+  inputOne = range.key
+}
+
+resource "countPipeline" "simple:index:resource" {
+  __logicalName = "count_pipeline"
+  options {
+    range = length(invoke("std:index:distinct", {
+      input = invoke("std:index:compact", {
+        input = names
+      }).result
+    }).result)
+  }
+  inputOne = "${range.value}"
+}
+
+resource "forEachMap" "simple:index:resource" {
+  __logicalName = "for_each_map"
+  options {
+    range = { for name in invoke("std:index:compact", {
+      input = names
+      }).result : name => invoke("std:index:upper", {
+      input = name
+    }).result }
+  }
+  inputOne = range.value
+}
+
+forEachSet = { for __key, __value in { for __key in names : __key => __key } : __key => invoke("simple:index:dataSource", {
+  inputOne = __key
+}) }
+
+output "setResult" {
+  value = forEachSet["a"].result
+}
@@ -0,0 +1,21 @@
+variable "azs" {
+    type = list(string)
+}
+
+variable "cidr" {
+    type = string
+}
+
+resource "simple_resource" "subnet" {
+    input_one = "subnet"
+    input_two = 1
+}
+
+locals {
+    # The index of a list can be used in calls in the body, the keys of a set are its elements, and variables that
+    # would hide the resource the body refers to are renamed.
+    subnets = [for i, az in var.azs : cidrsubnet(var.cidr, 8, i)]
+    by_az   = { for i, az in var.azs : az => element(var.azs, i) }
+    upper   = { for k, v in toset(var.azs) : k => upper(v) }
+    results = [for subnet in var.azs : "${subnet}-${simple_resource.subnet.result}"]
+}
@@ -0,0 +1,25 @@
+config "azs" "list(string)" {
+}
+
+config "cidr" "string" {
+}
+
+resource "subnet" "simple:index:resource" {
+  inputOne = "subnet"
+  inputTwo = 1
+}
+
+# The index of a list can be used in calls in the body, the keys of a set are its elements, and variables that
+# would hide the resource the body refers to are renamed.
+subnets = [for i, az in azs : invoke("std:index:cidrsubnet", {
+  input   = cidr
+  newbits = 8
+  netnum  = i
+}).result]
+byAz = { for i, az in azs : az => element(azs, i) }
+upper = { for v in invoke("std:index:toset", {
+  input = azs
+  }).result : v => invoke("std:index:upper", {
+  input = v
+}).result }
+results = [for subnet2 in azs : "${subnet2}-${subnet.result}"]
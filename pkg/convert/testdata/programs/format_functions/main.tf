@@ -0,0 +1,28 @@
+variable "prefix" {
+    type = string
+}
+
+variable "names" {
+    type = list(string)
+}
+
+variable "ports" {
+    type = list(number)
+}
+
+variable "anything" {}
+
+locals {
+    # The other lists of formatlist are indexed alongside the first, and the strings repeated for each element.
+    # anything may or may not be a list, which changes what formatlist returns. %v writes lists as JSON, so it's only
+    # converted to a template for strings, numbers and bools.
+    name = format("%s-%d", var.prefix, 4)
+    padded = format("%05d", var.ports[0])
+    hosts = formatlist("%s.example.com", var.names)
+    endpoints = formatlist("%s:%d via %s", var.names, var.ports, var.prefix)
+    single = formatlist("%s!", var.prefix)
+    unknown = formatlist("%s", var.anything)
+    described = format("%v items", var.prefix)
+    listed = format("names: %v", var.names)
+    port_names = formatlist("port %v", var.ports)
+}
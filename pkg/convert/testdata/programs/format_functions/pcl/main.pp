@@ -0,0 +1,30 @@
+config "prefix" "string" {
+}
+
+config "names" "list(string)" {
+}
+
+config "ports" "list(number)" {
+}
+
+config "anything" {
+}
+
+# The other lists of formatlist are indexed alongside the first, and the strings repeated for each element.
+# anything may or may not be a list, which changes what formatlist returns. %v writes lists as JSON, so it's only
+# converted to a template for strings, numbers and bools.
+name = "${prefix}-4"
+padded = invoke("std:index:format", {
+  input = "%05d"
+  args  = [ports[0]]
+}).result
+hosts     = [for item in names : "${item}.example.com"]
+endpoints = [for index, item in names : "${item}:${ports[index]} via ${prefix}"]
+single    = ["${prefix}!"]
+unknown   = notImplemented("formatlist(\"%s\",var.anything)")
+described = "${prefix} items"
+listed = invoke("std:index:format", {
+  input = "names: %v"
+  args  = [names]
+}).result
+portNames = [for item in ports : "port ${item}"]
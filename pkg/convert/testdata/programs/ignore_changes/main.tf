@@ -0,0 +1,35 @@
+# Map keys and list indexes are kept and the index into the flattened disk block is dropped. The key that can't be
+# written in a property path and the index into the set of rules are widened to the whole property.
+resource "ignored_resource" "main" {
+    name = "main"
+    tags = {
+        CreatedOn = "today"
+    }
+    network_interface {
+        subnet_id = "subnet"
+    }
+    disk {
+        size_gb = 10
+    }
+    rule {
+        port = 80
+    }
+    lifecycle {
+        ignore_changes = [
+            tags["CreatedOn"],
+            tags["kubernetes.io/created-for"],
+            network_interface[0].subnet_id,
+            disk[0].size_gb,
+            rule[0].port,
+        ]
+    }
+}
+
+# ignore_changes = all is converted to every property set on the resource.
+resource "ignored_resource" "all" {
+    name = "all"
+    tags = {}
+    lifecycle {
+        ignore_changes = all
+    }
+}
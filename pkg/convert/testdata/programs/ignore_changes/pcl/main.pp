@@ -0,0 +1,30 @@
+# Map keys and list indexes are kept and the index into the flattened disk block is dropped. The key that can't be
+# written in a property path and the index into the set of rules are widened to the whole property.
+resource "main" "ignored:index:Resource" {
+  options {
+    ignoreChanges = [tags["CreatedOn"], tags, networkInterfaces[0].subnetId, disk.sizeGb, rules]
+  }
+  name = "main"
+  tags = {
+    CreatedOn = "today"
+  }
+  networkInterfaces = [{
+    subnetId = "subnet"
+  }]
+  disk = {
+    sizeGb = 10
+  }
+  rules = [{
+    port = 80
+  }]
+}
+
+
+# ignore_changes = all is converted to every property set on the resource.
+resource "all" "ignored:index:Resource" {
+  options {
+    ignoreChanges = [name, tags]
+  }
+  name = "all"
+  tags = {}
+}
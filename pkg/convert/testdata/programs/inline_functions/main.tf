@@ -0,0 +1,29 @@
+variable "input" {
+    type = string
+}
+
+variable "n" {
+    type = number
+}
+
+output "encoded" {
+    value = base64encode(var.input)
+}
+
+output "largest" {
+    value = max(var.n, 1)
+}
+
+# Arguments that aren't references or literals aren't repeated.
+output "longest" {
+    value = max(length(var.input), var.n)
+}
+
+# Functions without an inline form, or in other categories, are still invokes.
+output "shouted" {
+    value = upper(var.input)
+}
+
+output "content" {
+    value = file(var.input)
+}
@@ -0,0 +1,35 @@
+config "input" "string" {
+}
+
+config "n" "number" {
+}
+
+output "encoded" {
+  value = toBase64(input)
+}
+
+output "largest" {
+  value = (n >= 1 ? n : 1)
+}
+
+
+# Arguments that aren't references or literals aren't repeated.
+output "longest" {
+  value = invoke("std:index:max", {
+    input = [length(input), n]
+  }).result
+}
+
+
+# Functions without an inline form, or in other categories, are still invokes.
+output "shouted" {
+  value = invoke("std:index:upper", {
+    input = input
+  }).result
+}
+
+output "content" {
+  value = invoke("std:index:file", {
+    input = input
+  }).result
+}
@@ -0,0 +1,23 @@
+variable "name" {
+    type = string
+}
+
+locals {
+    prefix = "my"
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = var.name
+}
+
+module "mod" {
+    source = "./mod"
+}
+
+output "name" {
+    value = "${var.name}-name"
+}
+
+output "result" {
+    value = simple_resource.a_resource.result
+}
@@ -0,0 +1,10 @@
+config "name" "string" {
+}
+
+output "name" {
+  value = "${name}-name"
+}
+
+output "result" {
+  value = notImplemented("simple_resource.a_resource.result")
+}
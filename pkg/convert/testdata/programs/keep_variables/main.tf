@@ -0,0 +1,15 @@
+variable "name" {
+    type = string
+}
+
+variable "unused" {
+    type = string
+}
+
+locals {
+    unused = "unused"
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = var.name
+}
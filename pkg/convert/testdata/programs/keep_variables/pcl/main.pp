@@ -0,0 +1,10 @@
+config "name" "string" {
+}
+
+config "unused" "string" {
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = name
+}
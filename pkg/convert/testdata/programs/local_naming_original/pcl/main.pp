@@ -0,0 +1,10 @@
+config "nameOutput" "string" {
+}
+name   = nameOutput
+prefix = "app"
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "${prefix}-${name}"
+  inputTwo      = 1
+}
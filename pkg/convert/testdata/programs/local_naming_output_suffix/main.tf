@@ -0,0 +1,14 @@
+variable "name_output" {
+    type = string
+}
+
+locals {
+    # Names that are taken, here by the variable, get a number appended.
+    name   = var.name_output
+    prefix = "app"
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = "${local.prefix}-${local.name}"
+    input_two = 1
+}
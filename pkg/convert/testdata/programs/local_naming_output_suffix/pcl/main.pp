@@ -0,0 +1,12 @@
+config "nameOutput" "string" {
+}
+
+# Names that are taken, here by the variable, get a number appended.
+nameOutput2  = nameOutput
+prefixOutput = "app"
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "${prefixOutput}-${nameOutput2}"
+  inputTwo      = 1
+}
@@ -0,0 +1,13 @@
+variable "name_output" {
+    type = string
+}
+
+locals {
+    name   = var.name_output
+    prefix = "app"
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = "${local.prefix}-${local.name}"
+    input_two = 1
+}
@@ -0,0 +1,10 @@
+config "nameOutput" "string" {
+}
+name_   = nameOutput
+prefix_ = "app"
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "${prefix_}-${name_}"
+  inputTwo      = 1
+}
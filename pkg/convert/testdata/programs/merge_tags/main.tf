@@ -0,0 +1,27 @@
+data "aws_caller_identity" "current" {}
+
+locals {
+  common_tags = {
+    Environment = "production"
+    ManagedBy   = "terraform"
+  }
+
+  # Tags that depend on a data source, so are only known once the program runs
+  account_tags = merge(local.common_tags, {
+    AccountId = data.aws_caller_identity.current.account_id
+  })
+}
+
+resource "aws_iam_role" "role" {
+  assume_role_policy = "{}"
+
+  tags = merge(local.common_tags, {
+    Name = "my-role"
+  })
+}
+
+resource "aws_iam_role" "account_role" {
+  assume_role_policy = "{}"
+
+  tags = merge(local.account_tags, { Name = "account-role" }, { "kubernetes.io/cluster" = "owned" })
+}
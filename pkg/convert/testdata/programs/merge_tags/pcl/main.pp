@@ -0,0 +1,33 @@
+current = invoke("aws:index/getCallerIdentity:getCallerIdentity", {})
+commonTags = {
+  "Environment" = "production"
+  "ManagedBy"   = "terraform"
+}
+
+# Tags that depend on a data source, so are only known once the program runs
+accountTags = invoke("std:index:merge", {
+  input = [commonTags, {
+    "AccountId" = current.accountId
+  }]
+}).result
+
+resource "role" "aws:iam/role:Role" {
+  assumeRolePolicy = "{}"
+  tags = invoke("std:index:merge", {
+    input = [commonTags, {
+      Name = "my-role"
+    }]
+  }).result
+}
+
+resource "accountRole" "aws:iam/role:Role" {
+  __logicalName    = "account_role"
+  assumeRolePolicy = "{}"
+  tags = invoke("std:index:merge", {
+    input = [accountTags, {
+      Name = "account-role"
+      }, {
+      "kubernetes.io/cluster" = "owned"
+    }]
+  }).result
+}
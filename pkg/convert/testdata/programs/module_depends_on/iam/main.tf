@@ -0,0 +1,3 @@
+resource "simple_resource" "a_resource" {
+    input_one = "role"
+}
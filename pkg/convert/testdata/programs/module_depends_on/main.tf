@@ -0,0 +1,15 @@
+module "iam" {
+    source = "./iam"
+}
+
+# Both the range and the dependency of the module go in the same options block.
+module "cluster" {
+    source = "./iam"
+    count = 2
+    depends_on = [module.iam]
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = "hello"
+    depends_on = [module.iam]
+}
@@ -0,0 +1,4 @@
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "role"
+}
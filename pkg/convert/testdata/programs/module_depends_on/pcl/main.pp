@@ -0,0 +1,19 @@
+component "iam" "./iam" {
+}
+
+
+# Both the range and the dependency of the module go in the same options block.
+component "cluster" "./iam" {
+  options {
+    range     = 2
+    dependsOn = [iam]
+  }
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  options {
+    dependsOn = [iam]
+  }
+  inputOne = "hello"
+}
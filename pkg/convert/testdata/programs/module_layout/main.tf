@@ -0,0 +1,7 @@
+module "vpc" {
+    source = "./modules/vpc"
+}
+
+output "text" {
+    value = module.vpc.text
+}
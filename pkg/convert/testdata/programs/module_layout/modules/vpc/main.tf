@@ -0,0 +1,7 @@
+module "subnet" {
+    source = "./subnet"
+}
+
+output "text" {
+    value = module.subnet.text
+}
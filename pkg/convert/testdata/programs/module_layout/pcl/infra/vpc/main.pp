@@ -0,0 +1,6 @@
+component "subnet" "../subnet" {
+}
+
+output "text" {
+  value = subnet.text
+}
@@ -0,0 +1,6 @@
+component "vpc" "./infra/vpc" {
+}
+
+output "text" {
+  value = vpc.text
+}
@@ -0,0 +1,12 @@
+variable "name" {
+    type = string
+}
+
+resource "simple_resource" "main" {
+    input_one = var.name
+    input_two = true
+}
+
+output "id" {
+    value = simple_resource.main.result
+}
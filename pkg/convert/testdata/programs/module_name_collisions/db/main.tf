@@ -0,0 +1,12 @@
+resource "simple_resource" "main" {
+    input_one = "db"
+    input_two = false
+}
+
+data "simple_data_source" "main" {
+    input_one = simple_resource.main.result
+}
+
+output "id" {
+    value = data.simple_data_source.main.result
+}
@@ -0,0 +1,26 @@
+# Every module has its own component program, so resources with the same type and name in different modules, or in
+# two calls of the same module, don't collide. Names in the same program that clash are renamed.
+resource "simple_resource" "main" {
+    input_one = "root"
+    input_two = true
+}
+
+module "main" {
+    source = "./app"
+
+    name = simple_resource.main.result
+}
+
+module "replica" {
+    source = "./app"
+
+    name = module.main.id
+}
+
+module "db" {
+    source = "./db"
+}
+
+output "ids" {
+    value = [simple_resource.main.result, module.main.id, module.replica.id, module.db.id]
+}
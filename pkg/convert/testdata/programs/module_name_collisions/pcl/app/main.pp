@@ -0,0 +1,11 @@
+config "name" "string" {
+}
+
+resource "main" "simple:index:resource" {
+  inputOne = name
+  inputTwo = true
+}
+
+output "id" {
+  value = main.result
+}
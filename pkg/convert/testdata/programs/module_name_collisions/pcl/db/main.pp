@@ -0,0 +1,13 @@
+resource "mainResource" "simple:index:resource" {
+  __logicalName = "main"
+  inputOne      = "db"
+  inputTwo      = false
+}
+
+main = invoke("simple:index:dataSource", {
+  inputOne = mainResource.result
+})
+
+output "id" {
+  value = main.result
+}
@@ -0,0 +1,21 @@
+# Every module has its own component program, so resources with the same type and name in different modules, or in
+# two calls of the same module, don't collide. Names in the same program that clash are renamed.
+resource "main" "simple:index:resource" {
+  inputOne = "root"
+  inputTwo = true
+}
+
+component "mainComponent" "./app" {
+  name = main.result
+}
+
+component "replica" "./app" {
+  name = mainComponent.id
+}
+
+component "db" "./db" {
+}
+
+output "ids" {
+  value = [main.result, mainComponent.id, replica.id, db.id]
+}
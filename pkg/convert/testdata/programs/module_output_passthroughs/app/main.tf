@@ -0,0 +1,16 @@
+variable "environment" {
+    type = string
+}
+
+module "network" {
+    source = "./network"
+    environment = var.environment
+}
+
+output "environment" {
+    value = module.network.environment
+}
+
+output "result" {
+    value = module.network.result
+}
@@ -0,0 +1,15 @@
+variable "environment" {
+    type = string
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = var.environment
+}
+
+output "environment" {
+    value = var.environment
+}
+
+output "result" {
+    value = simple_resource.a_resource.result
+}
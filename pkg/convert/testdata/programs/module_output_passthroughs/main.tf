@@ -0,0 +1,17 @@
+variable "env" {
+    type = string
+}
+
+module "app" {
+    source = "./app"
+    environment = var.env
+}
+
+# The environment passes through both modules, so is read from the variable it came from.
+output "environment" {
+    value = module.app.environment
+}
+
+output "result" {
+    value = module.app.result
+}
@@ -0,0 +1,14 @@
+config "environment" "string" {
+}
+
+component "network" "./network" {
+  environment = environment
+}
+
+output "environment" {
+  value = environment
+}
+
+output "result" {
+  value = network.result
+}
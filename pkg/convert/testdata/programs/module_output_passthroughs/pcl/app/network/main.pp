@@ -0,0 +1,15 @@
+config "environment" "string" {
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = environment
+}
+
+output "environment" {
+  value = environment
+}
+
+output "result" {
+  value = aResource.result
+}
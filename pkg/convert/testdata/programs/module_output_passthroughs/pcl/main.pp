@@ -0,0 +1,16 @@
+config "env" "string" {
+}
+
+component "app" "./app" {
+  environment = env
+}
+
+
+# The environment passes through both modules, so is read from the variable it came from.
+output "environment" {
+  value = env
+}
+
+output "result" {
+  value = app.result
+}
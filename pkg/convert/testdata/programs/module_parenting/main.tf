@@ -0,0 +1,12 @@
+# The resources of a module are written into the program of the component the module is converted to, so the
+# code generated for the component parents them under it, as are the components of nested modules.
+module "network" {
+    source = "./network"
+
+    cidr = "10.0.0.0/16"
+}
+
+resource "simple_resource" "app" {
+    input_one = module.network.subnet_id
+    input_two = true
+}
@@ -0,0 +1,18 @@
+variable "cidr" {
+    type = string
+}
+
+resource "simple_resource" "vpc" {
+    input_one = var.cidr
+    input_two = true
+}
+
+module "subnet" {
+    source = "./subnet"
+
+    vpc_id = simple_resource.vpc.result
+}
+
+output "subnet_id" {
+    value = module.subnet.id
+}
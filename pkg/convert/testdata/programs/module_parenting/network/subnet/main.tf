@@ -0,0 +1,12 @@
+variable "vpc_id" {
+    type = string
+}
+
+resource "simple_resource" "subnet" {
+    input_one = var.vpc_id
+    input_two = false
+}
+
+output "id" {
+    value = simple_resource.subnet.result
+}
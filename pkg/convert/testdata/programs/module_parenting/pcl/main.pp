@@ -0,0 +1,10 @@
+# The resources of a module are written into the program of the component the module is converted to, so the
+# code generated for the component parents them under it, as are the components of nested modules.
+component "network" "./network" {
+  cidr = "10.0.0.0/16"
+}
+
+resource "app" "simple:index:resource" {
+  inputOne = network.subnetId
+  inputTwo = true
+}
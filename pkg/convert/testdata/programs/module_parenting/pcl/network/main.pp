@@ -0,0 +1,15 @@
+config "cidr" "string" {
+}
+
+resource "vpc" "simple:index:resource" {
+  inputOne = cidr
+  inputTwo = true
+}
+
+component "subnet" "./subnet" {
+  vpcId = vpc.result
+}
+
+output "subnetId" {
+  value = subnet.id
+}
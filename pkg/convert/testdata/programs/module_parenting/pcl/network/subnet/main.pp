@@ -0,0 +1,11 @@
+config "vpcId" "string" {
+}
+
+resource "subnet" "simple:index:resource" {
+  inputOne = vpcId
+  inputTwo = false
+}
+
+output "id" {
+  value = subnet.result
+}
@@ -0,0 +1,8 @@
+provider "aws" {
+    alias = "replica"
+}
+
+resource "aws_kms_replica_key" "replica" {
+    provider        = aws.replica
+    primary_key_arn = "arn"
+}
@@ -0,0 +1,33 @@
+provider "aws" {
+    region = "us-east-1"
+}
+
+provider "aws" {
+    alias  = "west"
+    region = "us-west-2"
+}
+
+# Aliased providers are passed as config of the component, the default provider as its provider.
+module "replication" {
+    source    = "./replication"
+    providers = {
+        aws         = aws
+        aws.replica = aws.west
+    }
+}
+
+module "regional" {
+    source    = "./replication"
+    providers = {
+        aws         = aws.west
+        aws.replica = aws.west
+    }
+}
+
+# Modules that declare an empty provider block for an alias, rather than a configuration_alias, are passed it too.
+module "legacy" {
+    source    = "./legacy"
+    providers = {
+        aws.replica = aws.west
+    }
+}
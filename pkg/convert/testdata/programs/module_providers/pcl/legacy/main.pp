@@ -0,0 +1,12 @@
+config "replica" {
+  default     = null
+  description = "The aws.replica provider"
+}
+
+resource "replicaReplicaKey" "aws:kms/replicaKey:ReplicaKey" {
+  __logicalName = "replica"
+  options {
+    provider = replica
+  }
+  primaryKeyArn = "arn"
+}
@@ -0,0 +1,23 @@
+
+resource "west" "pulumi:providers:aws" {
+  region = "us-west-2"
+}
+
+
+# Aliased providers are passed as config of the component, the default provider as its provider.
+component "replication" "./replication" {
+  replica = west
+}
+
+component "regional" "./replication" {
+  options {
+    provider = west
+  }
+  replica = west
+}
+
+
+# Modules that declare an empty provider block for an alias, rather than a configuration_alias, are passed it too.
+component "legacy" "./legacy" {
+  replica = west
+}
@@ -0,0 +1,16 @@
+config "replica" {
+  default     = null
+  description = "The aws.replica provider"
+}
+
+resource "source" "aws:s3/bucket:Bucket" {
+  bucket = "source"
+}
+
+resource "replicaBucket" "aws:s3/bucket:Bucket" {
+  __logicalName = "replica"
+  options {
+    provider = replica
+  }
+  bucket = "replica"
+}
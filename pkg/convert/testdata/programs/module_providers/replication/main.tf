@@ -0,0 +1,17 @@
+terraform {
+    required_providers {
+        aws = {
+            source                = "hashicorp/aws"
+            configuration_aliases = [aws.replica]
+        }
+    }
+}
+
+resource "aws_s3_bucket" "source" {
+    bucket = "source"
+}
+
+resource "aws_s3_bucket" "replica" {
+    provider = aws.replica
+    bucket   = "replica"
+}
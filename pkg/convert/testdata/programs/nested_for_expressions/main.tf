@@ -0,0 +1,15 @@
+variable "rules" {
+    type = map(object({ ports = list(number) }))
+}
+
+locals {
+    rules = { for k, v in var.rules : k => [for p in v.ports : {from = p, to = p}] }
+}
+
+# The objects in the fors are renamed by the schema of the list they're elements of, at both levels.
+resource "renames_resource" "a_resource" {
+    a_list = [for k, v in var.rules : {
+        inner_number = length(v.ports)
+        inner_list = [for p in v.ports : { inner_port = p }]
+    }]
+}
@@ -0,0 +1,18 @@
+config "rules" "map(object({ports=list(number)}))" {
+}
+myRules = { for k, v in rules : k => [for p in v.ports : {
+  from = p
+  to   = p
+}] }
+
+
+# The objects in the fors are renamed by the schema of the list they're elements of, at both levels.
+resource "aResource" "renames:index/index:resource" {
+  __logicalName = "a_resource"
+  theList = [for k, v in rules : {
+    number = length(v.ports)
+    ports = [for p in v.ports : {
+      port = p
+    }]
+  }]
+}
@@ -0,0 +1,25 @@
+variable "prefix" {
+    type = string
+}
+variable "x" {
+    type = string
+}
+variable "env" {
+    type = string
+}
+
+output "name" {
+    value = "${var.prefix}-${element(split(",", var.x), 0)}-${var.env == "prod" ? "p" : "np"}"
+}
+
+output "upper" {
+    value = "${upper(var.prefix)}-%{ if var.env == "prod" }live%{ else }test%{ endif }"
+}
+
+output "loop" {
+    value = "%{ for s in split(",", var.x) }${s}-%{ endfor }"
+}
+
+output "nested" {
+    value = "${var.prefix}-${join("-", [for s in split(",", var.x) : "${s}-${lower(var.env)}"])}"
+}
@@ -0,0 +1,38 @@
+config "prefix" "string" {
+}
+config "x" "string" {
+}
+config "env" "string" {
+}
+
+output "name" {
+  value = "${prefix}-${element(invoke("std:index:split", {
+    separator = ","
+    text      = x
+  }).result, 0)}-${env == "prod" ? "p" : "np"}"
+}
+
+output "upper" {
+  value = "${invoke("std:index:upper", {
+    input = prefix
+  }).result}-${env == "prod" ? "live" : "test"}"
+}
+
+output "loop" {
+  value = "${join("", [for s in invoke("std:index:split", {
+    separator = ","
+    text      = x
+  }).result : "${s}-"])}"
+}
+
+output "nested" {
+  value = "${prefix}-${invoke("std:index:join", {
+    separator = "-"
+    input = [for s in invoke("std:index:split", {
+      separator = ","
+      text      = x
+      }).result : "${s}-${invoke("std:index:lower", {
+        input = env
+    }).result}"]
+  }).result}"
+}
@@ -0,0 +1,52 @@
+variable "port" {
+    type = string
+}
+
+variable "count_number" {
+    type = number
+}
+
+variable "enabled" {
+    type = string
+}
+
+variable "name" {
+    type = string
+    default = null
+}
+
+output "arithmetic" {
+    value = var.port + 1
+}
+
+output "literal_arithmetic" {
+    value = var.count_number * "2"
+}
+
+output "comparison" {
+    value = var.port > var.count_number
+}
+
+output "logical" {
+    value = var.enabled && "true"
+}
+
+output "not" {
+    value = !var.enabled
+}
+
+output "negate" {
+    value = -var.port
+}
+
+output "null_comparison" {
+    value = var.name == null ? "unnamed" : var.name
+}
+
+output "mixed_equality" {
+    value = var.port == 80
+}
+
+output "precedence" {
+    value = (var.count_number + 1) * 2 > 3 || !(var.count_number == 0)
+}
@@ -0,0 +1,58 @@
+config "port" "string" {
+}
+
+config "countNumber" "number" {
+}
+
+config "enabled" "string" {
+}
+
+config "name" "string" {
+  default = null
+}
+
+output "arithmetic" {
+  value = invoke("std:index:tonumber", {
+    input = port
+  }).result + 1
+}
+
+output "literalArithmetic" {
+  value = countNumber * 2
+}
+
+output "comparison" {
+  value = invoke("std:index:tonumber", {
+    input = port
+  }).result > countNumber
+}
+
+output "logical" {
+  value = invoke("std:index:tobool", {
+    input = enabled
+  }).result && true
+}
+
+output "not" {
+  value = !invoke("std:index:tobool", {
+    input = enabled
+  }).result
+}
+
+output "negate" {
+  value = -invoke("std:index:tonumber", {
+    input = port
+  }).result
+}
+
+output "nullComparison" {
+  value = name == null ? "unnamed" : name
+}
+
+output "mixedEquality" {
+  value = port == 80
+}
+
+output "precedence" {
+  value = (countNumber + 1) * 2 > 3 || !(countNumber == 0)
+}
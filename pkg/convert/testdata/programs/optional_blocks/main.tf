@@ -0,0 +1,57 @@
+variable "enabled" {
+    type = bool
+}
+
+variable "name" {
+    type = string
+}
+
+resource "blocks_resource" "conditional" {
+    dynamic "a_list_of_resources" {
+        for_each = var.name != null ? [var.name] : []
+        content {
+            inner_string = a_list_of_resources.value
+        }
+    }
+}
+
+resource "blocks_resource" "compacted" {
+    dynamic "a_list_of_resources" {
+        for_each = compact([var.name])
+        iterator = "item"
+        content {
+            inner_string = item.value
+        }
+    }
+}
+
+resource "blocks_resource" "concatenated" {
+    dynamic "a_list_of_resources" {
+        for_each = concat([], var.enabled ? [] : ["fallback"])
+        content {
+            inner_string = a_list_of_resources.value
+        }
+    }
+}
+
+# Optional blocks mixed with other blocks are concatenated, so they're empty rather than null.
+resource "blocks_resource" "mixed" {
+    a_list_of_resources {
+        inner_string = "static"
+    }
+    dynamic "a_list_of_resources" {
+        for_each = try(var.enabled ? [var.name] : [], [])
+        content {
+            inner_string = a_list_of_resources.value
+        }
+    }
+}
+
+resource "maxItemsOne_resource" "single" {
+    dynamic "innerResource" {
+        for_each = var.enabled ? [1] : []
+        content {
+            someInput = true
+        }
+    }
+}
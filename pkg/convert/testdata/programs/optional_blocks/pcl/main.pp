@@ -0,0 +1,41 @@
+config "enabled" "bool" {
+}
+
+config "name" "string" {
+}
+
+resource "conditional" "blocks:index/index:resource" {
+  aListOfResources = name != null ? [{
+    innerString = name
+  }] : null
+}
+
+resource "compacted" "blocks:index/index:resource" {
+  aListOfResources = name != null && name != "" ? [{
+    innerString = name
+  }] : null
+}
+
+resource "concatenated" "blocks:index/index:resource" {
+  aListOfResources = !enabled ? [{
+    innerString = "fallback"
+  }] : null
+}
+
+
+# Optional blocks mixed with other blocks are concatenated, so they're empty rather than null.
+resource "mixed" "blocks:index/index:resource" {
+  aListOfResources = invoke("std:index:concat", {
+    input = [[{
+      innerString = "static"
+      }], enabled ? [{
+      innerString = name
+    }] : []]
+  }).result
+}
+
+resource "single" "maxItemsOne:index/index:resource" {
+  innerResource = enabled ? {
+    someInput = true
+  } : null
+}
@@ -0,0 +1,11 @@
+output "result" {
+    value = simple_resource.second.result
+}
+
+resource "simple_resource" "second" {
+    input_one = local.name
+}
+
+locals {
+    name = simple_resource.first.result
+}
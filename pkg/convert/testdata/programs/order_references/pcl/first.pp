@@ -0,0 +1,3 @@
+resource "first" "simple:index:resource" {
+  inputOne = prefix
+}
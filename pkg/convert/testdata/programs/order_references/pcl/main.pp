@@ -0,0 +1,8 @@
+name = first.result
+
+resource "second" "simple:index:resource" {
+  inputOne = name
+}
+output "result" {
+  value = second.result
+}
@@ -0,0 +1,2 @@
+config "prefix" "string" {
+}
@@ -0,0 +1,3 @@
+resource "simple_resource" "first" {
+    input_one = var.prefix
+}
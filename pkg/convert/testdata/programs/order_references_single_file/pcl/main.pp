@@ -0,0 +1,13 @@
+config "prefix" "string" {
+}
+resource "first" "simple:index:resource" {
+  inputOne = prefix
+}
+name = first.result
+
+resource "second" "simple:index:resource" {
+  inputOne = name
+}
+output "result" {
+  value = second.result
+}
@@ -0,0 +1,36 @@
+variable "multiplier" {
+    type = number
+    default = 2
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = "hello"
+    input_two = 1
+}
+
+resource "simple_resource" "b_resource" {
+    input_one = "${simple_resource.a_resource.result}-b"
+    input_two = simple_resource.a_resource.input_two * var.multiplier + 1
+}
+
+resource "blocks_resource" "a_resource" {
+    a_list_of_resources {
+        inner_string = "hello"
+    }
+}
+
+output "doubled" {
+    value = simple_resource.b_resource.input_two * 2
+}
+
+output "mixed" {
+    value = length(blocks_resource.a_resource.a_list_of_resources) * simple_resource.a_resource.input_two
+}
+
+output "comparison" {
+    value = simple_resource.b_resource.input_two > 10 ? "large" : "small"
+}
+
+output "indexed" {
+    value = length(blocks_resource.a_resource.a_list_of_resources[0].inner_string) * var.multiplier
+}
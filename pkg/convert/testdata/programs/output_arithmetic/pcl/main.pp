@@ -0,0 +1,38 @@
+config "multiplier" "number" {
+  default = 2
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "hello"
+  inputTwo      = 1
+}
+
+resource "bResource" "simple:index:resource" {
+  __logicalName = "b_resource"
+  inputOne      = "${aResource.result}-b"
+  inputTwo      = aResource.inputTwo * multiplier + 1
+}
+
+resource "aResourceResource" "blocks:index/index:resource" {
+  __logicalName = "a_resource"
+  aListOfResources = [{
+    innerString = "hello"
+  }]
+}
+
+output "doubled" {
+  value = bResource.inputTwo * 2
+}
+
+output "mixed" {
+  value = length(aResourceResource.aListOfResources) * aResource.inputTwo
+}
+
+output "comparison" {
+  value = bResource.inputTwo > 10 ? "large" : "small"
+}
+
+output "indexed" {
+  value = length(aResourceResource.aListOfResources[0].innerString) * multiplier
+}
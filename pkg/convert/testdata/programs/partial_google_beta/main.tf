@@ -0,0 +1,30 @@
+# Both providers configure the same stack config, the google provider's region is kept.
+provider "google" {
+    project = "my-project"
+    region  = "us-central1"
+}
+
+provider "google-beta" {
+    project = "my-project"
+    region  = "europe-west1"
+}
+
+provider "google-beta" {
+    alias   = "preview"
+    project = "other-project"
+}
+
+resource "google_compute_network" "ga" {
+    name = "ga"
+}
+
+resource "google_compute_network" "beta" {
+    provider = google-beta
+    name     = "beta"
+    network_firewall_policy_enforcement_order = "BEFORE_CLASSIC_FIREWALL"
+}
+
+resource "google_compute_network" "aliased" {
+    provider = google-beta.preview
+    name     = "aliased"
+}
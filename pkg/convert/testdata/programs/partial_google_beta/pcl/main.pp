@@ -0,0 +1,23 @@
+
+resource "preview" "pulumi:providers:gcp" {
+  project = "other-project"
+}
+
+resource "ga" "gcp:compute/network:Network" {
+  name = "ga"
+}
+
+// This resource used the google-beta provider and is converted to use the google provider.
+// The google provider doesn't support these fields, which may be beta only: network_firewall_policy_enforcement_order.
+resource "beta" "gcp:compute/network:Network" {
+  name                                  = "beta"
+  networkFirewallPolicyEnforcementOrder = "BEFORE_CLASSIC_FIREWALL"
+}
+
+// This resource used the google-beta provider and is converted to use the google provider.
+resource "aliased" "gcp:compute/network:Network" {
+  options {
+    provider = preview
+  }
+  name = "aliased"
+}
@@ -0,0 +1,23 @@
+resource "simple_resource" "cluster" {
+    input_one = "cluster"
+}
+
+provider "kubernetes" {
+    host                   = simple_resource.cluster.result
+    cluster_ca_certificate = base64decode(simple_resource.cluster.result)
+    token                  = simple_resource.cluster.result
+}
+
+provider "helm" {
+    kubernetes {
+        host = simple_resource.cluster.result
+        exec {
+            api_version = "client.authentication.k8s.io/v1beta1"
+            command     = "aws"
+            args        = ["eks", "get-token", "--cluster-name", simple_resource.cluster.result]
+            env = {
+                AWS_PROFILE = "prod"
+            }
+        }
+    }
+}
@@ -0,0 +1,13 @@
+resource "cluster" "simple:index:resource" {
+  inputOne = "cluster"
+}
+kubernetesKubeconfig = "apiVersion: v1\nkind: Config\nclusters:\n- name: cluster\n  cluster:\n    server: ${cluster.result}\n    certificate-authority-data: ${cluster.result}\nusers:\n- name: user\n  user:\n    token: ${cluster.result}\ncontexts:\n- name: context\n  context:\n    cluster: cluster\n    user: user\ncurrent-context: context\n"
+
+resource "kubernetes" "pulumi:providers:kubernetes" {
+  kubeconfig = kubernetesKubeconfig
+}
+helmKubeconfig = "apiVersion: v1\nkind: Config\nclusters:\n- name: cluster\n  cluster:\n    server: ${cluster.result}\nusers:\n- name: user\n  user:\n    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n      command: aws\n      args:\n      - eks\n      - get-token\n      - \"--cluster-name\"\n      - ${cluster.result}\n      env:\n      - name: AWS_PROFILE\n        value: prod\n      interactiveMode: Never\ncontexts:\n- name: context\n  context:\n    cluster: cluster\n    user: user\ncurrent-context: context\n"
+
+resource "helm" "pulumi:providers:helm" {
+  kubeconfig = helmKubeconfig
+}
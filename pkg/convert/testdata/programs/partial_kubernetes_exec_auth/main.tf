@@ -0,0 +1,30 @@
+resource "simple_resource" "cluster" {
+    input_one = "cluster"
+}
+
+# The kubernetes provider is configured from the cluster, so it's converted to a provider resource with a
+# kubeconfig built from the cluster.
+provider "kubernetes" {
+    host                   = simple_resource.cluster.result
+    cluster_ca_certificate = base64decode(simple_resource.cluster.result)
+    exec {
+        api_version = "client.authentication.k8s.io/v1beta1"
+        command     = "aws"
+        args        = ["eks", "get-token", "--cluster-name", simple_resource.cluster.result]
+        env = {
+            AWS_PROFILE = "prod"
+        }
+    }
+}
+
+# The helm provider is static, so its kubeconfig is stack config.
+provider "helm" {
+    kubernetes {
+        host                   = "https://example.com"
+        cluster_ca_certificate = "cert"
+        exec {
+            api_version = "client.authentication.k8s.io/v1beta1"
+            command     = "gke-gcloud-auth-plugin"
+        }
+    }
+}
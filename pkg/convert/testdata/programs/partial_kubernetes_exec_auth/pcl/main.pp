@@ -0,0 +1,45 @@
+resource "cluster" "simple:index:resource" {
+  inputOne = "cluster"
+}
+
+
+# The kubernetes provider is configured from the cluster, so it's converted to a provider resource with a
+# kubeconfig built from the cluster.
+resource "kubernetes" "pulumi:providers:kubernetes" {
+  kubeconfig = toJSON({
+    "apiVersion" = "v1"
+    "kind"       = "Config"
+    "clusters" = [{
+      "name" = "cluster"
+      "cluster" = {
+        "server"                     = cluster.result
+        "certificate-authority-data" = cluster.result
+      }
+    }]
+    "users" = [{
+      "name" = "user"
+      "user" = {
+        "exec" = {
+          "apiVersion" = "client.authentication.k8s.io/v1beta1"
+          "command"    = "aws"
+          "args"       = ["eks", "get-token", "--cluster-name", cluster.result]
+          "env" = [for name, value in {
+            "AWS_PROFILE" = "prod"
+            } : {
+            name  = name
+            value = value
+          }]
+          "interactiveMode" = "Never"
+        }
+      }
+    }]
+    "contexts" = [{
+      "name" = "context"
+      "context" = {
+        "cluster" = "cluster"
+        "user"    = "user"
+      }
+    }]
+    "current-context" = "context"
+  })
+}
@@ -0,0 +1,12 @@
+# s3_force_path_style was renamed in the terraform provider, so has a different name in the Pulumi provider.
+provider "aws" {
+    alias                       = "localstack"
+    region                      = "us-east-1"
+    s3_force_path_style         = true
+    skip_credentials_validation = true
+    skip_metadata_api_check     = true
+    skip_requesting_account_id  = true
+    endpoints {
+        s3 = "http://localhost:4566"
+    }
+}
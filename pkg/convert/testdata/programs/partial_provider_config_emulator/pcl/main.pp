@@ -0,0 +1,11 @@
+# s3_force_path_style was renamed in the terraform provider, so has a different name in the Pulumi provider.
+resource "localstack" "pulumi:providers:aws" {
+  region                    = "us-east-1"
+  s3UsePathStyle            = true
+  skipCredentialsValidation = true
+  skipMetadataApiCheck      = true
+  skipRequestingAccountId   = true
+  endpoints = [{
+    s3 = "http://localhost:4566"
+  }]
+}
@@ -0,0 +1,27 @@
+// Wait: local-exec sleep in provisioner 0.
+// Resources that refer to this one don't wait for its provisioner, add it to their dependsOn to keep the wait.
+resource "role" "simple:index:resource" {
+  inputOne = "role"
+}
+resource "roleProvisioner0" "command:local:Command" {
+  options {
+    dependsOn = [role]
+  }
+  create = "sleep 10"
+}
+
+// Wait: time_sleep (create_duration = "1m30s").
+// Resources that depend on this only wait for it if the time provider is available.
+resource "wait" "time:index:sleep" {
+  options {
+    dependsOn = [role]
+  }
+  createDuration = "1m30s"
+}
+
+resource "cluster" "simple:index:resource" {
+  options {
+    dependsOn = [wait]
+  }
+  inputOne = role.result
+}
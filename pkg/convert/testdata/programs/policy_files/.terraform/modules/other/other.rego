@@ -0,0 +1,3 @@
+deny[msg] {
+    msg := "ignored"
+}
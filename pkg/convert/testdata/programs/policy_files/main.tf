@@ -0,0 +1,4 @@
+resource "simple_resource" "a_resource" {
+  input_one = "hello"
+  input_two = 1
+}
@@ -0,0 +1,16 @@
+package main
+
+deny[msg] {
+    not input.tags
+    msg := "resources must be tagged"
+}
+
+deny[msg] {
+    input.public
+    msg := "resources must not be public"
+}
+
+warn_missing_owner[msg] {
+    not input.tags.owner
+    msg := "resources should have an owner"
+}
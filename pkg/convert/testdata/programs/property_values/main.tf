@@ -0,0 +1,23 @@
+variable "some_bool" {
+  type = bool
+}
+
+resource "complex_resource" "a_resource" {
+  a_deprecated_string = "hello"
+}
+
+# Literals that terraform converts to the type of the property, and properties without an enum in the Pulumi schema,
+# aren't warned about.
+resource "complex_resource" "b_resource" {
+  a_bool   = "true"
+  a_number = "1.5"
+  a_string = 1
+  inner_object {
+    inner_string = "anything"
+  }
+}
+
+resource "complex_resource" "c_resource" {
+  a_bool   = var.some_bool
+  a_number = null
+}
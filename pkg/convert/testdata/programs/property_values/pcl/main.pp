@@ -0,0 +1,26 @@
+config "someBool" "bool" {
+}
+
+resource "aResource" "complex:index/index:resource" {
+  __logicalName     = "a_resource"
+  aDeprecatedString = "hello"
+}
+
+
+# Literals that terraform converts to the type of the property, and properties without an enum in the Pulumi schema,
+# aren't warned about.
+resource "bResource" "complex:index/index:resource" {
+  __logicalName = "b_resource"
+  aBool         = "true"
+  aNumber       = "1.5"
+  aString       = 1
+  innerObject = {
+    innerString = "anything"
+  }
+}
+
+resource "cResource" "complex:index/index:resource" {
+  __logicalName = "c_resource"
+  aBool         = someBool
+  aNumber       = null
+}
@@ -0,0 +1,17 @@
+variable "region" {
+    type = string
+}
+
+provider "configured" {
+    alias          = "local"
+    string_config  = var.region
+    renamed_config = "renamed"
+    object_config {
+        inner_string = "an object"
+    }
+}
+
+resource "configured_resource" "a_resource" {
+    provider  = configured.local
+    input_one = "hi"
+}
\ No newline at end of file
@@ -0,0 +1,18 @@
+config "region" "string" {
+}
+
+resource "local" "pulumi:providers:configured" {
+  stringConfig = region
+  anotherName  = "renamed"
+  objectConfig = {
+    innerString = "an object"
+  }
+}
+
+resource "aResource" "configured:index:resource" {
+  __logicalName = "a_resource"
+  options {
+    provider = local
+  }
+  inputOne = "hi"
+}
@@ -0,0 +1,11 @@
+provider "configured" {
+    string_config = "a string"
+    endpoints {
+        s3       = "http://localhost:4566"
+        dynamodb = "http://localhost:4566"
+    }
+}
+
+resource "configured_resource" "a_default_resource" {
+    input_one = "hi"
+}
\ No newline at end of file
@@ -0,0 +1,5 @@
+
+resource "aDefaultResource" "configured:index:resource" {
+  __logicalName = "a_default_resource"
+  inputOne      = "hi"
+}
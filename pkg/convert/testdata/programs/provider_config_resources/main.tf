@@ -0,0 +1,23 @@
+resource "simple_resource" "cluster" {
+    input_one = "cluster"
+}
+
+data "simple_data_source" "auth" {
+    input_one = simple_resource.cluster.result
+}
+
+locals {
+    endpoint = simple_resource.cluster.result
+}
+
+provider "configured" {
+    string_config  = local.endpoint
+    renamed_config = "renamed"
+    object_config {
+        inner_string = data.simple_data_source.auth.result
+    }
+}
+
+resource "configured_resource" "a_resource" {
+    input_one = "hi"
+}
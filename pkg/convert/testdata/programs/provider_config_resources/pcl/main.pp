@@ -0,0 +1,24 @@
+resource "cluster" "simple:index:resource" {
+  inputOne = "cluster"
+}
+
+auth = invoke("simple:index:dataSource", {
+  inputOne = cluster.result
+})
+endpoint = cluster.result
+
+resource "configured" "pulumi:providers:configured" {
+  stringConfig = endpoint
+  anotherName  = "renamed"
+  objectConfig = {
+    innerString = auth.result
+  }
+}
+
+resource "aResource" "configured:index:resource" {
+  __logicalName = "a_resource"
+  options {
+    provider = configured
+  }
+  inputOne = "hi"
+}
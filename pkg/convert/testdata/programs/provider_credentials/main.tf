@@ -0,0 +1,27 @@
+variable "region" {
+  type = string
+}
+
+variable "secret" {
+  type = string
+}
+
+variable "api_token" {
+  type      = string
+  sensitive = true
+}
+
+variable "password" {
+  type = string
+}
+
+provider "configured" {
+  string_config = var.region
+  secret_config = var.secret
+  api_token     = var.api_token
+  password      = var.password
+}
+
+resource "configured_resource" "a_resource" {
+  input_one = var.region
+}
@@ -0,0 +1,16 @@
+config "region" "string" {
+}
+
+config "secret" "string" {
+}
+
+config "apiToken" "string" {
+}
+
+config "password" "string" {
+}
+
+resource "aResource" "configured:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = region
+}
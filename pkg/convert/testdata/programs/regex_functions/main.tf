@@ -0,0 +1,15 @@
+variable "role_arn" {
+    type = string
+}
+
+# Each capture group is a replace of the whole string with the group, and whether a string matches is whether
+# replacing it with different strings gives different results.
+resource "simple_resource" "a_resource" {
+    input_one = regex("^arn:aws:iam::(\\d+):role/(.*)$", var.role_arn)[1]
+    input_two = can(regex("^arn:", var.role_arn)) ? 1 : 2
+}
+
+resource "simple_resource" "named" {
+    input_one = regex("role/(?P<name>.*)", var.role_arn).name
+    input_two = length(regexall("admin", var.role_arn)) > 0 ? 1 : 2
+}
@@ -0,0 +1,49 @@
+config "roleArn" "string" {
+}
+
+
+# Each capture group is a replace of the whole string with the group, and whether a string matches is whether
+# replacing it with different strings gives different results.
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne = [
+    invoke("std:index:replace", {
+      text    = roleArn
+      search  = "/^(?s:.*?)(?:^arn:aws:iam::(\\d+):role/(.*)$)(?s:.*)$/"
+      replace = "$${1}"
+    }).result,
+    invoke("std:index:replace", {
+      text    = roleArn
+      search  = "/^(?s:.*?)(?:^arn:aws:iam::(\\d+):role/(.*)$)(?s:.*)$/"
+      replace = "$${2}"
+    }).result,
+  ][1]
+  inputTwo = (invoke("std:index:replace", {
+    text    = roleArn
+    search  = "/^(?s:.*?)(^arn:)(?s:.*)$/"
+    replace = "0"
+    }).result != invoke("std:index:replace", {
+    text    = roleArn
+    search  = "/^(?s:.*?)(^arn:)(?s:.*)$/"
+    replace = "1"
+  }).result) ? 1 : 2
+}
+
+resource "named" "simple:index:resource" {
+  inputOne = {
+    "name" = invoke("std:index:replace", {
+      text    = roleArn
+      search  = "/^(?s:.*?)(?:role/(?P<name>.*))(?s:.*)$/"
+      replace = "$${name}"
+    }).result
+  }.name
+  inputTwo = (invoke("std:index:replace", {
+    text    = roleArn
+    search  = "/^(?s:.*?)(admin)(?s:.*)$/"
+    replace = "0"
+    }).result != invoke("std:index:replace", {
+    text    = roleArn
+    search  = "/^(?s:.*?)(admin)(?s:.*)$/"
+    replace = "1"
+  }).result) ? 1 : 2
+}
@@ -0,0 +1,29 @@
+data "terraform_remote_state" "network" {
+  backend = "remote"
+  config = {
+    organization = "acme"
+    workspaces = {
+      name = "networking"
+    }
+  }
+}
+
+output "vpc_id" {
+  value = data.terraform_remote_state.network.outputs.vpc_id
+}
+
+# State in other backends doesn't have a stack name
+data "terraform_remote_state" "database" {
+  backend = "s3"
+  config = {
+    bucket = "acme-state"
+    key    = "database/terraform.tfstate"
+  }
+  defaults = {
+    endpoint = "localhost"
+  }
+}
+
+output "database_endpoint" {
+  value = data.terraform_remote_state.database.outputs.endpoint
+}
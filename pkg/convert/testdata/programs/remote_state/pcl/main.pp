@@ -0,0 +1,17 @@
+resource "network" "pulumi:pulumi:StackReference" {
+  name = "acme/networking/networking"
+}
+
+output "vpcId" {
+  value = network.outputs.vpcId
+}
+
+
+# State in other backends doesn't have a stack name
+resource "database" "pulumi:pulumi:StackReference" {
+  name = "database"
+}
+
+output "databaseEndpoint" {
+  value = database.outputs.endpoint
+}
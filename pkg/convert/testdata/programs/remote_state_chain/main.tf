@@ -0,0 +1,24 @@
+variable "environment" {
+  type = string
+}
+
+# Each layer reads the outputs of the layer below it in the same environment
+data "terraform_remote_state" "network" {
+  backend = "remote"
+  config = {
+    organization = "acme"
+    workspaces = {
+      name = "network-${var.environment}"
+    }
+  }
+}
+
+module "app" {
+  source      = "./modules/app"
+  environment = var.environment
+  vpc_id      = data.terraform_remote_state.network.outputs.vpc_id
+}
+
+output "cluster_name" {
+  value = module.app.cluster_name
+}
@@ -0,0 +1,21 @@
+variable "environment" {
+  type = string
+}
+
+variable "vpc_id" {
+  type = string
+}
+
+data "terraform_remote_state" "platform" {
+  backend = "remote"
+  config = {
+    organization = "acme"
+    workspaces = {
+      name = "platform-${var.environment}"
+    }
+  }
+}
+
+output "cluster_name" {
+  value = "${data.terraform_remote_state.platform.outputs.cluster_name}-${var.vpc_id}"
+}
@@ -0,0 +1,17 @@
+config "environment" "string" {
+}
+
+
+# Each layer reads the outputs of the layer below it in the same environment
+resource "network" "pulumi:pulumi:StackReference" {
+  name = "acme/network-${environment}/network-${environment}"
+}
+
+component "app" "./modules/app" {
+  environment = environment
+  vpcId       = network.outputs.vpcId
+}
+
+output "clusterName" {
+  value = app.clusterName
+}
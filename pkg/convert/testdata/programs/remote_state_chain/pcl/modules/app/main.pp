@@ -0,0 +1,13 @@
+config "environment" "string" {
+}
+
+config "vpcId" "string" {
+}
+
+resource "platform" "pulumi:pulumi:StackReference" {
+  name = "acme/platform-${environment}/platform-${environment}"
+}
+
+output "clusterName" {
+  value = "${platform.outputs.clusterName}-${vpcId}"
+}
@@ -0,0 +1,21 @@
+variable "name" {
+    type = string
+}
+
+variable "unused" {
+    type = string
+}
+
+locals {
+    prefix = "my"
+    full_name = "${local.prefix}-${var.name}"
+    unused = "unused"
+}
+
+data "simple_data_source" "unused" {
+    input_one = local.unused
+}
+
+resource "simple_resource" "a_resource" {
+    input_one = local.full_name
+}
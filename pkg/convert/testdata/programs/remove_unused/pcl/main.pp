@@ -0,0 +1,9 @@
+config "name" "string" {
+}
+prefix   = "my"
+fullName = "${prefix}-${name}"
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = fullName
+}
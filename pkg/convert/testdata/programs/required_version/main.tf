@@ -0,0 +1,8 @@
+terraform {
+  required_version = ">= 1.0"
+}
+
+resource "simple_resource" "a_resource" {
+  input_one = "hello"
+  input_two = 1
+}
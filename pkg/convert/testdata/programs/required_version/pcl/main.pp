@@ -0,0 +1,6 @@
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = "hello"
+  inputTwo      = 1
+}
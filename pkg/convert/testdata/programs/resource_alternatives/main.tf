@@ -0,0 +1,4 @@
+# Resources without a Pulumi equivalent are converted to the resource that replaced them.
+resource "aws_db_security_group" "legacy" {
+    name = "legacy"
+}
@@ -0,0 +1,6 @@
+# Resources without a Pulumi equivalent are converted to the resource that replaced them.
+// Converted from aws_db_security_group, which has no Pulumi equivalent, to aws_security_group.
+// EC2-Classic was retired, DB instances use the VPC security groups in vpc_security_group_ids.
+resource "legacy" "aws:ec2/securityGroup:SecurityGroup" {
+  name = "legacy"
+}
@@ -1,3 +1,5 @@
+// The terraform timeouts of this resource (create = "60m", delete = "2h") aren't converted.
+// Set the customTimeouts resource option to keep them.
 resource "aResource" "simple:index:resource" {
   __logicalName = "a_resource"
   inputOne      = "hello"
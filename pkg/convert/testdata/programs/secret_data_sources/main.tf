@@ -0,0 +1,18 @@
+data "aws_secretsmanager_secret_version" "db" {
+    secret_id = "db-credentials"
+}
+
+# The secret string is marked secret before it's decoded, so the values read from it stay secret.
+resource "simple_resource" "a_resource" {
+    input_one = jsondecode(data.aws_secretsmanager_secret_version.db.secret_string)["password"]
+}
+
+output "username" {
+    value     = jsondecode(data.aws_secretsmanager_secret_version.db.secret_string)["username"]
+    sensitive = true
+}
+
+# Other attributes of the data source aren't secrets.
+output "version" {
+    value = data.aws_secretsmanager_secret_version.db.version_id
+}
@@ -0,0 +1,24 @@
+db = invoke("aws:secretsmanager/getSecretVersion:getSecretVersion", {
+  secretId = "db-credentials"
+})
+
+
+# The secret string is marked secret before it's decoded, so the values read from it stay secret.
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne = invoke("std:index:jsondecode", {
+    input = secret(db.secretString)
+  }).result["password"]
+}
+
+output "username" {
+  value = secret(invoke("std:index:jsondecode", {
+    input = secret(db.secretString)
+  }).result["username"])
+}
+
+
+# Other attributes of the data source aren't secrets.
+output "version" {
+  value = db.versionId
+}
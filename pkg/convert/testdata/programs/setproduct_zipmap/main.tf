@@ -0,0 +1,14 @@
+variable "roles" {
+    type = list(string)
+}
+
+variable "policies" {
+    type = list(string)
+}
+
+locals {
+    # The variables of setproduct's for expressions don't hide those of the for expressions around it.
+    attachments = setproduct(var.roles, var.policies)
+    per_role = [for item in var.roles : setproduct([item], var.policies)]
+    role_policies = zipmap(var.roles, var.policies)
+}
@@ -0,0 +1,17 @@
+config "roles" "list(string)" {
+}
+
+config "policies" "list(string)" {
+}
+
+# The variables of setproduct's for expressions don't hide those of the for expressions around it.
+attachments = invoke("std:index:concat", {
+  input = [for item in roles : [for item2 in policies : [item, item2]]]
+}).result
+perRole = [for item in roles : invoke("std:index:concat", {
+  input = [for item2 in [item] : [for item3 in policies : [item2, item3]]]
+}).result]
+rolePolicies = invoke("std:index:zipmap", {
+  keys   = roles
+  values = policies
+}).result
@@ -0,0 +1,46 @@
+variable "enabled" {
+    type = bool
+}
+
+variable "names" {
+    type = list(string)
+}
+
+variable "mode" {}
+
+output "equal" {
+    value = var.enabled == true
+}
+
+output "not_equal" {
+    value = var.enabled != true
+}
+
+output "flipped" {
+    value = false == var.enabled
+}
+
+output "compared" {
+    value = length(var.names) > 0 ? true : false
+}
+
+output "inverted" {
+    value = length(var.names) > 0 ? false : true
+}
+
+output "counted" {
+    value = (var.enabled ? 1 : 0) == 1
+}
+
+output "not_counted" {
+    value = (var.enabled ? 0 : 1) > 0
+}
+
+output "signum" {
+    value = signum(length(var.names))
+}
+
+# Variables without a type may not be bools, so comparing them with true is kept.
+output "untyped" {
+    value = var.mode == true
+}
@@ -0,0 +1,46 @@
+config "enabled" "bool" {
+}
+
+config "names" "list(string)" {
+}
+
+config "mode" {
+}
+
+output "equal" {
+  value = enabled
+}
+
+output "notEqual" {
+  value = !enabled
+}
+
+output "flipped" {
+  value = !enabled
+}
+
+output "compared" {
+  value = length(names) > 0
+}
+
+output "inverted" {
+  value = !(length(names) > 0)
+}
+
+output "counted" {
+  value = enabled
+}
+
+output "notCounted" {
+  value = !enabled
+}
+
+output "signum" {
+  value = length(names) > 0 ? 1 : 0
+}
+
+
+# Variables without a type may not be bools, so comparing them with true is kept.
+output "untyped" {
+  value = mode == true
+}
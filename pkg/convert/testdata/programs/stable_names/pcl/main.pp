@@ -0,0 +1,15 @@
+resource "aRole" "aws:iam/role:Role" {
+  __logicalName    = "a_role"
+  name             = "snapshot-${notImplemented("formatdate(\"YYYYMMDD\",timestamp())")}"
+  assumeRolePolicy = "{}"
+}
+
+
+# Names whose changes are ignored only use the timestamp when the resource is created.
+resource "ignored" "aws:iam/role:Role" {
+  options {
+    ignoreChanges = [name]
+  }
+  name             = "snapshot-${invoke("std:index:timestamp", {}).result}"
+  assumeRolePolicy = "{}"
+}
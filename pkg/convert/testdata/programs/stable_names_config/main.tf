@@ -0,0 +1,14 @@
+resource "aws_iam_role" "a_role" {
+    name               = "snapshot-${formatdate("YYYYMMDD", timestamp())}"
+    assume_role_policy = "{}"
+}
+
+# Names whose changes are ignored only use the timestamp when the resource is created.
+resource "aws_iam_role" "ignored" {
+    name               = "snapshot-${timestamp()}"
+    assume_role_policy = "{}"
+
+    lifecycle {
+        ignore_changes = [name]
+    }
+}
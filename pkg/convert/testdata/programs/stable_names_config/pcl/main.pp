@@ -0,0 +1,18 @@
+config "aRoleNameSuffix" "string" {
+  description = "The suffix of the name of aws_iam_role.a_role, which terraform built from a timestamp"
+}
+resource "aRole" "aws:iam/role:Role" {
+  __logicalName    = "a_role"
+  name             = "snapshot-${aRoleNameSuffix}"
+  assumeRolePolicy = "{}"
+}
+
+
+# Names whose changes are ignored only use the timestamp when the resource is created.
+resource "ignored" "aws:iam/role:Role" {
+  options {
+    ignoreChanges = [name]
+  }
+  name             = "snapshot-${invoke("std:index:timestamp", {}).result}"
+  assumeRolePolicy = "{}"
+}
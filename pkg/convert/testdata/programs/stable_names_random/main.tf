@@ -0,0 +1,4 @@
+resource "aws_iam_role" "a_role" {
+    name               = "snapshot-${formatdate("YYYYMMDD", timestamp())}"
+    assume_role_policy = "{}"
+}
@@ -0,0 +1,8 @@
+resource "aRoleNameSuffix" "random:index/randomId:RandomId" {
+  byteLength = 4
+}
+resource "aRole" "aws:iam/role:Role" {
+  __logicalName    = "a_role"
+  name             = "snapshot-${aRoleNameSuffix.hex}"
+  assumeRolePolicy = "{}"
+}
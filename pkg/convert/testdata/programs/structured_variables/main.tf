@@ -0,0 +1,34 @@
+variable "settings" {}
+
+variable "subnets" {
+    type = any
+}
+
+variable "mixed" {}
+
+resource "simple_resource" "settings" {
+    input_one = var.settings.name
+    input_two = var.settings.size
+}
+
+resource "simple_resource" "subnets" {
+    count     = length(var.subnets)
+    input_one = var.subnets[count.index].id
+}
+
+output "description" {
+    value = var.settings.description
+}
+
+output "mixed_name" {
+    value = var.mixed.name
+}
+
+# mixed is used as a whole, so it may have more attributes than name.
+output "mixed" {
+    value = var.mixed
+}
+
+output "letters" {
+    value = [for letter in ["a", "b"] : upper(letter)]
+}
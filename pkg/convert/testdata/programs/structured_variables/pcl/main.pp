@@ -0,0 +1,44 @@
+config "settings" "object({description=any, name=string, size=number})" {
+}
+
+config "subnets" "list(object({id=string}))" {
+}
+
+// The type of this config couldn't be inferred from how it's used, so it's read as a dynamic value.
+// In typed languages its attributes and elements have to be accessed dynamically, or give the variable a type.
+config "mixed" {
+}
+
+resource "settingsResource" "simple:index:resource" {
+  __logicalName = "settings"
+  inputOne      = settings.name
+  inputTwo      = settings.size
+}
+
+resource "subnetsResource" "simple:index:resource" {
+  __logicalName = "subnets"
+  options {
+    range = subnets
+  }
+  inputOne = range.value.id
+}
+
+output "description" {
+  value = settings.description
+}
+
+output "mixedName" {
+  value = mixed.name
+}
+
+
+# mixed is used as a whole, so it may have more attributes than name.
+output "mixed" {
+  value = mixed
+}
+
+output "letters" {
+  value = [for letter in ["a", "b"] : invoke("std:index:upper", {
+    input = letter
+  }).result]
+}
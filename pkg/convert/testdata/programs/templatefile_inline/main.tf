@@ -0,0 +1,15 @@
+variable "port" {
+    type = number
+}
+
+# The template's variables are replaced with the expressions given for them.
+resource "simple_resource" "a_resource" {
+    input_one = templatefile("${path.module}/init.tftpl", { port = var.port, names = ["a", "b"] })
+    input_two = 1
+}
+
+# Templates that can't be read are left as they were.
+resource "simple_resource" "missing" {
+    input_one = templatefile("${path.module}/missing.tftpl", {})
+    input_two = 2
+}
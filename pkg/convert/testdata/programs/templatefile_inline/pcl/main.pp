@@ -0,0 +1,19 @@
+config "port" "number" {
+}
+
+
+# The template's variables are replaced with the expressions given for them.
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne = "listen ${port}\n${join("", [for name in ["a", "b"] : "server ${invoke("std:index:upper", {
+    input = name
+  }).result}\n"])}"
+  inputTwo = 1
+}
+
+
+# Templates that can't be read are left as they were.
+resource "missing" "simple:index:resource" {
+  inputOne = notImplemented("templatefile(\"$${path.module}/missing.tftpl\",{})")
+  inputTwo = 2
+}
@@ -0,0 +1,12 @@
+variables {
+  name = "test"
+}
+
+run "check_input" {
+  command = plan
+
+  assert {
+    condition     = simple_resource.a_resource.input_one == "hello"
+    error_message = "input_one did not match"
+  }
+}
@@ -0,0 +1,14 @@
+run "first" {
+  assert {
+    condition     = length(simple_resource.a_resource.input_one) > 0
+    error_message = "input_one was empty"
+  }
+
+  assert {
+    condition     = simple_resource.a_resource.result != null
+    error_message = "result was $${simple_resource.a_resource.result}"
+  }
+}
+
+run "no_asserts" {
+}
@@ -0,0 +1,50 @@
+variable "settings" {
+    type = map(string)
+}
+
+variable "name" {
+    type = string
+}
+
+variable "nested" {
+    type = map(map(string))
+}
+
+variable "untyped" {
+    type = any
+}
+
+locals {
+    defaults = { timeout = 10 }
+}
+
+# Attributes that might be missing are looked up with the rest of the arguments as the default, and arguments that
+# fail at conversion time are skipped.
+resource "simple_resource" "a_resource" {
+    input_one = try(var.settings.port, "80")
+    input_two = try(local.defaults.retries, local.defaults.timeout, 5)
+}
+
+# can is converted to whether the lookup isn't null, so it's false for keys that are present but set to null.
+resource "simple_resource" "another_resource" {
+    input_one = can(var.settings.debug)
+    input_two = can(local.defaults.timeout)
+}
+
+# Nested maps are looked up at each level.
+resource "simple_resource" "nested_resource" {
+    input_one = try(var.nested.network.port, "80")
+    input_two = can(var.nested.network) ? 1 : 0
+}
+
+# Anything else is left as it was, including lookups in values that aren't known to be maps, which PCL's lookup
+# doesn't take.
+resource "simple_resource" "unconverted" {
+    input_one = try(tonumber(var.name), 0)
+    input_two = 1
+}
+
+resource "simple_resource" "untyped" {
+    input_one = try(var.untyped.network.port, 80)
+    input_two = can(var.untyped.debug) ? 1 : 0
+}
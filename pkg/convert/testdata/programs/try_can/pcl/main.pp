@@ -0,0 +1,53 @@
+config "settings" "map(string)" {
+}
+
+config "name" "string" {
+}
+
+config "nested" "map(map(string))" {
+}
+
+config "untyped" "object({debug=any, network=object({port=any})})" {
+}
+defaults = {
+  timeout = 10
+}
+
+
+# Attributes that might be missing are looked up with the rest of the arguments as the default, and arguments that
+# fail at conversion time are skipped.
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = lookup(settings, "port", "80")
+  inputTwo      = defaults.timeout
+}
+
+
+# can is converted to whether the lookup isn't null, so it's false for keys that are present but set to null.
+resource "anotherResource" "simple:index:resource" {
+  __logicalName = "another_resource"
+  inputOne      = (lookup(settings, "debug", null) != null)
+  inputTwo      = true
+}
+
+
+# Nested maps are looked up at each level.
+resource "nestedResource" "simple:index:resource" {
+  __logicalName = "nested_resource"
+  inputOne      = lookup(lookup(nested, "network", {}), "port", "80")
+  inputTwo      = (lookup(nested, "network", null) != null) ? 1 : 0
+}
+
+
+# Anything else is left as it was, including lookups in values that aren't known to be maps, which PCL's lookup
+# doesn't take.
+resource "unconverted" "simple:index:resource" {
+  inputOne = notImplemented("try(tonumber(var.name),0)")
+  inputTwo = 1
+}
+
+resource "untypedResource" "simple:index:resource" {
+  __logicalName = "untyped"
+  inputOne      = notImplemented("try(var.untyped.network.port,80)")
+  inputTwo      = notImplemented("can(var.untyped.debug)") ? 1 : 0
+}
@@ -0,0 +1,5 @@
+component "vpc" "./vpc_5.1.0" {
+}
+
+component "network" "./network" {
+}
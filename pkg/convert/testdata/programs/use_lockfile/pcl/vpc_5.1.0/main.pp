@@ -0,0 +1,2 @@
+component "subnets" "./modules/subnets" {
+}
@@ -0,0 +1,45 @@
+# Only used as a string attribute, so must be a string
+variable "name" {}
+
+# Used as both a number and a string, so we can't tell
+variable "ambiguous" {}
+
+# Only used in a nested block
+variable "inner" {}
+
+# Has a default so keeps the type of that
+variable "with_default" {
+    default = 1
+}
+
+# Has an explicit type which always wins
+variable "explicit" {
+    type = any
+}
+
+# Passed to something we don't know the type of
+variable "unknown_use" {}
+
+resource "simple_resource" "a_resource" {
+    input_one = var.name
+    input_two = var.ambiguous
+}
+
+data "simple_data_source" "a_data_source" {
+    input_one = var.ambiguous
+    input_two = var.with_default
+}
+
+resource "blocks_resource" "a_resource" {
+    a_list_of_resources {
+        inner_string = var.inner
+    }
+}
+
+resource "simple_another_resource" "a_resource" {
+    input_one = var.explicit
+}
+
+output "unknown_use" {
+    value = var.unknown_use
+}
@@ -0,0 +1,56 @@
+# Only used as a string attribute, so must be a string
+config "name" "string" {
+}
+
+
+# Used as both a number and a string, so we can't tell
+config "ambiguous" {
+}
+
+
+# Only used in a nested block
+config "inner" "string" {
+}
+
+
+# Has a default so keeps the type of that
+config "withDefault" "number" {
+  default = 1
+}
+
+
+# Has an explicit type which always wins
+config "explicit" {
+}
+
+
+# Passed to something we don't know the type of
+config "unknownUse" {
+}
+
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne      = name
+  inputTwo      = ambiguous
+}
+
+aDataSource = invoke("simple:index:dataSource", {
+  inputOne = ambiguous
+  inputTwo = withDefault
+})
+
+resource "aResourceResource" "blocks:index/index:resource" {
+  __logicalName = "a_resource"
+  aListOfResources = [{
+    innerString = inner
+  }]
+}
+
+resource "aResourceAnotherResource" "simple:index:anotherResource" {
+  __logicalName = "a_resource"
+  inputOne      = explicit
+}
+
+output "unknownUse" {
+  value = unknownUse
+}
@@ -0,0 +1,17 @@
+resource "simple_resource" "role" {
+    input_one = "role"
+
+    provisioner "local-exec" {
+        command = "sleep 10"
+    }
+}
+
+resource "time_sleep" "wait" {
+    create_duration = "1m30s"
+    depends_on = [simple_resource.role]
+}
+
+resource "simple_resource" "cluster" {
+    input_one = simple_resource.role.result
+    depends_on = [time_sleep.wait]
+}
@@ -0,0 +1,27 @@
+// Wait: local-exec sleep in provisioner 0.
+// Resources that refer to this one depend on its provisioner so they still wait.
+resource "role" "simple:index:resource" {
+  inputOne = "role"
+}
+resource "roleProvisioner0" "command:local:Command" {
+  options {
+    dependsOn = [role]
+  }
+  create = "sleep 10"
+}
+
+// Wait: time_sleep (create_duration = "1m30s").
+// Converted to a command that sleeps for as long.
+resource "wait" "command:local:Command" {
+  options {
+    dependsOn = [role, roleProvisioner0]
+  }
+  create = "sleep 90"
+}
+
+resource "cluster" "simple:index:resource" {
+  options {
+    dependsOn = [wait, roleProvisioner0]
+  }
+  inputOne = role.result
+}
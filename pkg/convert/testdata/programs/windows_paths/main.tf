@@ -0,0 +1,13 @@
+# A module with a Windows style path
+module "network" {
+    source = ".\\modules\\network"
+}
+
+locals {
+    # Policies are read with Windows separators
+    policy = file("policies\\bucket.json")
+}
+
+output "policy" {
+    value = local.policy
+}
@@ -0,0 +1,12 @@
+# A module with a Windows style path
+component "network" "./modules/network" {
+}
+
+# Policies are read with Windows separators
+policy = invoke("std:index:file", {
+  input = "policies/bucket.json"
+}).result
+
+output "policy" {
+  value = policy
+}
@@ -0,0 +1,3 @@
+config "cidr" "string" {
+  default = "10.0.0.0/16"
+}
@@ -0,0 +1,21 @@
+variable "labels" {
+    type = map(string)
+}
+
+# The manifest is decoded when converting, and its keys are kept as they are.
+resource "simple_resource" "a_resource" {
+    input_one = yamldecode(file("${path.module}/manifest.yaml"))["kind"]
+    input_two = 1
+}
+
+# Values that aren't known are encoded as JSON.
+resource "simple_resource" "encoded" {
+    input_one = yamlencode({ labels = var.labels })
+    input_two = 2
+}
+
+# Files that can't be decoded are left as they were.
+resource "simple_resource" "invalid" {
+    input_one = yamldecode(file("${path.module}/invalid.yaml"))
+    input_two = 3
+}
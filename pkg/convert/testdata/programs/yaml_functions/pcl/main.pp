@@ -0,0 +1,32 @@
+config "labels" "map(string)" {
+}
+
+
+# The manifest is decoded when converting, and its keys are kept as they are.
+resource "aResource" "simple:index:resource" {
+  __logicalName = "a_resource"
+  inputOne = {
+    apiVersion = "v1"
+    data = {
+      user_name = "admin"
+    }
+    kind = "ConfigMap"
+  }["kind"]
+  inputTwo = 1
+}
+
+
+# Values that aren't known are encoded as JSON.
+resource "encoded" "simple:index:resource" {
+  inputOne = toJSON({
+    "labels" = labels
+  })
+  inputTwo = 2
+}
+
+
+# Files that can't be decoded are left as they were.
+resource "invalid" "simple:index:resource" {
+  inputOne = notImplemented("yamldecode(file(\"$${path.module}/invalid.yaml\"))")
+  inputTwo = 3
+}
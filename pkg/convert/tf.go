@@ -17,13 +17,15 @@ package convert
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2"
@@ -34,18 +36,22 @@ import (
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2/syntax"
 	"github.com/pulumi/pulumi/pkg/v3/codegen/pcl"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 	"github.com/pulumi/terraform/pkg/addrs"
 	"github.com/pulumi/terraform/pkg/configs"
 	"github.com/pulumi/terraform/pkg/getmodules"
+	"github.com/pulumi/terraform/pkg/modsdir"
 	"github.com/pulumi/terraform/pkg/registry"
 	"github.com/pulumi/terraform/pkg/registry/regsrc"
+	tfversion "github.com/pulumi/terraform/version"
 	"github.com/spf13/afero"
 	"github.com/zclconf/go-cty/cty"
-	ctyjson "github.com/zclconf/go-cty/cty/json"
+	ctyconvert "github.com/zclconf/go-cty/cty/convert"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slog"
 
 	yaml "gopkg.in/yaml.v3"
 )
@@ -67,6 +73,37 @@ func loadConfigDir(fs afero.Fs, path string) (map[string][]byte, *configs.Module
 	return p.Sources(), mod, diags
 }
 
+// checkCoreVersion returns a diagnostic for each required_version constraint in the module that isn't satisfied by
+// the version of the Terraform language we load configuration with. These are errors if the module also failed to
+// load, as the version mismatch is the most likely cause, otherwise they are just warnings.
+func checkCoreVersion(module *configs.Module, loadFailed bool) hcl.Diagnostics {
+	if module == nil {
+		return nil
+	}
+
+	severity := hcl.DiagWarning
+	if loadFailed {
+		severity = hcl.DiagError
+	}
+
+	var diagnostics hcl.Diagnostics
+	for _, constraint := range module.CoreVersionConstraints {
+		if constraint.Required.Check(tfversion.SemVer) {
+			continue
+		}
+		subject := constraint.DeclRange
+		diagnostics = append(diagnostics, &hcl.Diagnostic{
+			Subject:  &subject,
+			Severity: severity,
+			Summary:  "Unsupported Terraform version",
+			Detail: fmt.Sprintf("This configuration requires Terraform %s, but the converter supports the "+
+				"Terraform language as of version %s. Newer language features may fail to convert.",
+				constraint.Required, tfversion.SemVer),
+		})
+	}
+	return diagnostics
+}
+
 func inferPrimitiveType(input cty.Type, defaultType string) string {
 	if input.Equals(cty.Number) {
 		return "number"
@@ -276,6 +313,11 @@ func getTrivia(sources map[string][]byte, r hcl.Range, blockLike bool) (hclwrite
 			last = i
 		}
 	}
+	// Empty ranges, like the empty string in a template, start at the token after the one they end at, and have
+	// no trivia of their own.
+	if first > last {
+		return nil, nil
+	}
 
 	return getTrivaFromIndex(tokens, first, last, blockLike)
 }
@@ -309,6 +351,9 @@ func getAttributeRange(sources map[string][]byte, r hcl.Range) hcl.Range {
 		}
 		first = first - 1
 	}
+	if first < 0 {
+		return r
+	}
 
 	return hcl.Range{
 		Filename: r.Filename,
@@ -411,6 +456,8 @@ func projectListToSingleton(tokens hclwrite.Tokens) hclwrite.Tokens {
 var tfFunctionRenames = map[string]string{
 	"sensitive":  "secret",
 	"jsonencode": "toJSON",
+	// Only for values that aren't known when converting, with a warning, see convertYAMLFunction.
+	"yamlencode": "toJSON",
 	"length":     "length",
 	"element":    "element",
 }
@@ -476,27 +523,29 @@ var tfFunctionStd = map[string]struct {
 		inputs: []string{"input"},
 		output: "result",
 	},
+	"coalesce": {
+		token:     "std:index:coalesce",
+		inputs:    []string{"input"},
+		output:    "result",
+		paramArgs: true,
+	},
+	"coalescelist": {
+		token:     "std:index:coalescelist",
+		inputs:    []string{"input"},
+		output:    "result",
+		paramArgs: true,
+	},
 	"concat": {
 		token:     "std:index:concat",
 		inputs:    []string{"input"},
 		output:    "result",
 		paramArgs: true,
 	},
-	/* Currently failing due to: cannot assign expression of type { input: (string, string, string, null,
-	string) } to location of type { input: list(output(string) | string) | output(list(string)) } | output({
-	input: list(string) }):
-	*/
-	//
-	//	on main.pp line 263:
-	//	264:   value = invoke("std:index:compact", {
-	//	265:     input = ["a", "", "b", null, "c"]
-	//	266:   }).result
-	//
-	//"compact": {
-	//	token:  "std:index:compact",
-	//	inputs: []string{"input"},
-	//	output: "result",
-	//},
+	"compact": {
+		token:  "std:index:compact",
+		inputs: []string{"input"},
+		output: "result",
+	},
 	"cidrhost": {
 		token:  "std:index:cidrhost",
 		inputs: []string{"input", "host"},
@@ -517,6 +566,11 @@ var tfFunctionStd = map[string]struct {
 		inputs: []string{"input"},
 		output: "result",
 	},
+	"distinct": {
+		token:  "std:index:distinct",
+		inputs: []string{"input"},
+		output: "result",
+	},
 	"dirname": {
 		token:  "std:index:dirname",
 		inputs: []string{"input"},
@@ -572,16 +626,31 @@ var tfFunctionStd = map[string]struct {
 		inputs: []string{"input"},
 		output: "result",
 	},
+	"flatten": {
+		token:  "std:index:flatten",
+		inputs: []string{"input"},
+		output: "result",
+	},
 	"floor": {
 		token:  "std:index:floor",
 		inputs: []string{"input"},
 		output: "result",
 	},
+	"format": {
+		token:  "std:index:format",
+		inputs: []string{"input", "args"},
+		output: "result",
+	},
 	"indent": {
 		token:  "std:index:indent",
 		inputs: []string{"spaces", "input"},
 		output: "result",
 	},
+	"jsondecode": {
+		token:  "std:index:jsondecode",
+		inputs: []string{"input"},
+		output: "result",
+	},
 	"join": {
 		token:  "std:index:join",
 		inputs: []string{"separator", "input"},
@@ -608,6 +677,12 @@ var tfFunctionStd = map[string]struct {
 		inputs: []string{"input"},
 		output: "result",
 	},
+	"merge": {
+		token:     "std:index:merge",
+		inputs:    []string{"input"},
+		output:    "result",
+		paramArgs: true,
+	},
 	"min": {
 		token:     "std:index:min",
 		inputs:    []string{"input"},
@@ -714,6 +789,21 @@ var tfFunctionStd = map[string]struct {
 		inputs: []string{"input"},
 		output: "result",
 	},
+	"tobool": {
+		token:  "std:index:tobool",
+		inputs: []string{"input"},
+		output: "result",
+	},
+	"tonumber": {
+		token:  "std:index:tonumber",
+		inputs: []string{"input"},
+		output: "result",
+	},
+	"toset": {
+		token:  "std:index:toset",
+		inputs: []string{"input"},
+		output: "result",
+	},
 	"transpose": {
 		token:  "std:index:transpose",
 		inputs: []string{"input"},
@@ -754,6 +844,11 @@ var tfFunctionStd = map[string]struct {
 		inputs: []string{},
 		output: "result",
 	},
+	"zipmap": {
+		token:  "std:index:zipmap",
+		inputs: []string{"keys", "values"},
+		output: "result",
+	},
 }
 
 type convertState struct {
@@ -765,6 +860,102 @@ type convertState struct {
 
 	// Determines whether converting objects should rewrite keys to camelCase or keep it as is
 	rewriteObjectKeys bool
+
+	// Counts of what couldn't be converted, nil unless statistics were asked for
+	statistics *Statistics
+
+	// Set while converting the arguments of a module call. The PCL binder doesn't load the packages used by
+	// invokes in component blocks, so we avoid adding invokes of our own there.
+	inComponentArguments bool
+
+	// The stacks the program depends on, nil unless they were asked for
+	stackDependencies *[]StackDependency
+
+	// Hooks to call for resources of the given Terraform types
+	resourceHooks map[string]resourceHook
+
+	// The rules that apply to each resource, keyed by "type.name"
+	rules map[string]Rule
+
+	// The prefix of the addresses of things in the module being converted, e.g. "module.network.", or "" for the
+	// root module
+	modulePrefix string
+
+	// The exact versions of terraform providers from the dependency lockfile, keyed by provider name
+	providerLocks map[string]string
+	// The Pulumi versions of providers to pin resources to, keyed by terraform provider name. This is "" for
+	// providers whose mapping doesn't match the locked version.
+	pinnedVersions map[string]string
+
+	// If set function calls that can be evaluated at conversion time are replaced with their value
+	foldConstants bool
+
+	// If set kubernetes providers configured from a cluster are given a templated kubeconfig
+	kubeconfigTemplate bool
+
+	// The categories of functions that are written inline rather than as invokes, see WithInlineFunctions
+	inlineFunctions map[FunctionCategory]bool
+
+	// The arguments of module calls that module output references are converted to, keyed by the reference, and
+	// the references whose arguments are being converted
+	forwardedOutputs map[string]hclsyntax.Expression
+	forwarding       map[string]bool
+
+	// If set waits are converted so the converted program still waits, see WithWaits
+	waits bool
+	// The index of the last provisioner that waits of each resource that has one, keyed by "type.name"
+	waitingProvisioners map[string]int
+
+	// What timestamps in resource names are replaced with, see WithStableNames
+	stableNames NameStabilization
+	// The expressions timestamps in resource names are converted to, keyed by the path of the attribute, and the
+	// expression for the attribute being converted
+	nameSuffixes map[string]hclwrite.Tokens
+	nameSuffix   hclwrite.Tokens
+
+	// The archive_file data sources that are converted to the archives of the resources that use them, keyed by
+	// "data.archive_file.name"
+	archiveAssets map[string]*configs.Resource
+
+	// If set uploads of every file in a directory are converted to synced folders, see WithSyncedFolders
+	syncedFolders bool
+	// The resources that upload the files in a directory, keyed by "type.name", and the content types of the files
+	// they upload keyed by the path of the attribute
+	folderUploads      map[string]*folderUpload
+	folderContentTypes map[string]hclwrite.Tokens
+
+	// The aws_route53_record resources that validate certificates, keyed by "type.name", and the validation of the
+	// record being converted
+	certificateValidations map[string]*certificateValidation
+	certificateValidation  *certificateValidation
+
+	// The filesystem and directory of the module being converted, which templatefile reads templates from, and the
+	// variables of the template file being converted
+	sourceRoot        afero.Fs
+	sourceDirectory   string
+	templateVariables *templateVariables
+
+	// The resources without a Pulumi equivalent that are converted to the closest Pulumi resource, keyed by
+	// "type.name"
+	resourceAlternatives map[string]resourceAlternative
+
+	// The aliased providers the module is passed by its callers, keyed by providerKey, which are converted to config
+	providerInputs map[string]bool
+
+	// The files long strings have been extracted to, keyed by their path relative to the program. This is nil
+	// unless files are being extracted.
+	extractedFiles map[string]string
+
+	// Where the progress of converting the module is logged
+	logger *slog.Logger
+
+	// The loader for the Pulumi schemas of providers, see WithSchemaLoader, and the schemas it's loaded keyed by
+	// package name. A schema that couldn't be loaded is nil.
+	schemaLoader pschema.ReferenceLoader
+	schemas      map[string]*pschema.Package
+
+	// The internal errors hit converting the program, nil unless a bug report was asked for
+	internalErrors *[]InternalError
 }
 
 func (state *convertState) disableRewritingObjectKeys(f func()) {
@@ -793,19 +984,140 @@ func notImplemented(state *convertState, rng hcl.Range) hclwrite.Tokens {
 	return hclwrite.TokensForFunctionCall("notImplemented", hclwrite.TokensForValue(text))
 }
 
+// joinAsTemplate returns a template expression equivalent to a call to join with a literal separator and a literal
+// list, e.g. join(":", ["arn", var.partition, "iam"]) is the same as "arn:${var.partition}:iam". This pattern is
+// common for building ARNs, and a template converts to a much simpler interpolation than an invoke of std.join.
+func joinAsTemplate(call *hclsyntax.FunctionCallExpr) (*hclsyntax.TemplateExpr, bool) {
+	if call.Name != "join" || len(call.Args) != 2 || call.ExpandFinal {
+		return nil, false
+	}
+	separator, isIdentifier := matchStaticString(call.Args[0])
+	if separator == nil || isIdentifier {
+		return nil, false
+	}
+	list, ok := call.Args[1].(*hclsyntax.TupleConsExpr)
+	if !ok || len(list.Exprs) == 0 {
+		return nil, false
+	}
+
+	parts := []hclsyntax.Expression{}
+	for i, expr := range list.Exprs {
+		if i > 0 {
+			parts = append(parts, &hclsyntax.LiteralValueExpr{
+				Val:      cty.StringVal(*separator),
+				SrcRange: call.Args[0].Range(),
+			})
+		}
+		// Splice nested templates into this one rather than interpolating a template inside a template.
+		switch expr := expr.(type) {
+		case *hclsyntax.TemplateExpr:
+			parts = append(parts, expr.Parts...)
+		case *hclsyntax.TemplateWrapExpr:
+			parts = append(parts, expr.Wrapped)
+		default:
+			parts = append(parts, expr)
+		}
+	}
+	return &hclsyntax.TemplateExpr{Parts: parts, SrcRange: call.Range()}, true
+}
+
 func convertFunctionCallExpr(state *convertState,
 	scopes *scopes, fullyQualifiedPath string, call *hclsyntax.FunctionCallExpr,
 ) hclwrite.Tokens {
 	callRange := hcl.RangeOver(call.NameRange, call.CloseParenRange)
+	call = normalizePathArguments(call)
+	call = compactWithoutNulls(call)
+
+	// element(list, count.index) is the value of the range when ranging over the list, see convertCount.
+	if call.Name == "element" && len(call.Args) == 2 &&
+		scopes.countList != nil && isCountIndex(scopes.countList, call.Args[0], call.Args[1]) {
+		return hclwrite.TokensForTraversal(scopes.countValue)
+	}
+
+	if simplified, ok := convertSignumOfLength(state, scopes, fullyQualifiedPath, call); ok {
+		return simplified
+	}
+
+	// Timestamps in resource names are replaced with a stable suffix, see WithStableNames.
+	if state.nameSuffix != nil && isTimestamp(call) {
+		return state.nameSuffix
+	}
+
+	if state.foldConstants {
+		if folded, ok := foldFunctionCall(state, scopes, call); ok {
+			return folded
+		}
+	}
+
+	// Joins of a literal list are rewritten to string templates, this needs to happen before we convert the
+	// arguments below.
+	if template, ok := joinAsTemplate(call); ok {
+		return convertTemplateExpr(state, scopes, fullyQualifiedPath, template)
+	}
+
+	// Template files that can be read are converted to string templates.
+	if template, ok := convertTemplateFile(state, scopes, call); ok {
+		return template
+	}
+
+	// try and can have no equivalent, but those that guard against missing attributes can be converted to lookups.
+	if tokens, ok := convertTryOrCan(state, scopes, call); ok {
+		return tokens
+	}
+
+	// cidrsubnets with known newbits is a list of cidrsubnet calls.
+	if subnets, ok := convertCidrSubnets(state, scopes, call); ok {
+		return subnets
+	}
+
+	// setproduct is converted to nested for expressions over its sets.
+	if product, ok := convertSetProduct(state, scopes, call); ok {
+		return product
+	}
+
+	// flatten of nested for expressions is converted to concats of them, see convertFlatten.
+	if tokens, ok := convertFlatten(state, scopes, fullyQualifiedPath, call); ok {
+		return tokens
+	}
+
+	// format and formatlist are converted to string templates where they can be, see convertFormat.
+	if tokens, ok := convertFormat(state, scopes, call); ok {
+		return tokens
+	}
+
+	// regex is converted to std replaces of its capture groups, see convertRegexFunction.
+	if tokens, ok := convertRegexFunction(state, scopes, call); ok {
+		return tokens
+	}
+
+	// YAML that's known at conversion time is decoded or encoded like terraform would.
+	if tokens, ok := convertYAMLFunction(state, scopes, call); ok {
+		return tokens
+	}
+
+	// std coalesce only takes strings, so there's no equivalent for coalescing objects or lists.
+	if call.Name == "coalesce" && hasCollectionArgument(call) {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Subject:  &callRange,
+			Severity: hcl.DiagWarning,
+			Summary:  "Function not supported",
+			Detail:   "Function coalesce can only be converted for strings, numbers and bools, not objects or lists",
+		})
+		state.countNotImplemented("function:" + call.Name)
+		return notImplemented(state, call.Range())
+	}
 
 	args := []hclwrite.Tokens{}
 	for _, arg := range call.Args {
-		if call.Name == "jsonencode" {
+		if call.Name == "jsonencode" || call.Name == "yamlencode" {
 			// when encountering a jsonencode function, we need to convert the underlying content without
 			// rewriting the object keys to camelCase (for example when converting JSON policy document of an IAM role)
 			state.disableRewritingObjectKeys(func() {
 				args = append(args, convertExpression(state, false, scopes, "", arg))
 			})
+		} else if call.Name == "merge" {
+			// The maps being merged have the same type as the result, so their keys should be treated the same
+			args = append(args, convertExpression(state, false, scopes, fullyQualifiedPath, arg))
 		} else {
 			args = append(args, convertExpression(state, false, scopes, "", arg))
 		}
@@ -837,6 +1149,11 @@ func convertFunctionCallExpr(state *convertState,
 		return hclwrite.TokensForFunctionCall(newName, args...)
 	}
 
+	// Next see if it should be written inline rather than as an invoke
+	if inline, ok := convertInlineFunction(state, call, args); ok {
+		return inline
+	}
+
 	// Next see if it's mapped to a PCL invoke
 	if invoke, has := tfFunctionStd[call.Name]; has {
 		invokeArgs := make([]hclwrite.ObjectAttrTokens, 0)
@@ -905,10 +1222,55 @@ func convertFunctionCallExpr(state *convertState,
 		Summary:  "Function not yet implemented",
 		Detail:   fmt.Sprintf("Function %s not yet implemented", call.Name),
 	})
+	state.countNotImplemented("function:" + call.Name)
 
 	return notImplemented(state, call.Range())
 }
 
+// compactWithoutNulls returns call without the null literals in its list if it's a call of compact. compact removes
+// nulls from the list anyway, and std compact only takes strings.
+func compactWithoutNulls(call *hclsyntax.FunctionCallExpr) *hclsyntax.FunctionCallExpr {
+	if call.Name != "compact" || len(call.Args) != 1 {
+		return call
+	}
+	tuple, ok := call.Args[0].(*hclsyntax.TupleConsExpr)
+	if !ok {
+		return call
+	}
+
+	exprs := make([]hclsyntax.Expression, 0, len(tuple.Exprs))
+	for _, expr := range tuple.Exprs {
+		if literal, ok := expr.(*hclsyntax.LiteralValueExpr); ok && literal.Val.IsNull() {
+			continue
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == len(tuple.Exprs) {
+		return call
+	}
+
+	compacted := *tuple
+	compacted.Exprs = exprs
+	withoutNulls := *call
+	withoutNulls.Args = []hclsyntax.Expression{&compacted}
+	return &withoutNulls
+}
+
+// hasCollectionArgument returns true if any of the arguments of call is an object or list literal, or a for
+// expression. A list that's expanded into the arguments doesn't count.
+func hasCollectionArgument(call *hclsyntax.FunctionCallExpr) bool {
+	for i, arg := range call.Args {
+		if call.ExpandFinal && i == len(call.Args)-1 {
+			continue
+		}
+		switch arg.(type) {
+		case *hclsyntax.ObjectConsExpr, *hclsyntax.TupleConsExpr, *hclsyntax.ForExpr:
+			return true
+		}
+	}
+	return false
+}
+
 func convertTupleConsExpr(state *convertState, inBlock bool, scopes *scopes,
 	fullyQualifiedPath string, expr *hclsyntax.TupleConsExpr,
 ) hclwrite.Tokens {
@@ -1004,6 +1366,7 @@ func convertObjectConsExpr(state *convertState, inBlock bool, scopes *scopes,
 				} else {
 					// We either don't know this type, or know it's not a map, so we should try to rename the keys.
 					subQualifiedPath = appendPath(fullyQualifiedPath, *name)
+					checkKnownProperty(state, scopes, subQualifiedPath, item.KeyExpr.Range())
 					*name = scopes.pulumiName(subQualifiedPath)
 				}
 
@@ -1071,6 +1434,16 @@ func convertTemplateWrapExpr(state *convertState,
 	return tokens
 }
 
+// convertTemplateJoinExpr converts a %{ for } template directive. The directive is parsed as a for expression
+// producing a list of strings, which we join back together.
+func convertTemplateJoinExpr(state *convertState,
+	scopes *scopes, fullyQualifiedPath string, expr *hclsyntax.TemplateJoinExpr,
+) hclwrite.Tokens {
+	return hclwrite.TokensForFunctionCall("join",
+		hclwrite.TokensForValue(cty.StringVal("")),
+		convertExpression(state, false, scopes, "", expr.Tuple))
+}
+
 func convertTemplateExpr(state *convertState,
 	scopes *scopes, fullyQualifiedPath string, expr *hclsyntax.TemplateExpr,
 ) hclwrite.Tokens {
@@ -1110,8 +1483,12 @@ func camelCaseName(name string) string {
 	}
 
 	name = tfbridge.TerraformToPulumiNameV2(name, nil, nil)
-	name = strings.ToLower(string(rune(name[0]))) + name[1:]
-	return name
+	if name == "" {
+		return name
+	}
+	// Identifiers can start with any unicode letter, so lower case the first rune rather than the first byte.
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(first)) + name[size:]
 }
 
 // Returns whether the fully qualified path is being applied for a property.
@@ -1175,6 +1552,10 @@ func rewriteTraversal(
 	// We need to rewrite traversals, because we don't have the same top level variable names as terraform.
 	contract.Requiref(len(traversal) > 0, "traversal", "Traversal must have at least one element")
 
+	if tokens, ok := rewriteOptionalBlockIterator(scopes, traversal); ok {
+		return tokens
+	}
+
 	newTraversal := make([]hcl.Traverser, 0)
 
 	var maybeFirstAttr *hcl.TraverseAttr
@@ -1214,6 +1595,7 @@ func rewriteTraversal(
 				Context:  &subjectRange,
 				Subject:  &contextRange,
 			})
+			state.countNotImplemented("reference:" + root.Name + "." + maybeFirstAttr.Name)
 			return notImplemented(state, getTraversalRange(traversal))
 		} else if root.Name == "var" && maybeFirstAttr != nil {
 			// This is a lookup of a var etc, we need to rewrite this traversal such that the root is now the
@@ -1234,6 +1616,9 @@ func rewriteTraversal(
 			rootName := scopes.lookup(path)
 			if rootName != "" {
 				newName := scopes.getOrAddPulumiName(path, "", "data"+camelCaseName(rootName))
+				if scopes.roots[path].ConditionalCount {
+					return rewriteConditionalDataReference(scopes, path, newName, traversal[3:])
+				}
 				newTraversal = append(newTraversal, hcl.TraverseRoot{Name: newName})
 				newTraversal = append(newTraversal, rewriteRelativeTraversal(scopes, path, traversal[3:])...)
 			} else {
@@ -1379,7 +1764,35 @@ func convertScopeTraversalExpr(
 	state *convertState, inBlock bool,
 	scopes *scopes, fullyQualifiedPath string, expr *hclsyntax.ScopeTraversalExpr,
 ) hclwrite.Tokens {
-	return rewriteTraversal(state, scopes, fullyQualifiedPath, expr.Traversal)
+	if key, argument, ok := forwardedOutput(state, expr.Traversal); ok {
+		if state.forwarding == nil {
+			state.forwarding = map[string]bool{}
+		}
+		state.forwarding[key] = true
+		defer delete(state.forwarding, key)
+		return convertExpression(state, inBlock, scopes, fullyQualifiedPath, argument)
+	}
+
+	// References to the variables of a template file are converted to the expressions given for them, which are in
+	// the scope of the templatefile call rather than the template.
+	if value, ok := templateVariable(state, scopes, expr.Traversal); ok {
+		variables := state.templateVariables
+		state.templateVariables = nil
+		defer func() { state.templateVariables = variables }()
+		return convertExpression(state, inBlock, scopes, "", value)
+	}
+
+	if state.certificateValidation != nil {
+		if value, ok := state.certificateValidation.value(expr.Traversal); ok {
+			return convertExpression(state, inBlock, scopes, "", value)
+		}
+	}
+
+	tokens := rewriteTraversal(state, scopes, fullyQualifiedPath, expr.Traversal)
+	if isSecretDataSourceAttribute(scopes, expr.Traversal) {
+		return tokensForSecret(tokens)
+	}
+	return tokens
 }
 
 func convertRelativeTraversalExpr(
@@ -1392,10 +1805,111 @@ func convertRelativeTraversalExpr(
 	return tokens
 }
 
+// staticType returns the type of the expression if it's known without evaluating it, that is for literals, templates
+// and references to variables with a primitive type constraint. Otherwise it returns cty.DynamicPseudoType.
+func staticType(scopes *scopes, expr hclsyntax.Expression) cty.Type {
+	switch expr := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		return expr.Val.Type()
+	case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+		return cty.String
+	case *hclsyntax.ParenthesesExpr:
+		return staticType(scopes, expr.Expression)
+	case *hclsyntax.ScopeTraversalExpr:
+		if len(expr.Traversal) != 2 || expr.Traversal.RootName() != "var" {
+			break
+		}
+		attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		if root, has := scopes.roots["var."+attr.Name]; has &&
+			root.VariableType != cty.NilType && root.VariableType.IsPrimitiveType() {
+			return root.VariableType
+		}
+	}
+	return cty.DynamicPseudoType
+}
+
+// convertOperand converts an operand of an operator that terraform would implicitly convert to the given type
+// (number or bool). Target languages are stricter than terraform, so string operands are converted explicitly,
+// literals directly and anything else with the std conversion functions.
+func convertOperand(state *convertState, scopes *scopes,
+	fullyQualifiedPath string, expr hclsyntax.Expression, typ cty.Type,
+) hclwrite.Tokens {
+	if !staticType(scopes, expr).Equals(cty.String) {
+		return convertExpression(state, false, scopes, fullyQualifiedPath, expr)
+	}
+
+	// Quoted strings are templates, but ones without any interpolation are just literal values.
+	var literal *cty.Value
+	if lit, ok := expr.(*hclsyntax.LiteralValueExpr); ok {
+		literal = &lit.Val
+	} else if template, ok := expr.(*hclsyntax.TemplateExpr); ok && template.IsStringLiteral() {
+		val, diags := template.Value(nil)
+		if !diags.HasErrors() {
+			literal = &val
+		}
+	}
+	if literal != nil && !literal.IsNull() {
+		val, err := ctyconvert.Convert(*literal, typ)
+		if err == nil {
+			return hclwrite.TokensForValue(val)
+		}
+	}
+
+	if state.inComponentArguments {
+		return convertExpression(state, false, scopes, fullyQualifiedPath, expr)
+	}
+
+	name := "tonumber"
+	if typ.Equals(cty.Bool) {
+		name = "tobool"
+	}
+	return tokensForStdInvoke(name, convertExpression(state, false, scopes, fullyQualifiedPath, expr))
+}
+
 func convertBinaryOpExpr(state *convertState, inBlock bool, scopes *scopes,
 	fullyQualifiedPath string, expr *hclsyntax.BinaryOpExpr,
 ) hclwrite.Tokens {
-	tokens := convertExpression(state, false, scopes, fullyQualifiedPath, expr.LHS)
+	if simplified, ok := convertSimplifiedCondition(state, inBlock, scopes, fullyQualifiedPath, expr); ok {
+		return simplified
+	}
+	if match, ok := convertRegexallMatch(state, scopes, expr); ok {
+		return match
+	}
+
+	// Terraform converts the operands of arithmetic and comparison operators to numbers, and of logical operators
+	// to bools, but equality never converts.
+	operandType := expr.Op.Type
+	if operandType.Equals(cty.Bool) && expr.Op != hclsyntax.OpLogicalOr && expr.Op != hclsyntax.OpLogicalAnd {
+		operandType = cty.Number
+	}
+	if expr.Op == hclsyntax.OpEqual || expr.Op == hclsyntax.OpNotEqual {
+		operandType = cty.DynamicPseudoType
+
+		lhsType, rhsType := staticType(scopes, expr.LHS), staticType(scopes, expr.RHS)
+		if lhsType != cty.DynamicPseudoType && rhsType != cty.DynamicPseudoType && !lhsType.Equals(rhsType) {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Comparison of mixed types",
+				Detail: fmt.Sprintf("Terraform never considers a %s equal to a %s, "+
+					"but the converted comparison may convert between them",
+					lhsType.FriendlyName(), rhsType.FriendlyName()),
+				Subject: expr.SrcRange.Ptr(),
+			})
+		}
+	}
+
+	convertSide := func(side hclsyntax.Expression) hclwrite.Tokens {
+		if operandType == cty.DynamicPseudoType {
+			return convertExpression(state, false, scopes, fullyQualifiedPath, side)
+		}
+		return convertOperand(state, scopes, fullyQualifiedPath, side, operandType)
+	}
+
+	precedence := binaryPrecedence(expr.Op)
+	tokens := parenthesizeOperand(convertSide(expr.LHS), precedence, false)
 	switch expr.Op {
 	case hclsyntax.OpLogicalOr:
 		tokens = append(tokens, makeToken(hclsyntax.TokenOr, "||"))
@@ -1426,7 +1940,7 @@ func convertBinaryOpExpr(state *convertState, inBlock bool, scopes *scopes,
 	default:
 		contract.Failf("unknown binary operation: %T", expr)
 	}
-	tokens = append(tokens, convertExpression(state, false, scopes, fullyQualifiedPath, expr.RHS)...)
+	tokens = append(tokens, parenthesizeOperand(convertSide(expr.RHS), precedence, true)...)
 	return tokens
 }
 
@@ -1442,27 +1956,90 @@ func convertUnaryOpExpr(state *convertState, inBlock bool, scopes *scopes,
 	default:
 		contract.Failf("unknown unary operation: %T", expr)
 	}
-	tokens = append(tokens, convertExpression(state, false, scopes, fullyQualifiedPath, expr.Val)...)
+	operand := convertOperand(state, scopes, fullyQualifiedPath, expr.Val, expr.Op.Type)
+	tokens = append(tokens, parenthesizeOperand(operand, precedenceUnary, false)...)
 	return tokens
 }
 
+// isSetExpr returns true if expr is known to be a set without evaluating it: a toset call, a set typed variable or a
+// set attribute of a data source.
+func isSetExpr(scopes *scopes, expr hclsyntax.Expression) bool {
+	switch expr := unwrapParentheses(expr).(type) {
+	case *hclsyntax.FunctionCallExpr:
+		return expr.Name == "toset" && len(expr.Args) == 1 && !expr.ExpandFinal
+	case *hclsyntax.ScopeTraversalExpr:
+		return isSetVariable(scopes, expr.Traversal) || isSetDataAttribute(scopes, expr.Traversal)
+	}
+	return false
+}
+
+// forVariableName returns the name a variable of a for expression is converted to, its name in camelCase with a
+// number appended if that would hide a top level name, or a variable of an enclosing for expression, that the body
+// might refer to. taken are the names of the expression's other variables.
+func forVariableName(scopes *scopes, name string, taken map[string]bool) string {
+	pulumiName := camelCaseName(name)
+	hides := func(candidate string) bool {
+		if taken[candidate] || scopes.isUsed(candidate) {
+			return true
+		}
+		for _, locals := range scopes.locals {
+			for tfName, localName := range locals {
+				// Hiding a variable of the same name is fine, terraform hides it too.
+				if localName == candidate && tfName != name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	candidate := pulumiName
+	for counter := 2; hides(candidate); counter++ {
+		candidate = fmt.Sprintf("%s%d", pulumiName, counter)
+	}
+	return candidate
+}
+
 func convertForExpr(state *convertState, inBlock bool, scopes *scopes,
 	fullyQualifiedPath string, expr *hclsyntax.ForExpr,
+) hclwrite.Tokens {
+	// Each value is an element of the resulting list or map, or of a list in the map if grouped, so its type is
+	// known if the for's is, even through nested fors, and objects in it can be renamed like in a list or map.
+	valuePath := appendPathArray(fullyQualifiedPath)
+	if expr.Group {
+		valuePath = appendPathArray(valuePath)
+	}
+	return convertForExprWithValue(state, scopes, expr, func() hclwrite.Tokens {
+		return convertExpression(state, false, scopes, valuePath, expr.ValExpr)
+	})
+}
+
+// convertForExprWithValue converts expr like convertForExpr, but with its value converted by convertValue, which is
+// called with the for's variables in scope.
+func convertForExprWithValue(state *convertState, scopes *scopes,
+	expr *hclsyntax.ForExpr, convertValue func() hclwrite.Tokens,
 ) hclwrite.Tokens {
 	// The collection doesn't yet have access to the key/value scopes
 	collTokens := convertExpression(state, false, scopes, "", expr.CollExpr)
 
-	// TODO: We should ensure key and value vars are unique
+	// The keys of a set are its elements, but sets are converted to lists whose keys are their indices, so the key
+	// variable of a for over a set is converted to the value variable.
+	setKey := expr.KeyVar != "" && isSetExpr(scopes, expr.CollExpr)
+
+	valueName := forVariableName(scopes, expr.ValVar, nil)
 	locals := map[string]string{
-		expr.ValVar: camelCaseName(expr.ValVar),
+		expr.ValVar: valueName,
 	}
-	if expr.KeyVar != "" {
-		locals[expr.KeyVar] = camelCaseName(expr.KeyVar)
+	keyName := ""
+	if setKey {
+		locals[expr.KeyVar] = valueName
+	} else if expr.KeyVar != "" {
+		keyName = forVariableName(scopes, expr.KeyVar, map[string]bool{valueName: true})
+		locals[expr.KeyVar] = keyName
 	}
 	scopes.push(locals)
 
 	keyTokens := convertExpression(state, false, scopes, "", expr.KeyExpr)
-	valueTokens := convertExpression(state, false, scopes, "", expr.ValExpr)
+	valueTokens := convertValue()
 	condTokens := convertExpression(state, false, scopes, "", expr.CondExpr)
 
 	scopes.pop()
@@ -1484,11 +2061,11 @@ func convertForExpr(state *convertState, inBlock bool, scopes *scopes,
 
 	// Write the intro
 	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "for"))
-	if locals[expr.KeyVar] != "" {
-		tokens = append(tokens, makeToken(hclsyntax.TokenIdent, locals[expr.KeyVar]))
+	if keyName != "" {
+		tokens = append(tokens, makeToken(hclsyntax.TokenIdent, keyName))
 		tokens = append(tokens, makeToken(hclsyntax.TokenComma, ","))
 	}
-	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, locals[expr.ValVar]))
+	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, valueName))
 	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "in"))
 	tokens = append(tokens, collTokens...)
 	tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
@@ -1525,9 +2102,101 @@ func convertForExpr(state *convertState, inBlock bool, scopes *scopes,
 	return tokens
 }
 
+// countedList returns the list that count counts, if count is length(list) and the body indexes the list by
+// count.index. This is the common pattern of a resource per element of a list, like a subnet per availability zone,
+// which is clearer ranging over the list itself than over its indices.
+func countedList(count hcl.Expression, body hcl.Body) (*hclsyntax.ScopeTraversalExpr, bool) {
+	call, ok := count.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "length" || len(call.Args) != 1 {
+		return nil, false
+	}
+	list, ok := call.Args[0].(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil, false
+	}
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+
+	indexed := false
+	hclsyntax.VisitAll(syntaxBody, func(node hclsyntax.Node) hcl.Diagnostics {
+		switch node := node.(type) {
+		case *hclsyntax.IndexExpr:
+			indexed = indexed || isCountIndex(list.Traversal, node.Collection, node.Key)
+		case *hclsyntax.FunctionCallExpr:
+			indexed = indexed || (node.Name == "element" && len(node.Args) == 2 &&
+				isCountIndex(list.Traversal, node.Args[0], node.Args[1]))
+		}
+		return nil
+	})
+	return list, indexed
+}
+
+// isCountIndex returns true if collection is list and key is count.index.
+func isCountIndex(list hcl.Traversal, collection, key hcl.Expression) bool {
+	collectionTraversal, ok := collection.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || !sameTraversal(list, collectionTraversal.Traversal) {
+		return false
+	}
+	keyTraversal, ok := key.(*hclsyntax.ScopeTraversalExpr)
+	return ok && sameTraversal(keyTraversal.Traversal, hcl.Traversal{
+		hcl.TraverseRoot{Name: "count"}, hcl.TraverseAttr{Name: "index"},
+	})
+}
+
+// sameTraversal returns true if a and b traverse the same path, ignoring where they are in the source.
+func sameTraversal(a, b hcl.Traversal) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		switch a := a[i].(type) {
+		case hcl.TraverseRoot:
+			if b, ok := b[i].(hcl.TraverseRoot); !ok || a.Name != b.Name {
+				return false
+			}
+		case hcl.TraverseAttr:
+			if b, ok := b[i].(hcl.TraverseAttr); !ok || a.Name != b.Name {
+				return false
+			}
+		case hcl.TraverseIndex:
+			if b, ok := b[i].(hcl.TraverseIndex); !ok || !a.Key.RawEquals(b.Key) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// convertCount converts the count of a resource or module call, whose arguments are body, to a range and sets up
+// scopes to map count.index.
+func convertCount(state *convertState, scopes *scopes, count hcl.Expression, body hcl.Body) hclwrite.Tokens {
+	if list, ok := countedList(count, body); ok {
+		// Range over the list itself, count.index is then the key of each element and indexing the list by it is
+		// the value.
+		countExpr := convertExpression(state, true, scopes, "", list)
+		scopes.countIndex = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "key"}}
+		scopes.countList = list.Traversal
+		scopes.countValue = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
+		return countExpr
+	}
+
+	countExpr := convertExpression(state, true, scopes, "", count)
+	// Set the count_index scope
+	scopes.countIndex = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
+	return countExpr
+}
+
 func convertIndexExpr(state *convertState, inBlock bool, scopes *scopes,
 	fullyQualifiedPath string, expr *hclsyntax.IndexExpr,
 ) hclwrite.Tokens {
+	if scopes.countList != nil && isCountIndex(scopes.countList, expr.Collection, expr.Key) {
+		return hclwrite.TokensForTraversal(scopes.countValue)
+	}
+
 	collection := convertExpression(state, inBlock, scopes, fullyQualifiedPath, expr.Collection)
 	key := convertExpression(state, false, scopes, "", expr.Key)
 
@@ -1561,8 +2230,14 @@ func convertAnonSymbolExpr(scopes *scopes,
 func convertConditionalExpr(state *convertState, inBlock bool, scopes *scopes,
 	fullyQualifiedPath string, expr *hclsyntax.ConditionalExpr,
 ) hclwrite.Tokens {
+	if simplified, ok := convertSimplifiedCondition(state, inBlock, scopes, fullyQualifiedPath, expr); ok {
+		return simplified
+	}
+
 	condition := convertExpression(state, inBlock, scopes, "", expr.Condition)
+	condition = parenthesizeOperand(condition, precedenceConditional, true)
 	trueResult := convertExpression(state, false, scopes, "", expr.TrueResult)
+	trueResult = parenthesizeOperand(trueResult, precedenceConditional, true)
 	falseResult := convertExpression(state, inBlock, scopes, "", expr.FalseResult)
 
 	tokens := condition
@@ -1583,6 +2258,64 @@ func convertParenthesesExpr(state *convertState, inBlock bool, scopes *scopes,
 	return append(leading, append(tokens, trailing...)...)
 }
 
+// isSetVariable returns true if the traversal is a reference to an input variable with a set type.
+func isSetVariable(scopes *scopes, traversal hcl.Traversal) bool {
+	if len(traversal) != 2 || traversal.RootName() != "var" {
+		return false
+	}
+	attr, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+	root, has := scopes.roots["var."+attr.Name]
+	return has && root.VariableType != cty.NilType && root.VariableType.IsSetType()
+}
+
+// convertForEachExpr converts an expression used to drive for_each. Terraform only allows for_each over maps and
+// sets, and for sets each.key is the same as each.value. Pulumi ranges over lists by index, so sets built with toset,
+// passed in as set typed variables, or read from set attributes of data sources are converted to a map from each
+// element to itself to keep the same keys.
+func convertForEachExpr(state *convertState, scopes *scopes,
+	fullyQualifiedPath string, expr hcl.Expression,
+) hclwrite.Tokens {
+	inner := expr
+	for {
+		paren, ok := inner.(*hclsyntax.ParenthesesExpr)
+		if !ok {
+			break
+		}
+		inner = paren.Expression
+	}
+
+	var elements hclwrite.Tokens
+	if call, ok := inner.(*hclsyntax.FunctionCallExpr); ok &&
+		call.Name == "toset" && len(call.Args) == 1 && !call.ExpandFinal {
+		elements = convertExpression(state, true, scopes, "", call.Args[0])
+	} else if traversal, ok := inner.(*hclsyntax.ScopeTraversalExpr); ok &&
+		(isSetVariable(scopes, traversal.Traversal) || isSetDataAttribute(scopes, traversal.Traversal)) {
+		elements = convertExpression(state, true, scopes, "", traversal)
+	} else {
+		return convertExpression(state, true, scopes, fullyQualifiedPath, expr)
+	}
+	return tokensForSetRange(elements)
+}
+
+// tokensForSetRange returns the range of a for_each over a set of the given elements, a map of each element to
+// itself as each.key and each.value are both the element.
+func tokensForSetRange(elements hclwrite.Tokens) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrace, "{")}
+	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "for"))
+	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "__key"))
+	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "in"))
+	tokens = append(tokens, elements...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "__key"))
+	tokens = append(tokens, makeToken(hclsyntax.TokenFatArrow, "=>"))
+	tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "__key"))
+	tokens = append(tokens, makeToken(hclsyntax.TokenCBrace, "}"))
+	return tokens
+}
+
 func convertExpression(state *convertState, inBlock bool, scopes *scopes,
 	fullyQualifiedPath string, expr hcl.Expression,
 ) hclwrite.Tokens {
@@ -1621,6 +2354,8 @@ func convertExpression(state *convertState, inBlock bool, scopes *scopes,
 		return convertAnonSymbolExpr(scopes, fullyQualifiedPath, expr)
 	case *hclsyntax.TemplateWrapExpr:
 		return convertTemplateWrapExpr(state, scopes, fullyQualifiedPath, expr)
+	case *hclsyntax.TemplateJoinExpr:
+		return convertTemplateJoinExpr(state, scopes, fullyQualifiedPath, expr)
 	case *hclsyntax.ConditionalExpr:
 		return convertConditionalExpr(state, inBlock, scopes, fullyQualifiedPath, expr)
 	case *hclsyntax.ParenthesesExpr:
@@ -1722,26 +2457,124 @@ func bodyContent(body hcl.Body) *hcl.BodyContent {
 	return content
 }
 
-// Convert a hcl.Body treating sub-bodies as attributes
-func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string, body hcl.Body) bodyAttrsTokens {
-	contract.Assertf(fullyQualifiedPath != "", "fullyQualifiedPath should not be empty")
+// checkKnownProperty adds a warning if the provider schema for the object containing fullyQualifiedPath is
+// known, but doesn't contain the property at the end of the path. These will fail to bind on the Pulumi side.
+func checkKnownProperty(state *convertState, scopes *scopes, fullyQualifiedPath string, subject hcl.Range) {
+	if !scopes.isUnknownProperty(fullyQualifiedPath) {
+		return
+	}
 
-	content := bodyContent(body)
-	newAttributes := make(bodyAttrsTokens, 0)
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Subject:  &subject,
+		Severity: hcl.DiagWarning,
+		Summary:  "Unknown property",
+		Detail:   fmt.Sprintf("The property %q is not defined in the provider schema", fullyQualifiedPath),
+	})
+}
 
-	// If we see blocks we turn those into lists (unless maxItems==1)
-	blockLists := make(map[string][]bodyAttrsTokens)
-	for _, block := range content.Blocks {
-		if block.Type == "timeouts" {
-			// Timeouts are a special resource option block, we can't currently convert that PCL so just skip
-			continue
-		}
+// checkPropertyValue adds warnings for attributes that the provider schema marks as deprecated, and for literal
+// values that aren't one of the values of the property's enum in the Pulumi schema, see WithSchemaLoader. These
+// would otherwise only show up as errors when running the converted program.
+func checkPropertyValue(state *convertState, scopes *scopes, fullyQualifiedPath string, attr *hcl.Attribute) {
+	info := scopes.getInfo(fullyQualifiedPath)
 
-		blockPath := appendPath(fullyQualifiedPath, block.Type)
-		if block.Type == "dynamic" {
+	deprecated := ""
+	if info.Schema != nil {
+		deprecated = info.Schema.Deprecated()
+	}
+	if info.SchemaInfo != nil && info.SchemaInfo.DeprecationMessage != "" {
+		deprecated = info.SchemaInfo.DeprecationMessage
+	}
+	if deprecated != "" {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Subject:  &attr.NameRange,
+			Severity: hcl.DiagWarning,
+			Summary:  "Deprecated property",
+			Detail:   fmt.Sprintf("The property %q is deprecated: %s", fullyQualifiedPath, deprecated),
+		})
+	}
+
+	// We only check constant expressions, anything referencing other values can't be known until the
+	// program runs.
+	if len(attr.Expr.Variables()) != 0 {
+		return
+	}
+	typ := state.propertyType(scopes, fullyQualifiedPath)
+	if typ == nil {
+		return
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() {
+		return
+	}
+	checkEnumValue(state, fullyQualifiedPath, typ, value, attr.Expr.Range())
+}
+
+// tokensForConcat returns an invoke of std concat to join the given lists.
+func tokensForConcat(lists []hclwrite.Tokens) hclwrite.Tokens {
+	listTokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
+	for i, list := range lists {
+		if i > 0 {
+			listTokens = append(listTokens, makeToken(hclsyntax.TokenComma, ","))
+		}
+		listTokens = append(listTokens, list...)
+	}
+	listTokens = append(listTokens, makeToken(hclsyntax.TokenCBrack, "]"))
+	return tokensForStdInvoke("concat", listTokens)
+}
+
+// tokensForStdInvoke returns the tokens to invoke the std function for the named terraform function with the given
+// arguments, in the same order as the function's inputs.
+func tokensForStdInvoke(name string, args ...hclwrite.Tokens) hclwrite.Tokens {
+	invoke, has := tfFunctionStd[name]
+	contract.Assertf(has, "std function %s not found", name)
+	contract.Assertf(len(args) == len(invoke.inputs), "std function %s expects %d arguments", name, len(invoke.inputs))
+
+	attrs := make([]hclwrite.ObjectAttrTokens, len(args))
+	for i, arg := range args {
+		attrs[i] = hclwrite.ObjectAttrTokens{
+			Name:  hclwrite.TokensForIdentifier(invoke.inputs[i]),
+			Value: arg,
+		}
+	}
+	call := hclwrite.TokensForFunctionCall("invoke",
+		hclwrite.TokensForValue(cty.StringVal(invoke.token)),
+		hclwrite.TokensForObject(attrs))
+	call = append(call, makeToken(hclsyntax.TokenDot, "."))
+	return append(call, makeToken(hclsyntax.TokenIdent, invoke.output))
+}
+
+// Convert a hcl.Body treating sub-bodies as attributes
+func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string, body hcl.Body) bodyAttrsTokens {
+	contract.Assertf(fullyQualifiedPath != "", "fullyQualifiedPath should not be empty")
+
+	content := bodyContent(body)
+	newAttributes := make(bodyAttrsTokens, 0)
+
+	// If we see blocks we turn those into lists (unless maxItems==1). Lists can be made of both static blocks and
+	// dynamic blocks, so we keep them in source order to join them together.
+	type blockListItem struct {
+		static  bodyAttrsTokens
+		dynamic hclwrite.Tokens
+		// For optional blocks, the value to use if it's the only item, which is null rather than empty if the
+		// block isn't present
+		optional hclwrite.Tokens
+		line     int
+	}
+	blockLists := make(map[string][]blockListItem)
+	for _, block := range content.Blocks {
+		if block.Type == "timeouts" {
+			// Timeouts are a special resource option block, we can't currently convert that PCL so just skip. A
+			// comment is written above the resource to say what they were, see timeoutsComment.
+			continue
+		}
+
+		blockPath := appendPath(fullyQualifiedPath, block.Type)
+		if block.Type == "dynamic" {
 			// For dynamic blocks the path is the first label, not "dynamic"
 			blockPath = appendPath(fullyQualifiedPath, block.Labels[0])
 		}
+		checkKnownProperty(state, scopes, blockPath, block.TypeRange)
 		// If this is a list so add [] to the path
 		isList := !scopes.maxItemsOne(blockPath)
 		name := scopes.pulumiName(blockPath)
@@ -1766,6 +2599,27 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 				tfEachVar = block.Labels[0]
 			}
 
+			if forEachAttr, has := dynamicBody.Attributes["for_each"]; has {
+				if optional, ok := matchOptionalBlock(forEachAttr.Expr); ok {
+					condition := convertOptionalBlockCondition(state, scopes, optional)
+					value := convertOptionalBlockContent(state, scopes, blockPath, tfEachVar, dynamicBody, optional)
+					if !isList {
+						newAttributes = append(newAttributes, bodyAttrTokens{
+							Name:  name,
+							Value: tokensForOptional(condition, value, hclwrite.TokensForIdentifier("null")),
+						})
+					} else {
+						value = hclwrite.TokensForTuple([]hclwrite.Tokens{value})
+						blockLists[name] = append(blockLists[name], blockListItem{
+							dynamic:  tokensForOptional(condition, value, hclwrite.TokensForTuple(nil)),
+							optional: tokensForOptional(condition, value, hclwrite.TokensForIdentifier("null")),
+							line:     block.DefRange.Start.Line,
+						})
+					}
+					continue
+				}
+			}
+
 			pulumiEachVar := scopes.generateUniqueName("entry", "", "")
 
 			dynamicTokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
@@ -1779,7 +2633,7 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 			}
 
 			// wrap the collection expression into `entries(collection)` so that each entry has key and value
-			forEachExprTokens := convertExpression(state, true, scopes, fullyQualifiedPath, forEachAttr.Expr)
+			forEachExprTokens := convertForEachExpr(state, scopes, fullyQualifiedPath, forEachAttr.Expr)
 			dynamicTokens = append(dynamicTokens, makeToken(hclsyntax.TokenIdent, "entries"))
 			dynamicTokens = append(dynamicTokens, makeToken(hclsyntax.TokenOParen, "("))
 			dynamicTokens = append(dynamicTokens, forEachExprTokens...)
@@ -1804,12 +2658,16 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 			if !isList {
 				// This is a block attribute, not a list
 				dynamicTokens = hclwrite.TokensForFunctionCall("singleOrNone", dynamicTokens)
+				newAttributes = append(newAttributes, bodyAttrTokens{
+					Name:  name,
+					Value: dynamicTokens,
+				})
+			} else {
+				blockLists[name] = append(blockLists[name], blockListItem{
+					dynamic: dynamicTokens,
+					line:    block.DefRange.Start.Line,
+				})
 			}
-
-			newAttributes = append(newAttributes, bodyAttrTokens{
-				Name:  name,
-				Value: dynamicTokens,
-			})
 		} else {
 			if !isList {
 				// This is a block attribute, not a list
@@ -1819,9 +2677,8 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 					Value: tokensForObject(convertBody(state, scopes, blockPath, block.Body)),
 				})
 			} else {
-				list := blockLists[name]
-				list = append(list, convertBody(state, scopes, blockPath, block.Body))
-				blockLists[name] = list
+				item := convertBody(state, scopes, blockPath, block.Body)
+				blockLists[name] = append(blockLists[name], blockListItem{static: item, line: item.Line()})
 			}
 		}
 	}
@@ -1834,25 +2691,46 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 	for _, name := range names {
 		items := blockLists[name]
 
-		listTokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
-		first := true
+		// Each run of static blocks becomes a list literal, and each dynamic block a for expression.
+		var lists []hclwrite.Tokens
+		var listTokens hclwrite.Tokens
 		line := math.MaxInt32
 		for _, item := range items {
-			if !first {
-				listTokens = append(listTokens, makeToken(hclsyntax.TokenComma, ","))
+			if item.line < line {
+				line = item.line
 			}
-			first = false
-			listTokens = append(listTokens, tokensForObject(item)...)
-			if item.Line() < line {
-				line = item.Line()
+			if item.dynamic != nil {
+				if listTokens != nil {
+					lists = append(lists, append(listTokens, makeToken(hclsyntax.TokenCBrack, "]")))
+					listTokens = nil
+				}
+				lists = append(lists, item.dynamic)
+				continue
+			}
+
+			if listTokens == nil {
+				listTokens = hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
+			} else {
+				listTokens = append(listTokens, makeToken(hclsyntax.TokenComma, ","))
 			}
+			listTokens = append(listTokens, tokensForObject(item.static)...)
+		}
+		if listTokens != nil {
+			lists = append(lists, append(listTokens, makeToken(hclsyntax.TokenCBrack, "]")))
+		}
+
+		value := lists[0]
+		if len(items) == 1 && items[0].optional != nil {
+			value = items[0].optional
+		} else if len(lists) > 1 {
+			// Mixing static and dynamic blocks, so concat the lists together.
+			value = tokensForConcat(lists)
 		}
-		listTokens = append(listTokens, makeToken(hclsyntax.TokenCBrack, "]"))
 
 		newAttributes = append(newAttributes, bodyAttrTokens{
 			Line:  line,
 			Name:  name,
-			Value: listTokens,
+			Value: value,
 		})
 	}
 
@@ -1862,11 +2740,30 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 	for _, name := range names {
 		attr := content.Attributes[name]
 		attrPath := appendPath(fullyQualifiedPath, attr.Name)
+		checkKnownProperty(state, scopes, attrPath, attr.NameRange)
+		checkPropertyValue(state, scopes, attrPath, attr)
 		name := scopes.pulumiName(attrPath)
 
 		// We need the leading trivia here, but the trailing trivia will be handled by convertExpression
 		leading, _ := getTrivia(state.sources, getAttributeRange(state.sources, attr.Expr.Range()), true)
-		expr := convertExpression(state, true, scopes, attrPath, attr.Expr)
+		asset := scopes.isAsset(attrPath)
+		var expr hclwrite.Tokens
+		if key, attribute, ok := archiveFileReference(attr.Expr); ok && state.archiveAssets[key] != nil {
+			if attribute != "output_path" {
+				// Pulumi hashes the archive itself.
+				continue
+			}
+			expr = convertArchiveAsset(state, scopes, state.archiveAssets[key])
+			asset = nil
+		} else if contentType, has := state.folderContentTypes[attrPath]; has {
+			expr = contentType
+		} else if extracted, ok := state.extractFile(scopes, attrPath, content.Attributes, attr, asset != nil); ok {
+			expr = extracted
+		} else {
+			state.nameSuffix = state.nameSuffixes[attrPath]
+			expr = convertExpression(state, true, scopes, attrPath, attr.Expr)
+			state.nameSuffix = nil
+		}
 
 		// If this is a maxItemsOne property then in terraform it will be a list, but in Pulumi it will be a
 		// single value. We need to project the list expression we've just converted into a single value, for
@@ -1886,7 +2783,6 @@ func convertBody(state *convertState, scopes *scopes, fullyQualifiedPath string,
 			}
 		}
 
-		asset := scopes.isAsset(attrPath)
 		if asset != nil {
 			if asset.Kind == tfbridge.FileArchive || asset.Kind == tfbridge.BytesArchive {
 				expr = hclwrite.TokensForFunctionCall("fileArchive", expr)
@@ -1975,6 +2871,12 @@ func convertVariable(state *convertState, scopes *scopes,
 	labels := []string{pulumiName}
 
 	pulumiType := convertCtyType(variable.Type)
+	if variable.Type == cty.DynamicPseudoType {
+		// We might have been able to infer a type from how the variable is used
+		if inferred := scopes.roots["var."+variable.Name].VariableType; inferred != cty.DynamicPseudoType {
+			pulumiType = convertCtyType(inferred)
+		}
+	}
 	if !variable.Default.IsNull() && variable.Type == cty.DynamicPseudoType {
 		// If we don't have an explicit type but we do have a default value, use its type
 		// Only do this for primitive types. For complex types such as objects and lists
@@ -2012,6 +2914,9 @@ func convertVariable(state *convertState, scopes *scopes,
 		blockBody.SetAttributeValue("nullable", cty.BoolVal(variable.Nullable))
 	}
 	leading, trailing := getTrivia(state.sources, variable.DeclRange, false)
+	if scopes.roots["var."+variable.Name].DynamicConfig {
+		leading = append(leading, dynamicConfigComment()...)
+	}
 	return leading, block, trailing
 }
 
@@ -2032,11 +2937,39 @@ func impliedToken(typeName string) string {
 	return camelCaseName(typeName)
 }
 
+// Invoke tokens for data sources that are used by nearly every program for their provider. We know these tokens
+// so that even if we fail to get the provider mapping we still generate a working invoke for them, rather than
+// a guess that breaks every expression that uses them (e.g. ARNs built from the account id and partition).
+var wellKnownDataSourceTokens = map[string]string{
+	"aws_caller_identity": "aws:index/getCallerIdentity:getCallerIdentity",
+	"aws_partition":       "aws:index/getPartition:getPartition",
+	"aws_region":          "aws:index/getRegion:getRegion",
+}
+
+// Returns the invoke token for a data source, preferring the token from the provider mapping if we have one.
+func dataSourceToken(typeName string, info *tfbridge.DataSourceInfo) string {
+	if info != nil {
+		return info.Tok.String()
+	}
+	if token, has := wellKnownDataSourceTokens[typeName]; has {
+		return token
+	}
+	return impliedToken(typeName)
+}
+
 func convertLocal(state *convertState, scopes *scopes,
 	local *configs.Local,
 ) (hclwrite.Tokens, string, hclwrite.Tokens, hclwrite.Tokens) {
-	identifier := scopes.roots["local."+local.Name].Name
-	expr := convertExpression(state, true, scopes, "", local.Expr)
+	root := scopes.roots["local."+local.Name]
+	identifier := root.Name
+	var expr hclwrite.Tokens
+	if root.UsedAsMap {
+		state.disableRewritingObjectKeys(func() {
+			expr = convertExpression(state, true, scopes, "", local.Expr)
+		})
+	} else {
+		expr = convertExpression(state, true, scopes, "", local.Expr)
+	}
 	// The trailing trivia will have been caught by convertExpression, but we need the leading trivia before the identifier
 	leading, _ := getTrivia(state.sources, local.DeclRange, true)
 	return leading, identifier, expr, nil
@@ -2057,18 +2990,48 @@ func convertDataResource(state *convertState,
 	if dataResource.Type == "template_file" {
 		text := cty.StringVal("The template_file data resource is not yet supported.")
 		dataResourceExpression := hclwrite.TokensForFunctionCall("notImplemented", hclwrite.TokensForValue(text))
+		state.countNotImplemented("data:template_file")
 		leading, trailing := getTrivia(state.sources, dataResource.DeclRange, false)
 		return leading, pulumiName, dataResourceExpression, trailing
 	}
 
-	invokeToken := cty.StringVal(impliedToken(dataResource.Type))
-	if root.DataSourceInfo != nil {
-		invokeToken = cty.StringVal(root.DataSourceInfo.Tok.String())
+	invokeToken := cty.StringVal(dataSourceToken(dataResource.Type, root.DataSourceInfo))
+
+	// Invokes can't be given options: the PCL binder we target types the third argument of invoke as a string, so
+	// it rejects both a provider resource and an options object with a provider or dependsOn. Until it accepts
+	// invoke options we at least tell the user that the data source will be read with the default provider and
+	// without waiting for its dependencies.
+	if dataResource.ProviderConfigRef != nil && dataResource.ProviderConfigRef.Alias != "" {
+		ref := dataResource.ProviderConfigRef
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "converting provider for data sources is not supported",
+			Detail: fmt.Sprintf("%s will be read using the default %s provider rather than %s.%s",
+				path, ref.Name, ref.Name, ref.Alias),
+			Subject: ref.NameRange.Ptr(),
+		})
+	} else if provider := dataSourceProvider(dataResource); scopes.roots[providerKey(provider, "")].Name != "" {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "converting provider for data sources is not supported",
+			Detail: fmt.Sprintf("%s will be read using the default %s provider rather than the %s provider "+
+				"resource its provider config is converted to", path, provider, provider),
+			Subject: dataResource.DeclRange.Ptr(),
+		})
+	}
+	if len(dataResource.DependsOn) > 0 {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "converting depends_on for data sources is not supported",
+			Detail:   fmt.Sprintf("%s will be read without waiting for the resources it depends on", path),
+			Subject:  dataResource.DependsOn[0].SourceRange().Ptr(),
+		})
 	}
 
-	// If count is set we'll make this into an array expression
+	// If count is set we'll make this into an array expression, unless it's only used to read the data source
+	// conditionally
 	var countExpr hclwrite.Tokens
-	if dataResource.Count != nil {
+	if dataResource.Count != nil && !root.ConditionalCount {
 		countExpr = convertExpression(state, true, scopes, "", dataResource.Count)
 		scopes.countIndex = hcl.Traversal{hcl.TraverseRoot{Name: "__index"}}
 	}
@@ -2076,7 +3039,7 @@ func convertDataResource(state *convertState,
 	// If for_each is set we'll make this into an object expression
 	var forEachExpr hclwrite.Tokens
 	if dataResource.ForEach != nil {
-		forEachExpr = convertExpression(state, true, scopes, "", dataResource.ForEach)
+		forEachExpr = convertForEachExpr(state, scopes, "", dataResource.ForEach)
 		scopes.eachKey = hcl.Traversal{hcl.TraverseRoot{Name: "__key"}}
 		scopes.eachValue = hcl.Traversal{hcl.TraverseRoot{Name: "__value"}}
 	}
@@ -2090,7 +3053,9 @@ func convertDataResource(state *convertState,
 
 	dataResourceExpression := functionCall
 	// If count is set then we need to turn this into a for array expression
-	if dataResource.Count != nil {
+	if root.ConditionalCount {
+		dataResourceExpression = convertConditionalInvoke(state, scopes, dataResource, functionCall)
+	} else if dataResource.Count != nil {
 		dataResourceExpression = hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
 		dataResourceExpression = append(dataResourceExpression, makeToken(hclsyntax.TokenIdent, "for"))
 		dataResourceExpression = append(dataResourceExpression, makeToken(hclsyntax.TokenIdent, "__index"))
@@ -2154,7 +3119,7 @@ func convertProvisioner(
 	optionsBlockBody := optionsBlock.Body()
 
 	if forEach != nil {
-		forEachExpr := convertExpression(state, true, scopes, "", forEach)
+		forEachExpr := convertForEachExpr(state, scopes, "", forEach)
 		scopes.eachKey = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "key"}}
 		scopes.eachValue = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
 		optionsBlockBody.SetAttributeRaw("range", forEachExpr)
@@ -2227,10 +3192,25 @@ func convertManagedResources(state *convertState,
 	contract.Assertf(has, "resource %s not found", path)
 	pulumiName := root.Name
 
+	rule := state.rules[path]
 	resourceToken := impliedToken(managedResource.Type)
-	if root.ResourceInfo != nil {
+	if rule.Type != "" {
+		resourceToken = rule.Type
+	} else if hook := state.resourceHooks[managedResource.Type]; hook.token != "" {
+		resourceToken = hook.token
+	} else if alternative, has := state.resourceAlternatives[path]; has {
+		resourceToken = alternative.token
+	} else if root.ResourceInfo != nil {
 		resourceToken = root.ResourceInfo.Tok.String()
 	}
+	sleepCreate, sleepDestroy, sleep := convertSleep(state, managedResource, root)
+	if sleep {
+		resourceToken = "command:local:Command"
+	}
+	upload := state.folderUploads[path]
+	if upload != nil && upload.synced {
+		resourceToken = syncedFolderToken
+	}
 
 	labels := []string{pulumiName, resourceToken}
 	block := hclwrite.NewBlock("resource", labels)
@@ -2238,26 +3218,28 @@ func convertManagedResources(state *convertState,
 
 	// If the pulumi name differs from the terraform name we should set __logicalName so that we don't change
 	// the name of the resource in state.
-	if pulumiName != managedResource.Name {
+	if rule.LogicalName != "" {
+		blockBody.SetAttributeRaw("__logicalName", hclwrite.TokensForValue(cty.StringVal(rule.LogicalName)))
+	} else if pulumiName != managedResource.Name {
 		blockBody.SetAttributeRaw("__logicalName", hclwrite.TokensForValue(cty.StringVal(managedResource.Name)))
 	}
 
 	var options *hclwrite.Block
 	// Does this resource have dependencies? If so set the "dependsOn" attribute
-	if len(managedResource.DependsOn) > 0 {
+	waits := waitDependencies(state, scopes, managedResource)
+	if len(managedResource.DependsOn) > 0 || len(waits) > 0 {
 		if options == nil {
 			options = hclwrite.NewBlock("options", nil)
 		}
-		dependsOn := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
-		for idx, dep := range managedResource.DependsOn {
-			if idx > 0 {
-				dependsOn = append(dependsOn, makeToken(hclsyntax.TokenComma, ","))
-			}
-			tokens := rewriteTraversal(state, scopes, "", dep)
-			dependsOn = append(dependsOn, tokens...)
+		options.Body().SetAttributeRaw("dependsOn",
+			convertDependsOn(state, scopes, managedResource.DependsOn, waits...))
+	}
+
+	if ignoreChanges := convertIgnoreChanges(state, scopes, managedResource); ignoreChanges != nil {
+		if options == nil {
+			options = hclwrite.NewBlock("options", nil)
 		}
-		dependsOn = append(dependsOn, makeToken(hclsyntax.TokenCBrack, "]"))
-		options.Body().SetAttributeRaw("dependsOn", dependsOn)
+		options.Body().SetAttributeRaw("ignoreChanges", ignoreChanges)
 	}
 
 	if managedResource.Managed != nil && managedResource.Managed.CreateBeforeDestroySet {
@@ -2283,37 +3265,116 @@ func convertManagedResources(state *convertState,
 		if options == nil {
 			options = hclwrite.NewBlock("options", nil)
 		}
-		countExpr := convertExpression(state, true, scopes, "", managedResource.Count)
-		// Set the count_index scope
-		scopes.countIndex = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
+		countExpr := convertCount(state, scopes, managedResource.Count, managedResource.Config)
 		options.Body().SetAttributeRaw("range", countExpr)
 	}
-	if managedResource.ForEach != nil {
+	uploadRanged := false
+	if managedResource.ForEach != nil && (upload == nil || !upload.synced) {
 		if options == nil {
 			options = hclwrite.NewBlock("options", nil)
 		}
-		forEachExpr := convertExpression(state, true, scopes, "", managedResource.ForEach)
+		var forEachExpr hclwrite.Tokens
+		if upload != nil {
+			forEachExpr, uploadRanged = convertFolderRange(state, scopes, upload)
+		}
+		if validation := state.certificateValidations[path]; validation != nil {
+			forEachExpr = tokensForSetRange(convertExpression(state, false, scopes, "", validation.domains()))
+			state.certificateValidation = validation
+		} else if !uploadRanged {
+			forEachExpr = convertForEachExpr(state, scopes, "", managedResource.ForEach)
+		}
 		scopes.eachKey = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "key"}}
 		scopes.eachValue = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
 		options.Body().SetAttributeRaw("range", forEachExpr)
 	}
 
+	// Resources using an aliased provider, or a default provider configured from resources, use the explicit
+	// provider resource it's converted to
+	providerName, providerAlias := impliedProvider(managedResource.Type), ""
+	if ref := managedResource.ProviderConfigRef; ref != nil {
+		providerName, providerAlias = ref.Name, ref.Alias
+	}
+	if providerRoot, has := scopes.roots[providerKey(providerName, providerAlias)]; has {
+		if options == nil {
+			options = hclwrite.NewBlock("options", nil)
+		}
+		options.Body().SetAttributeTraversal("provider", hcl.Traversal{hcl.TraverseRoot{Name: providerRoot.Name}})
+	} else if ref := managedResource.ProviderConfigRef; ref != nil && ref.Alias != "" {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Provider not found",
+			Detail: fmt.Sprintf("%s uses the provider %s.%s which isn't configured in this module, it will use "+
+				"the default %s provider instead", path, ref.Name, ref.Alias, ref.Name),
+			Subject: ref.NameRange.Ptr(),
+		})
+	}
+
+	// Pin the resource to the Pulumi provider that maps the locked version of the terraform provider
+	if version := state.pinnedVersions[impliedProvider(managedResource.Type)]; version != "" &&
+		root.ResourceInfo != nil && rule.Type == "" {
+		if options == nil {
+			options = hclwrite.NewBlock("options", nil)
+		}
+		options.Body().SetAttributeValue("version", cty.StringVal(version))
+	}
+
+	if rule.Protect != nil || rule.RetainOnDelete != nil {
+		if options == nil {
+			options = hclwrite.NewBlock("options", nil)
+		}
+		if rule.Protect != nil {
+			options.Body().SetAttributeValue("protect", cty.BoolVal(*rule.Protect))
+		}
+		if rule.RetainOnDelete != nil {
+			options.Body().SetAttributeValue("retainOnDelete", cty.BoolVal(*rule.RetainOnDelete))
+		}
+	}
+
 	if options != nil {
 		blockBody.AppendBlock(options)
 	}
 
-	resourceArgs := convertBody(state, scopes, path, managedResource.Config)
-	for _, arg := range resourceArgs {
-		blockBody.SetAttributeRaw(arg.Name, arg.Value)
+	nameSuffix := convertNameSuffix(state, scopes, managedResource)
+	if sleep {
+		blockBody.SetAttributeValue("create", cty.StringVal(sleepCreate))
+		if sleepDestroy != "" {
+			blockBody.SetAttributeValue("delete", cty.StringVal(sleepDestroy))
+		}
+	} else if upload != nil && upload.synced {
+		convertSyncedFolder(state, scopes, managedResource, upload, blockBody)
+	} else {
+		if upload != nil {
+			if contentType, ok := convertFolderContentType(state, scopes, managedResource); ok {
+				state.folderContentTypes[appendPath(path, "content_type")] = contentType
+			}
+		}
+		resourceArgs := convertBody(state, scopes, path, managedResource.Config)
+		for _, arg := range resourceArgs {
+			blockBody.SetAttributeRaw(arg.Name, arg.Value)
+		}
 	}
 
 	// Clear any index we set
+	state.certificateValidation = nil
 	scopes.countIndex = nil
+	scopes.countList = nil
 	scopes.eachKey = nil
 	scopes.eachValue = nil
 	leading, trailing := getTrivia(state.sources, managedResource.DeclRange, false)
+	comment := append(providerVariantComment(state, managedResource, root),
+		timeoutsComment(state, managedResource, root)...)
+	comment = append(comment, dataRangeComment(state, scopes, managedResource)...)
+	comment = append(comment, waitComment(state, managedResource, root, sleep)...)
+	comment = append(comment, folderUploadComment(state, managedResource, upload, uploadRanged)...)
+	comment = append(comment, alternativeComment(state, managedResource)...)
+
+	runResourceHook(state, managedResource, block)
 
+	if nameSuffix != nil {
+		target.AppendBlock(nameSuffix)
+	}
 	target.AppendUnstructuredTokens(leading)
+	target.AppendUnstructuredTokens(comment)
 	target.AppendBlock(block)
 	target.AppendUnstructuredTokens(trailing)
 
@@ -2323,6 +3384,28 @@ func convertManagedResources(state *convertState,
 	}
 }
 
+// convertDependsOn returns the list of resources and components that the given depends_on references are
+// converted to, followed by the resources with the given names. A reference to a whole module, like module.iam, is
+// converted to its component.
+func convertDependsOn(
+	state *convertState, scopes *scopes, dependsOn []hcl.Traversal, names ...string,
+) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
+	for idx, dep := range dependsOn {
+		if idx > 0 {
+			tokens = append(tokens, makeToken(hclsyntax.TokenComma, ","))
+		}
+		tokens = append(tokens, rewriteTraversal(state, scopes, "", dep)...)
+	}
+	for idx, name := range names {
+		if idx > 0 || len(dependsOn) > 0 {
+			tokens = append(tokens, makeToken(hclsyntax.TokenComma, ","))
+		}
+		tokens = append(tokens, makeToken(hclsyntax.TokenIdent, name))
+	}
+	return append(tokens, makeToken(hclsyntax.TokenCBrack, "]"))
+}
+
 func convertModuleCall(
 	state *convertState,
 	scopes *scopes,
@@ -2330,7 +3413,9 @@ func convertModuleCall(
 	destinationDirectory string,
 	moduleCall *configs.ModuleCall,
 ) (hclwrite.Tokens, *hclwrite.Block, hclwrite.Tokens) {
-	// We translate module calls into components
+	// We translate module calls into components. The module's resources are written into the component's program,
+	// and the code generated for a component parents everything it declares under it, so they don't need an
+	// explicit parent option (PCL has no way to refer to the enclosing component anyway).
 	path := "module." + moduleCall.Name
 	pulumiName := scopes.roots[path].Name
 
@@ -2360,30 +3445,54 @@ func convertModuleCall(
 	block := hclwrite.NewBlock("component", labels)
 	blockBody := block.Body()
 
+	var options *hclwrite.Block
 	// Does this resource have a count? If so set the "range" attribute
 	if moduleCall.Count != nil {
-		options := blockBody.AppendNewBlock("options", nil)
-		countExpr := convertExpression(state, true, scopes, "", moduleCall.Count)
-		// Set the count_index scope
-		scopes.countIndex = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
+		options = blockBody.AppendNewBlock("options", nil)
+		countExpr := convertCount(state, scopes, moduleCall.Count, moduleCall.Config)
 		options.Body().SetAttributeRaw("range", countExpr)
 	}
 
 	if moduleCall.ForEach != nil {
-		options := blockBody.AppendNewBlock("options", nil)
-		forEachExpr := convertExpression(state, true, scopes, "", moduleCall.ForEach)
+		if options == nil {
+			options = blockBody.AppendNewBlock("options", nil)
+		}
+		forEachExpr := convertForEachExpr(state, scopes, "", moduleCall.ForEach)
 		scopes.eachKey = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "key"}}
 		scopes.eachValue = hcl.Traversal{hcl.TraverseRoot{Name: "range"}, hcl.TraverseAttr{Name: "value"}}
 		options.Body().SetAttributeRaw("range", forEachExpr)
 	}
 
+	// Depending on a module waits for everything in it, as depending on its component waits for its children
+	if len(moduleCall.DependsOn) > 0 {
+		if options == nil {
+			options = blockBody.AppendNewBlock("options", nil)
+		}
+		options.Body().SetAttributeRaw("dependsOn", convertDependsOn(state, scopes, moduleCall.DependsOn))
+	}
+
+	// Providers passed to the module are set as the component's provider, or as the config it has for them
+	provider, passedProviders := convertPassedProviders(state, scopes, moduleCall)
+	if provider != nil {
+		if options == nil {
+			options = blockBody.AppendNewBlock("options", nil)
+		}
+		options.Body().SetAttributeTraversal("provider", provider)
+	}
+
+	state.inComponentArguments = true
 	moduleArgs := convertBody(state, scopes, path, moduleCall.Config)
+	state.inComponentArguments = false
 	for _, arg := range moduleArgs {
 		blockBody.SetAttributeRaw(arg.Name, arg.Value)
 	}
+	for _, passed := range passedProviders {
+		blockBody.SetAttributeTraversal(passed.name, passed.value)
+	}
 
 	// Clear any index we set
 	scopes.countIndex = nil
+	scopes.countList = nil
 	scopes.eachKey = nil
 	scopes.eachValue = nil
 	leading, trailing := getTrivia(state.sources, moduleCall.DeclRange, false)
@@ -2391,19 +3500,46 @@ func convertModuleCall(
 }
 
 func convertOutput(state *convertState, scopes *scopes,
-	output *configs.Output,
+	output *configs.Output, interfaceOnly bool,
 ) (hclwrite.Tokens, *hclwrite.Block, hclwrite.Tokens) {
 	labels := []string{scopes.roots["output."+output.Name].Name}
 	block := hclwrite.NewBlock("output", labels)
 	blockBody := block.Body()
 	leading, _ := getTrivia(state.sources, getAttributeRange(state.sources, output.Expr.Range()), true)
 	blockBody.AppendUnstructuredTokens(leading)
-	blockBody.SetAttributeRaw("value", convertExpression(state, true, scopes, "", output.Expr))
+	if interfaceOnly && !onlyReferencesVariables(output.Expr) {
+		// Only variables are converted in interface only mode, so anything else this output refers to won't exist.
+		blockBody.SetAttributeRaw("value", notImplemented(state, output.Expr.Range()))
+	} else if output.Sensitive {
+		blockBody.SetAttributeRaw("value", tokensForSecret(convertExpression(state, false, scopes, "", output.Expr)))
+	} else {
+		blockBody.SetAttributeRaw("value", convertExpression(state, true, scopes, "", output.Expr))
+	}
 
 	leading, trailing := getTrivia(state.sources, output.DeclRange, false)
+	// PCL outputs don't have descriptions, so we write it as a comment above the output instead so the outputs of
+	// components stay documented.
+	if output.DescriptionSet {
+		for _, line := range strings.Split(strings.TrimSpace(output.Description), "\n") {
+			leading = append(leading, &hclwrite.Token{
+				Type:  hclsyntax.TokenComment,
+				Bytes: []byte(strings.TrimRight("# "+line, " ") + "\n"),
+			})
+		}
+	}
 	return leading, block, trailing
 }
 
+// onlyReferencesVariables returns true if the only things the expression refers to are input variables.
+func onlyReferencesVariables(expr hcl.Expression) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "var" {
+			return false
+		}
+	}
+	return true
+}
+
 // An "item" from a terraform file
 type terraformItem struct {
 	variable   *configs.Variable
@@ -2476,6 +3612,55 @@ func makeModuleKey(call *configs.ModuleCall) moduleKey {
 	}
 }
 
+// checkModuleCycles returns an error for each module call in items that calls the module being translated, or one of
+// the modules that called it.
+func checkModuleCycles(
+	modules map[moduleKey]string, destinationDirectory string, items terraformItems, options translateOptions,
+) hcl.Diagnostics {
+	ancestors := append(append([]string{}, options.moduleAncestors...), destinationDirectory)
+	// The manifest key is the path of module call names from the root module, so ancestors[i] was called by
+	// names[i-1].
+	var names []string
+	if options.manifestKey != "" {
+		names = strings.Split(options.manifestKey, ".")
+	}
+
+	var diagnostics hcl.Diagnostics
+	for _, item := range items {
+		if item.moduleCall == nil {
+			continue
+		}
+		destinationPath, has := modules[makeModuleKey(item.moduleCall)]
+		if !has {
+			continue
+		}
+		for i, ancestor := range ancestors {
+			if ancestor != destinationPath {
+				continue
+			}
+
+			caller := "The root module"
+			if i > 0 {
+				caller = "module." + names[i-1]
+			}
+			var cycle []string
+			for _, name := range names[i:] {
+				cycle = append(cycle, "module."+name)
+			}
+			cycle = append(cycle, "module."+item.moduleCall.Name)
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Module cycle",
+				Detail: fmt.Sprintf("%s calls itself through %s, modules can't be converted to components that call "+
+					"themselves", caller, strings.Join(cycle, " -> ")),
+				Subject: item.moduleCall.DeclRange.Ptr(),
+			})
+			break
+		}
+	}
+	return diagnostics
+}
+
 func translateRemoteModule(
 	modules map[moduleKey]string, // A map of module source addresses to paths in destination.
 	packageAddr string, // The address of the remote terraform module to translate.
@@ -2483,6 +3668,7 @@ func translateRemoteModule(
 	destinationRoot afero.Fs, // The root of the destination filesystem to write PCL to.
 	destinationDirectory string, // A path in destination to write the translated code to.
 	info il.ProviderInfoSource,
+	options translateOptions,
 ) hcl.Diagnostics {
 	fetcher := getmodules.NewPackageFetcher()
 	tempPath, err := os.MkdirTemp("", "pulumi-tf-registry")
@@ -2495,10 +3681,19 @@ func translateRemoteModule(
 			},
 		}
 	}
+	// The module is only read while it's translated below, so don't leave copies of every module behind.
+	defer os.RemoveAll(tempPath)
 	instPath := filepath.Join(tempPath, "src")
 
-	err = fetcher.FetchPackage(context.TODO(), instPath, packageAddr)
+	logger := moduleLogger(options)
+	logger.Info("fetching module", "source", packageAddr)
+	start := time.Now()
+	err = fetcher.FetchPackage(options.ctx, instPath, packageAddr)
 	if err != nil {
+		if options.ctx.Err() != nil {
+			return hcl.Diagnostics{cancelledDiagnostic(options.ctx.Err())}
+		}
+		logger.Error("failed to fetch module", "source", packageAddr, "error", err)
 		return hcl.Diagnostics{
 			&hcl.Diagnostic{
 				Severity: hcl.DiagError,
@@ -2508,6 +3703,8 @@ func translateRemoteModule(
 		}
 	}
 
+	logger.Info("fetched module", "source", packageAddr, "duration", time.Since(start))
+
 	modDir, err := getmodules.ExpandSubdirGlobs(instPath, packageSubdir)
 	if err != nil {
 		return hcl.Diagnostics{
@@ -2521,11 +3718,16 @@ func translateRemoteModule(
 
 	sourceRoot := afero.NewBasePathFs(afero.NewOsFs(), modDir)
 
+	// The downloaded module is in its own filesystem, so the root and source directory of the program being
+	// converted don't apply to any local modules it uses.
+	options.root = ""
+	options.sourceDirectory = ""
 	return translateModuleSourceCode(
 		modules,
 		sourceRoot, "/",
 		destinationRoot, destinationDirectory,
 		info,
+		options,
 	)
 }
 
@@ -2536,20 +3738,50 @@ func translateModuleSourceCode(
 	destinationRoot afero.Fs, // The root of the destination filesystem to write PCL to.
 	destinationDirectory string, // A path in destination to write the translated code to.
 	info il.ProviderInfoSource,
+	options translateOptions,
 ) hcl.Diagnostics {
+	moduleLogger(options).Info("converting module", "source", sourceDirectory, "destination", destinationDirectory)
 	sources, module, moduleDiagnostics := loadConfigDir(sourceRoot, sourceDirectory)
+	versionDiagnostics := checkCoreVersion(module, moduleDiagnostics.HasErrors())
 	if moduleDiagnostics.HasErrors() {
 		// No syntax.Files to return here because we're relying on terraform to load and parse, means no
-		// source context gets printed with warnings/errors here.
-		return moduleDiagnostics
+		// source context gets printed with warnings/errors here. Report any version mismatches first as
+		// they're likely the cause of the other errors.
+		return append(versionDiagnostics, moduleDiagnostics...)
 	}
 
 	scopes := newScopes(info)
 
 	state := &convertState{
-		sources:           sources,
-		diagnostics:       hcl.Diagnostics{},
-		rewriteObjectKeys: true,
+		sources:              sources,
+		sourceRoot:           sourceRoot,
+		sourceDirectory:      sourceDirectory,
+		diagnostics:          append(hcl.Diagnostics{}, versionDiagnostics...),
+		rewriteObjectKeys:    true,
+		statistics:           options.statistics,
+		stackDependencies:    options.stackDependencies,
+		resourceHooks:        options.resourceHooks,
+		providerLocks:        options.providerLocks,
+		pinnedVersions:       make(map[string]string),
+		foldConstants:        options.foldConstants,
+		kubeconfigTemplate:   options.kubeconfigTemplate,
+		inlineFunctions:      options.inlineFunctions,
+		waits:                options.waits,
+		stableNames:          options.stableNames,
+		syncedFolders:        options.syncedFolders,
+		nameSuffixes:         make(map[string]hclwrite.Tokens),
+		folderContentTypes:   make(map[string]hclwrite.Tokens),
+		resourceAlternatives: make(map[string]resourceAlternative),
+		logger:               moduleLogger(options),
+		schemaLoader:         options.schemaLoader,
+		schemas:              make(map[string]*pschema.Package),
+		internalErrors:       options.internalErrors,
+	}
+	if options.extractFiles && len(options.moduleAncestors) == 0 {
+		state.extractedFiles = make(map[string]string)
+	}
+	if options.manifestKey != "" {
+		state.modulePrefix = "module." + strings.ReplaceAll(options.manifestKey, ".", ".module.") + "."
 	}
 
 	// First go through and add everything to the items list so we can sort it by source order
@@ -2557,37 +3789,70 @@ func translateModuleSourceCode(
 	for _, variable := range module.Variables {
 		items = append(items, terraformItem{variable: variable})
 	}
-	for _, local := range module.Locals {
-		items = append(items, terraformItem{local: local})
-	}
-	for _, data := range module.DataResources {
-		items = append(items, terraformItem{data: data})
-	}
-	for _, moduleCall := range module.ModuleCalls {
-		items = append(items, terraformItem{moduleCall: moduleCall})
-	}
-	for _, resource := range module.ManagedResources {
-		items = append(items, terraformItem{resource: resource})
-	}
 	for _, output := range module.Outputs {
 		items = append(items, terraformItem{output: output})
 	}
-	for _, provider := range module.ProviderConfigs {
-		items = append(items, terraformItem{provider: provider})
+	// In interface only mode we just want the variables and outputs of the module, skip everything else.
+	if !options.interfaceOnly {
+		for _, local := range module.Locals {
+			items = append(items, terraformItem{local: local})
+		}
+		for _, data := range module.DataResources {
+			items = append(items, terraformItem{data: data})
+		}
+		for _, moduleCall := range module.ModuleCalls {
+			items = append(items, terraformItem{moduleCall: moduleCall})
+		}
+		for _, resource := range module.ManagedResources {
+			items = append(items, terraformItem{resource: resource})
+		}
+		for _, provider := range module.ProviderConfigs {
+			items = append(items, terraformItem{provider: provider})
+		}
 	}
 	// Now sort that items array by source location
 	sort.Sort(items)
+	// Aliased providers that are only declared in configuration_aliases have no block to sort by, so go first
+	if !options.interfaceOnly {
+		var declared []*configs.Provider
+		state.providerInputs, declared = findProviderInputs(module, len(options.moduleAncestors) == 0)
+		sort.Slice(declared, func(i, j int) bool {
+			return providerKey(declared[i].Name, declared[i].Alias) < providerKey(declared[j].Name, declared[j].Alias)
+		})
+		inputs := make(terraformItems, 0, len(declared)+len(items))
+		for _, provider := range declared {
+			inputs = append(inputs, terraformItem{provider: provider})
+		}
+		items = append(inputs, items...)
+	}
+	state.rules, items = resourceRules(state, options, items)
+	if options.removeUnused {
+		items = removeUnusedItems(state, items, len(options.moduleAncestors) == 0 && !options.keepVariables)
+	}
 
-	// Now go through and generate unique names for all the things
+	// Now go through and generate unique names for all the things, starting with the providers the module is
+	// passed as callers set them by their alias
+	for _, item := range items {
+		if item.provider != nil && state.providerInputs[providerKey(item.provider.Name, item.provider.Alias)] {
+			scopes.getOrAddPulumiName(providerKey(item.provider.Name, item.provider.Alias), "", "Provider")
+		}
+	}
 	for _, item := range items {
 		if item.variable != nil {
-			scopes.getOrAddPulumiName("var."+item.variable.Name, "", "Config")
+			key := "var." + item.variable.Name
+			scopes.getOrAddPulumiName(key, "", "Config")
+			root := scopes.roots[key]
+			root.VariableType = item.variable.Type
+			if !item.variable.Sensitive {
+				root.VariableDefault = item.variable.Default
+			}
+			scopes.roots[key] = root
 		}
 	}
 	for _, item := range items {
 		if item.local != nil {
 			key := "local." + item.local.Name
-			scopes.getOrAddPulumiName(key, "my", "")
+			addLocalName(scopes, options.localNaming, item.local.Name)
 			root := scopes.roots[key]
 			root.Expression = &item.local.Expr
 			scopes.roots[key] = root
@@ -2600,11 +3865,13 @@ func translateModuleSourceCode(
 			// Try to grab the info for this data type
 			provider := impliedProvider(dataResource.Type)
 			root := PathInfo{}
-			if provider != "template" {
+			state.countResource()
+			if provider != "template" && !isRemoteState(dataResource) {
 				// We rewrite uses of template because it's really common but the provider for it is
-				// deprecated. As such we don't want to try and do a mapping lookup for it.
+				// deprecated. As such we don't want to try and do a mapping lookup for it. Remote state is
+				// converted to a stack reference so doesn't need a mapping either.
 
-				providerInfo, err := info.GetProviderInfo("", "", provider, "")
+				providerInfo, err := state.getProviderInfo(info, provider)
 				if err != nil {
 					state.appendDiagnostic(&hcl.Diagnostic{
 						Subject:  &dataResource.DeclRange,
@@ -2618,50 +3885,110 @@ func translateModuleSourceCode(
 					root.Resource = providerInfo.P.DataSourcesMap().Get(dataResource.Type)
 					root.DataSourceInfo = providerInfo.DataSources[dataResource.Type]
 				}
+				if _, known := wellKnownDataSourceTokens[dataResource.Type]; root.DataSourceInfo == nil && !known {
+					state.countUnmappedResource(dataResource.Type)
+				}
 			}
 
-			invokeToken := impliedToken(dataResource.Type)
-			if root.DataSourceInfo != nil {
-				invokeToken = root.DataSourceInfo.Tok.String()
-			}
+			invokeToken := dataSourceToken(dataResource.Type, root.DataSourceInfo)
 			tokenParts := strings.Split(invokeToken, ":")
 			suffix := strings.Title(tokenParts[len(tokenParts)-1])
+			if isRemoteState(dataResource) {
+				suffix = "StackReference"
+			}
 			root.Name = scopes.getOrAddPulumiName(key, "", suffix)
+			_, _, root.ConditionalCount = conditionalCount(dataResource)
 			scopes.roots[key] = root
 		}
 	}
+	markDataReadAfterApply(scopes, items)
+	for _, item := range items {
+		if item.provider != nil && isExplicitProvider(scopes, item.provider) {
+			name := scopes.getOrAddPulumiName(providerKey(item.provider.Name, item.provider.Alias), "", "Provider")
+			addKubeconfigTemplateName(state, scopes, item.provider, name)
+		}
+	}
 	for _, item := range items {
 		if item.resource != nil {
 			managedResource := item.resource
 			key := managedResource.Type + "." + managedResource.Name
-			// Try to grab the info for this resource type
-			provider := impliedProvider(managedResource.Type)
-			providerInfo, err := info.GetProviderInfo("", "", provider, "")
-			if err != nil {
-				state.appendDiagnostic(&hcl.Diagnostic{
-					Subject:  &managedResource.DeclRange,
-					Severity: hcl.DiagWarning,
-					Summary:  "Failed to get provider info",
-					Detail:   fmt.Sprintf("Failed to get provider info for %q: %v", managedResource.Type, err),
-				})
-			}
-
 			root := PathInfo{}
-			if providerInfo != nil {
-				root.Resource = providerInfo.P.ResourcesMap().Get(managedResource.Type)
-				root.ResourceInfo = providerInfo.Resources[managedResource.Type]
-			}
-
+			state.countResource()
 			resourceToken := impliedToken(managedResource.Type)
-			if root.ResourceInfo != nil {
-				resourceToken = root.ResourceInfo.Tok.String()
+			if rule := state.rules[key]; rule.Type != "" {
+				// A rule or hook gives the type this resource converts to, so we don't need a mapping for it.
+				resourceToken = rule.Type
+			} else if hook := options.resourceHooks[managedResource.Type]; hook.token != "" {
+				resourceToken = hook.token
+			} else {
+				// Try to grab the info for this resource type
+				provider := impliedProvider(managedResource.Type)
+				providerInfo, err := state.getProviderInfo(info, provider)
+				if err != nil {
+					state.appendDiagnostic(&hcl.Diagnostic{
+						Subject:  &managedResource.DeclRange,
+						Severity: hcl.DiagWarning,
+						Summary:  "Failed to get provider info",
+						Detail:   fmt.Sprintf("Failed to get provider info for %q: %v", managedResource.Type, err),
+					})
+				}
+
+				if providerInfo != nil {
+					root.Resource = providerInfo.P.ResourcesMap().Get(managedResource.Type)
+					root.ResourceInfo = providerInfo.Resources[managedResource.Type]
+				}
+
+				if root.ResourceInfo != nil {
+					resourceToken = root.ResourceInfo.Tok.String()
+				} else if alternative, ok := findResourceAlternative(
+					state, providerInfo, managedResource, &root); ok {
+					state.resourceAlternatives[key] = alternative
+					resourceToken = alternative.token
+				} else {
+					state.countUnmappedResource(managedResource.Type)
+				}
 			}
 			tokenParts := strings.Split(resourceToken, ":")
 			suffix := strings.Title(tokenParts[len(tokenParts)-1])
-			root.Name = scopes.getOrAddPulumiName(key, "", suffix)
+			if name := state.rules[key].Name; name != "" && !scopes.isUsed(name) {
+				root.Name = name
+			} else {
+				if name != "" {
+					state.appendDiagnostic(&hcl.Diagnostic{
+						Severity: hcl.DiagWarning,
+						Summary:  "Rule name already used",
+						Detail: fmt.Sprintf("The name %q given to %s by a rule is already used, a generated name "+
+							"is used instead", name, key),
+						Subject: managedResource.DeclRange.Ptr(),
+					})
+				}
+				root.Name = scopes.getOrAddPulumiName(key, "", suffix)
+			}
 			scopes.roots[key] = root
+			addNameSuffixName(state, scopes, managedResource, root.Name)
+		}
+	}
+	// Now we know the schemas of all the resources and data sources we can use them to fill in missing variable types
+	inferVariableTypes(state, scopes, items)
+	markMapLocals(scopes, items)
+
+	// Backends are only used by the root module, partial backend config is merged in as terraform init would.
+	if len(options.backendAttributes) > 0 && len(options.moduleAncestors) == 0 {
+		if module.Backend != nil {
+			module.Backend.Config = mergeBackendConfig(module.Backend.Config, options.backendAttributes)
+		} else {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Backend config without a backend",
+				Detail:   "Backend config was given but the program has no backend block, so it has been ignored",
+			})
 		}
 	}
+	var bootstrap map[string]bool
+	if options.bootstrapProject && len(options.moduleAncestors) == 0 {
+		bootstrap = findBootstrapResources(state, module.Backend, items)
+	}
+
 	for _, item := range items {
 		if item.moduleCall != nil {
 			moduleCall := item.moduleCall
@@ -2671,12 +3998,65 @@ func translateModuleSourceCode(
 			// translate it again.
 			moduleKey := makeModuleKey(moduleCall)
 
-			if _, has := modules[moduleKey]; !has {
-				// We need the source code for this module. But it might be a reference to a module from the
-				// registry (e.g. "terraform-aws-modules/s3-bucket/aws")
+			// Modules are keyed in the lockfile by their path of module calls from the root module.
+			childOptions := options
+			childOptions.manifestKey = moduleCall.Name
+			if options.manifestKey != "" {
+				childOptions.manifestKey = options.manifestKey + "." + moduleCall.Name
+			}
+			childOptions.moduleAncestors = append(
+				append([]string{}, options.moduleAncestors...), destinationDirectory)
 
-				addr := moduleCall.SourceAddr
-				switch addr := addr.(type) {
+			if _, has := modules[moduleKey]; !has {
+				// If we're using the lockfile and terraform has already installed this module then use that
+				// copy rather than downloading it again, this means we convert exactly what was deployed.
+				if dir, version, ok := vendoredModule(state, childOptions, moduleCall); ok {
+					state.logger.Debug("using installed module", "source", moduleCall.SourceAddr.String(),
+						"directory", dir)
+					// Match the paths we use for modules we download
+					destinationPath := filepath.Join(destinationDirectory, filepath.Base(moduleCall.SourceAddr.String()))
+					if addr, ok := moduleCall.SourceAddr.(addrs.ModuleSourceRegistry); ok && version != nil {
+						destinationPath = filepath.Join(destinationDirectory,
+							fmt.Sprintf("%s_%s", addr.Package.Name, version), addr.Subdir)
+					}
+					destinationPath = options.moduleDestination(destinationPath)
+					// Check that this path isn't already taken
+					for _, path := range modules {
+						if path == destinationPath {
+							state.appendDiagnostic(&hcl.Diagnostic{
+								Severity: hcl.DiagError,
+								Summary:  "Duplicate module path",
+								Detail:   fmt.Sprintf("The module path %q is already taken by another module", destinationPath),
+							})
+							return state.diagnostics
+						}
+					}
+					modules[moduleKey] = destinationPath
+
+					// Like remote modules, local paths in vendored modules are relative to the module not
+					// the program being converted.
+					childOptions.root = ""
+					childOptions.sourceDirectory = ""
+					diags := translateModuleSourceCode(
+						modules,
+						options.manifestFs,
+						dir,
+						destinationRoot,
+						destinationPath,
+						info,
+						childOptions)
+					state.diagnostics = append(state.diagnostics, diags...)
+					if diags.HasErrors() {
+						return state.diagnostics
+					}
+					continue
+				}
+
+				// We need the source code for this module. But it might be a reference to a module from the
+				// registry (e.g. "terraform-aws-modules/s3-bucket/aws")
+
+				addr := moduleCall.SourceAddr
+				switch addr := addr.(type) {
 				case addrs.ModuleSourceLocal:
 					// Local modules are the simplest case, the module is in the same package just at a
 					// different path. We need to do another check for uniquness here though as multiple
@@ -2694,6 +4074,26 @@ func translateModuleSourceCode(
 
 					sourcePath := filepath.Join(sourceDirectory, addr.String())
 					destinationPath := filepath.Join(destinationDirectory, addr.String())
+					if options.root != "" {
+						// If we've been given a root directory then modules are placed relative to the
+						// program being converted, or to the root if they're outside of that. This means
+						// modules from anywhere under the root get a unique destination.
+						if rel, ok := relativeWithin(options.sourceDirectory, sourcePath); ok {
+							destinationPath = filepath.Join("/", rel)
+						} else if rel, ok := relativeWithin(options.root, sourcePath); ok {
+							destinationPath = filepath.Join("/", rel)
+						} else {
+							state.appendDiagnostic(&hcl.Diagnostic{
+								Subject:  &moduleCall.SourceAddrRange,
+								Severity: hcl.DiagError,
+								Summary:  "Module outside of root",
+								Detail: fmt.Sprintf("The module source %q resolves to %q which is outside of the root directory %q",
+									addr.String(), sourcePath, options.root),
+							})
+							return state.diagnostics
+						}
+					}
+					destinationPath = options.moduleDestination(destinationPath)
 					// Check that this path isn't already taken
 					for _, path := range modules {
 						if path == destinationPath {
@@ -2716,7 +4116,8 @@ func translateModuleSourceCode(
 						sourcePath,
 						destinationRoot,
 						destinationPath,
-						info)
+						info,
+						childOptions)
 					state.diagnostics = append(state.diagnostics, diags...)
 					if diags.HasErrors() {
 						return state.diagnostics
@@ -2726,6 +4127,7 @@ func translateModuleSourceCode(
 					// Get the _name_ of this module, which is the last part of the path
 					moduleName := filepath.Base(addr.String())
 					destinationPath := filepath.Join(destinationDirectory, moduleName)
+					destinationPath = options.moduleDestination(destinationPath)
 					// Check that this path isn't already taken
 					for _, path := range modules {
 						if path == destinationPath {
@@ -2747,7 +4149,8 @@ func translateModuleSourceCode(
 						addr.Subdir,
 						destinationRoot,
 						destinationPath,
-						info)
+						info,
+						childOptions)
 					if diags.HasErrors() {
 						return state.diagnostics
 					}
@@ -2757,7 +4160,12 @@ func translateModuleSourceCode(
 					services := disco.NewWithCredentialsSource(nil)
 					reg := registry.NewClient(services, nil)
 					regsrcAddr := regsrc.ModuleFromRegistryPackageAddr(addr.Package)
-					resp, err := reg.ModuleVersions(context.TODO(), regsrcAddr)
+					state.logger.Info("looking up module versions", "source", addr.String())
+					resp, err := reg.ModuleVersions(options.ctx, regsrcAddr)
+					if options.ctx.Err() != nil {
+						state.appendDiagnostic(cancelledDiagnostic(options.ctx.Err()))
+						return state.diagnostics
+					}
 					if err != nil {
 						state.appendDiagnostic(&hcl.Diagnostic{
 							Severity: hcl.DiagError,
@@ -2795,7 +4203,11 @@ func translateModuleSourceCode(
 						return state.diagnostics
 					}
 
-					realAddrRaw, err := reg.ModuleLocation(context.TODO(), regsrcAddr, latestVersion.String())
+					realAddrRaw, err := reg.ModuleLocation(options.ctx, regsrcAddr, latestVersion.String())
+					if options.ctx.Err() != nil {
+						state.appendDiagnostic(cancelledDiagnostic(options.ctx.Err()))
+						return state.diagnostics
+					}
 					if err != nil {
 						state.appendDiagnostic(&hcl.Diagnostic{
 							Severity: hcl.DiagError,
@@ -2839,6 +4251,7 @@ func translateModuleSourceCode(
 
 					destinationPath := filepath.Join(destinationDirectory,
 						fmt.Sprintf("%s_%s", addr.Package.Name, latestVersion), addr.Subdir)
+					destinationPath = options.moduleDestination(destinationPath)
 					// Check that this path isn't already taken
 					for _, path := range modules {
 						if path == destinationPath {
@@ -2860,7 +4273,8 @@ func translateModuleSourceCode(
 						remoteAddr.Subdir,
 						destinationRoot,
 						destinationPath,
-						info)
+						info,
+						childOptions)
 
 					if diags.HasErrors() {
 						return state.diagnostics
@@ -2870,6 +4284,33 @@ func translateModuleSourceCode(
 		}
 	}
 
+	// A module that calls itself, directly or through other modules, would be converted to components that can
+	// never be bound. Report the cycle rather than writing them out.
+	if diags := checkModuleCycles(modules, destinationDirectory, items, options); diags.HasErrors() {
+		return append(state.diagnostics, diags...)
+	}
+
+	// References to module outputs that pass through one of the module's inputs are converted to the argument given
+	// for it, see forwardedOutputs.
+	state.forwardedOutputs = forwardedOutputs(items, modules, options.outputPassthroughs)
+	if options.outputPassthroughs != nil {
+		options.outputPassthroughs[destinationDirectory] = passthroughOutputs(state, items)
+	}
+
+	// Resources that refer to a resource with a provisioner that waits can depend on it, see WithWaits.
+	state.waitingProvisioners = findWaitingProvisioners(state, items)
+
+	// archive_file data sources that only zip the code of resources are converted to archives, see tf_archives.go.
+	state.archiveAssets = findArchiveAssets(scopes, items)
+	items = removeArchiveAssets(items, state.archiveAssets)
+
+	// Uploads of the files in a directory are converted to a loop over readDir or a synced folder, see
+	// tf_synced_folders.go.
+	state.folderUploads = findFolderUploads(state, items)
+
+	// Records that validate certificates range over their domains, see tf_certificate_validation.go.
+	state.certificateValidations = findCertificateValidations(items)
+
 	for _, item := range items {
 		if item.output != nil {
 			scopes.getOrAddOutput("output." + item.output.Name)
@@ -2881,14 +4322,9 @@ func translateModuleSourceCode(
 		if item.provider != nil {
 			provider := item.provider
 
-			// If an alias is set just warn and ignore this, we can't support this yet
-			if provider.Alias != "" {
-				state.appendDiagnostic(&hcl.Diagnostic{
-					Subject:  &provider.DeclRange,
-					Severity: hcl.DiagWarning,
-					Summary:  "Provider alias not supported",
-					Detail:   fmt.Sprintf("Provider aliases are not supported, ignoring %s=%s", provider.Name, provider.Alias),
-				})
+			// Aliased providers, and providers configured from resources, are converted to explicit provider
+			// resources, see convertProviderResource
+			if isExplicitProvider(scopes, provider) {
 				continue
 			}
 
@@ -2903,8 +4339,13 @@ func translateModuleSourceCode(
 				}
 			}
 
+			// Variants of a provider, like google-beta, are configured through the provider they're converted to,
+			// and config is namespaced by that provider's Pulumi package name (google config is under gcp).
+			providerName := baseProvider(provider.Name)
+			namespace := il.GetPulumiProviderName(providerName)
+
 			// Try to grab the info for this provider config
-			providerInfo, err := info.GetProviderInfo("", "", provider.Name, "")
+			providerInfo, err := state.getProviderInfo(info, providerName)
 			if err != nil {
 				state.appendDiagnostic(&hcl.Diagnostic{
 					Subject:  &provider.DeclRange,
@@ -2922,14 +4363,32 @@ func translateModuleSourceCode(
 
 			content := bodyContent(provider.Config)
 
-			// There might be blocks for "dynamic" or just object attributes, for now we just warn that they're being skipped
-			for _, block := range content.Blocks {
-				state.appendDiagnostic(&hcl.Diagnostic{
-					Subject:  &block.DefRange,
-					Severity: hcl.DiagWarning,
-					Summary:  "Provider config not supported",
-					Detail:   fmt.Sprintf("Blocks in provider config are not supported, ignoring %s:%s", provider.Name, block.Type),
-				})
+			// Kubernetes connection settings with exec auth are converted to a kubeconfig
+			content, connection := splitKubeconfig(providerName, content, false)
+			if connection != nil {
+				if value := evalKubeconfig(state, scopes, provider, connection); value != "" {
+					setProviderConfig(state, cfg, provider, namespace+":kubeconfig", value)
+				}
+			}
+
+			// Blocks, like the endpoints block of the aws provider, are converted to objects
+			configSchemas, configInfos := providerConfigSchemas(providerInfo)
+			blockValues := convertProviderConfigBlocks(
+				state, scopes, provider, content.Blocks, configSchemas, configInfos)
+			blockNames := maps.Keys(blockValues)
+			sort.Strings(blockNames)
+			for _, name := range blockNames {
+				yamlValue, err := providerConfigValue(blockValues[name])
+				if err != nil {
+					state.appendDiagnostic(&hcl.Diagnostic{
+						Subject:  &provider.DeclRange,
+						Severity: hcl.DiagError,
+						Summary:  "Failed to marshal provider config",
+						Detail:   fmt.Sprintf("Could not marshal value for %s:%s: %v", provider.Name, name, err),
+					})
+					continue
+				}
+				setProviderConfig(state, cfg, provider, namespace+":"+name, yamlValue)
 			}
 
 			// We need to iterate over the attributes in a stable order to ensure we get the same output
@@ -2944,9 +4403,24 @@ func translateModuleSourceCode(
 				return ia.Range.Start.Line < ja.Range.Start.Line
 			})
 
+			var credentials []providerCredential
 			for _, attrKey := range attrKeys {
-				// Evauluate and marshal the attribute to a YAML like value for Pulumi config
+				// Check if we need to rename this config key, but default to camelcase
+				name, configInfo := providerConfigName(providerName, attrKey, configInfos)
+
+				// Credentials read from variables shouldn't end up in stack config, they're left for an ESC
+				// environment to set instead.
 				value := content.Attributes[attrKey]
+				if variable, ok := variableReference(value.Expr); ok &&
+					isCredential(attrKey, module.Variables[variable], configInfo) {
+					credentials = append(credentials, providerCredential{
+						key:      namespace + ":" + name,
+						variable: variable,
+					})
+					continue
+				}
+
+				// Evauluate and marshal the attribute to a YAML like value for Pulumi config
 				val, diags := scopes.EvalExpr(value.Expr)
 				if diags.HasErrors() {
 					state.appendDiagnostic(&hcl.Diagnostic{
@@ -2959,8 +4433,7 @@ func translateModuleSourceCode(
 					val = cty.StringVal("TODO: " + state.sourceCode(value.Expr.Range()))
 				}
 
-				// Simplest way to get a cty type into YAML is to roundtrip it through JSON
-				buffer, err := json.Marshal(ctyjson.SimpleJSONValue{Value: val})
+				yamlValue, err := providerConfigValue(val)
 				if err != nil {
 					state.appendDiagnostic(&hcl.Diagnostic{
 						Subject:  &provider.DeclRange,
@@ -2970,43 +4443,50 @@ func translateModuleSourceCode(
 					})
 					continue
 				}
-				var yamlValue interface{}
-				err = json.Unmarshal(buffer, &yamlValue)
-				if err != nil {
-					state.appendDiagnostic(&hcl.Diagnostic{
-						Subject:  &provider.DeclRange,
-						Severity: hcl.DiagError,
-						Summary:  "Failed to marshal provider config",
-						Detail:   fmt.Sprintf("Failed to unmarshal provider config for %s:%s: %v", provider.Name, attrKey, err),
-					})
-					continue
-				}
-
-				// Check if we need to rename this config key, but default to camelcase
-				name := camelCaseName(attrKey)
-				if providerInfo != nil {
-					if info, has := providerInfo.Config[attrKey]; has && info.Name != "" {
-						name = info.Name
-					}
-				}
 
-				cfg[provider.Name+":"+name] = workspace.ProjectConfigType{
-					Value: yamlValue,
-				}
+				setProviderConfig(state, cfg, provider, namespace+":"+name, yamlValue)
+			}
+			if len(credentials) > 0 {
+				state.appendDiagnostic(credentialsDiagnostic(provider, credentials))
 			}
 		}
 	}
 
 	pclFiles := make(map[string]*hclwrite.File)
 
-	// We want to write things out to matching .pp files and in source order
-	for _, item := range items {
-		r := item.DeclRange()
-		path := changeExtension(r.Filename, ".pp")
-		path, err := filepath.Rel(sourceDirectory, path)
+	// We want to write things out to matching .pp files, or to main.pp if everything is written to a single file, in
+	// source order except that everything comes after what it refers to
+	pclPath := func(item terraformItem) string {
+		filename := item.DeclRange().Filename
+		if options.singleFile {
+			filename = filepath.Join(sourceDirectory, "main.tf")
+		}
+		path, err := filepath.Rel(sourceDirectory, changeExtension(filename, ".pp"))
 		if err != nil {
 			panic("Rel should never fail")
 		}
+		if item.resource != nil && bootstrap[item.resource.Type+"."+item.resource.Name] {
+			path = filepath.Join(bootstrapDirectory, path)
+		}
+		return path
+	}
+	var currentFile string
+	for _, item := range orderItems(items, pclPath) {
+		// Stop between items if the conversion has been cancelled, rather than writing a partial module.
+		if err := options.ctx.Err(); err != nil {
+			state.appendDiagnostic(cancelledDiagnostic(err))
+			return state.diagnostics
+		}
+
+		r := item.DeclRange()
+		if r.Filename != currentFile {
+			currentFile = r.Filename
+			state.logger.Debug("converting file", "file", r.Filename)
+		}
+		if key := item.itemKey(); key != "" {
+			state.logger.Debug("converting item", "address", state.modulePrefix+key)
+		}
+		path := pclPath(item)
 		file := pclFiles[path]
 		if file == nil {
 			file = hclwrite.NewFile()
@@ -3015,45 +4495,71 @@ func translateModuleSourceCode(
 
 		body := file.Body()
 
-		// First handle any inputs, these will be picked up by the "vars" scope
-		if item.variable != nil {
-			leading, block, trailing := convertVariable(state, scopes, item.variable)
-			body.AppendUnstructuredTokens(leading)
-			body.AppendBlock(block)
-			body.AppendUnstructuredTokens(trailing)
-		}
-		// Next handle any locals, these will be picked up by the "locals" scope
-		if item.local != nil {
-			leading, name, value, trailing := convertLocal(state, scopes, item.local)
-			body.AppendUnstructuredTokens(leading)
-			body.SetAttributeRaw(name, value)
-			body.AppendUnstructuredTokens(trailing)
-		}
-		// Next handle any data sources
-		if item.data != nil {
-			leading, name, value, trailing := convertDataResource(state, info, scopes, item.data)
-			body.AppendUnstructuredTokens(leading)
-			body.SetAttributeRaw(name, value)
-			body.AppendUnstructuredTokens(trailing)
-		}
-		// Next handle any resources
-		if item.resource != nil {
-			convertManagedResources(state, info, scopes, item.resource, body)
-		}
-		// Next handle any modules
-		if item.moduleCall != nil {
-			leading, block, trailing := convertModuleCall(state, scopes, modules, destinationDirectory, item.moduleCall)
-			body.AppendUnstructuredTokens(leading)
-			body.AppendBlock(block)
-			body.AppendUnstructuredTokens(trailing)
-		}
-		// Finally handle any outputs
-		if item.output != nil {
-			leading, block, trailing := convertOutput(state, scopes, item.output)
-			body.AppendUnstructuredTokens(leading)
-			body.AppendBlock(block)
-			body.AppendUnstructuredTokens(trailing)
-		}
+		// Each item is converted separately, so that an internal error converting one of them only loses that item.
+		convertItemSafely(state, scopes, item, body, func() {
+			// First handle any inputs, these will be picked up by the "vars" scope
+			if item.variable != nil {
+				leading, block, trailing := convertVariable(state, scopes, item.variable)
+				body.AppendUnstructuredTokens(leading)
+				body.AppendBlock(block)
+				body.AppendUnstructuredTokens(trailing)
+			}
+			// Next handle any locals, these will be picked up by the "locals" scope
+			if item.local != nil {
+				leading, name, value, trailing := convertLocal(state, scopes, item.local)
+				body.AppendUnstructuredTokens(leading)
+				body.SetAttributeRaw(name, value)
+				body.AppendUnstructuredTokens(trailing)
+			}
+			// Next handle any data sources, remote state is read from a stack reference
+			if item.data != nil && isRemoteState(item.data) {
+				leading, block, trailing := convertRemoteState(state, scopes, item.data)
+				body.AppendUnstructuredTokens(leading)
+				body.AppendBlock(block)
+				body.AppendUnstructuredTokens(trailing)
+			} else if item.data != nil {
+				leading, name, value, trailing := convertDataResource(state, info, scopes, item.data)
+				body.AppendUnstructuredTokens(leading)
+				body.SetAttributeRaw(name, value)
+				body.AppendUnstructuredTokens(trailing)
+			}
+			// Next handle any resources, aliased providers are explicit provider resources
+			if item.provider != nil && state.providerInputs[providerKey(item.provider.Name, item.provider.Alias)] {
+				leading, block, trailing := convertProviderInput(state, scopes, item.provider)
+				body.AppendUnstructuredTokens(leading)
+				body.AppendBlock(block)
+				body.AppendUnstructuredTokens(trailing)
+			} else if item.provider != nil && isExplicitProvider(scopes, item.provider) {
+				if name, kubeconfig, ok := convertKubeconfigTemplate(state, scopes, item.provider); ok {
+					body.SetAttributeRaw(name, kubeconfig)
+				}
+				leading, block, trailing := convertProviderResource(state, info, scopes, item.provider)
+				body.AppendUnstructuredTokens(leading)
+				body.AppendBlock(block)
+				body.AppendUnstructuredTokens(trailing)
+			}
+			if item.resource != nil {
+				convertManagedResources(state, info, scopes, item.resource, body)
+			}
+			// Next handle any modules
+			if item.moduleCall != nil {
+				leading, block, trailing := convertModuleCall(state, scopes, modules, destinationDirectory, item.moduleCall)
+				body.AppendUnstructuredTokens(leading)
+				body.AppendBlock(block)
+				body.AppendUnstructuredTokens(trailing)
+			}
+			// Finally handle any outputs
+			if item.output != nil {
+				leading, block, trailing := convertOutput(state, scopes, item.output, options.interfaceOnly)
+				body.AppendUnstructuredTokens(leading)
+				body.AppendBlock(block)
+				body.AppendUnstructuredTokens(trailing)
+			}
+		})
+	}
+	if options.graph != nil {
+		addToGraph(options.graph, scopes, modules, destinationDirectory,
+			strings.TrimSuffix(state.modulePrefix, "."), items)
 	}
 
 	// Now we've written everything generate formatted output files.
@@ -3088,8 +4594,12 @@ func translateModuleSourceCode(
 			return state.diagnostics
 		}
 
-		// Reformat to canonical style
-		formatted := hclwrite.Format(buffer.Bytes())
+		// Reformat to canonical style. Trivia is copied from the source as is, so if that was written on Windows
+		// the line endings need normalizing too.
+		formatted := hclwrite.Format(bytes.ReplaceAll(buffer.Bytes(), []byte("\r\n"), []byte("\n")))
+		if options.header {
+			formatted = append([]byte(headerComment(options)), formatted...)
+		}
 		err = afero.WriteFile(destinationRoot, fullpath, formatted, 0o644)
 		if err != nil {
 			state.appendDiagnostic(&hcl.Diagnostic{
@@ -3098,11 +4608,43 @@ func translateModuleSourceCode(
 			})
 			return state.diagnostics
 		}
+		state.logger.Debug("wrote file", "path", fullpath)
 	}
 
-	// Finally write out the Pulumi.yaml file if needed
+	// Then any files that strings were extracted to
+	for key, content := range state.extractedFiles {
+		fullpath := filepath.Join(destinationDirectory, filepath.FromSlash(key))
+		err = destinationRoot.MkdirAll(filepath.Dir(fullpath), 0o755)
+		if err != nil {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("could not create destination directory for extracted file: %s", err),
+			})
+			return state.diagnostics
+		}
+
+		err = afero.WriteFile(destinationRoot, fullpath, []byte(content), 0o644)
+		if err != nil {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("could not write extracted file to destination: %s", err),
+			})
+			return state.diagnostics
+		}
+	}
+
+	// Finally write out the Pulumi.yaml file if needed, the bootstrap project uses the same provider config
+	projects := map[string]*workspace.Project{}
 	if pulumiYaml != nil {
-		fullpath := filepath.Join(destinationDirectory, "Pulumi.yaml")
+		projects[destinationDirectory] = pulumiYaml
+		if len(bootstrap) > 0 {
+			bootstrapYaml := *pulumiYaml
+			bootstrapYaml.Name = tokens.PackageName(string(pulumiYaml.Name) + "-" + bootstrapDirectory)
+			projects[filepath.Join(destinationDirectory, bootstrapDirectory)] = &bootstrapYaml
+		}
+	}
+	for projectDirectory, pulumiYaml := range projects {
+		fullpath := filepath.Join(projectDirectory, "Pulumi.yaml")
 		keyDirectory := filepath.Dir(fullpath)
 		err := destinationRoot.MkdirAll(keyDirectory, 0o755)
 		if err != nil {
@@ -3132,15 +4674,447 @@ func translateModuleSourceCode(
 		}
 	}
 
+	// The root module's config is set from the Terraform Cloud workspace's variables
+	if options.tfcWorkspace != "" && len(options.moduleAncestors) == 0 {
+		name, data, err := tfcStackConfig(state, scopes, module, filepath.Base(sourceDirectory), options)
+		if err != nil {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("could not format stack config YAML: %s", err),
+			})
+			return state.diagnostics
+		}
+
+		err = afero.WriteFile(destinationRoot, filepath.Join(destinationDirectory, name), data, 0o644)
+		if err != nil {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("could not write stack config YAML to destination: %s", err),
+			})
+			return state.diagnostics
+		}
+	}
+
 	return state.diagnostics
 }
 
+// vendoredModule returns the directory and version terraform installed the given module call to, if we're using the
+// lockfile and the installed module still matches the call's source and version constraints.
+func vendoredModule(
+	state *convertState, options translateOptions, moduleCall *configs.ModuleCall,
+) (string, *version.Version, bool) {
+	if options.manifest == nil {
+		return "", nil, false
+	}
+	if _, ok := moduleCall.SourceAddr.(addrs.ModuleSourceLocal); ok {
+		// Terraform doesn't install local modules, it just reads them from their source directory.
+		return "", nil, false
+	}
+
+	record, has := options.manifest[options.manifestKey]
+	if !has {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Subject:  &moduleCall.SourceAddrRange,
+			Severity: hcl.DiagWarning,
+			Summary:  "Module not installed",
+			Detail: fmt.Sprintf("The module %q is not in the module lockfile, it will be downloaded instead",
+				options.manifestKey),
+		})
+		return "", nil, false
+	}
+	if record.SourceAddr != moduleCall.SourceAddr.String() ||
+		(record.Version != nil && !moduleCall.Version.Required.Check(record.Version)) {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Subject:  &moduleCall.SourceAddrRange,
+			Severity: hcl.DiagWarning,
+			Summary:  "Module lockfile out of date",
+			Detail: fmt.Sprintf("The installed module %q does not match its configuration, it will be downloaded "+
+				"instead. Run `terraform init` to update the installed modules.", options.manifestKey),
+		})
+		return "", nil, false
+	}
+
+	return filepath.Join(options.manifestDirectory, portablePath(record.Dir)), record.Version, true
+}
+
+// TranslateOption is an option that can be passed to TranslateModule.
+type TranslateOption func(*translateOptions)
+
+type translateOptions struct {
+	// The directory that local module sources are allowed to resolve to, or "" to allow any path.
+	root string
+	// The source directory of the program being converted, this is only set when root is.
+	sourceDirectory string
+
+	// If set modules are read from where terraform installed them (.terraform/modules) rather than downloaded.
+	useLockfile bool
+	// The installed module manifest, the filesystem it was read from, and the directory that the module paths
+	// in it are relative to. These are set by TranslateModule if useLockfile is set.
+	manifest          modsdir.Manifest
+	manifestFs        afero.Fs
+	manifestDirectory string
+	// The key of the module being translated in the manifest, "" for the root module.
+	manifestKey string
+
+	// The exact provider versions read from the root module's .terraform.lock.hcl, keyed by provider name.
+	providerLocks map[string]string
+
+	// A template for the directory modules are written to, "" to write them to their default paths.
+	moduleLayout string
+
+	// If set only the variables and outputs of the module are converted.
+	interfaceOnly bool
+
+	// If set function calls that can be evaluated at conversion time are replaced with their value.
+	foldConstants bool
+
+	// If set kubernetes and helm providers configured from a cluster are given a templated kubeconfig string.
+	kubeconfigTemplate bool
+
+	// If set each module is written to a single main.pp rather than a file for each of its source files.
+	singleFile bool
+
+	// The categories of functions written inline rather than as std invokes.
+	inlineFunctions map[FunctionCategory]bool
+
+	// The outputs of each converted module that pass through one of its variables, keyed by the path the module
+	// was written to and then the output's name.
+	outputPassthroughs map[string]map[string]string
+
+	// If set waits for eventual consistency are converted so the converted program still waits.
+	waits bool
+
+	// What timestamps in resource names are replaced with, or "" to leave them.
+	stableNames NameStabilization
+
+	// How the variables locals are converted to are named, or "" to name them in camelCase.
+	localNaming LocalNaming
+
+	// If set uploads of every file in a directory are converted to synced folders.
+	syncedFolders bool
+
+	// If set long strings in the root module are written to files rather than converted to string literals.
+	extractFiles bool
+
+	// If set locals, data sources and root variables that nothing uses are removed.
+	removeUnused bool
+	// If set every variable is converted, even when removeUnused is set.
+	keepVariables bool
+
+	// If set this is called with the statistics for the conversion, which are collected in statistics.
+	onStatistics func(Statistics)
+	statistics   *Statistics
+
+	// If set every diagnostic is returned, rather than grouping repeats of the same diagnostic.
+	verboseDiagnostics bool
+
+	// If set the warnings from the conversion are replaced with a summary.
+	summary bool
+	// The percentage of resources that must be mapped, below this an error is returned.
+	minimumCoverage float64
+
+	// The destination directories of the modules that called the module being translated, starting with the root
+	// module. These are used to detect modules that call themselves.
+	moduleAncestors []string
+
+	// If set the resources for the program's s3 state backend are moved to a separate bootstrap project.
+	bootstrapProject bool
+
+	// Where the progress of the conversion is logged, this discards everything unless a logger was given.
+	logger *slog.Logger
+
+	// The conversion stops when this is done, this is context.Background() unless a context was given.
+	ctx context.Context
+
+	// Partial configuration for the root module's backend, as key=value pairs or paths of files, and the backend
+	// settings read from them by TranslateModule.
+	backendConfig     []string
+	backendAttributes hclsyntax.Attributes
+
+	// The "organization/workspace" and variables of the Terraform Cloud workspace to write stack config for.
+	tfcWorkspace string
+	tfcVariables []TerraformCloudVariable
+
+	// If set this is called with the stacks the program depends on, which are collected in stackDependencies.
+	onStackDependencies func([]StackDependency)
+	stackDependencies   *[]StackDependency
+
+	// If set this is called with the dependency graph of the program, which is collected in graph.
+	onGraph func(Graph)
+	graph   *Graph
+
+	// Hooks registered by WithResourceHook, keyed by Terraform resource type.
+	resourceHooks map[string]resourceHook
+
+	// If set this is called with a report of the internal errors, which are collected in internalErrors.
+	onBugReport    func(BugReport)
+	internalErrors *[]InternalError
+
+	// Rules to rewrite resources with, from WithRules.
+	rules []Rule
+	// Globs of the resource types to set retainOnDelete on.
+	retainOnDeleteTypes []string
+	// If set each generated file starts with a comment giving the converter's version, the arguments it was run with
+	// and the features they turned on.
+	header     bool
+	headerArgs []string
+	// The loader for the Pulumi schemas that enum values are checked against.
+	schemaLoader pschema.ReferenceLoader
+}
+
+// WithRoot limits the local modules that can be used to those in the given directory, which must contain the
+// source directory. This allows programs to use shared modules from elsewhere in a repository (e.g.
+// "../../modules/network"), these are written to the destination at their path relative to the root.
+func WithRoot(root string) TranslateOption {
+	return func(o *translateOptions) {
+		o.root = root
+	}
+}
+
+// WithUseLockfile makes remote and registry modules be read from where `terraform init` installed them, rather
+// than downloaded. This means the conversion uses exactly the versions of modules that were last deployed.
+func WithUseLockfile() TranslateOption {
+	return func(o *translateOptions) {
+		o.useLockfile = true
+	}
+}
+
+// WithModuleLayout sets the directory in the destination that each module is written to, rather than a path based on
+// its source. The layout is a slash separated path relative to the destination, where "{module}" is replaced with
+// the name of the module's directory, for example "infra/{module}".
+func WithModuleLayout(layout string) TranslateOption {
+	return func(o *translateOptions) {
+		o.moduleLayout = layout
+	}
+}
+
+// WithInterfaceOnly only converts the variables and outputs of the module, skipping its resources, data sources,
+// locals, providers and module calls. Outputs that depend on anything but variables are converted to notImplemented
+// calls. This gives the interface of a module for its internals to be written by hand.
+func WithInterfaceOnly() TranslateOption {
+	return func(o *translateOptions) {
+		o.interfaceOnly = true
+	}
+}
+
+// WithVerboseDiagnostics returns every diagnostic from the conversion. By default when the same diagnostic is
+// reported many times (e.g. for each use of an unsupported function) only the first few occurrences are returned.
+func WithVerboseDiagnostics() TranslateOption {
+	return func(o *translateOptions) {
+		o.verboseDiagnostics = true
+	}
+}
+
+// moduleDestination returns the path that a module which would by default be written to defaultPath should be
+// written to according to the module layout.
+func (o translateOptions) moduleDestination(defaultPath string) string {
+	if o.moduleLayout == "" {
+		return defaultPath
+	}
+	name := filepath.Base(defaultPath)
+	return filepath.Join("/", filepath.FromSlash(strings.ReplaceAll(o.moduleLayout, "{module}", name)))
+}
+
+// relativeWithin returns the path of target relative to dir, and true if target is dir or inside it.
+func relativeWithin(dir, target string) (string, bool) {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// readModuleManifest reads the module manifest terraform writes when installing modules, returning nil if it
+// doesn't exist.
+func readModuleManifest(source afero.Fs, path string) (modsdir.Manifest, error) {
+	f, err := source.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return modsdir.ReadManifestSnapshot(f)
+}
+
 func TranslateModule(
 	source afero.Fs, sourceDirectory string,
 	destination afero.Fs, info il.ProviderInfoSource,
+	opts ...TranslateOption,
 ) hcl.Diagnostics {
+	var options translateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.logger == nil {
+		options.logger = slog.New(discardHandler{})
+	}
+	if options.ctx == nil {
+		options.ctx = context.Background()
+	}
+	if options.root != "" {
+		options.root = filepath.Clean(options.root)
+		options.sourceDirectory = filepath.Clean(sourceDirectory)
+		if _, ok := relativeWithin(options.root, options.sourceDirectory); !ok {
+			return hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Source directory outside of root",
+				Detail: fmt.Sprintf("The source directory %q is not inside the root directory %q",
+					sourceDirectory, options.root),
+			}}
+		}
+	}
+
+	if options.moduleLayout != "" {
+		layout := filepath.Clean(filepath.FromSlash(options.moduleLayout))
+		if _, ok := relativeWithin(".", layout); !ok || filepath.IsAbs(layout) ||
+			!strings.Contains(layout, "{module}") {
+			return hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid module layout",
+				Detail: fmt.Sprintf("The module layout %q must be a relative path containing {module}",
+					options.moduleLayout),
+			}}
+		}
+	}
+	if diags := checkFunctionCategories(options.inlineFunctions); diags.HasErrors() {
+		return diags
+	}
+	if diags := checkNameStabilization(options.stableNames); diags.HasErrors() {
+		return diags
+	}
+	if diags := checkLocalNaming(options.localNaming); diags.HasErrors() {
+		return diags
+	}
+	options.outputPassthroughs = map[string]map[string]string{}
+
+	var diagnostics hcl.Diagnostics
+	if options.useLockfile {
+		manifestPath := filepath.Join(sourceDirectory, ".terraform", "modules", modsdir.ManifestSnapshotFilename)
+		manifest, err := readModuleManifest(source, manifestPath)
+		if err != nil {
+			return hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read module lockfile",
+				Detail:   fmt.Sprintf("Failed to read module lockfile %s: %v", manifestPath, err),
+			}}
+		}
+		if manifest == nil {
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Module lockfile not found",
+				Detail: fmt.Sprintf("No module lockfile was found at %s, modules will be downloaded instead. "+
+					"Run `terraform init` to install modules.", manifestPath),
+			})
+			manifest = modsdir.Manifest{}
+		}
+		options.manifest = manifest
+		options.manifestFs = source
+		options.manifestDirectory = sourceDirectory
+	}
+
+	// Terraform only locks providers for the root module, so every module is converted with the same versions.
+	providerLocks, lockDiagnostics := readProviderLocks(source, filepath.Join(sourceDirectory, providerLockFilename))
+	if lockDiagnostics.HasErrors() {
+		return append(diagnostics, lockDiagnostics...)
+	}
+	diagnostics = append(diagnostics, lockDiagnostics...)
+	options.providerLocks = providerLocks
+
+	if len(options.backendConfig) > 0 {
+		backendAttributes, backendDiagnostics := readBackendConfig(source, sourceDirectory, options.backendConfig)
+		if backendDiagnostics.HasErrors() {
+			return append(diagnostics, backendDiagnostics...)
+		}
+		diagnostics = append(diagnostics, backendDiagnostics...)
+		options.backendAttributes = backendAttributes
+	}
+
+	if options.onStatistics != nil || options.summary || options.minimumCoverage > 0 {
+		options.statistics = newStatistics()
+	}
+	if options.onStackDependencies != nil {
+		options.stackDependencies = &[]StackDependency{}
+	}
+	if options.onGraph != nil {
+		options.graph = &Graph{}
+	}
+	if options.onBugReport != nil {
+		options.internalErrors = &[]InternalError{}
+	}
+
 	modules := make(map[moduleKey]string)
-	return translateModuleSourceCode(modules, source, sourceDirectory, destination, "/", info)
+	diagnostics = append(diagnostics,
+		translateModuleSourceCode(modules, source, sourceDirectory, destination, "/", info, options)...)
+	diagnostics = append(diagnostics, checkPolicyFiles(source, sourceDirectory)...)
+	diagnostics = append(diagnostics, checkTestFiles(source, sourceDirectory)...)
+	if options.onStatistics != nil {
+		options.onStatistics(*options.statistics)
+	}
+	if options.onStackDependencies != nil {
+		options.onStackDependencies(*options.stackDependencies)
+	}
+	if options.onGraph != nil {
+		options.onGraph(*options.graph)
+	}
+	if options.onBugReport != nil {
+		options.onBugReport(newBugReport(options))
+	}
+	if options.summary || options.minimumCoverage > 0 {
+		diagnostics = summarizeDiagnostics(options, *options.statistics, diagnostics)
+	}
+	if options.verboseDiagnostics {
+		return diagnostics
+	}
+	return groupDiagnostics(diagnostics, maxGroupedDiagnostics)
+}
+
+// maxGroupedDiagnostics is how many occurrences of the same diagnostic groupDiagnostics keeps.
+const maxGroupedDiagnostics = 3
+
+// groupDiagnostics returns the diagnostics with repeats of the same diagnostic (the same severity, summary and
+// detail) limited to the first limit occurrences. The last occurrence kept notes how many more were dropped.
+func groupDiagnostics(diagnostics hcl.Diagnostics, limit int) hcl.Diagnostics {
+	type diagnosticKey struct {
+		severity hcl.DiagnosticSeverity
+		summary  string
+		detail   string
+	}
+	keyOf := func(diag *hcl.Diagnostic) diagnosticKey {
+		return diagnosticKey{diag.Severity, diag.Summary, diag.Detail}
+	}
+
+	counts := map[diagnosticKey]int{}
+	for _, diag := range diagnostics {
+		counts[keyOf(diag)]++
+	}
+
+	seen := map[diagnosticKey]int{}
+	grouped := make(hcl.Diagnostics, 0, len(diagnostics))
+	for _, diag := range diagnostics {
+		key := keyOf(diag)
+		seen[key]++
+		if seen[key] > limit {
+			continue
+		}
+		if seen[key] == limit && counts[key] > limit {
+			// Copy the diagnostic so we don't modify the original.
+			last := *diag
+			note := "1 more occurrence not shown"
+			if more := counts[key] - limit; more > 1 {
+				note = fmt.Sprintf("%d more occurrences not shown", more)
+			}
+			if last.Detail == "" {
+				last.Detail = note
+			} else {
+				last.Detail = fmt.Sprintf("%s (%s)", last.Detail, note)
+			}
+			diag = &last
+		}
+		grouped = append(grouped, diag)
+	}
+	return grouped
 }
 
 func errorf(subject hcl.Range, f string, args ...interface{}) *hcl.Diagnostic {
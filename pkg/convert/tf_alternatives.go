@@ -0,0 +1,151 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// resourceAlternative is the closest Pulumi resource to a terraform resource that has none, because it was
+// deprecated and removed from the provider, or replaced by other resources.
+type resourceAlternative struct {
+	// The terraform type of the alternative, and the token of the Pulumi resource it's mapped to.
+	typ   string
+	token string
+	// How the alternative differs, for the comment on resources converted to it.
+	note string
+}
+
+// Resources without a Pulumi equivalent, and the resources they're converted to instead of a type that doesn't
+// exist.
+var resourceAlternatives = map[string]resourceAlternative{
+	"aws_db_security_group": {
+		typ:   "aws_security_group",
+		token: "aws:ec2/securityGroup:SecurityGroup",
+		note:  "EC2-Classic was retired, DB instances use the VPC security groups in vpc_security_group_ids.",
+	},
+	"aws_elasticache_security_group": {
+		typ:   "aws_security_group",
+		token: "aws:ec2/securityGroup:SecurityGroup",
+		note:  "EC2-Classic was retired, ElastiCache clusters use the VPC security groups in security_group_ids.",
+	},
+	"aws_redshift_security_group": {
+		typ:   "aws_security_group",
+		token: "aws:ec2/securityGroup:SecurityGroup",
+		note:  "EC2-Classic was retired, Redshift clusters use the VPC security groups in vpc_security_group_ids.",
+	},
+	"azurerm_app_service": {
+		typ:   "azurerm_linux_web_app",
+		token: "azure:appservice/linuxWebApp:LinuxWebApp",
+		note:  "Windows apps use azurerm_windows_web_app, and the app's settings move into site_config.",
+	},
+	"azurerm_app_service_plan": {
+		typ:   "azurerm_service_plan",
+		token: "azure:appservice/servicePlan:ServicePlan",
+		note:  "The sku block is replaced by sku_name, and kind and reserved by os_type.",
+	},
+	"azurerm_app_service_slot": {
+		typ:   "azurerm_linux_web_app_slot",
+		token: "azure:appservice/linuxWebAppSlot:LinuxWebAppSlot",
+		note:  "Windows apps use azurerm_windows_web_app_slot.",
+	},
+	"azurerm_function_app": {
+		typ:   "azurerm_linux_function_app",
+		token: "azure:appservice/linuxFunctionApp:LinuxFunctionApp",
+		note:  "Windows apps use azurerm_windows_function_app, and the app's settings move into site_config.",
+	},
+	"azurerm_function_app_slot": {
+		typ:   "azurerm_linux_function_app_slot",
+		token: "azure:appservice/linuxFunctionAppSlot:LinuxFunctionAppSlot",
+		note:  "Windows apps use azurerm_windows_function_app_slot.",
+	},
+	"azurerm_sql_database": {
+		typ:   "azurerm_mssql_database",
+		token: "azure:mssql/database:Database",
+		note:  "The database refers to its server by server_id rather than server_name and resource_group_name.",
+	},
+	"azurerm_sql_elasticpool": {
+		typ:   "azurerm_mssql_elasticpool",
+		token: "azure:mssql/elasticPool:ElasticPool",
+		note:  "The sku and per_database_settings blocks replace the edition and dtu arguments.",
+	},
+	"azurerm_sql_firewall_rule": {
+		typ:   "azurerm_mssql_firewall_rule",
+		token: "azure:mssql/firewallRule:FirewallRule",
+		note:  "The rule refers to its server by server_id rather than server_name and resource_group_name.",
+	},
+	"azurerm_sql_server": {
+		typ:   "azurerm_mssql_server",
+		token: "azure:mssql/server:Server",
+		note:  "Most arguments are the same, see the azurerm_mssql_server docs for those that aren't.",
+	},
+}
+
+// findResourceAlternative returns the alternative that resource, which has no mapping, is converted to if it has
+// one, and sets root to the alternative's schema and info if the provider maps it. It warns that the resource is
+// converted to a different type.
+func findResourceAlternative(
+	state *convertState, providerInfo *tfbridge.ProviderInfo, resource *configs.Resource, root *PathInfo,
+) (resourceAlternative, bool) {
+	alternative, has := resourceAlternatives[resource.Type]
+	if !has {
+		return resourceAlternative{}, false
+	}
+	if providerInfo != nil {
+		if info := providerInfo.Resources[alternative.typ]; info != nil {
+			alternative.token = info.Tok.String()
+			root.ResourceInfo = info
+			if providerInfo.P != nil {
+				root.Resource = providerInfo.P.ResourcesMap().Get(alternative.typ)
+			}
+		}
+	}
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Resource has no Pulumi equivalent",
+		Detail: fmt.Sprintf("%s has no Pulumi equivalent, so %s.%s is converted to %s (%s), the closest Pulumi "+
+			"resource. Its arguments may need to be changed: %s", resource.Type, resource.Type, resource.Name,
+			alternative.typ, alternative.token, alternative.note),
+		Subject: resource.DeclRange.Ptr(),
+	})
+	return alternative, true
+}
+
+// alternativeComment returns comment lines to write above a resource converted to an alternative, saying what it
+// was converted from and how the alternative differs. It returns nil if resource wasn't converted to one.
+func alternativeComment(state *convertState, resource *configs.Resource) hclwrite.Tokens {
+	alternative, has := state.resourceAlternatives[resource.Type+"."+resource.Name]
+	if !has {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("Converted from %s, which has no Pulumi equivalent, to %s.", resource.Type, alternative.typ),
+		alternative.note,
+	}
+	tokens := hclwrite.Tokens{}
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
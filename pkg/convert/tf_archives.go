@@ -0,0 +1,254 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Terraform programs deploy code, like that of an aws_lambda_function, by zipping it with an archive_file data
+// source, passing the zip's output_path as the filename and its hash as the source_code_hash so the code is updated
+// when it changes. Pulumi archives are zipped and hashed by the provider, so an archive_file that's only used this
+// way is converted to an archive of its sources, the resource's archive property is set to that directly and the
+// hash, which Pulumi computes itself, is dropped.
+
+// archiveFileReference returns the key of the archive_file data source that expr refers to an attribute of, e.g.
+// "data.archive_file.lambda" for data.archive_file.lambda.output_path, and the attribute.
+func archiveFileReference(expr hcl.Expression) (string, string, bool) {
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(traversal.Traversal) != 4 || traversal.Traversal.RootName() != "data" {
+		return "", "", false
+	}
+	var names []string
+	for _, step := range traversal.Traversal[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			return "", "", false
+		}
+		names = append(names, attr.Name)
+	}
+	if names[0] != "archive_file" {
+		return "", "", false
+	}
+	return "data.archive_file." + names[1], names[2], true
+}
+
+// archiveSources returns true if the sources of archive can be converted to a Pulumi archive: a source_dir without
+// excludes, a source_file with a literal name, or source_content and source blocks with literal filenames.
+func archiveSources(archive *configs.Resource) bool {
+	if archive.Count != nil || archive.ForEach != nil {
+		return false
+	}
+	content := bodyContent(archive.Config)
+	if _, has := content.Attributes["excludes"]; has {
+		return false
+	}
+	if _, has := content.Attributes["source_dir"]; has {
+		return true
+	}
+	if attr, has := content.Attributes["source_file"]; has {
+		_, ok := archiveFileName(attr.Expr)
+		return ok
+	}
+	if attr, has := content.Attributes["source_content_filename"]; has {
+		_, ok := staticString(attr.Expr)
+		return ok
+	}
+	sources := 0
+	for _, block := range content.Blocks {
+		if block.Type != "source" {
+			continue
+		}
+		filename, has := bodyContent(block.Body).Attributes["filename"]
+		if !has {
+			return false
+		}
+		if _, ok := staticString(filename.Expr); !ok {
+			return false
+		}
+		sources++
+	}
+	return sources > 0
+}
+
+// staticString returns the value of expr if it's a string literal.
+func staticString(expr hcl.Expression) (string, bool) {
+	syntax, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return "", false
+	}
+	str, ident := matchStaticString(syntax)
+	if str == nil || ident {
+		return "", false
+	}
+	return *str, true
+}
+
+// archiveFileName returns the name a source_file is given in the archive, the last element of its path, if that's
+// literal, e.g. "index.js" for "${path.module}/src/index.js".
+func archiveFileName(expr hcl.Expression) (string, bool) {
+	var last string
+	switch expr := expr.(type) {
+	case *hclsyntax.TemplateExpr:
+		if len(expr.Parts) == 0 {
+			return "", false
+		}
+		literal, ok := expr.Parts[len(expr.Parts)-1].(*hclsyntax.LiteralValueExpr)
+		if !ok || literal.Val.Type() != cty.String || literal.Val.IsNull() {
+			return "", false
+		}
+		last = literal.Val.AsString()
+		// After an interpolation the name is only literal if the path has a directory separator after it.
+		if len(expr.Parts) > 1 && !strings.Contains(last, "/") {
+			return "", false
+		}
+	default:
+		str, ok := staticString(expr)
+		if !ok {
+			return "", false
+		}
+		last = str
+	}
+	name := path.Base(last)
+	return name, name != "." && name != "/"
+}
+
+// isArchiveHash returns true if a resource's attribute name set to the attribute of an archive_file is the
+// archive's hash, like source_code_hash = data.archive_file.lambda.output_base64sha256.
+func isArchiveHash(name, attribute string) bool {
+	return strings.HasSuffix(name, "hash") && strings.HasPrefix(attribute, "output_") && attribute != "output_path"
+}
+
+// findArchiveAssets returns the archive_file data sources in items that are only used as the archive properties of
+// resources, and the hashes that go with them, keyed by their address.
+func findArchiveAssets(scopes *scopes, items terraformItems) map[string]*configs.Resource {
+	archives := map[string]*configs.Resource{}
+	for _, item := range items {
+		if item.data != nil && item.data.Type == "archive_file" && archiveSources(item.data) {
+			archives[item.itemKey()] = item.data
+		}
+	}
+	if len(archives) == 0 {
+		return nil
+	}
+
+	// Count every reference to each archive, and the references that are archive properties or hashes.
+	references := map[string]int{}
+	for _, item := range items {
+		for _, traversal := range item.references() {
+			references[referenceKey(traversal)]++
+		}
+	}
+	uses := map[string]int{}
+	assets := map[string]bool{}
+	for _, item := range items {
+		if item.resource == nil {
+			continue
+		}
+		resourcePath := item.resource.Type + "." + item.resource.Name
+		for name, attr := range bodyContent(item.resource.Config).Attributes {
+			key, attribute, ok := archiveFileReference(attr.Expr)
+			if !ok || archives[key] == nil {
+				continue
+			}
+			if attribute == "output_path" {
+				asset := scopes.isAsset(appendPath(resourcePath, name))
+				if asset == nil || (asset.Kind != tfbridge.FileArchive && asset.Kind != tfbridge.BytesArchive) {
+					continue
+				}
+				assets[key] = true
+			} else if !isArchiveHash(name, attribute) {
+				continue
+			}
+			uses[key]++
+		}
+	}
+
+	inlined := map[string]*configs.Resource{}
+	for key, archive := range archives {
+		if assets[key] && uses[key] == references[key] {
+			inlined[key] = archive
+		}
+	}
+	return inlined
+}
+
+// removeArchiveAssets returns items without the archive_file data sources that are converted to archives.
+func removeArchiveAssets(items terraformItems, archives map[string]*configs.Resource) terraformItems {
+	if len(archives) == 0 {
+		return items
+	}
+	kept := make(terraformItems, 0, len(items))
+	for _, item := range items {
+		if item.data != nil && archives[item.itemKey()] != nil {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// convertArchiveAsset returns the Pulumi archive of the sources of archive.
+func convertArchiveAsset(state *convertState, scopes *scopes, archive *configs.Resource) hclwrite.Tokens {
+	content := bodyContent(archive.Config)
+	if attr, has := content.Attributes["source_dir"]; has {
+		return hclwrite.TokensForFunctionCall("fileArchive", convertExpression(state, false, scopes, "", attr.Expr))
+	}
+
+	var assets []hclwrite.ObjectAttrTokens
+	if attr, has := content.Attributes["source_file"]; has {
+		name, _ := archiveFileName(attr.Expr)
+		assets = append(assets, hclwrite.ObjectAttrTokens{
+			Name: hclwrite.TokensForValue(cty.StringVal(name)),
+			Value: hclwrite.TokensForFunctionCall("fileAsset",
+				convertExpression(state, false, scopes, "", attr.Expr)),
+		})
+	}
+	if attr, has := content.Attributes["source_content_filename"]; has {
+		filename, _ := staticString(attr.Expr)
+		source := hclwrite.TokensForValue(cty.StringVal(""))
+		if content, has := content.Attributes["source_content"]; has {
+			source = convertExpression(state, false, scopes, "", content.Expr)
+		}
+		assets = append(assets, hclwrite.ObjectAttrTokens{
+			Name:  hclwrite.TokensForValue(cty.StringVal(filename)),
+			Value: hclwrite.TokensForFunctionCall("stringAsset", source),
+		})
+	}
+	for _, block := range content.Blocks {
+		if block.Type != "source" {
+			continue
+		}
+		attributes := bodyContent(block.Body).Attributes
+		filename, _ := staticString(attributes["filename"].Expr)
+		source := hclwrite.TokensForValue(cty.StringVal(""))
+		if attr, has := attributes["content"]; has {
+			source = convertExpression(state, false, scopes, "", attr.Expr)
+		}
+		assets = append(assets, hclwrite.ObjectAttrTokens{
+			Name:  hclwrite.TokensForValue(cty.StringVal(filename)),
+			Value: hclwrite.TokensForFunctionCall("stringAsset", source),
+		})
+	}
+	return hclwrite.TokensForFunctionCall("assetArchive", hclwrite.TokensForObject(assets))
+}
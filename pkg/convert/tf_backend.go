@@ -0,0 +1,104 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// WithBackendConfig sets partial configuration for the program's state backend, as passed to `terraform init` with
+// -backend-config. Each value is either a key=value pair or the path of a file of backend settings, such as
+// prod.s3.tfbackend. Relative paths are read from the source directory. Later values override earlier ones, and all
+// of them override the settings in the program's backend block.
+func WithBackendConfig(values ...string) TranslateOption {
+	return func(o *translateOptions) {
+		o.backendConfig = append(o.backendConfig, values...)
+	}
+}
+
+// readBackendConfig returns the backend settings given by the partial configuration values, see WithBackendConfig.
+func readBackendConfig(
+	source afero.Fs, sourceDirectory string, values []string,
+) (hclsyntax.Attributes, hcl.Diagnostics) {
+	var diagnostics hcl.Diagnostics
+	attributes := hclsyntax.Attributes{}
+	for _, value := range values {
+		if key, val, isPair := strings.Cut(value, "="); isPair {
+			key = strings.TrimSpace(key)
+			attributes[key] = &hclsyntax.Attribute{
+				Name: key,
+				Expr: &hclsyntax.LiteralValueExpr{Val: cty.StringVal(val)},
+			}
+			continue
+		}
+
+		path := value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sourceDirectory, path)
+		}
+		src, err := afero.ReadFile(source, path)
+		if err != nil {
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read backend config",
+				Detail:   fmt.Sprintf("Failed to read backend config file %s: %v", path, err),
+			})
+			continue
+		}
+		file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+		diagnostics = append(diagnostics, diags...)
+		if diags.HasErrors() {
+			continue
+		}
+
+		body := file.Body.(*hclsyntax.Body)
+		for _, block := range body.Blocks {
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Unsupported block in backend config",
+				Detail:   fmt.Sprintf("Backend config files can only set attributes, ignoring the %s block", block.Type),
+				Subject:  block.DefRange().Ptr(),
+			})
+		}
+		for key, attr := range body.Attributes {
+			attributes[key] = attr
+		}
+	}
+	return attributes, diagnostics
+}
+
+// mergeBackendConfig returns config, the body of a backend block, with its attributes overridden by attributes.
+func mergeBackendConfig(config hcl.Body, attributes hclsyntax.Attributes) hcl.Body {
+	body, ok := config.(*hclsyntax.Body)
+	contract.Assertf(ok, "%T was not a hclsyntax.Body", config)
+
+	merged := *body
+	merged.Attributes = make(hclsyntax.Attributes, len(body.Attributes)+len(attributes))
+	for key, attr := range body.Attributes {
+		merged.Attributes[key] = attr
+	}
+	for key, attr := range attributes {
+		merged.Attributes[key] = attr
+	}
+	return &merged
+}
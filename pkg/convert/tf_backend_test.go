@@ -0,0 +1,91 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+)
+
+func TestReadBackendConfig(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/prod.s3.tfbackend", []byte(`
+bucket         = "my-state"
+key            = "prod/terraform.tfstate"
+dynamodb_table = "my-locks"
+`), 0o600)
+	require.NoError(t, err)
+
+	attributes, diagnostics := readBackendConfig(src, "/prog",
+		[]string{"prod.s3.tfbackend", "dynamodb_table=other-locks"})
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.Len(t, attributes, 3)
+
+	values := map[string]string{}
+	for key, attr := range attributes {
+		value, diags := attr.Expr.Value(nil)
+		require.False(t, diags.HasErrors(), "%v", diags)
+		values[key] = value.AsString()
+	}
+	assert.Equal(t, map[string]string{
+		"bucket":         "my-state",
+		"key":            "prod/terraform.tfstate",
+		"dynamodb_table": "other-locks",
+	}, values)
+
+	_, diagnostics = readBackendConfig(src, "/prog", []string{"missing.s3.tfbackend"})
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, "Failed to read backend config", diagnostics[0].Summary)
+}
+
+func TestTranslateWithBackendConfig(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+terraform {
+    backend "s3" {
+        key = "prod/terraform.tfstate"
+    }
+}
+
+resource "aws_s3_bucket" "state" {
+    bucket = "my-state"
+}
+`), 0o600)
+	require.NoError(t, err)
+	err = afero.WriteFile(src, "/prog/prod.s3.tfbackend", []byte(`bucket = "my-state"`), 0o600)
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithBootstrapProject(), WithBackendConfig("prod.s3.tfbackend"))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	// The bucket is only named by the partial config, so without it the bucket couldn't be moved.
+	bootstrap, err := afero.ReadFile(dst, "/bootstrap/main.pp")
+	require.NoError(t, err)
+	assert.Contains(t, string(bootstrap), `bucket = "my-state"`)
+}
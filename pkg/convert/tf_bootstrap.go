@@ -0,0 +1,279 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The directory, relative to the converted program, that the bootstrap project is written to.
+const bootstrapDirectory = "bootstrap"
+
+// WithBootstrapProject moves the S3 bucket and DynamoDB table that hold the program's own terraform state into a
+// separate bootstrap project, written to the "bootstrap" directory. Otherwise the converted program would manage the
+// resources that its state backend needs to exist before it can be deployed. Resources configuring the bucket, such
+// as aws_s3_bucket_versioning, are moved with it.
+func WithBootstrapProject() TranslateOption {
+	return func(o *translateOptions) {
+		o.bootstrapProject = true
+	}
+}
+
+// staticStringAttribute returns the value of the named attribute in body if it's a literal string.
+func staticStringAttribute(body hcl.Body, name string) (string, bool) {
+	attr, has := bodyContent(body).Attributes[name]
+	if !has {
+		return "", false
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// bodyReferences returns all the traversals used by the attributes in body, including those in nested blocks.
+func bodyReferences(body hcl.Body) []hcl.Traversal {
+	var traversals []hcl.Traversal
+	content := bodyContent(body)
+	for _, attr := range content.Attributes {
+		traversals = append(traversals, attr.Expr.Variables()...)
+	}
+	for _, block := range content.Blocks {
+		if block.Type != "dynamic" || len(block.Labels) != 1 {
+			traversals = append(traversals, bodyReferences(block.Body)...)
+			continue
+		}
+		// The iterator of a dynamic block, named by its label or iterator attribute, is local to the block.
+		iterator := block.Labels[0]
+		if attr, has := bodyContent(block.Body).Attributes["iterator"]; has {
+			iterator = hcl.ExprAsKeyword(attr.Expr)
+		}
+		for _, traversal := range bodyReferences(block.Body) {
+			if traversal.RootName() != iterator {
+				traversals = append(traversals, traversal)
+			}
+		}
+	}
+	return traversals
+}
+
+// references returns all the traversals used by the item.
+func (item terraformItem) references() []hcl.Traversal {
+	var traversals []hcl.Traversal
+	expressions := func(exprs ...hcl.Expression) {
+		for _, expr := range exprs {
+			if expr != nil {
+				traversals = append(traversals, expr.Variables()...)
+			}
+		}
+	}
+	switch {
+	case item.local != nil:
+		expressions(item.local.Expr)
+	case item.data != nil:
+		traversals = append(traversals, bodyReferences(item.data.Config)...)
+		expressions(item.data.Count, item.data.ForEach)
+		traversals = append(traversals, item.data.DependsOn...)
+	case item.resource != nil:
+		traversals = append(traversals, bodyReferences(item.resource.Config)...)
+		expressions(item.resource.Count, item.resource.ForEach)
+		traversals = append(traversals, item.resource.DependsOn...)
+		if item.resource.Managed != nil {
+			for _, provisioner := range item.resource.Managed.Provisioners {
+				traversals = append(traversals, bodyReferences(provisioner.Config)...)
+			}
+		}
+	case item.moduleCall != nil:
+		traversals = append(traversals, bodyReferences(item.moduleCall.Config)...)
+		expressions(item.moduleCall.Count, item.moduleCall.ForEach)
+		traversals = append(traversals, item.moduleCall.DependsOn...)
+	case item.output != nil:
+		expressions(item.output.Expr)
+		traversals = append(traversals, item.output.DependsOn...)
+	case item.provider != nil:
+		traversals = append(traversals, bodyReferences(item.provider.Config)...)
+	}
+	return traversals
+}
+
+// referenceKey returns the key of the variable, local, data source, module or resource that traversal refers to, or ""
+// if it refers to something that isn't part of the program, like count.index or path.module.
+func referenceKey(traversal hcl.Traversal) string {
+	root := traversal.RootName()
+	parts := 2
+	switch root {
+	case "count", "each", "path", "self", "terraform":
+		return ""
+	case "var", "local", "module":
+	case "data":
+		parts = 3
+	default:
+		// Anything else is a resource, e.g. aws_s3_bucket.state.
+	}
+	key := root
+	for _, part := range traversal[1:] {
+		if len(strings.Split(key, ".")) == parts {
+			break
+		}
+		attr, ok := part.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		key += "." + attr.Name
+	}
+	return key
+}
+
+// findBootstrapResources returns the keys of the resources that should be moved to the bootstrap project. These are
+// the aws_s3_bucket and aws_dynamodb_table named by the program's s3 backend, and any other aws_s3_bucket_ resources
+// that only refer to those. Nothing is moved, with a warning saying why, if the rest of the program uses them or they
+// use anything else in the program.
+func findBootstrapResources(
+	state *convertState, backend *configs.Backend, items terraformItems,
+) map[string]bool {
+	if backend == nil || backend.Type != "s3" {
+		var subject *hcl.Range
+		if backend != nil {
+			subject = backend.TypeRange.Ptr()
+		}
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "No state backend to bootstrap",
+			Detail:   "A bootstrap project can only be generated for programs that use the s3 backend",
+			Subject:  subject,
+		})
+		return nil
+	}
+
+	bucket, ok := staticStringAttribute(backend.Config, "bucket")
+	if !ok {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "No state backend to bootstrap",
+			Detail:   "The bucket of the s3 backend must be a literal string to generate a bootstrap project",
+			Subject:  backend.DeclRange.Ptr(),
+		})
+		return nil
+	}
+	// The lock table is optional, newer versions of terraform can lock using the bucket itself.
+	table, hasTable := staticStringAttribute(backend.Config, "dynamodb_table")
+
+	bootstrap := map[string]bool{}
+	for _, item := range items {
+		resource := item.resource
+		if resource == nil || resource.Count != nil || resource.ForEach != nil {
+			continue
+		}
+		switch resource.Type {
+		case "aws_s3_bucket":
+			if name, ok := staticStringAttribute(resource.Config, "bucket"); ok && name == bucket {
+				bootstrap[resource.Type+"."+resource.Name] = true
+			}
+		case "aws_dynamodb_table":
+			if name, ok := staticStringAttribute(resource.Config, "name"); ok && hasTable && name == table {
+				bootstrap[resource.Type+"."+resource.Name] = true
+			}
+		}
+	}
+	if len(bootstrap) == 0 {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "No state backend to bootstrap",
+			Detail: fmt.Sprintf("The state bucket %q isn't created by this program, so no bootstrap project "+
+				"is needed", bucket),
+			Subject: backend.DeclRange.Ptr(),
+		})
+		return nil
+	}
+
+	// Resources that configure the bucket, like its versioning and encryption, go with it.
+	for _, item := range items {
+		resource := item.resource
+		if resource == nil || !strings.HasPrefix(resource.Type, "aws_s3_bucket_") ||
+			resource.Count != nil || resource.ForEach != nil {
+			continue
+		}
+		references := item.references()
+		onlyBootstrap := len(references) > 0
+		for _, traversal := range references {
+			if key := referenceKey(traversal); key != "" && !bootstrap[key] {
+				onlyBootstrap = false
+				break
+			}
+		}
+		if onlyBootstrap {
+			bootstrap[resource.Type+"."+resource.Name] = true
+		}
+	}
+
+	// The two projects can't refer to each other, so check nothing crosses between them.
+	for _, item := range items {
+		if item.variable != nil {
+			continue
+		}
+		isBootstrap := item.resource != nil && bootstrap[item.resource.Type+"."+item.resource.Name]
+		if isBootstrap && item.resource.ProviderConfigRef != nil && item.resource.ProviderConfigRef.Alias != "" {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Can't generate bootstrap project",
+				Detail: fmt.Sprintf("%s.%s uses an aliased provider, so the state backend resources are kept "+
+					"in the program", item.resource.Type, item.resource.Name),
+				Subject: item.resource.DeclRange.Ptr(),
+			})
+			return nil
+		}
+		for _, traversal := range item.references() {
+			key := referenceKey(traversal)
+			if key == "" || isBootstrap == bootstrap[key] {
+				continue
+			}
+			user := item.DeclRange()
+			detail := fmt.Sprintf("%s is used by the rest of the program, so the state backend resources are "+
+				"kept in the program", key)
+			if isBootstrap {
+				detail = fmt.Sprintf("%s.%s uses %s, so the state backend resources are kept in the program",
+					item.resource.Type, item.resource.Name, key)
+			}
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Can't generate bootstrap project",
+				Detail:   detail,
+				Subject:  &user,
+			})
+			return nil
+		}
+	}
+
+	moved := make([]string, 0, len(bootstrap))
+	for key := range bootstrap {
+		moved = append(moved, key)
+	}
+	sort.Strings(moved)
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Generated bootstrap project",
+		Detail: fmt.Sprintf("The state backend resources (%s) have been moved to a separate bootstrap project, "+
+			"deploy it before the converted program", strings.Join(moved, ", ")),
+		Subject: backend.DeclRange.Ptr(),
+	})
+	return bootstrap
+}
@@ -0,0 +1,142 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+)
+
+// redactedLiteral replaces the text of string literals in bug reports.
+const redactedLiteral = "REDACTED"
+
+// BugReport describes the internal errors hit converting a program, with enough of the program to reproduce them
+// and attach to an issue. String literals and comments are redacted from the program's source, but the structure,
+// resource types and attribute names are kept.
+type BugReport struct {
+	// The version of the converter.
+	ConverterVersion string `json:"converterVersion"`
+	// The exact versions of terraform providers from the program's dependency lockfile, keyed by provider name.
+	Providers map[string]string `json:"providers"`
+	// The internal errors, in the order they were hit.
+	Errors []InternalError `json:"errors"`
+}
+
+// InternalError is a panic recovered from while converting part of a program.
+type InternalError struct {
+	// The address of what failed to convert, e.g. "module.network.aws_vpc.main".
+	Address string `json:"address"`
+	// The value the converter panicked with.
+	Error string `json:"error"`
+	// The stack trace of the panic.
+	Stack string `json:"stack"`
+	// The source of what failed to convert, with string literals and comments redacted.
+	Source string `json:"source"`
+}
+
+// WithBugReport calls the given function with a report of the internal errors hit by the conversion once it's
+// finished, so they can be written to a file to attach to an issue. The report has no errors if none were hit.
+func WithBugReport(callback func(BugReport)) TranslateOption {
+	return func(o *translateOptions) {
+		o.onBugReport = callback
+	}
+}
+
+// Records an internal error hit converting item, if a bug report was asked for.
+func (s *convertState) recordInternalError(item terraformItem, address, err, stack string) {
+	if s.internalErrors == nil {
+		return
+	}
+	*s.internalErrors = append(*s.internalErrors, InternalError{
+		Address: address,
+		Error:   err,
+		Stack:   stack,
+		Source:  redactSource(s.sources, item.DeclRange()),
+	})
+}
+
+func newBugReport(options translateOptions) BugReport {
+	providers := options.providerLocks
+	if providers == nil {
+		providers = map[string]string{}
+	}
+	return BugReport{
+		ConverterVersion: version.Version,
+		Providers:        providers,
+		Errors:           *options.internalErrors,
+	}
+}
+
+// redactSource returns the source of the block or attribute declared at rng, with the text of string literals and
+// comments removed. The type and labels of a block are kept, as without them the error can't be reproduced.
+func redactSource(sources map[string][]byte, rng hcl.Range) string {
+	src := sources[rng.Filename]
+
+	// Items are declared by their block's header, so find the block for the whole of its body.
+	isBlock := false
+	file, diags := hclsyntax.ParseConfig(src, rng.Filename, hcl.InitialPos)
+	if body, ok := file.Body.(*hclsyntax.Body); ok && !diags.HasErrors() {
+		for _, block := range body.Blocks {
+			if block.DefRange().Start == rng.Start {
+				rng = block.Range()
+				isBlock = true
+				break
+			}
+		}
+	}
+
+	tokens, _ := hclsyntax.LexConfig(src, rng.Filename, hcl.InitialPos)
+	var text strings.Builder
+	inBody := !isBlock
+	offset := rng.Start.Byte
+	for _, token := range tokens {
+		if token.Range.Start.Byte < rng.Start.Byte || token.Range.End.Byte > rng.End.Byte {
+			continue
+		}
+		// Keep the whitespace between tokens so the source reads the same, except for indentation before comments.
+		space := string(src[offset:token.Range.Start.Byte])
+		if token.Type == hclsyntax.TokenComment {
+			space = strings.TrimRight(space, " \t")
+		}
+		text.WriteString(space)
+		offset = token.Range.End.Byte
+
+		switch {
+		case token.Type == hclsyntax.TokenOBrace:
+			inBody = true
+			text.Write(token.Bytes)
+		case token.Type == hclsyntax.TokenComment:
+			// Line comments include their newline, which has to be kept to keep the source valid.
+			if strings.HasSuffix(string(token.Bytes), "\n") {
+				text.WriteString("\n")
+			}
+		case token.Type == hclsyntax.TokenQuotedLit && inBody:
+			text.WriteString(redactedLiteral)
+		case token.Type == hclsyntax.TokenStringLit && inBody:
+			// Heredoc lines include their newline.
+			text.WriteString(redactedLiteral)
+			if strings.HasSuffix(string(token.Bytes), "\n") {
+				text.WriteString("\n")
+			}
+		default:
+			text.Write(token.Bytes)
+		}
+	}
+	return strings.Replace(text.String(), "\r\n", "\n", -1)
+}
@@ -0,0 +1,251 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ACM certificates are validated with a DNS record for each of their domains, created with for_each over the
+// certificate's domain_validation_options:
+//
+//	resource "aws_route53_record" "validation" {
+//	  for_each = {
+//	    for dvo in aws_acm_certificate.cert.domain_validation_options : dvo.domain_name => {
+//	      name   = dvo.resource_record_name
+//	      record = dvo.resource_record_value
+//	      type   = dvo.resource_record_type
+//	    }
+//	  }
+//	  name    = each.value.name
+//	  records = [each.value.record]
+//	  type    = each.value.type
+//	  ...
+//	}
+//
+// The options are only known once the certificate is created, so converted as they are the range would be an
+// output, which Pulumi can't create resources over. The domains they're keyed by are known though, so the record is
+// converted to range over the certificate's domain_name and subject_alternative_names, and each.value to the
+// option for the domain, which is looked up inside an apply.
+
+// certificateValidation is an aws_route53_record that validates the domains of an aws_acm_certificate.
+type certificateValidation struct {
+	// The for expression the record's for_each is over.
+	forExpr *hclsyntax.ForExpr
+	// The certificate being validated.
+	certificate *configs.Resource
+	// The attribute of the validation option that each attribute of each.value is, e.g. resource_record_name for
+	// name.
+	values map[string]hcl.Traversal
+}
+
+// findCertificateValidation returns the certificate that resource validates the domains of, if it's an
+// aws_route53_record with for_each over the domain_validation_options of a certificate in certificates, keyed by
+// their domain_name, and each.value is only used for the attributes of the options.
+func findCertificateValidation(
+	resource *configs.Resource, certificates map[string]*configs.Resource,
+) *certificateValidation {
+	if resource.Type != "aws_route53_record" || resource.ForEach == nil {
+		return nil
+	}
+	forEach, ok := resource.ForEach.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+	forExpr, ok := unwrapParentheses(forEach).(*hclsyntax.ForExpr)
+	if !ok || forExpr.KeyExpr == nil || forExpr.CondExpr != nil || forExpr.Group || forExpr.KeyVar != "" {
+		return nil
+	}
+
+	// The collection must be the options of a certificate, and the key their domain.
+	collection, ok := forExpr.CollExpr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(collection.Traversal) != 3 {
+		return nil
+	}
+	attr, ok := collection.Traversal[2].(hcl.TraverseAttr)
+	if !ok || attr.Name != "domain_validation_options" {
+		return nil
+	}
+	certificate := certificates[referenceKey(collection.Traversal)]
+	if certificate == nil {
+		return nil
+	}
+	if !isOptionAttribute(forExpr.KeyExpr, forExpr.ValVar, "domain_name") {
+		return nil
+	}
+
+	// Each attribute of the value must be an attribute of the option.
+	object, ok := forExpr.ValExpr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil
+	}
+	values := map[string]hcl.Traversal{}
+	for _, item := range object.Items {
+		name, _ := matchStaticString(item.KeyExpr)
+		value, ok := item.ValueExpr.(*hclsyntax.ScopeTraversalExpr)
+		if name == nil || !ok || value.Traversal.RootName() != forExpr.ValVar {
+			return nil
+		}
+		values[*name] = value.Traversal
+	}
+
+	// each.value can't be used other than for those attributes, as there's no value to convert it to.
+	for _, traversal := range bodyReferences(resource.Config) {
+		if traversal.RootName() != "each" || len(traversal) < 2 {
+			continue
+		}
+		if attr, ok := traversal[1].(hcl.TraverseAttr); !ok || attr.Name != "value" {
+			continue
+		}
+		if len(traversal) < 3 {
+			return nil
+		}
+		attr, ok := traversal[2].(hcl.TraverseAttr)
+		if !ok || values[attr.Name] == nil {
+			return nil
+		}
+	}
+	return &certificateValidation{forExpr: forExpr, certificate: certificate, values: values}
+}
+
+// isOptionAttribute returns true if expr is the attribute name of the for expression's variable valVar.
+func isOptionAttribute(expr hclsyntax.Expression, valVar, name string) bool {
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != valVar {
+		return false
+	}
+	attr, ok := traversal.Traversal[1].(hcl.TraverseAttr)
+	return ok && attr.Name == name
+}
+
+// findCertificateValidations returns the certificate validations in items, keyed by the address of their record.
+func findCertificateValidations(items terraformItems) map[string]*certificateValidation {
+	certificates := map[string]*configs.Resource{}
+	for _, item := range items {
+		resource := item.resource
+		if resource == nil || resource.Managed == nil || resource.Type != "aws_acm_certificate" ||
+			resource.Count != nil || resource.ForEach != nil {
+			continue
+		}
+		if _, has := bodyContent(resource.Config).Attributes["domain_name"]; has {
+			certificates[item.itemKey()] = resource
+		}
+	}
+	if len(certificates) == 0 {
+		return nil
+	}
+
+	validations := map[string]*certificateValidation{}
+	for _, item := range items {
+		if item.resource == nil || item.resource.Managed == nil {
+			continue
+		}
+		if validation := findCertificateValidation(item.resource, certificates); validation != nil {
+			validations[item.itemKey()] = validation
+		}
+	}
+	return validations
+}
+
+// emptyRange returns an empty range at the start of rng, for expressions that are built by the converter so have
+// no source, and so no trivia, of their own.
+func emptyRange(rng hcl.Range) hcl.Range {
+	return hcl.Range{Filename: rng.Filename, Start: rng.Start, End: rng.Start}
+}
+
+// domains returns the domains the certificate of validation is for, its domain_name followed by its
+// subject_alternative_names, which is what the record is converted to range over.
+func (validation *certificateValidation) domains() hclsyntax.Expression {
+	rng := emptyRange(validation.forExpr.SrcRange)
+	attributes := bodyContent(validation.certificate.Config).Attributes
+	domain := attributes["domain_name"].Expr.(hclsyntax.Expression)
+	list := &hclsyntax.TupleConsExpr{Exprs: []hclsyntax.Expression{domain}, SrcRange: rng, OpenRange: rng}
+
+	names, has := attributes["subject_alternative_names"]
+	if !has {
+		return list
+	}
+	switch names := names.Expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		list.Exprs = append(list.Exprs, names.Exprs...)
+		return list
+	case hclsyntax.Expression:
+		return &hclsyntax.FunctionCallExpr{
+			Name:            "concat",
+			Args:            []hclsyntax.Expression{list, names},
+			NameRange:       rng,
+			OpenParenRange:  rng,
+			CloseParenRange: rng,
+		}
+	}
+	return list
+}
+
+// value returns what a reference to each.value in the record is converted to, the attribute of the validation
+// option for each.key: [for dvo in cert.domain_validation_options : dvo if dvo.domain_name == each.key][0].name.
+// It returns false if traversal isn't each.value.
+func (validation *certificateValidation) value(traversal hcl.Traversal) (hclsyntax.Expression, bool) {
+	if traversal.RootName() != "each" || len(traversal) < 3 {
+		return nil, false
+	}
+	if attr, ok := traversal[1].(hcl.TraverseAttr); !ok || attr.Name != "value" {
+		return nil, false
+	}
+	attr, ok := traversal[2].(hcl.TraverseAttr)
+	if !ok || validation.values[attr.Name] == nil {
+		return nil, false
+	}
+
+	forExpr := validation.forExpr
+	rng := emptyRange(forExpr.SrcRange)
+	option := &hclsyntax.ScopeTraversalExpr{
+		Traversal: hcl.Traversal{hcl.TraverseRoot{Name: forExpr.ValVar, SrcRange: rng}},
+		SrcRange:  rng,
+	}
+	each := &hclsyntax.ScopeTraversalExpr{
+		Traversal: hcl.Traversal{
+			hcl.TraverseRoot{Name: "each", SrcRange: rng},
+			hcl.TraverseAttr{Name: "key", SrcRange: rng},
+		},
+		SrcRange: rng,
+	}
+	options := &hclsyntax.ForExpr{
+		ValVar:   forExpr.ValVar,
+		CollExpr: forExpr.CollExpr,
+		ValExpr:  option,
+		CondExpr: &hclsyntax.BinaryOpExpr{
+			LHS:      forExpr.KeyExpr,
+			Op:       hclsyntax.OpEqual,
+			RHS:      each,
+			SrcRange: rng,
+		},
+		SrcRange:   rng,
+		OpenRange:  rng,
+		CloseRange: rng,
+	}
+	matching := &hclsyntax.IndexExpr{
+		Collection:   options,
+		Key:          &hclsyntax.LiteralValueExpr{Val: cty.NumberIntVal(0), SrcRange: rng},
+		SrcRange:     rng,
+		OpenRange:    rng,
+		BracketRange: rng,
+	}
+	relative := append(hcl.Traversal{}, validation.values[attr.Name][1:]...)
+	relative = append(relative, traversal[3:]...)
+	return &hclsyntax.RelativeTraversalExpr{Source: matching, Traversal: relative, SrcRange: rng}, true
+}
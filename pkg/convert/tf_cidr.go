@@ -0,0 +1,108 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// cidrsubnets(prefix, newbits...) allocates a run of consecutive subnets of prefix, each starting at the next
+// address aligned to its size. That only depends on the newbits, not on prefix, so when they're known at conversion
+// time it's converted to a list of cidrsubnet calls with the network number each subnet is allocated, e.g.
+// cidrsubnets(var.cidr, 4, 4, 8) is converted to
+// [cidrsubnet(var.cidr, 4, 0), cidrsubnet(var.cidr, 4, 1), cidrsubnet(var.cidr, 8, 32)].
+
+// The largest newbits cidrsubnets is converted for, which keeps the allocation within an int64.
+const maxCidrNewbits = 62
+
+// cidrSubnetNumbers returns the network number of each subnet cidrsubnets allocates for the given newbits, or false
+// if they aren't valid.
+func cidrSubnetNumbers(newbits []int64) ([]int64, bool) {
+	var largest int64
+	for _, bits := range newbits {
+		if bits < 1 || bits > maxCidrNewbits {
+			return nil, false
+		}
+		if bits > largest {
+			largest = bits
+		}
+	}
+
+	// Addresses are counted in units of the smallest subnet, so each subnet is 2^(largest-bits) units.
+	var next int64
+	numbers := make([]int64, len(newbits))
+	for i, bits := range newbits {
+		size := int64(1) << (largest - bits)
+		// Round up to the next address aligned to the subnet's size.
+		next = (next + size - 1) / size * size
+		numbers[i] = next / size
+		next += size
+		if next > int64(1)<<largest {
+			// The subnets don't fit in the prefix, which terraform reports as an error.
+			return nil, false
+		}
+	}
+	return numbers, true
+}
+
+// convertCidrSubnets returns call, a cidrsubnets call, converted to a list of cidrsubnet calls, or false if its
+// newbits aren't known at conversion time.
+func convertCidrSubnets(
+	state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if call.Name != "cidrsubnets" || len(call.Args) < 2 || call.ExpandFinal {
+		return nil, false
+	}
+	prefix, args := call.Args[0], call.Args[1:]
+	newbits := make([]int64, len(args))
+	for i, arg := range args {
+		if !isStaticExpr(scopes, arg, map[string]bool{}) {
+			return nil, false
+		}
+		value, diags := scopes.EvalExpr(arg)
+		if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() || value.Type() != cty.Number {
+			return nil, false
+		}
+		bits, accuracy := value.AsBigFloat().Int64()
+		if accuracy != 0 {
+			return nil, false
+		}
+		newbits[i] = bits
+	}
+	numbers, ok := cidrSubnetNumbers(newbits)
+	if !ok {
+		return nil, false
+	}
+
+	rng := emptyRange(call.Range())
+	subnets := make([]hclwrite.Tokens, len(args))
+	for i, arg := range args {
+		subnet := &hclsyntax.FunctionCallExpr{
+			Name: "cidrsubnet",
+			Args: []hclsyntax.Expression{
+				prefix,
+				arg,
+				&hclsyntax.LiteralValueExpr{Val: cty.NumberIntVal(numbers[i]), SrcRange: rng},
+			},
+			NameRange:       rng,
+			OpenParenRange:  rng,
+			CloseParenRange: rng,
+		}
+		subnets[i] = convertExpression(state, false, scopes, "", subnet)
+	}
+	return multilineTuple(subnets), true
+}
@@ -0,0 +1,46 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCidrSubnetNumbers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		newbits []int64
+		numbers []int64
+	}{
+		// The examples from the terraform docs, e.g. cidrsubnets("10.1.0.0/16", 4, 4, 8, 4) is 10.1.0.0/20,
+		// 10.1.16.0/20, 10.1.32.0/24 and 10.1.48.0/20.
+		{[]int64{4, 4, 8, 4}, []int64{0, 1, 32, 3}},
+		{[]int64{16, 16, 16, 32}, []int64{0, 1, 2, 196608}},
+		{[]int64{8, 8, 8, 8}, []int64{0, 1, 2, 3}},
+		// Smaller subnets after larger ones don't need aligning.
+		{[]int64{1, 2, 3}, []int64{0, 2, 6}},
+		// Subnets that don't fit in the prefix, or have no new bits, are errors.
+		{[]int64{1, 1, 1}, nil},
+		{[]int64{0}, nil},
+	}
+	for _, tt := range tests {
+		numbers, ok := cidrSubnetNumbers(tt.newbits)
+		assert.Equal(t, tt.numbers != nil, ok, "%v", tt.newbits)
+		assert.Equal(t, tt.numbers, numbers, "%v", tt.newbits)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// conditionalCount returns the condition of a data source whose count is `condition ? 1 : 0`, the pattern older
+// modules use to only read a data source when it's needed, and whether the condition is negated, for
+// `condition ? 0 : 1`. Data sources whose config uses count.index aren't matched.
+func conditionalCount(dataResource *configs.Resource) (hclsyntax.Expression, bool, bool) {
+	if dataResource.Count == nil || dataResource.ForEach != nil {
+		return nil, false, false
+	}
+	count, ok := dataResource.Count.(hclsyntax.Expression)
+	if !ok {
+		return nil, false, false
+	}
+	condition, negated, ok := countCondition(count)
+	if !ok {
+		return nil, false, false
+	}
+	for _, traversal := range bodyReferences(dataResource.Config) {
+		if traversal.RootName() == "count" {
+			return nil, false, false
+		}
+	}
+	return condition, negated, true
+}
+
+// convertConditionalInvoke returns the expression for a data source with a conditional count, which is the invoke
+// if the condition holds and null otherwise, rather than a list of zero or one invokes.
+func convertConditionalInvoke(state *convertState, scopes *scopes,
+	dataResource *configs.Resource, invoke hclwrite.Tokens,
+) hclwrite.Tokens {
+	condition, negated, _ := conditionalCount(dataResource)
+	whenTrue, whenFalse := invoke, hclwrite.TokensForIdentifier("null")
+	if negated {
+		whenTrue, whenFalse = whenFalse, whenTrue
+	}
+	tokens := convertExpression(state, false, scopes, "", condition)
+	tokens = append(tokens, makeToken(hclsyntax.TokenQuestion, "?"))
+	tokens = append(tokens, whenTrue...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+	tokens = append(tokens, whenFalse...)
+	return tokens
+}
+
+// rewriteConditionalDataReference rewrites a reference to the data source at path with a conditional count, which
+// is converted to name. Indexing the data source, e.g. data.aws_vpc.selected[0].id, is converted to a lookup that's
+// null if the data source wasn't read. Referring to it as a whole, e.g. for a splat or length(), is converted to a
+// list of the result if it was read, so the reference stays a list of zero or one results as in terraform.
+func rewriteConditionalDataReference(scopes *scopes, path, name string, rest hcl.Traversal) hclwrite.Tokens {
+	root := hclwrite.TokensForTraversal(hcl.Traversal{hcl.TraverseRoot{Name: name}})
+	isNull := append(append(hclwrite.Tokens{}, root...),
+		makeToken(hclsyntax.TokenEqualOp, "=="), makeToken(hclsyntax.TokenIdent, "null"))
+	conditional := func(whenNull, otherwise hclwrite.Tokens) hclwrite.Tokens {
+		tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOParen, "(")}
+		tokens = append(tokens, isNull...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenQuestion, "?"))
+		tokens = append(tokens, whenNull...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+		tokens = append(tokens, otherwise...)
+		return append(tokens, makeToken(hclsyntax.TokenCParen, ")"))
+	}
+
+	if len(rest) > 0 {
+		if _, ok := rest[0].(hcl.TraverseIndex); ok {
+			attributes := rewriteRelativeTraversal(scopes, path, rest[1:])
+			if len(attributes) == 0 {
+				return root
+			}
+			lookup := append(hcl.Traversal{hcl.TraverseRoot{Name: name}}, attributes...)
+			return conditional(hclwrite.TokensForIdentifier("null"), hclwrite.TokensForTraversal(lookup))
+		}
+	}
+
+	list := conditional(hclwrite.TokensForTuple(nil), hclwrite.TokensForTuple([]hclwrite.Tokens{root}))
+	return append(list, hclwrite.TokensForTraversal(rewriteRelativeTraversal(scopes, path, rest))...)
+}
@@ -0,0 +1,47 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// WithContext stops the conversion when ctx is cancelled or its deadline passes. Downloads of modules and registry
+// lookups are cancelled as they happen, otherwise the conversion is stopped before the next block is converted.
+// Nothing is written for a module whose conversion was stopped, and an error is returned.
+func WithContext(ctx context.Context) TranslateOption {
+	return func(o *translateOptions) {
+		o.ctx = ctx
+	}
+}
+
+// cancelledDiagnostic returns the error for a conversion that was stopped because its context ended with err.
+func cancelledDiagnostic(err error) *hcl.Diagnostic {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Conversion timed out",
+			Detail:   "The conversion took too long and was stopped before it finished",
+		}
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Conversion cancelled",
+		Detail:   "The conversion was cancelled before it finished",
+	}
+}
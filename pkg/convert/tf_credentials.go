@@ -0,0 +1,71 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// Matches the names of provider config attributes that are commonly credentials, e.g. access_key, secret_key,
+// client_secret, token and password.
+var credentialAttributeRegexp = regexp.MustCompile(`(^|_)(access_key|secret|token|password|api_key|private_key)(_|$)`)
+
+// A provider config attribute that is set from an input variable holding a credential.
+type providerCredential struct {
+	// The Pulumi config key, e.g. "aws:accessKey".
+	key string
+	// The name of the variable it was set from.
+	variable string
+}
+
+// isCredential returns true if the provider config attribute attrKey, set from variable, holds a credential. That's
+// if the variable is sensitive, the provider marks the config as secret or it has a name credentials usually have.
+func isCredential(attrKey string, variable *configs.Variable, info *tfbridge.SchemaInfo) bool {
+	if variable != nil && variable.Sensitive {
+		return true
+	}
+	if info != nil && info.Secret != nil && *info.Secret {
+		return true
+	}
+	return credentialAttributeRegexp.MatchString(attrKey)
+}
+
+// credentialsDiagnostic returns a warning for a provider configured with credentials from variables, with a stub
+// Pulumi ESC environment definition to set them from. Keeping credentials in an environment means they don't have to
+// be committed to stack config.
+func credentialsDiagnostic(provider *configs.Provider, credentials []providerCredential) *hcl.Diagnostic {
+	variables := make([]string, 0, len(credentials))
+	stub := &strings.Builder{}
+	stub.WriteString("values:\n  pulumiConfig:\n")
+	for _, credential := range credentials {
+		variables = append(variables, "var."+credential.variable)
+		fmt.Fprintf(stub, "    %s:\n      fn::secret: TODO the value of var.%s\n", credential.key, credential.variable)
+	}
+
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Provider credentials from variables",
+		Detail: fmt.Sprintf("The %s provider is configured with credentials from %s. Rather than setting these "+
+			"in stack config, define them in a Pulumi ESC environment and add it to the stack's environment list. "+
+			"For example:\n\n%s", provider.Name, strings.Join(variables, ", "), stub.String()),
+		Subject: provider.DeclRange.Ptr(),
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// Resources are often created for each result of a data source, like a route table association for each of
+// `data.aws_subnet_ids.private.ids`. These are converted to a range over the invoke's result. If the data source's
+// arguments refer to resources the invoke returns an output, and the range is over an output, which is converted to
+// creating the resources in an apply.
+
+// isSetDataAttribute returns true if traversal refers to a set attribute of a data source, like
+// data.aws_subnet_ids.private.ids. Ranging over a set gives each.key the elements of the set, rather than the indexes
+// that ranging over the list it's converted to gives.
+func isSetDataAttribute(scopes *scopes, traversal hcl.Traversal) bool {
+	if len(traversal) != 4 || traversal.RootName() != "data" {
+		return false
+	}
+	path := []string{"data"}
+	for _, part := range traversal[1:] {
+		attr, ok := part.(hcl.TraverseAttr)
+		if !ok {
+			return false
+		}
+		path = append(path, attr.Name)
+	}
+	info := scopes.getInfo(strings.Join(path, "."))
+	return info.Schema != nil && info.Schema.Type() == shim.TypeSet
+}
+
+// dataReferences returns the traversals the arguments of a data source use. depends_on isn't included, as data
+// sources are read without waiting for what they depend on.
+func dataReferences(dataResource *configs.Resource) []hcl.Traversal {
+	traversals := bodyReferences(dataResource.Config)
+	for _, expr := range []hcl.Expression{dataResource.Count, dataResource.ForEach} {
+		if expr != nil {
+			traversals = append(traversals, expr.Variables()...)
+		}
+	}
+	return traversals
+}
+
+// markDataReadAfterApply sets ReadAfterApply on the roots of the data sources in items whose arguments refer to
+// resources or modules, directly or through locals and other data sources.
+func markDataReadAfterApply(scopes *scopes, items terraformItems) {
+	byKey := map[string]terraformItem{}
+	for _, item := range items {
+		if item.local != nil || item.data != nil {
+			byKey[item.itemKey()] = item
+		}
+	}
+
+	var readsAfterApply func(traversals []hcl.Traversal, seen map[string]bool) bool
+	readsAfterApply = func(traversals []hcl.Traversal, seen map[string]bool) bool {
+		for _, traversal := range traversals {
+			key := referenceKey(traversal)
+			switch {
+			case key == "" || strings.HasPrefix(key, "var."):
+			case strings.HasPrefix(key, "local.") || strings.HasPrefix(key, "data."):
+				item, has := byKey[key]
+				if !has || seen[key] {
+					continue
+				}
+				seen[key] = true
+				if item.local != nil && readsAfterApply(item.references(), seen) ||
+					item.data != nil && readsAfterApply(dataReferences(item.data), seen) {
+					return true
+				}
+			default:
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, item := range items {
+		if item.data == nil {
+			continue
+		}
+		key := item.itemKey()
+		root, has := scopes.roots[key]
+		if !has {
+			continue
+		}
+		root.ReadAfterApply = readsAfterApply(dataReferences(item.data), map[string]bool{key: true})
+		scopes.roots[key] = root
+	}
+}
+
+// readAfterApplySource returns the key of a data source that's read after apply which traversals refer to,
+// directly or through locals, or "" if there isn't one.
+func readAfterApplySource(scopes *scopes, traversals []hcl.Traversal, seen map[string]bool) string {
+	for _, traversal := range traversals {
+		key := referenceKey(traversal)
+		switch {
+		case strings.HasPrefix(key, "data."):
+			if scopes.roots[key].ReadAfterApply {
+				return key
+			}
+		case strings.HasPrefix(key, "local."):
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			root := scopes.roots[key]
+			if root.Expression == nil {
+				continue
+			}
+			if source := readAfterApplySource(scopes, (*root.Expression).Variables(), seen); source != "" {
+				return source
+			}
+		}
+	}
+	return ""
+}
+
+// dataRangeComment returns comment lines to write above a resource whose count or for_each uses the results of a
+// data source that's read after apply, saying that the resources are created in an apply, and reports a warning for
+// it. It returns nil if the resource's count and for_each don't use such a data source.
+func dataRangeComment(state *convertState, scopes *scopes, resource *configs.Resource) hclwrite.Tokens {
+	expr, argument := resource.ForEach, "for_each"
+	if expr == nil {
+		expr, argument = resource.Count, "count"
+	}
+	if expr == nil {
+		return nil
+	}
+	source := readAfterApplySource(scopes, expr.Variables(), map[string]bool{})
+	if source == "" {
+		return nil
+	}
+
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Resources created for the results of a data source read after apply",
+		Detail: fmt.Sprintf("The %s of %s.%s uses %s, which is only read once the resources its arguments "+
+			"refer to are created. The resources are created for its results in an apply, so previews won't "+
+			"show them until it's been read", argument, resource.Type, resource.Name, source),
+		Subject: expr.Range().Ptr(),
+	})
+
+	lines := []string{
+		fmt.Sprintf("The %s of this resource uses %s, which is read once the resources its arguments", argument, source),
+		"refer to are created, so these resources are created for its results in an apply.",
+	}
+	tokens := hclwrite.Tokens{}
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
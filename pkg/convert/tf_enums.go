@@ -0,0 +1,224 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The provider mappings don't say which values a property allows, but the Pulumi schemas of the providers do for
+// properties that are enums, like instance types or storage classes. With a schema loader constant values of these
+// properties are checked when they're converted, rather than failing when the converted program is run.
+
+// WithSchemaLoader gives the loader for the Pulumi schemas of providers. Constant values of enum properties that
+// aren't one of the enum's values, or that the schema marks as deprecated, are warned about.
+func WithSchemaLoader(loader pschema.ReferenceLoader) TranslateOption {
+	return func(o *translateOptions) {
+		o.schemaLoader = loader
+	}
+}
+
+// loadSchema returns the Pulumi schema of the package that token is in, or nil if it couldn't be loaded.
+func (state *convertState) loadSchema(token string) *pschema.Package {
+	name, _, _ := strings.Cut(token, ":")
+	if pkg, has := state.schemas[name]; has {
+		return pkg
+	}
+
+	var pkg *pschema.Package
+	ref, err := state.schemaLoader.LoadPackageReference(name, nil)
+	if err == nil {
+		pkg, err = ref.Definition()
+	}
+	if err != nil {
+		state.logger.Debug("could not load schema", "package", name, "error", err)
+		pkg = nil
+	}
+	state.schemas[name] = pkg
+	return pkg
+}
+
+// propertyType returns the type in the Pulumi schema of the property of a resource or data source at
+// fullyQualifiedPath (e.g. aws_instance.web.instance_type), without any input or optional wrapper. This returns nil
+// if there's no schema loader, or the schema doesn't have the property.
+func (state *convertState) propertyType(scopes *scopes, fullyQualifiedPath string) pschema.Type {
+	if state.schemaLoader == nil {
+		return nil
+	}
+
+	parts := strings.Split(fullyQualifiedPath, ".")
+	rootParts := 2
+	if parts[0] == "data" {
+		rootParts = 3
+	}
+	if len(parts) <= rootParts {
+		return nil
+	}
+	path := strings.Join(parts[:rootParts], ".")
+	root := scopes.getInfo(path)
+
+	var properties []*pschema.Property
+	switch {
+	case root.ResourceInfo != nil:
+		pkg := state.loadSchema(string(root.ResourceInfo.Tok))
+		if pkg == nil {
+			return nil
+		}
+		resource, has := pkg.GetResource(string(root.ResourceInfo.Tok))
+		if !has {
+			return nil
+		}
+		properties = resource.InputProperties
+	case root.DataSourceInfo != nil:
+		pkg := state.loadSchema(string(root.DataSourceInfo.Tok))
+		if pkg == nil {
+			return nil
+		}
+		function, has := pkg.GetFunction(string(root.DataSourceInfo.Tok))
+		if !has || function.Inputs == nil {
+			return nil
+		}
+		properties = function.Inputs.Properties
+	default:
+		return nil
+	}
+
+	var typ pschema.Type
+	for _, part := range parts[rootParts:] {
+		name := strings.TrimRight(part, "[]")
+		path = appendPath(path, name)
+		pulumiName := scopes.pulumiName(path)
+
+		typ = nil
+		for _, property := range properties {
+			if property.Name == pulumiName {
+				typ = unwrapPropertyType(property.Type)
+				break
+			}
+		}
+		// Blocks are only indexed if they're lists in the Pulumi schema as well, max items one blocks are flattened
+		// to their element.
+		for i := strings.Count(part, "[]"); i > 0 && typ != nil; i-- {
+			switch t := typ.(type) {
+			case *pschema.ArrayType:
+				typ = unwrapPropertyType(t.ElementType)
+			case *pschema.MapType:
+				typ = unwrapPropertyType(t.ElementType)
+			default:
+				typ = nil
+			}
+			path = appendPathArray(path)
+		}
+		if typ == nil {
+			return nil
+		}
+
+		properties = nil
+		if object, ok := typ.(*pschema.ObjectType); ok {
+			properties = object.Properties
+		}
+	}
+	return typ
+}
+
+// unwrapPropertyType returns typ without any input or optional wrappers.
+func unwrapPropertyType(typ pschema.Type) pschema.Type {
+	for {
+		switch t := typ.(type) {
+		case *pschema.InputType:
+			typ = t.ElementType
+		case *pschema.OptionalType:
+			typ = t.ElementType
+		default:
+			return typ
+		}
+	}
+}
+
+// checkEnumValue adds warnings if value, the constant value of the property at fullyQualifiedPath, isn't one of the
+// values of the property's enum or is a deprecated value. Values of open enums, which are a union of the enum and
+// its element type, are only checked for deprecation.
+func checkEnumValue(state *convertState, fullyQualifiedPath string, typ pschema.Type, value cty.Value,
+	subject hcl.Range,
+) {
+	open := false
+	if union, ok := typ.(*pschema.UnionType); ok {
+		typ = nil
+		for _, element := range union.ElementTypes {
+			if enum, ok := unwrapPropertyType(element).(*pschema.EnumType); ok {
+				typ, open = enum, true
+				break
+			}
+		}
+	}
+
+	switch typ := typ.(type) {
+	case *pschema.ArrayType:
+		if !value.CanIterateElements() || (!value.Type().IsListType() && !value.Type().IsSetType() &&
+			!value.Type().IsTupleType()) {
+			return
+		}
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			checkEnumValue(state, fullyQualifiedPath, unwrapPropertyType(typ.ElementType), element, subject)
+		}
+	case *pschema.EnumType:
+		var text string
+		switch {
+		case !value.IsKnown() || value.IsNull():
+			return
+		case value.Type() == cty.String:
+			text = value.AsString()
+		case value.Type() == cty.Number:
+			text = value.AsBigFloat().Text('f', -1)
+		case value.Type() == cty.Bool:
+			text = fmt.Sprint(value.True())
+		default:
+			return
+		}
+
+		values := make([]string, 0, len(typ.Elements))
+		for _, element := range typ.Elements {
+			if fmt.Sprint(element.Value) != text {
+				values = append(values, fmt.Sprintf("%q", fmt.Sprint(element.Value)))
+				continue
+			}
+			if element.DeprecationMessage != "" {
+				state.appendDiagnostic(&hcl.Diagnostic{
+					Subject:  &subject,
+					Severity: hcl.DiagWarning,
+					Summary:  "Deprecated property value",
+					Detail: fmt.Sprintf("The value %q for property %q is deprecated: %s",
+						text, fullyQualifiedPath, element.DeprecationMessage),
+				})
+			}
+			return
+		}
+		if !open {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Subject:  &subject,
+				Severity: hcl.DiagWarning,
+				Summary:  "Invalid property value",
+				Detail: fmt.Sprintf("The value %q for property %q is not one of the values of %s: %s",
+					text, fullyQualifiedPath, typ.Token, strings.Join(values, ", ")),
+			})
+		}
+	}
+}
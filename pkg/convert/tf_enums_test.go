@@ -0,0 +1,93 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCheckEnumValue(t *testing.T) {
+	t.Parallel()
+
+	// The schemas generated from the test mappings can't have enums, so these are built by hand.
+	storageClass := &pschema.EnumType{
+		Token: "aws:s3/storageClass:StorageClass",
+		Elements: []*pschema.Enum{
+			{Value: "STANDARD"},
+			{Value: "GLACIER"},
+			{Value: "REDUCED_REDUNDANCY", DeprecationMessage: "Use STANDARD instead"},
+		},
+		ElementType: pschema.StringType,
+	}
+	openStorageClass := &pschema.UnionType{
+		ElementTypes: []pschema.Type{pschema.StringType, &pschema.InputType{ElementType: storageClass}},
+	}
+	ports := &pschema.EnumType{
+		Token:       "aws:ec2/port:Port",
+		Elements:    []*pschema.Enum{{Value: float64(80)}, {Value: float64(443)}},
+		ElementType: pschema.NumberType,
+	}
+
+	tests := []struct {
+		typ     pschema.Type
+		value   cty.Value
+		details []string
+	}{
+		{storageClass, cty.StringVal("STANDARD"), nil},
+		{storageClass, cty.StringVal("ARCHIVE"), []string{
+			`The value "ARCHIVE" for property "bucket.storage_class" is not one of the values of ` +
+				`aws:s3/storageClass:StorageClass: "STANDARD", "GLACIER", "REDUCED_REDUNDANCY"`,
+		}},
+		{storageClass, cty.StringVal("REDUCED_REDUNDANCY"), []string{
+			`The value "REDUCED_REDUNDANCY" for property "bucket.storage_class" is deprecated: Use STANDARD instead`,
+		}},
+		// Open enums take any string, but their deprecated values are still warned about.
+		{openStorageClass, cty.StringVal("ARCHIVE"), nil},
+		{openStorageClass, cty.StringVal("REDUCED_REDUNDANCY"), []string{
+			`The value "REDUCED_REDUNDANCY" for property "bucket.storage_class" is deprecated: Use STANDARD instead`,
+		}},
+		{ports, cty.NumberIntVal(443), nil},
+		{ports, cty.StringVal("443"), nil},
+		{ports, cty.NumberIntVal(8080), []string{
+			`The value "8080" for property "bucket.storage_class" is not one of the values of aws:ec2/port:Port: ` +
+				`"80", "443"`,
+		}},
+		// Each element of a list of enums is checked.
+		{&pschema.ArrayType{ElementType: storageClass}, cty.TupleVal([]cty.Value{
+			cty.StringVal("GLACIER"), cty.StringVal("COLD"),
+		}), []string{
+			`The value "COLD" for property "bucket.storage_class" is not one of the values of ` +
+				`aws:s3/storageClass:StorageClass: "STANDARD", "GLACIER", "REDUCED_REDUNDANCY"`,
+		}},
+		// Properties that aren't enums aren't checked.
+		{pschema.StringType, cty.StringVal("anything"), nil},
+		{storageClass, cty.NullVal(cty.String), nil},
+	}
+	for _, tt := range tests {
+		state := &convertState{}
+		checkEnumValue(state, "bucket.storage_class", tt.typ, tt.value, hcl.Range{})
+
+		var details []string
+		for _, diag := range state.diagnostics {
+			details = append(details, diag.Detail)
+		}
+		assert.Equal(t, tt.details, details, "%s %#v", tt.typ, tt.value)
+	}
+}
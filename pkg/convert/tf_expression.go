@@ -0,0 +1,82 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"golang.org/x/exp/slog"
+)
+
+// maxExpressionLength is the longest source ConvertExpression converts. Expressions are parsed and converted
+// recursively, so this bounds how long converting a deeply nested expression can take.
+const maxExpressionLength = 64 * 1024
+
+// expressionFilename is the name expressions converted by ConvertExpression are reported in.
+const expressionFilename = "<expression>"
+
+// ConvertExpression converts a single Terraform expression, such as `"${var.name}-${count.index}"`, to PCL. It
+// returns diagnostics rather than panicking for any input, so it is safe to use on expressions from untrusted sources
+// like third party modules. References are converted as they would be in a module that doesn't declare them.
+func ConvertExpression(src string, info il.ProviderInfoSource) (result string, diagnostics hcl.Diagnostics) {
+	if len(src) > maxExpressionLength {
+		return "", hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Expression too long",
+			Detail: fmt.Sprintf("The expression is %d bytes long, only expressions up to %d bytes can be converted",
+				len(src), maxExpressionLength),
+		}}
+	}
+
+	expr, diagnostics := hclsyntax.ParseExpression([]byte(src), expressionFilename, hcl.InitialPos)
+	if diagnostics.HasErrors() {
+		return "", diagnostics
+	}
+
+	state := &convertState{
+		sources:           map[string][]byte{expressionFilename: []byte(src)},
+		rewriteObjectKeys: true,
+		pinnedVersions:    make(map[string]string),
+		logger:            slog.New(discardHandler{}),
+	}
+	scopes := newScopes(info)
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = ""
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Internal error",
+				Detail: fmt.Sprintf("The converter failed converting the expression. "+
+					"Please report this as a bug: %v\n\n%s", r, debug.Stack()),
+				Subject: expr.Range().Ptr(),
+			})
+		}
+	}()
+
+	tokens := convertExpression(state, false, scopes, "", expr)
+	diagnostics = append(diagnostics, state.diagnostics...)
+	// The tokens have no spacing of their own, so they're formatted as tokens like a program is. Formatting their
+	// bytes would lex a-(b) as a call of the identifier a-.
+	file := hclwrite.NewEmptyFile()
+	file.Body().AppendUnstructuredTokens(tokens)
+	return strings.TrimSpace(string(file.Bytes())), diagnostics
+}
@@ -0,0 +1,136 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+)
+
+func TestConvertExpression(t *testing.T) {
+	t.Parallel()
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	info := il.NewMapperProviderInfoSource(mapper)
+
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+		summary  string
+	}{
+		{
+			name:     "variable",
+			input:    "var.bucket_name",
+			expected: "bucketName",
+		},
+		{
+			name:     "template",
+			input:    `"${var.name}-bucket"`,
+			expected: `"${name}-bucket"`,
+		},
+		{
+			name:     "conditional",
+			input:    "var.enabled ? 1 : 0",
+			expected: "enabled ? 1 : 0",
+		},
+		{
+			name:    "syntax error",
+			input:   "var.",
+			summary: "Invalid attribute name",
+		},
+		{
+			name:    "count outside of a resource",
+			input:   "count.index",
+			summary: `Reference to "count" in non-counted context`,
+		},
+		{
+			name:    "too long",
+			input:   strings.Repeat("a", maxExpressionLength+1),
+			summary: "Expression too long",
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, diagnostics := ConvertExpression(tt.input, info)
+			if tt.summary == "" {
+				require.Empty(t, diagnostics)
+				assert.Equal(t, tt.expected, result)
+				return
+			}
+			require.True(t, diagnostics.HasErrors())
+			assert.Equal(t, tt.summary, diagnostics[0].Summary)
+		})
+	}
+}
+
+// FuzzConvertExpression checks that no expression makes the converter panic or write invalid UTF-8. Inputs that did
+// are kept in testdata/fuzz/FuzzConvertExpression, run `go test -fuzz FuzzConvertExpression` to look for more.
+func FuzzConvertExpression(f *testing.F) {
+	seeds := []string{
+		`var.name`,
+		`local.common_tags["Name"]`,
+		`data.aws_ami.ubuntu.id`,
+		`aws_instance.web[0].private_ip`,
+		`aws_instance.web[*].id`,
+		`aws_instance.web.*.id`,
+		`"${var.prefix}-${terraform.workspace}"`,
+		`"%{ for name in var.names }${name},%{ endfor }"`,
+		`"%{ if var.enabled }on%{ else }off%{ endif }"`,
+		"<<EOT\nhello ${var.name}\nEOT\n",
+		`[for k, v in var.map : upper(v) if k != ""]`,
+		`{ for k, v in var.map : v => k... }`,
+		`join(",", ["a", var.b])`,
+		`merge(local.tags, { Name = "web" })`,
+		`lookup(var.map, "key", null)`,
+		`concat(var.lists...)`,
+		`-var.count + 2 * 3 % 4 >= 5 || !(var.a && var.b)`,
+		`path.module`,
+		`each.value`,
+		`{ "a b" = 1, c = null, (var.key) = [] }`,
+		`tolist(toset(var.list))[0]`,
+		`/* comment */ (1)`,
+		`""`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	info := il.NewMapperProviderInfoSource(mapper)
+	f.Fuzz(func(t *testing.T, src string) {
+		result, diagnostics := ConvertExpression(src, info)
+		if utf8.ValidString(src) && !utf8.ValidString(result) {
+			t.Fatalf("converting %q wrote invalid UTF-8: %q", src, result)
+		}
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Summary == "Internal error" {
+				t.Fatalf("converting %q: %s", src, diagnostic.Detail)
+			}
+		}
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// The directory, relative to the program, that extracted files are written to.
+	extractedFilesDirectory = "files"
+	// Strings with at least this many lines, or this many bytes, are extracted to files.
+	extractMinLines  = 10
+	extractMinLength = 1000
+)
+
+// Attributes that give the path of the file a resource writes, e.g. the file of a github_repository_file. The
+// content of these resources is extracted to a file with the same name.
+var filePathAttributes = []string{"file", "file_path", "filename"}
+
+// WithExtractFiles writes long strings and heredocs in resources, such as the content of github_repository_file or a
+// templated CODEOWNERS, to files next to the program rather than converting them to huge string literals. Plain
+// strings are read back with readFile, or passed as a fileAsset to asset properties, and templates are read back
+// with the std format function, their interpolations replaced by %v.
+//
+// Files are only extracted from the root program, as the programs of components don't run in their own directory.
+func WithExtractFiles() TranslateOption {
+	return func(o *translateOptions) {
+		o.extractFiles = true
+	}
+}
+
+// extractFile writes the content of a long string attribute to a file and returns the expression to read it back.
+// It returns false if files aren't being extracted, or the attribute isn't long enough or can't be extracted. asset
+// is set if the attribute is an asset, in which case this returns the path to the file.
+func (state *convertState) extractFile(scopes *scopes,
+	fullyQualifiedPath string, attributes hcl.Attributes, attr *hcl.Attribute, asset bool,
+) (hclwrite.Tokens, bool) {
+	if state.extractedFiles == nil {
+		return nil, false
+	}
+	template, ok := attr.Expr.(*hclsyntax.TemplateExpr)
+	if !ok || len(template.Parts) == 0 {
+		return nil, false
+	}
+
+	literal := func(part hclsyntax.Expression) (string, bool) {
+		if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String && lit.Val.IsKnown() {
+			return lit.Val.AsString(), true
+		}
+		return "", false
+	}
+
+	// Check the size of the string before converting anything, so nothing is converted twice if it's too short.
+	length, lines, interpolated := 0, 0, false
+	for _, part := range template.Parts {
+		if text, ok := literal(part); ok {
+			length += len(text)
+			lines += strings.Count(text, "\n")
+		} else {
+			interpolated = true
+		}
+	}
+	if lines < extractMinLines && length < extractMinLength {
+		return nil, false
+	}
+	if interpolated && asset {
+		// Assets are read as they are, so templates can't be extracted for them.
+		return nil, false
+	}
+
+	// Interpolations and directives are replaced by %v and passed as arguments to format, so any literal % needs
+	// escaping.
+	var content strings.Builder
+	var args []hclwrite.Tokens
+	for _, part := range template.Parts {
+		if text, ok := literal(part); ok {
+			if interpolated {
+				text = strings.ReplaceAll(text, "%", "%%")
+			}
+			content.WriteString(text)
+			continue
+		}
+		content.WriteString("%v")
+		args = append(args, convertExpression(state, false, scopes, "", part))
+	}
+	text := content.String()
+
+	filename := extractedFilename(state, fullyQualifiedPath, attributes, attr)
+	state.extractedFiles[filename] = text
+
+	if asset {
+		return hclwrite.TokensForValue(cty.StringVal(filename)), true
+	}
+	read := hclwrite.TokensForFunctionCall("readFile", hclwrite.TokensForValue(cty.StringVal(filename)))
+	if len(args) == 0 {
+		return read, true
+	}
+	call := hclwrite.TokensForFunctionCall("invoke",
+		hclwrite.TokensForValue(cty.StringVal("std:index:format")),
+		hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: hclwrite.TokensForIdentifier("input"), Value: read},
+			{Name: hclwrite.TokensForIdentifier("args"), Value: hclwrite.TokensForTuple(args)},
+		}))
+	call = append(call, makeToken(hclsyntax.TokenDot, "."))
+	return append(call, makeToken(hclsyntax.TokenIdent, "result")), true
+}
+
+// extractedFilename returns the path, relative to the program, to extract the attribute at fullyQualifiedPath to.
+// This is a directory for the resource, e.g. files/github_repository_file.codeowners/, holding the file the resource
+// writes if it has a literal file path attribute, or else a file named after the attribute.
+func extractedFilename(
+	state *convertState, fullyQualifiedPath string, attributes hcl.Attributes, attr *hcl.Attribute,
+) string {
+	directory, name := fullyQualifiedPath, attr.Name
+	if i := strings.LastIndex(fullyQualifiedPath, "."); i >= 0 {
+		directory = fullyQualifiedPath[:i]
+	}
+	directory = strings.ReplaceAll(directory, "[]", "")
+	for _, key := range filePathAttributes {
+		if pathAttr, has := attributes[key]; has {
+			value, diags := pathAttr.Expr.Value(nil)
+			if !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+				// The path may have been written on Windows.
+				name = path.Base(strings.ReplaceAll(value.AsString(), `\`, "/"))
+				break
+			}
+		}
+	}
+
+	filename := path.Join(extractedFilesDirectory, directory, name)
+	if _, has := state.extractedFiles[filename]; has {
+		filename = path.Join(extractedFilesDirectory, directory, attr.Name)
+	}
+	return filename
+}
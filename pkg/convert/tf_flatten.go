@@ -0,0 +1,90 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// flatten is most often used to turn nested for expressions into a single list, e.g.
+// flatten([for k, v in var.rules : [for p in v.ports : { rule = k, port = p }]]). std's flatten flattens whatever
+// lists it finds at any depth, so nothing is known about the elements of its result, and the objects in it can't be
+// renamed to match the attribute it's assigned to. Where the innermost values can't be lists, flattening is the same
+// as concatenating each level of for expressions, so this is converted to
+// invoke("std:index:concat", {input = [for k, v in rules : [for p in v.ports : { rule = k, port = p }]]}).result,
+// with the innermost values converted as elements of the list flatten's result is assigned to.
+
+// flattenedFors returns the tuple for expressions nested in arg, outermost first, if arg is a tuple for expression
+// whose value is either a tuple for expression of the same form or a value that can't be a list.
+func flattenedFors(arg hclsyntax.Expression) ([]*hclsyntax.ForExpr, bool) {
+	var fors []*hclsyntax.ForExpr
+	for {
+		forExpr, ok := arg.(*hclsyntax.ForExpr)
+		if !ok || forExpr.KeyExpr != nil {
+			break
+		}
+		fors = append(fors, forExpr)
+		arg = forExpr.ValExpr
+	}
+	if len(fors) == 0 {
+		return nil, false
+	}
+
+	switch arg.(type) {
+	case *hclsyntax.ObjectConsExpr, *hclsyntax.TemplateExpr, *hclsyntax.LiteralValueExpr, *hclsyntax.ForExpr:
+		// An object, a string, a primitive literal, or a map from a for with a key.
+		return fors, true
+	}
+	return nil, false
+}
+
+// convertFlatten returns call, a flatten call, converted to its value if its argument is known at conversion time, or
+// to concats of the for expressions it flattens. It returns false if the call should be converted to std's flatten.
+func convertFlatten(
+	state *convertState, scopes *scopes, fullyQualifiedPath string, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if call.Name != "flatten" || len(call.Args) != 1 || call.ExpandFinal {
+		return nil, false
+	}
+	if isStaticExpr(scopes, call, map[string]bool{}) {
+		value, diags := scopes.EvalExpr(call)
+		if diags.HasErrors() || !value.IsWhollyKnown() || value.Type() == cty.DynamicPseudoType {
+			return nil, false
+		}
+		return hclwrite.TokensForValue(value), true
+	}
+
+	fors, ok := flattenedFors(call.Args[0])
+	if !ok {
+		return nil, false
+	}
+
+	// Each level is converted with the levels inside it as its value, and all but the innermost are lists of lists
+	// to concatenate.
+	var convertLevel func(i int) hclwrite.Tokens
+	convertLevel = func(i int) hclwrite.Tokens {
+		if i == len(fors)-1 {
+			return convertForExprWithValue(state, scopes, fors[i], func() hclwrite.Tokens {
+				return convertExpression(state, false, scopes, appendPathArray(fullyQualifiedPath), fors[i].ValExpr)
+			})
+		}
+		return tokensForStdInvoke("concat", convertForExprWithValue(state, scopes, fors[i], func() hclwrite.Tokens {
+			return convertLevel(i + 1)
+		}))
+	}
+	return convertLevel(0), true
+}
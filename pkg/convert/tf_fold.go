@@ -0,0 +1,89 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Functions that read from the filesystem. The converter doesn't run in the program's directory, so these are never
+// folded even though terraform considers them pure.
+var unfoldableFunctions = map[string]struct{}{
+	"abspath":          {},
+	"file":             {},
+	"filebase64":       {},
+	"filebase64sha256": {},
+	"filebase64sha512": {},
+	"fileexists":       {},
+	"filemd5":          {},
+	"fileset":          {},
+	"filesha1":         {},
+	"filesha256":       {},
+	"filesha512":       {},
+	"pathexpand":       {},
+	"templatefile":     {},
+}
+
+// WithConstantFolding evaluates function calls that only depend on literals, locals, variables with defaults and
+// pure functions at conversion time, replacing them with their value. The call is kept in a comment before the
+// value, e.g. `/* cidrsubnet("10.0.0.0/16", 8, 1) */ "10.0.1.0/24"`.
+//
+// Note that folding a call that reads a variable uses the variable's default, so setting the variable in stack
+// config won't change the folded value.
+func WithConstantFolding() TranslateOption {
+	return func(o *translateOptions) {
+		o.foldConstants = true
+	}
+}
+
+// foldFunctionCall returns the value of call as a literal, preceded by a comment with the call's source, if it can
+// be evaluated at conversion time.
+func foldFunctionCall(state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr) (hclwrite.Tokens, bool) {
+	canFold := true
+	diags := hclsyntax.VisitAll(call, func(node hclsyntax.Node) hcl.Diagnostics {
+		if inner, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			if _, unfoldable := unfoldableFunctions[inner.Name]; unfoldable {
+				canFold = false
+			}
+		}
+		return nil
+	})
+	if !canFold || diags.HasErrors() {
+		return nil, false
+	}
+
+	scopes.useVariableDefaults = true
+	value, evalDiags := scopes.EvalExpr(call)
+	scopes.useVariableDefaults = false
+	if evalDiags.HasErrors() || !value.IsWhollyKnown() || value.ContainsMarked() {
+		return nil, false
+	}
+
+	// The call is quoted as it's written, the tokens of sourceCode lose their spacing.
+	callRange := hcl.RangeOver(call.NameRange, call.CloseParenRange)
+	source := string(callRange.SliceBytes(state.sources[callRange.Filename]))
+	tokens := hclwrite.Tokens{}
+	if !strings.Contains(source, "*/") {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("/* " + strings.TrimSpace(source) + " */"),
+		})
+	}
+	return append(tokens, hclwrite.TokensForValue(value)...), true
+}
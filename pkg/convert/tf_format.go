@@ -0,0 +1,235 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Terraform writes the arguments of the %s, %d and %v format verbs the same way as string interpolation does, so a
+// format string known at conversion time that only uses those verbs, without flags, widths or argument indexes, is
+// converted to a string template, which every language can write, e.g. format("%s-%d", var.prefix, var.index) is
+// converted to "${prefix}-${index}". %v also writes lists and maps, as JSON, which templates can't, so it's only
+// converted for arguments known to be strings, numbers or bools. formatlist is converted to a for expression over its list arguments with
+// the template as its value, e.g. formatlist("%s.example.com", var.names) is converted to
+// [for item in names : "${item}.example.com"]. Other calls to format are converted to std's format, which takes the
+// same format strings as terraform.
+
+// formatTemplate returns the literal text around the verbs of format, one more than there are verbs, and the verbs,
+// or false if it uses anything but the %s, %d, %v and %% verbs.
+func formatTemplate(format string) ([]string, []byte, bool) {
+	var literals []string
+	var verbs []byte
+	var literal strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return nil, nil, false
+		}
+		switch format[i] {
+		case '%':
+			literal.WriteByte('%')
+		case 's', 'd', 'v':
+			literals = append(literals, literal.String())
+			verbs = append(verbs, format[i])
+			literal.Reset()
+		default:
+			return nil, nil, false
+		}
+	}
+	return append(literals, literal.String()), verbs, true
+}
+
+// isPrimitiveArgument returns whether expr is known to be a string, number or bool, or for the list arguments of
+// formatlist, a list of them.
+func isPrimitiveArgument(scopes *scopes, expr hclsyntax.Expression, formatlist bool) bool {
+	expr = unwrapParentheses(expr)
+	if staticType(scopes, expr).IsPrimitiveType() {
+		return true
+	}
+	if !formatlist {
+		return false
+	}
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != "var" {
+		return false
+	}
+	attr, ok := traversal.Traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+	typ := scopes.roots["var."+attr.Name].VariableType
+	return typ != cty.NilType && typ.IsListType() && typ.ElementType().IsPrimitiveType()
+}
+
+// formatTemplateExpr returns the template of literals with args between them.
+func formatTemplateExpr(literals []string, args []hclsyntax.Expression, rng hcl.Range) *hclsyntax.TemplateExpr {
+	template := &hclsyntax.TemplateExpr{SrcRange: rng}
+	for i, literal := range literals {
+		if literal != "" {
+			template.Parts = append(template.Parts, &hclsyntax.LiteralValueExpr{Val: cty.StringVal(literal), SrcRange: rng})
+		}
+		if i < len(args) {
+			template.Parts = append(template.Parts, args[i])
+		}
+	}
+	return template
+}
+
+// isListArgument returns whether expr is known to be a list, or known not to be one, without evaluating it. The
+// second result is false if it's not known either way.
+func isListArgument(scopes *scopes, expr hclsyntax.Expression) (bool, bool) {
+	switch expr := unwrapParentheses(expr).(type) {
+	case *hclsyntax.TupleConsExpr, *hclsyntax.SplatExpr:
+		return true, true
+	case *hclsyntax.ForExpr:
+		return expr.KeyExpr == nil, true
+	case *hclsyntax.LiteralValueExpr, *hclsyntax.TemplateExpr:
+		return false, true
+	case *hclsyntax.ScopeTraversalExpr:
+		if len(expr.Traversal) != 2 || expr.Traversal.RootName() != "var" {
+			break
+		}
+		attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		typ := scopes.roots["var."+attr.Name].VariableType
+		if typ == cty.NilType || typ == cty.DynamicPseudoType || typ.IsSetType() {
+			break
+		}
+		return typ.IsListType() || typ.IsTupleType(), true
+	}
+	return false, false
+}
+
+// convertFormat returns call, a format or formatlist call, converted to its value if its arguments are known at
+// conversion time, or else to a string template or a for expression of one. Other calls to format are converted to
+// std's format. It returns false if the call can't be converted.
+func convertFormat(state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr) (hclwrite.Tokens, bool) {
+	if (call.Name != "format" && call.Name != "formatlist") || len(call.Args) == 0 {
+		return nil, false
+	}
+	if isStaticExpr(scopes, call, map[string]bool{}) {
+		value, diags := scopes.EvalExpr(call)
+		if !diags.HasErrors() && value.IsWhollyKnown() {
+			return hclwrite.TokensForValue(value), true
+		}
+	}
+
+	var literals []string
+	if !call.ExpandFinal && isStaticExpr(scopes, call.Args[0], map[string]bool{}) {
+		format, diags := scopes.EvalExpr(call.Args[0])
+		if !diags.HasErrors() && format.IsWhollyKnown() && !format.IsNull() && format.Type() == cty.String {
+			if text, verbs, ok := formatTemplate(format.AsString()); ok && len(text) == len(call.Args) {
+				literals = text
+				for i, verb := range verbs {
+					if verb == 'v' && !isPrimitiveArgument(scopes, call.Args[i+1], call.Name == "formatlist") {
+						literals = nil
+						break
+					}
+				}
+			}
+		}
+	}
+	rng := emptyRange(call.Range())
+
+	if call.Name == "format" {
+		if literals != nil {
+			template := formatTemplateExpr(literals, call.Args[1:], rng)
+			return convertExpression(state, false, scopes, "", template), true
+		}
+		format := convertExpression(state, false, scopes, "", call.Args[0])
+		var args hclwrite.Tokens
+		if call.ExpandFinal && len(call.Args) == 2 {
+			args = convertExpression(state, false, scopes, "", call.Args[1])
+		} else if call.ExpandFinal {
+			return nil, false
+		} else {
+			elems := make([]hclwrite.Tokens, 0, len(call.Args)-1)
+			for _, arg := range call.Args[1:] {
+				elems = append(elems, convertExpression(state, false, scopes, "", arg))
+			}
+			args = hclwrite.TokensForTuple(elems)
+		}
+		return tokensForStdInvoke("format", format, args), true
+	}
+
+	// formatlist iterates over its list arguments together, repeating the others for each element.
+	if literals == nil {
+		return nil, false
+	}
+	var lists []int
+	for i, arg := range call.Args[1:] {
+		isList, known := isListArgument(scopes, arg)
+		if !known {
+			return nil, false
+		}
+		if isList {
+			lists = append(lists, i)
+		}
+	}
+	args := append([]hclsyntax.Expression{}, call.Args[1:]...)
+	if len(lists) == 0 {
+		// With no lists terraform returns a list of the one string.
+		template := formatTemplateExpr(literals, args, rng)
+		tuple := &hclsyntax.TupleConsExpr{Exprs: []hclsyntax.Expression{template}, SrcRange: rng, OpenRange: rng}
+		return convertExpression(state, false, scopes, "", tuple), true
+	}
+
+	traversal := func(name string) hclsyntax.Expression {
+		return &hclsyntax.ScopeTraversalExpr{
+			Traversal: hcl.Traversal{hcl.TraverseRoot{Name: name, SrcRange: rng}},
+			SrcRange:  rng,
+		}
+	}
+	taken := map[string]bool{}
+	value := newForVariable(scopes, "item", taken)
+	taken[value] = true
+	key := ""
+	if len(lists) > 1 {
+		key = newForVariable(scopes, "index", taken)
+	}
+	collection := args[lists[0]]
+	args[lists[0]] = traversal(value)
+	for _, i := range lists[1:] {
+		args[i] = &hclsyntax.IndexExpr{
+			Collection:   args[i],
+			Key:          traversal(key),
+			SrcRange:     rng,
+			OpenRange:    rng,
+			BracketRange: rng,
+		}
+	}
+	forExpr := &hclsyntax.ForExpr{
+		KeyVar:     key,
+		ValVar:     value,
+		CollExpr:   collection,
+		ValExpr:    formatTemplateExpr(literals, args, rng),
+		SrcRange:   rng,
+		OpenRange:  rng,
+		CloseRange: rng,
+	}
+	return convertExpression(state, false, scopes, "", forExpr), true
+}
@@ -0,0 +1,313 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// FunctionCategory is a category of Terraform functions, as they're grouped in the Terraform docs.
+type FunctionCategory string
+
+const (
+	// FunctionCategoryNumeric is abs, max, min and signum.
+	FunctionCategoryNumeric FunctionCategory = "numeric"
+	// FunctionCategoryString is join and split.
+	FunctionCategoryString FunctionCategory = "string"
+	// FunctionCategoryCollection is coalesce and compact.
+	FunctionCategoryCollection FunctionCategory = "collection"
+	// FunctionCategoryEncoding is base64encode and base64decode.
+	FunctionCategoryEncoding FunctionCategory = "encoding"
+	// FunctionCategoryFilesystem is file, filebase64 and filebase64sha256.
+	FunctionCategoryFilesystem FunctionCategory = "filesystem"
+	// FunctionCategoryHash is sha1.
+	FunctionCategoryHash FunctionCategory = "hash"
+	// FunctionCategoryTypeConversion is toset.
+	FunctionCategoryTypeConversion FunctionCategory = "type"
+)
+
+// WithInlineFunctions converts the functions of the given categories that have one to a PCL builtin or an
+// expression, like `x < 0 ? -x : x` for abs(x), rather than an invoke of the std package. Expressions that repeat
+// their arguments are only used for references and literals. These read more naturally and aren't outputs, but some
+// are looser than the function they replace, for example coalesce only skips nulls and not empty strings, and toset
+// doesn't remove duplicates. By default every function is an invoke.
+func WithInlineFunctions(categories ...FunctionCategory) TranslateOption {
+	return func(o *translateOptions) {
+		if o.inlineFunctions == nil {
+			o.inlineFunctions = map[FunctionCategory]bool{}
+		}
+		for _, category := range categories {
+			o.inlineFunctions[category] = true
+		}
+	}
+}
+
+type inlineFunction struct {
+	category FunctionCategory
+	// Set if the expression repeats its arguments, like abs(x) as x < 0 ? -x : x. These are only written inline if
+	// their arguments are references or literals, so that nothing is evaluated or written out more than once.
+	repeatsArgs bool
+	// Returns the expression for the function called with args, or false if it can't be written inline for these
+	// arguments, in which case it's converted to an invoke.
+	convert func(args []hclwrite.Tokens) (hclwrite.Tokens, bool)
+}
+
+// builtin returns an inline function that calls the PCL builtin name with the same arguments.
+func builtin(category FunctionCategory, name string, arity int) inlineFunction {
+	return inlineFunction{category: category, convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+		if len(args) != arity {
+			return nil, false
+		}
+		return hclwrite.TokensForFunctionCall(name, args...), true
+	}}
+}
+
+// The functions that can be written inline, keyed by their Terraform name.
+var tfFunctionInline = map[string]inlineFunction{
+	"abs": {
+		category:    FunctionCategoryNumeric,
+		repeatsArgs: true,
+		convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+			if len(args) != 1 {
+				return nil, false
+			}
+			x := parenthesize(args[0])
+			negated := append(hclwrite.Tokens{makeToken(hclsyntax.TokenMinus, "-")}, x...)
+			return conditional(binary(x, hclsyntax.TokenLessThan, "<", number("0")), negated, x), true
+		},
+	},
+	"max": {
+		category:    FunctionCategoryNumeric,
+		repeatsArgs: true,
+		convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+			return minMax(args, hclsyntax.TokenGreaterThanEq, ">=")
+		},
+	},
+	"min": {
+		category:    FunctionCategoryNumeric,
+		repeatsArgs: true,
+		convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+			return minMax(args, hclsyntax.TokenLessThanEq, "<=")
+		},
+	},
+	"signum": {
+		category:    FunctionCategoryNumeric,
+		repeatsArgs: true,
+		convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+			if len(args) != 1 {
+				return nil, false
+			}
+			x := parenthesize(args[0])
+			negative := conditional(binary(x, hclsyntax.TokenLessThan, "<", number("0")), number("-1"), number("0"))
+			return conditional(binary(x, hclsyntax.TokenGreaterThan, ">", number("0")), number("1"), negative), true
+		},
+	},
+	"join":  builtin(FunctionCategoryString, "join", 2),
+	"split": builtin(FunctionCategoryString, "split", 2),
+	"coalesce": {
+		category:    FunctionCategoryCollection,
+		repeatsArgs: true,
+		convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+			if len(args) < 2 {
+				return nil, false
+			}
+			// coalesce(a, b, c) is a != null ? a : b != null ? b : c
+			result := args[len(args)-1]
+			for i := len(args) - 2; i >= 0; i-- {
+				arg := parenthesize(args[i])
+				result = conditional(binary(arg, hclsyntax.TokenNotEqual, "!=", null()), arg, result)
+			}
+			return result, true
+		},
+	},
+	"compact": {category: FunctionCategoryCollection, convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+		if len(args) != 1 {
+			return nil, false
+		}
+		// compact(list) is [for __item in list : __item if __item != null && __item != ""]
+		item := hclwrite.Tokens{makeToken(hclsyntax.TokenIdent, "__item")}
+		tokens := hclwrite.Tokens{
+			makeToken(hclsyntax.TokenOBrack, "["),
+			makeToken(hclsyntax.TokenIdent, "for"),
+		}
+		tokens = append(tokens, item...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "in"))
+		tokens = append(tokens, args[0]...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+		tokens = append(tokens, item...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenIdent, "if"))
+		tokens = append(tokens, binary(item, hclsyntax.TokenNotEqual, "!=", null())...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenAnd, "&&"))
+		tokens = append(tokens, binary(item, hclsyntax.TokenNotEqual, "!=", hclwrite.Tokens{
+			makeToken(hclsyntax.TokenOQuote, "\""),
+			makeToken(hclsyntax.TokenCQuote, "\""),
+		})...)
+		return append(tokens, makeToken(hclsyntax.TokenCBrack, "]")), true
+	}},
+	"base64encode":     builtin(FunctionCategoryEncoding, "toBase64", 1),
+	"base64decode":     builtin(FunctionCategoryEncoding, "fromBase64", 1),
+	"file":             builtin(FunctionCategoryFilesystem, "readFile", 1),
+	"filebase64":       builtin(FunctionCategoryFilesystem, "filebase64", 1),
+	"filebase64sha256": builtin(FunctionCategoryFilesystem, "filebase64sha256", 1),
+	"sha1":             builtin(FunctionCategoryHash, "sha1", 1),
+	"toset": {category: FunctionCategoryTypeConversion, convert: func(args []hclwrite.Tokens) (hclwrite.Tokens, bool) {
+		// Like tolist, sets are lists in Pulumi.
+		if len(args) != 1 {
+			return nil, false
+		}
+		return args[0], true
+	}},
+}
+
+// FunctionCategories are the categories that can be given to WithInlineFunctions.
+var FunctionCategories = []FunctionCategory{
+	FunctionCategoryNumeric,
+	FunctionCategoryString,
+	FunctionCategoryCollection,
+	FunctionCategoryEncoding,
+	FunctionCategoryFilesystem,
+	FunctionCategoryHash,
+	FunctionCategoryTypeConversion,
+}
+
+// checkFunctionCategories returns an error for each category given to WithInlineFunctions that isn't one.
+func checkFunctionCategories(categories map[FunctionCategory]bool) hcl.Diagnostics {
+	known := map[FunctionCategory]bool{}
+	names := make([]string, 0, len(FunctionCategories))
+	for _, category := range FunctionCategories {
+		known[category] = true
+		names = append(names, string(category))
+	}
+
+	var diagnostics hcl.Diagnostics
+	for _, category := range sortedCategories(categories) {
+		if !known[category] {
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown function category",
+				Detail: fmt.Sprintf("Unknown function category %q, expected one of %s",
+					category, strings.Join(names, ", ")),
+			})
+		}
+	}
+	return diagnostics
+}
+
+func sortedCategories(categories map[FunctionCategory]bool) []FunctionCategory {
+	sorted := make([]FunctionCategory, 0, len(categories))
+	for category := range categories {
+		sorted = append(sorted, category)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// convertInlineFunction returns call written inline, if its category was given to WithInlineFunctions and it can
+// be for its arguments.
+func convertInlineFunction(
+	state *convertState, call *hclsyntax.FunctionCallExpr, args []hclwrite.Tokens,
+) (hclwrite.Tokens, bool) {
+	inline, has := tfFunctionInline[call.Name]
+	if !has || !state.inlineFunctions[inline.category] || call.ExpandFinal {
+		return nil, false
+	}
+	if inline.repeatsArgs {
+		for _, arg := range call.Args {
+			if !isReferenceOrLiteral(arg) {
+				return nil, false
+			}
+		}
+	}
+	return inline.convert(args)
+}
+
+// isReferenceOrLiteral returns true if expr is a reference, like var.n or local.items[0], or a literal number, bool
+// or string.
+func isReferenceOrLiteral(expr hclsyntax.Expression) bool {
+	switch expr := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr, *hclsyntax.LiteralValueExpr:
+		return true
+	case *hclsyntax.TemplateExpr:
+		return expr.IsStringLiteral()
+	case *hclsyntax.ParenthesesExpr:
+		return isReferenceOrLiteral(expr.Expression)
+	}
+	return false
+}
+
+// parenthesize wraps tokens in parentheses, unless they're a single token like an identifier or number.
+func parenthesize(tokens hclwrite.Tokens) hclwrite.Tokens {
+	if len(tokens) == 1 {
+		return tokens
+	}
+	result := hclwrite.Tokens{makeToken(hclsyntax.TokenOParen, "(")}
+	result = append(result, tokens...)
+	return append(result, makeToken(hclsyntax.TokenCParen, ")"))
+}
+
+func binary(lhs hclwrite.Tokens, op hclsyntax.TokenType, opText string, rhs hclwrite.Tokens) hclwrite.Tokens {
+	tokens := append(hclwrite.Tokens{}, lhs...)
+	tokens = append(tokens, makeToken(op, opText))
+	return append(tokens, rhs...)
+}
+
+// conditional returns condition ? trueResult : falseResult, in parentheses so it can be used in other expressions.
+func conditional(condition, trueResult, falseResult hclwrite.Tokens) hclwrite.Tokens {
+	tokens := append(hclwrite.Tokens{}, condition...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenQuestion, "?"))
+	tokens = append(tokens, trueResult...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+	return parenthesize(append(tokens, falseResult...))
+}
+
+// multilineTuple returns a tuple of elems with each element on its own line, for elements like std invokes that
+// span several lines themselves.
+func multilineTuple(elems []hclwrite.Tokens) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "["), makeToken(hclsyntax.TokenNewline, "\n")}
+	for _, elem := range elems {
+		tokens = append(tokens, elem...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenComma, ","), makeToken(hclsyntax.TokenNewline, "\n"))
+	}
+	return append(tokens, makeToken(hclsyntax.TokenCBrack, "]"))
+}
+
+func number(text string) hclwrite.Tokens {
+	if strings.HasPrefix(text, "-") {
+		return hclwrite.Tokens{makeToken(hclsyntax.TokenMinus, "-"), makeToken(hclsyntax.TokenNumberLit, text[1:])}
+	}
+	return hclwrite.Tokens{makeToken(hclsyntax.TokenNumberLit, text)}
+}
+
+func null() hclwrite.Tokens {
+	return hclwrite.Tokens{makeToken(hclsyntax.TokenIdent, "null")}
+}
+
+// minMax returns max(a, b) as a >= b ? a : b, or min(a, b) as a <= b ? a : b. More arguments would repeat each of
+// them too many times, so these are left as invokes.
+func minMax(args []hclwrite.Tokens, op hclsyntax.TokenType, opText string) (hclwrite.Tokens, bool) {
+	if len(args) != 2 {
+		return nil, false
+	}
+	a, b := parenthesize(args[0]), parenthesize(args[1])
+	return conditional(binary(a, op, opText, b), a, b), true
+}
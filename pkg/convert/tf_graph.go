@@ -0,0 +1,256 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Graph is the dependency graph of a converted program, so that reviewers can check that it matches the graph of
+// the Terraform configuration. Each converted module is a cluster of the graph.
+type Graph struct {
+	// The converted modules, in the order they were converted.
+	Modules []GraphModule `json:"modules"`
+	// The converted items of every module.
+	Nodes []GraphNode `json:"nodes"`
+	// The references between nodes.
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphModule is a module of the converted program.
+type GraphModule struct {
+	// The directory the module was written to, "/" for the root module.
+	Path string `json:"path"`
+	// The address of the first module call that the module was converted for, e.g. "module.vpc.module.subnets", or
+	// "" for the root module. Modules that are called more than once are only converted once.
+	Address string `json:"address"`
+}
+
+// GraphNode is a variable, local, data source, resource, provider, module call or output of a converted module.
+type GraphNode struct {
+	// The module the node is in, the Path of one of the graph's Modules.
+	Module string `json:"module"`
+	// The Terraform address of the node in its module, e.g. "aws_vpc.main" or "var.region".
+	Address string `json:"address"`
+	// The name the node was given in the converted program.
+	Name string `json:"name"`
+	// One of "variable", "local", "data", "resource", "provider", "module" or "output".
+	Kind string `json:"kind"`
+	// For module calls, the Path of the module that's called.
+	Calls string `json:"calls,omitempty"`
+}
+
+// GraphEdge says that one node refers to another, in the same module.
+type GraphEdge struct {
+	Module string `json:"module"`
+	// The address of the node that refers to To.
+	From string `json:"from"`
+	// The address of the node that From refers to.
+	To string `json:"to"`
+}
+
+// WithGraph calls the given function with the dependency graph of the converted program once the conversion is
+// finished.
+func WithGraph(callback func(Graph)) TranslateOption {
+	return func(o *translateOptions) {
+		o.onGraph = callback
+	}
+}
+
+// graphKey returns the address of item in the graph, which is the key other items refer to it by, or for outputs
+// which nothing refers to, their address as an output.
+func (item terraformItem) graphKey() string {
+	if item.output != nil {
+		return "output." + item.output.Name
+	}
+	return item.orderKey()
+}
+
+func (item terraformItem) graphKind() string {
+	switch {
+	case item.variable != nil:
+		return "variable"
+	case item.local != nil:
+		return "local"
+	case item.data != nil:
+		return "data"
+	case item.resource != nil:
+		return "resource"
+	case item.provider != nil:
+		return "provider"
+	case item.moduleCall != nil:
+		return "module"
+	case item.output != nil:
+		return "output"
+	}
+	return ""
+}
+
+// addToGraph adds the module written to destinationDirectory, and the items converted for it, to graph. Providers
+// that are converted to stack config rather than provider resources aren't part of the program, so aren't added.
+func addToGraph(
+	graph *Graph, scopes *scopes, modules map[moduleKey]string,
+	destinationDirectory, address string, items terraformItems,
+) {
+	graph.Modules = append(graph.Modules, GraphModule{Path: destinationDirectory, Address: address})
+
+	nodes := map[string]bool{}
+	var converted terraformItems
+	for _, item := range items {
+		if item.provider != nil && !isExplicitProvider(scopes, item.provider) {
+			continue
+		}
+		key := item.graphKey()
+		if key == "" || nodes[key] {
+			continue
+		}
+		nodes[key] = true
+		converted = append(converted, item)
+
+		node := GraphNode{
+			Module:  destinationDirectory,
+			Address: key,
+			Name:    scopes.roots[key].Name,
+			Kind:    item.graphKind(),
+		}
+		if item.moduleCall != nil {
+			node.Calls = modules[makeModuleKey(item.moduleCall)]
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	for _, item := range converted {
+		from := item.graphKey()
+		seen := map[string]bool{}
+		for _, to := range item.orderDependencies() {
+			if to == from || !nodes[to] || seen[to] {
+				continue
+			}
+			seen[to] = true
+			graph.Edges = append(graph.Edges, GraphEdge{Module: destinationDirectory, From: from, To: to})
+		}
+	}
+}
+
+// graphLabel returns the label of a node, its converted name and its Terraform address.
+func graphLabel(node GraphNode) string {
+	if node.Name == "" {
+		return node.Address
+	}
+	return node.Name + "\n" + node.Address
+}
+
+// graphModuleLabel returns the label of the cluster of a module.
+func graphModuleLabel(module GraphModule) string {
+	if module.Address == "" {
+		return module.Path
+	}
+	return module.Path + " (" + module.Address + ")"
+}
+
+// sortedModules returns the modules of the graph ordered by path, so the root module comes first.
+func (g Graph) sortedModules() []GraphModule {
+	modules := append([]GraphModule{}, g.Modules...)
+	sort.SliceStable(modules, func(i, j int) bool {
+		return modules[i].Path < modules[j].Path
+	})
+	return modules
+}
+
+// DOT returns the graph in the Graphviz DOT language, with each module as a cluster. Edges point from each node to
+// the nodes it refers to, as in the output of `terraform graph`, and module calls point to the cluster of the
+// module they call.
+func (g Graph) DOT() string {
+	id := func(module, address string) string {
+		return fmt.Sprintf("%q", module+":"+address)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph {\n")
+	sb.WriteString("  compound = true;\n")
+	sb.WriteString("  rankdir = \"RL\";\n")
+	first := map[string]string{}
+	clusters := map[string]string{}
+	for i, module := range g.sortedModules() {
+		cluster := fmt.Sprintf("cluster_%d", i)
+		clusters[module.Path] = cluster
+		fmt.Fprintf(&sb, "  subgraph %s {\n", cluster)
+		fmt.Fprintf(&sb, "    label = %q;\n", graphModuleLabel(module))
+		for _, node := range g.Nodes {
+			if node.Module != module.Path {
+				continue
+			}
+			if _, has := first[module.Path]; !has {
+				first[module.Path] = id(node.Module, node.Address)
+			}
+			fmt.Fprintf(&sb, "    %s [label = %q];\n", id(node.Module, node.Address), graphLabel(node))
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&sb, "  %s -> %s;\n", id(edge.Module, edge.From), id(edge.Module, edge.To))
+	}
+	for _, node := range g.Nodes {
+		// Edges can only point to nodes, so point to a node of the cluster and clip the edge at the cluster.
+		if target, has := first[node.Calls]; has && node.Calls != "" {
+			fmt.Fprintf(&sb, "  %s -> %s [lhead = %q, style = \"dashed\"];\n",
+				id(node.Module, node.Address), target, clusters[node.Calls])
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart, with each module as a subgraph. Edges point the same way as in
+// DOT.
+func (g Graph) Mermaid() string {
+	ids := map[string]string{}
+	id := func(module, address string) string {
+		return ids[module+":"+address]
+	}
+	label := func(text string) string {
+		text = strings.ReplaceAll(text, "\"", "#quot;")
+		return "\"" + strings.ReplaceAll(text, "\n", "<br/>") + "\""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart RL\n")
+	clusters := map[string]string{}
+	for i, module := range g.sortedModules() {
+		cluster := fmt.Sprintf("module%d", i)
+		clusters[module.Path] = cluster
+		fmt.Fprintf(&sb, "  subgraph %s[%s]\n", cluster, label(graphModuleLabel(module)))
+		for _, node := range g.Nodes {
+			if node.Module != module.Path {
+				continue
+			}
+			key := node.Module + ":" + node.Address
+			ids[key] = fmt.Sprintf("node%d", len(ids))
+			fmt.Fprintf(&sb, "    %s[%s]\n", ids[key], label(graphLabel(node)))
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&sb, "  %s --> %s\n", id(edge.Module, edge.From), id(edge.Module, edge.To))
+	}
+	for _, node := range g.Nodes {
+		if cluster, has := clusters[node.Calls]; has && node.Calls != "" {
+			fmt.Fprintf(&sb, "  %s -.-> %s\n", id(node.Module, node.Address), cluster)
+		}
+	}
+	return sb.String()
+}
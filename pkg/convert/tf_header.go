@@ -0,0 +1,183 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+)
+
+// Feature is an optional part of the conversion, turned off unless the flag of the same name, or the matching
+// TranslateOption, is given.
+type Feature struct {
+	// The name of the feature, which is also the name of the flag that turns it on.
+	Name string
+	// What the feature changes about the conversion.
+	Description string
+
+	enabled func(o translateOptions) bool
+}
+
+// Features are the optional parts of the conversion, in the order they're listed.
+var Features = []Feature{
+	{
+		Name:        "use-lockfile",
+		Description: "read modules from where `terraform init` installed them rather than downloading them",
+		enabled:     func(o translateOptions) bool { return o.useLockfile },
+	},
+	{
+		Name:        "module-layout",
+		Description: "write modules to a directory from a template rather than a path based on their source",
+		enabled:     func(o translateOptions) bool { return o.moduleLayout != "" },
+	},
+	{
+		Name:        "interface-only",
+		Description: "only convert the variables and outputs of the program",
+		enabled:     func(o translateOptions) bool { return o.interfaceOnly },
+	},
+	{
+		Name:        "fold-constants",
+		Description: "replace function calls that are known when converting with their value",
+		enabled:     func(o translateOptions) bool { return o.foldConstants },
+	},
+	{
+		Name:        "remove-unused",
+		Description: "remove locals, data sources and variables that nothing uses",
+		enabled:     func(o translateOptions) bool { return o.removeUnused },
+	},
+	{
+		Name:        "keep-variables",
+		Description: "convert every variable, even with remove-unused",
+		enabled:     func(o translateOptions) bool { return o.keepVariables },
+	},
+	{
+		Name:        "extract-files",
+		Description: "write long strings and heredocs to files rather than string literals",
+		enabled:     func(o translateOptions) bool { return o.extractFiles },
+	},
+	{
+		Name:        "single-file",
+		Description: "write each module to a single main.pp",
+		enabled:     func(o translateOptions) bool { return o.singleFile },
+	},
+	{
+		Name:        "kubeconfig-template",
+		Description: "give kubernetes and helm providers configured from a cluster a templated kubeconfig",
+		enabled:     func(o translateOptions) bool { return o.kubeconfigTemplate },
+	},
+	{
+		Name:        "inline-functions",
+		Description: "write functions as PCL builtins or expressions rather than std invokes where they can be",
+		enabled:     func(o translateOptions) bool { return len(o.inlineFunctions) > 0 },
+	},
+	{
+		Name:        "stabilize-names",
+		Description: "replace timestamps in resource names with a stable suffix",
+		enabled:     func(o translateOptions) bool { return o.stableNames != "" },
+	},
+	{
+		Name:        "local-naming",
+		Description: "name the variables locals are converted to other than in camelCase",
+		enabled: func(o translateOptions) bool {
+			return o.localNaming != "" && o.localNaming != LocalNamingCamelCase
+		},
+	},
+	{
+		Name:        "convert-waits",
+		Description: "convert waits for eventual consistency so the converted program still waits",
+		enabled:     func(o translateOptions) bool { return o.waits },
+	},
+	{
+		Name:        "synced-folders",
+		Description: "convert uploads of every file in a directory to synced folders",
+		enabled:     func(o translateOptions) bool { return o.syncedFolders },
+	},
+	{
+		Name:        "bootstrap-project",
+		Description: "move the resources of the s3 state backend to a separate project",
+		enabled:     func(o translateOptions) bool { return o.bootstrapProject },
+	},
+	{
+		Name:        "retain-on-delete",
+		Description: "set retainOnDelete on stateful resources",
+		enabled:     func(o translateOptions) bool { return len(o.retainOnDeleteTypes) > 0 },
+	},
+	{
+		Name:        "rules-file",
+		Description: "rename, retype, protect or exclude resources by rules",
+		enabled:     func(o translateOptions) bool { return len(o.rules) > 0 },
+	},
+}
+
+// WithHeader starts each generated .pp file with a comment giving the version of the converter, the arguments it was
+// run with and the features they turned on, so that the conversion can be reproduced from its output. Values of
+// --backend-config are redacted from the arguments, as they may be credentials.
+func WithHeader(args []string) TranslateOption {
+	return func(o *translateOptions) {
+		o.header = true
+		o.headerArgs = args
+	}
+}
+
+// headerComment returns the comment that starts each generated file if a header was asked for.
+func headerComment(options translateOptions) string {
+	var features []string
+	for _, feature := range Features {
+		if feature.enabled(options) {
+			features = append(features, feature.Name)
+		}
+	}
+	return "// Converted from Terraform by pulumi-converter-terraform " + version.Version + ".\n" +
+		"// Options: " + orNone(strings.Join(redactArgs(options.headerArgs), " ")) + "\n" +
+		"// Features: " + orNone(strings.Join(features, ", ")) + "\n\n"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// redactArgs returns args with the values of --backend-config key=value pairs redacted, and quoted if they're empty
+// or contain spaces or quotes, so they read as they were passed.
+func redactArgs(args []string) []string {
+	redactPair := func(pair string) string {
+		if key, _, ok := strings.Cut(pair, "="); ok {
+			return key + "=" + redactedLiteral
+		}
+		// A path to a .tfbackend file.
+		return pair
+	}
+
+	redacted := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if value, ok := strings.CutPrefix(arg, "--backend-config="); ok {
+			arg = "--backend-config=" + redactPair(value)
+		} else if arg == "--backend-config" && i+1 < len(args) {
+			redacted = append(redacted, arg)
+			i++
+			arg = redactPair(args[i])
+		}
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		redacted = append(redacted, arg)
+	}
+	return redacted
+}
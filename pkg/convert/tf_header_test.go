@@ -0,0 +1,46 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-converter-terraform/pkg/version"
+)
+
+func TestHeaderComment(t *testing.T) {
+	t.Parallel()
+
+	options := translateOptions{
+		foldConstants: true,
+		singleFile:    true,
+		headerArgs: []string{
+			"--fold-constants", "--single-file", "--backend-config", "secret_key=hunter2",
+			"--backend-config=prod.tfbackend", "--module-layout", "infra/{module} v2",
+		},
+	}
+	assert.Equal(t, "// Converted from Terraform by pulumi-converter-terraform "+version.Version+".\n"+
+		"// Options: --fold-constants --single-file --backend-config secret_key=REDACTED "+
+		"--backend-config=prod.tfbackend --module-layout \"infra/{module} v2\"\n"+
+		"// Features: fold-constants, single-file\n"+
+		"\n", headerComment(options))
+
+	assert.Equal(t, "// Converted from Terraform by pulumi-converter-terraform "+version.Version+".\n"+
+		"// Options: none\n"+
+		"// Features: none\n"+
+		"\n", headerComment(translateOptions{}))
+}
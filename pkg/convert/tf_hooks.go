@@ -0,0 +1,79 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// HookedResource is a resource passed to a ResourceHook.
+type HookedResource struct {
+	// The Terraform type and name of the resource, e.g. "acme_database" and "main".
+	Type string
+	Name string
+	// The PCL resource block the resource has been converted to. The hook can change its labels, to convert to a
+	// different type, and its attributes and options.
+	Block *hclwrite.Block
+}
+
+// ResourceHook is called with each resource of the type it's registered for once it's been converted, to customise
+// the generated code. Any diagnostics returned without a subject are reported against the Terraform resource.
+type ResourceHook func(resource *HookedResource) hcl.Diagnostics
+
+// A ResourceHook and the type token it was registered with.
+type resourceHook struct {
+	token string
+	hook  ResourceHook
+}
+
+// WithResourceHook registers hook to be called for each resource of the given Terraform type, replacing any hook
+// already registered for it. This lets programs embedding the converter map resources, such as those of internal
+// providers, to their own Pulumi types without changing the converter.
+//
+// If token is not empty resources are converted to that Pulumi type token instead of looking up the type in the
+// provider mappings, so the provider doesn't need a Pulumi mapping. Their attributes are converted to camelCase
+// names as for any unmapped resource.
+func WithResourceHook(resourceType, token string, hook ResourceHook) TranslateOption {
+	return func(o *translateOptions) {
+		hooks := make(map[string]resourceHook, len(o.resourceHooks)+1)
+		for k, v := range o.resourceHooks {
+			hooks[k] = v
+		}
+		hooks[resourceType] = resourceHook{token: token, hook: hook}
+		o.resourceHooks = hooks
+	}
+}
+
+// runResourceHook calls the hook registered for the type of managedResource, if there is one, with block.
+func runResourceHook(state *convertState, managedResource *configs.Resource, block *hclwrite.Block) {
+	hook, has := state.resourceHooks[managedResource.Type]
+	if !has || hook.hook == nil {
+		return
+	}
+
+	diags := hook.hook(&HookedResource{
+		Type:  managedResource.Type,
+		Name:  managedResource.Name,
+		Block: block,
+	})
+	for _, diag := range diags {
+		if diag.Subject == nil {
+			diag.Subject = managedResource.DeclRange.Ptr()
+		}
+		state.appendDiagnostic(diag)
+	}
+}
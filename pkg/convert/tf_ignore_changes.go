@@ -0,0 +1,149 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Map keys that can be written in a Pulumi property path as they are. Pulumi program generation writes keys as
+// attribute accesses, e.g. tags["CreatedOn"] becomes "tags.CreatedOn", so keys with other characters can't be.
+var propertyPathKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// resourceFields returns the sorted names of the attributes and blocks set in the config of resource.
+func resourceFields(resource *configs.Resource) []string {
+	content := bodyContent(resource.Config)
+	fields := map[string]bool{}
+	for name := range content.Attributes {
+		fields[name] = true
+	}
+	for _, block := range content.Blocks {
+		switch {
+		case block.Type == "dynamic" && len(block.Labels) > 0:
+			fields[block.Labels[0]] = true
+		case block.Type != "timeouts":
+			fields[block.Type] = true
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// convertIgnoreChanges returns the ignoreChanges resource option for the ignore_changes of resource, or nil if it
+// doesn't ignore any changes. ignore_changes = all is converted to every attribute and block the resource sets.
+func convertIgnoreChanges(state *convertState, scopes *scopes, resource *configs.Resource) hclwrite.Tokens {
+	if resource.Managed == nil {
+		return nil
+	}
+	traversals := resource.Managed.IgnoreChanges
+	if resource.Managed.IgnoreAllChanges {
+		traversals = nil
+		for _, name := range resourceFields(resource) {
+			traversals = append(traversals, hcl.Traversal{hcl.TraverseAttr{Name: name}})
+		}
+	}
+
+	path := resource.Type + "." + resource.Name
+	seen := map[string]bool{}
+	var elems []hclwrite.Tokens
+	for _, traversal := range traversals {
+		converted := convertIgnoreChangesPath(state, scopes, path, traversal)
+		if len(converted) == 0 {
+			continue
+		}
+		tokens := hclwrite.TokensForTraversal(converted)
+		if key := string(tokens.Bytes()); !seen[key] {
+			seen[key] = true
+			elems = append(elems, tokens)
+		}
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+	return hclwrite.TokensForTuple(elems)
+}
+
+// convertIgnoreChangesPath converts an entry of ignore_changes on the resource at resourcePath to the Pulumi
+// property path it ignores. Map keys and indexes into lists are kept, except for blocks that are flattened to a
+// single object. Where a path can't be converted exactly, for map keys that can't be written in a property path and
+// indexes into sets, which aren't ordered in Pulumi, changes to the whole of the map or set are ignored instead and a
+// warning is reported.
+func convertIgnoreChangesPath(
+	state *convertState, scopes *scopes, resourcePath string, traversal hcl.Traversal,
+) hcl.Traversal {
+	path := resourcePath
+	converted := hcl.Traversal{}
+	appendName := func(name string) {
+		if len(converted) == 0 {
+			converted = append(converted, hcl.TraverseRoot{Name: name})
+		} else {
+			converted = append(converted, hcl.TraverseAttr{Name: name})
+		}
+	}
+	widen := func(reason string) hcl.Traversal {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "ignore_changes entry widened",
+			Detail: fmt.Sprintf("%s in the ignore_changes of %s can't be converted exactly as %s, changes to all of "+
+				"%s are ignored instead", state.sourceCode(traversal.SourceRange()), resourcePath, reason,
+				strings.TrimSpace(string(hclwrite.TokensForTraversal(converted).Bytes()))),
+			Subject: traversal.SourceRange().Ptr(),
+		})
+		return converted
+	}
+
+	for _, part := range traversal {
+		switch part := part.(type) {
+		case hcl.TraverseRoot:
+			path = appendPath(path, part.Name)
+			appendName(scopes.pulumiName(path))
+		case hcl.TraverseAttr:
+			path = appendPath(path, part.Name)
+			appendName(scopes.pulumiName(path))
+		case hcl.TraverseIndex:
+			// Terraform only allows ignore_changes to start with an attribute, but be safe.
+			if len(converted) == 0 {
+				return converted
+			}
+			if scopes.maxItemsOne(path) {
+				// The block is flattened to an object in Pulumi, so there's nothing to index.
+				path = appendPathArray(path)
+				continue
+			}
+			if info := scopes.getInfo(path); info.Schema != nil && info.Schema.Type() == shim.TypeSet {
+				return widen("the elements of sets aren't ordered")
+			}
+			if part.Key.Type() == cty.String && !propertyPathKey.MatchString(part.Key.AsString()) {
+				return widen(fmt.Sprintf("the key %q can't be written in a property path", part.Key.AsString()))
+			}
+			converted = append(converted, hcl.TraverseIndex{Key: part.Key})
+			path = appendPathArray(path)
+		}
+	}
+	return converted
+}
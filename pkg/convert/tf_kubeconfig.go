@@ -0,0 +1,357 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// The terraform kubernetes provider, and the kubernetes block of the helm provider, can be configured with the
+// connection settings of a cluster and an exec block to get credentials, which is how EKS, GKE and AKS clusters are
+// usually connected to:
+//
+//	provider "kubernetes" {
+//	  host                   = aws_eks_cluster.main.endpoint
+//	  cluster_ca_certificate = base64decode(aws_eks_cluster.main.certificate_authority[0].data)
+//	  exec {
+//	    api_version = "client.authentication.k8s.io/v1beta1"
+//	    command     = "aws"
+//	    args        = ["eks", "get-token", "--cluster-name", aws_eks_cluster.main.name]
+//	  }
+//	}
+//
+// The Pulumi kubernetes provider only takes a kubeconfig, so these settings are converted to a kubeconfig with a
+// single cluster, user and context.
+
+// The connection settings of the cluster, and their names in a kubeconfig cluster.
+var kubeconfigClusterAttributes = []kubeconfigAttributeMapping{
+	{"host", "server", ""},
+	{"cluster_ca_certificate", "certificate-authority-data", "base64"},
+	{"insecure", "insecure-skip-tls-verify", ""},
+	{"tls_server_name", "tls-server-name", ""},
+	{"proxy_url", "proxy-url", ""},
+}
+
+// The credentials for the cluster, and their names in a kubeconfig user.
+var kubeconfigUserAttributes = []kubeconfigAttributeMapping{
+	{"token", "token", ""},
+	{"client_certificate", "client-certificate-data", "base64"},
+	{"client_key", "client-key-data", "base64"},
+	{"username", "username", ""},
+	{"password", "password", ""},
+}
+
+// The attributes of an exec block, and their names in the exec of a kubeconfig user.
+var kubeconfigExecAttributes = []kubeconfigAttributeMapping{
+	{"api_version", "apiVersion", ""},
+	{"command", "command", ""},
+	{"args", "args", ""},
+	{"env", "env", "env"},
+}
+
+type kubeconfigAttributeMapping struct {
+	// The name of the attribute in the terraform config
+	terraform string
+	// The name of the field in the kubeconfig
+	kubeconfig string
+	// How the value is encoded in the kubeconfig: "base64" for PEM data, which terraform takes decoded, or "env"
+	// for the environment of exec, which terraform takes as a map and kubeconfig as a list of names and values.
+	encoding string
+}
+
+// kubeconfigValue is a value in a generated kubeconfig. It's an object if attributes is set, a list if elements is
+// set, an expression from the provider config if expr is set, and otherwise the constant value.
+type kubeconfigValue struct {
+	attributes []kubeconfigAttribute
+	elements   []kubeconfigValue
+	expr       hcl.Expression
+	encoding   string
+	constant   cty.Value
+}
+
+type kubeconfigAttribute struct {
+	name  string
+	value kubeconfigValue
+}
+
+func kubeconfigConstant(value string) kubeconfigValue {
+	return kubeconfigValue{constant: cty.StringVal(value)}
+}
+
+// splitKubeconfig returns the config of a kubernetes or helm provider without the connection settings that are
+// converted to a kubeconfig, and the content those settings are in. The content is nil if the provider isn't
+// configured with an exec block, or with a host if anyHost is set, in which case the config is converted as it is.
+func splitKubeconfig(
+	providerName string, content *hcl.BodyContent, anyHost bool,
+) (*hcl.BodyContent, *hcl.BodyContent) {
+	needsKubeconfig := func(content *hcl.BodyContent) bool {
+		if _, has := content.Attributes["host"]; has && anyHost {
+			return true
+		}
+		for _, block := range content.Blocks {
+			if block.Type == "exec" {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch providerName {
+	case "kubernetes":
+		if !needsKubeconfig(content) {
+			return content, nil
+		}
+		remaining := &hcl.BodyContent{Attributes: hcl.Attributes{}, MissingItemRange: content.MissingItemRange}
+		connection := &hcl.BodyContent{Attributes: hcl.Attributes{}, MissingItemRange: content.MissingItemRange}
+		isConnection := map[string]bool{}
+		for _, mapping := range append(kubeconfigClusterAttributes, kubeconfigUserAttributes...) {
+			isConnection[mapping.terraform] = true
+		}
+		for name, attr := range content.Attributes {
+			if isConnection[name] {
+				connection.Attributes[name] = attr
+			} else {
+				remaining.Attributes[name] = attr
+			}
+		}
+		for _, block := range content.Blocks {
+			if block.Type == "exec" {
+				connection.Blocks = append(connection.Blocks, block)
+			} else {
+				remaining.Blocks = append(remaining.Blocks, block)
+			}
+		}
+		return remaining, connection
+	case "helm":
+		// All the connection settings of the helm provider are in its kubernetes block.
+		for i, block := range content.Blocks {
+			if block.Type != "kubernetes" {
+				continue
+			}
+			kubernetes := bodyContent(block.Body)
+			if !needsKubeconfig(kubernetes) {
+				return content, nil
+			}
+			remaining := *content
+			remaining.Blocks = append(append(hcl.Blocks{}, content.Blocks[:i]...), content.Blocks[i+1:]...)
+			return &remaining, kubernetes
+		}
+	}
+	return content, nil
+}
+
+// kubeconfig returns the kubeconfig for the connection settings from splitKubeconfig.
+func kubeconfig(connection *hcl.BodyContent) kubeconfigValue {
+	object := func(attributes []kubeconfigAttributeMapping, content *hcl.BodyContent) []kubeconfigAttribute {
+		var fields []kubeconfigAttribute
+		for _, mapping := range attributes {
+			if attr, has := content.Attributes[mapping.terraform]; has {
+				fields = append(fields, kubeconfigAttribute{
+					name:  mapping.kubeconfig,
+					value: kubeconfigValue{expr: attr.Expr, encoding: mapping.encoding},
+				})
+			}
+		}
+		return fields
+	}
+
+	cluster := object(kubeconfigClusterAttributes, connection)
+	user := object(kubeconfigUserAttributes, connection)
+	for _, block := range connection.Blocks {
+		if block.Type != "exec" {
+			continue
+		}
+		exec := object(kubeconfigExecAttributes, bodyContent(block.Body))
+		// kubectl requires interactiveMode for the v1 exec API, and the converted program is never interactive.
+		exec = append(exec, kubeconfigAttribute{name: "interactiveMode", value: kubeconfigConstant("Never")})
+		user = append(user, kubeconfigAttribute{name: "exec", value: kubeconfigValue{attributes: exec}})
+	}
+
+	named := func(name, key string, value []kubeconfigAttribute) kubeconfigValue {
+		return kubeconfigValue{elements: []kubeconfigValue{{attributes: []kubeconfigAttribute{
+			{name: "name", value: kubeconfigConstant(name)},
+			{name: key, value: kubeconfigValue{attributes: value}},
+		}}}}
+	}
+	return kubeconfigValue{attributes: []kubeconfigAttribute{
+		{name: "apiVersion", value: kubeconfigConstant("v1")},
+		{name: "kind", value: kubeconfigConstant("Config")},
+		{name: "clusters", value: named("cluster", "cluster", cluster)},
+		{name: "users", value: named("user", "user", user)},
+		{name: "contexts", value: named("context", "context", []kubeconfigAttribute{
+			{name: "cluster", value: kubeconfigConstant("cluster")},
+			{name: "user", value: kubeconfigConstant("user")},
+		})},
+		{name: "current-context", value: kubeconfigConstant("context")},
+	}}
+}
+
+// base64DecodedArgument returns x if expr is base64decode(x). Certificates are often given to terraform decoded from
+// the base64 data of a cluster, which is what a kubeconfig takes.
+func base64DecodedArgument(expr hcl.Expression) (hcl.Expression, bool) {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "base64decode" || len(call.Args) != 1 {
+		return nil, false
+	}
+	return call.Args[0], true
+}
+
+// convertKubeconfig converts the connection settings from splitKubeconfig to an expression for the kubeconfig of an
+// explicit provider resource.
+func convertKubeconfig(state *convertState, scopes *scopes, connection *hcl.BodyContent) hclwrite.Tokens {
+	var convert func(value kubeconfigValue) hclwrite.Tokens
+	convert = func(value kubeconfigValue) hclwrite.Tokens {
+		switch {
+		case value.attributes != nil:
+			attrs := make([]hclwrite.ObjectAttrTokens, 0, len(value.attributes))
+			for _, attr := range value.attributes {
+				attrs = append(attrs, hclwrite.ObjectAttrTokens{
+					Name:  hclwrite.TokensForValue(cty.StringVal(attr.name)),
+					Value: convert(attr.value),
+				})
+			}
+			return hclwrite.TokensForObject(attrs)
+		case value.elements != nil:
+			elements := make([]hclwrite.Tokens, 0, len(value.elements))
+			for _, element := range value.elements {
+				elements = append(elements, convert(element))
+			}
+			return hclwrite.TokensForTuple(elements)
+		case value.expr == nil:
+			return hclwrite.TokensForValue(value.constant)
+		}
+
+		switch value.encoding {
+		case "base64":
+			if decoded, ok := base64DecodedArgument(value.expr); ok {
+				return convertExpression(state, false, scopes, "", decoded)
+			}
+			return tokensForStdInvoke("base64encode", convertExpression(state, false, scopes, "", value.expr))
+		case "env":
+			return convertKubeconfigEnv(state, scopes, value.expr)
+		}
+		return convertExpression(state, false, scopes, "", value.expr)
+	}
+	return hclwrite.TokensForFunctionCall("toJSON", convert(kubeconfig(connection)))
+}
+
+// convertKubeconfigEnv converts the env map of an exec block to the list of names and values a kubeconfig takes.
+func convertKubeconfigEnv(state *convertState, scopes *scopes, expr hcl.Expression) hclwrite.Tokens {
+	// The names of environment variables are kept as they are.
+	var env hclwrite.Tokens
+	state.disableRewritingObjectKeys(func() {
+		env = convertExpression(state, false, scopes, "", expr)
+	})
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrack, "[")}
+	tokens = append(tokens,
+		makeToken(hclsyntax.TokenIdent, "for"),
+		makeToken(hclsyntax.TokenIdent, "name"),
+		makeToken(hclsyntax.TokenComma, ","),
+		makeToken(hclsyntax.TokenIdent, "value"),
+		makeToken(hclsyntax.TokenIdent, "in"))
+	tokens = append(tokens, env...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+	tokens = append(tokens, hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+		{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForIdentifier("name")},
+		{Name: hclwrite.TokensForIdentifier("value"), Value: hclwrite.TokensForIdentifier("value")},
+	})...)
+	return append(tokens, makeToken(hclsyntax.TokenCBrack, "]"))
+}
+
+// evalKubeconfig evaluates the connection settings from splitKubeconfig to the kubeconfig for the stack config of
+// a default provider. Settings that can't be evaluated are reported and replaced with a TODO, like other provider
+// config.
+func evalKubeconfig(
+	state *convertState, scopes *scopes, provider *configs.Provider, connection *hcl.BodyContent,
+) string {
+	eval := func(expr hcl.Expression) cty.Value {
+		val, diags := scopes.EvalExpr(expr)
+		if diags.HasErrors() || !val.IsWhollyKnown() {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Subject:  &provider.DeclRange,
+				Severity: hcl.DiagWarning,
+				Summary:  "Failed to evaluate provider config",
+				Detail:   fmt.Sprintf("Could not evaluate expression for %s:kubeconfig", provider.Name),
+			})
+			return cty.StringVal("TODO: " + state.sourceCode(expr.Range()))
+		}
+		return val
+	}
+
+	var evaluate func(value kubeconfigValue) cty.Value
+	evaluate = func(value kubeconfigValue) cty.Value {
+		switch {
+		case value.attributes != nil:
+			attrs := make(map[string]cty.Value, len(value.attributes))
+			for _, attr := range value.attributes {
+				attrs[attr.name] = evaluate(attr.value)
+			}
+			return cty.ObjectVal(attrs)
+		case value.elements != nil:
+			elements := make([]cty.Value, 0, len(value.elements))
+			for _, element := range value.elements {
+				elements = append(elements, evaluate(element))
+			}
+			return cty.TupleVal(elements)
+		case value.expr == nil:
+			return value.constant
+		}
+
+		switch value.encoding {
+		case "base64":
+			if decoded, ok := base64DecodedArgument(value.expr); ok {
+				return eval(decoded)
+			}
+			val := eval(value.expr)
+			if val.Type() != cty.String || !val.IsKnown() || val.IsNull() {
+				return val
+			}
+			return cty.StringVal(base64.StdEncoding.EncodeToString([]byte(val.AsString())))
+		case "env":
+			val := eval(value.expr)
+			if !val.CanIterateElements() || !val.IsKnown() || val.IsNull() {
+				return val
+			}
+			env := []cty.Value{}
+			for it := val.ElementIterator(); it.Next(); {
+				name, value := it.Element()
+				env = append(env, cty.ObjectVal(map[string]cty.Value{"name": name, "value": value}))
+			}
+			return cty.TupleVal(env)
+		}
+		return eval(value.expr)
+	}
+
+	val := evaluate(kubeconfig(connection))
+	data, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Subject:  &provider.DeclRange,
+			Severity: hcl.DiagError,
+			Summary:  "Failed to marshal provider config",
+			Detail:   fmt.Sprintf("Could not marshal value for %s:kubeconfig: %v", provider.Name, err),
+		})
+		return ""
+	}
+	return string(data)
+}
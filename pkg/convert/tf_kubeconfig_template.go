@@ -0,0 +1,272 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// WithKubeconfigTemplate converts kubernetes and helm providers that are configured from the outputs of a cluster,
+// like the endpoint and certificate of an EKS, GKE or AKS cluster, to a local with a templated kubeconfig string
+// that the provider is given, as in the Pulumi examples for these clusters. By default only providers that use
+// exec auth are converted to a kubeconfig, and it's built as an object passed to toJSON.
+func WithKubeconfigTemplate() TranslateOption {
+	return func(o *translateOptions) {
+		o.kubeconfigTemplate = true
+	}
+}
+
+// kubeconfigTemplateKey returns the key of the local holding the kubeconfig of provider in scopes.roots.
+func kubeconfigTemplateKey(provider *configs.Provider) string {
+	return "kubeconfig." + providerKey(provider.Name, provider.Alias)
+}
+
+// addKubeconfigTemplateName reserves the name of the kubeconfig local for an explicit provider resource named name,
+// if kubeconfig templates are being generated and the provider connects to a cluster.
+func addKubeconfigTemplateName(state *convertState, scopes *scopes, provider *configs.Provider, name string) {
+	if !state.kubeconfigTemplate {
+		return
+	}
+	_, connection := splitKubeconfig(baseProvider(provider.Name), bodyContent(provider.Config), true)
+	if connection == nil {
+		return
+	}
+	scopes.roots[kubeconfigTemplateKey(provider)] = PathInfo{
+		Name: scopes.generateUniqueName(name+"Kubeconfig", "", ""),
+	}
+}
+
+// convertKubeconfigTemplate returns the name and value of the kubeconfig local for provider, or false if it
+// doesn't have one, see addKubeconfigTemplateName.
+func convertKubeconfigTemplate(
+	state *convertState, scopes *scopes, provider *configs.Provider,
+) (string, hclwrite.Tokens, bool) {
+	root, has := scopes.roots[kubeconfigTemplateKey(provider)]
+	if !has {
+		return "", nil, false
+	}
+	_, connection := splitKubeconfig(baseProvider(provider.Name), bodyContent(provider.Config), true)
+
+	template := &kubeconfigTemplate{state: state, scopes: scopes}
+	template.object(kubeconfig(connection).attributes, "", false)
+	return root.Name, template.tokens(), true
+}
+
+// kubeconfigTemplate builds the YAML of a kubeconfig as the parts of a template string, literal text and
+// interpolated expressions.
+type kubeconfigTemplate struct {
+	state  *convertState
+	scopes *scopes
+	text   strings.Builder
+	parts  []hclwrite.Tokens
+}
+
+// Strings that can be written to YAML without quotes, which excludes anything YAML would read as another type.
+var plainYAMLString = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9./_-]*$`)
+
+func (t *kubeconfigTemplate) flush() {
+	if t.text.Len() == 0 {
+		return
+	}
+	tokens := hclwrite.TokensForValue(cty.StringVal(t.text.String()))
+	// Strip the quotes, the text is written into the template
+	t.parts = append(t.parts, tokens[1:len(tokens)-1])
+	t.text.Reset()
+}
+
+func (t *kubeconfigTemplate) interpolate(expr hclwrite.Tokens) {
+	t.flush()
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenTemplateInterp, "${")}
+	tokens = append(tokens, expr...)
+	t.parts = append(t.parts, append(tokens, makeToken(hclsyntax.TokenTemplateSeqEnd, "}")))
+}
+
+// tokens returns the template as a string.
+func (t *kubeconfigTemplate) tokens() hclwrite.Tokens {
+	t.flush()
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenOQuote, "\"")}
+	for _, part := range t.parts {
+		tokens = append(tokens, part...)
+	}
+	return append(tokens, makeToken(hclsyntax.TokenCQuote, "\""))
+}
+
+// object writes the fields of an object indented by indent. The first field isn't indented if the object is an
+// element of a list, as it follows the "- " of the element.
+func (t *kubeconfigTemplate) object(attributes []kubeconfigAttribute, indent string, inList bool) {
+	for i, attr := range attributes {
+		if i > 0 || !inList {
+			t.text.WriteString(indent)
+		}
+		t.text.WriteString(attr.name + ":")
+		t.value(attr.value, indent)
+	}
+}
+
+// value writes value after the key of a field indented by indent.
+func (t *kubeconfigTemplate) value(value kubeconfigValue, indent string) {
+	switch {
+	case value.attributes != nil:
+		t.text.WriteString("\n")
+		t.object(value.attributes, indent+"  ", false)
+	case value.elements != nil:
+		t.text.WriteString("\n")
+		for _, element := range value.elements {
+			t.text.WriteString(indent + "- ")
+			if element.attributes != nil {
+				t.object(element.attributes, indent+"  ", true)
+			} else {
+				t.scalar(element)
+			}
+		}
+	case value.expr == nil:
+		t.text.WriteString(" ")
+		t.scalar(value)
+	default:
+		t.expression(value, indent)
+	}
+}
+
+// scalar writes a constant or an expression as a YAML scalar, followed by a newline.
+func (t *kubeconfigTemplate) scalar(value kubeconfigValue) {
+	switch {
+	case value.expr != nil:
+		t.interpolate(convertExpression(t.state, false, t.scopes, "", value.expr))
+	case value.constant.Type() == cty.Bool:
+		t.text.WriteString(fmt.Sprint(value.constant.True()))
+	case value.constant.Type() == cty.Number:
+		t.text.WriteString(value.constant.AsBigFloat().Text('f', -1))
+	default:
+		str := value.constant.AsString()
+		if plainYAMLString.MatchString(str) && str != "true" && str != "false" && str != "null" {
+			t.text.WriteString(str)
+		} else {
+			// JSON strings are valid YAML strings
+			quoted, _ := json.Marshal(str)
+			t.text.Write(quoted)
+		}
+	}
+	t.text.WriteString("\n")
+}
+
+// kubeconfigScalar returns expr as a constant if it's a literal string, bool or number.
+func kubeconfigScalar(expr hcl.Expression) kubeconfigValue {
+	switch expr := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		if !expr.Val.IsNull() && expr.Val.Type().IsPrimitiveType() {
+			return kubeconfigValue{constant: expr.Val}
+		}
+	case *hclsyntax.TemplateExpr:
+		if expr.IsStringLiteral() {
+			if val, diags := expr.Value(nil); !diags.HasErrors() && !val.IsNull() {
+				return kubeconfigValue{constant: val}
+			}
+		}
+	}
+	return kubeconfigValue{expr: expr}
+}
+
+// envName returns the name of an environment variable given by the key of an item of an env map, or false if the
+// key isn't a literal.
+func envName(expr hclsyntax.Expression) (string, bool) {
+	if name := hcl.ExprAsKeyword(expr); name != "" {
+		return name, true
+	}
+	if key, ok := expr.(*hclsyntax.ObjectConsKeyExpr); ok {
+		expr = key.Wrapped
+	}
+	name := kubeconfigScalar(expr)
+	if name.expr != nil || name.constant.Type() != cty.String {
+		return "", false
+	}
+	return name.constant.AsString(), true
+}
+
+// expression writes the value of a field that's set from the provider config. Literal lists, like the args of exec,
+// and literal maps, like its env, are written as YAML lists, and values that aren't scalars are interpolated as
+// JSON, which YAML reads too.
+func (t *kubeconfigTemplate) expression(value kubeconfigValue, indent string) {
+	switch value.encoding {
+	case "base64":
+		t.text.WriteString(" ")
+		if decoded, ok := base64DecodedArgument(value.expr); ok {
+			t.scalar(kubeconfigScalar(decoded))
+			return
+		}
+		t.interpolate(tokensForStdInvoke("base64encode",
+			convertExpression(t.state, false, t.scopes, "", value.expr)))
+		t.text.WriteString("\n")
+		return
+	case "env":
+		if env, ok := literalEnv(value.expr); ok {
+			t.value(env, indent)
+			return
+		}
+		t.text.WriteString(" ")
+		t.interpolate(hclwrite.TokensForFunctionCall("toJSON", convertKubeconfigEnv(t.state, t.scopes, value.expr)))
+		t.text.WriteString("\n")
+		return
+	}
+
+	switch expr := value.expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		if len(expr.Exprs) > 0 {
+			list := kubeconfigValue{}
+			for _, element := range expr.Exprs {
+				list.elements = append(list.elements, kubeconfigScalar(element))
+			}
+			t.value(list, indent)
+			return
+		}
+	case *hclsyntax.LiteralValueExpr, *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr,
+		*hclsyntax.ScopeTraversalExpr, *hclsyntax.RelativeTraversalExpr, *hclsyntax.IndexExpr:
+		t.text.WriteString(" ")
+		t.scalar(kubeconfigScalar(expr))
+		return
+	}
+	t.text.WriteString(" ")
+	t.interpolate(hclwrite.TokensForFunctionCall("toJSON",
+		convertExpression(t.state, false, t.scopes, "", value.expr)))
+	t.text.WriteString("\n")
+}
+
+// literalEnv returns the env of exec as a list of names and values if it's a literal map with literal keys.
+func literalEnv(expr hcl.Expression) (kubeconfigValue, bool) {
+	object, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok || len(object.Items) == 0 {
+		return kubeconfigValue{}, false
+	}
+	env := kubeconfigValue{}
+	for _, item := range object.Items {
+		name, ok := envName(item.KeyExpr)
+		if !ok {
+			return kubeconfigValue{}, false
+		}
+		env.elements = append(env.elements, kubeconfigValue{attributes: []kubeconfigAttribute{
+			{name: "name", value: kubeconfigConstant(name)},
+			{name: "value", value: kubeconfigScalar(item.ValueExpr)},
+		}})
+	}
+	return env, true
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// LocalNaming is how WithLocalNaming names the variables locals are converted to.
+type LocalNaming string
+
+const (
+	// LocalNamingCamelCase names locals in camelCase, e.g. local.vpc_cidr is vpcCidr, prefixing them with "my" if
+	// that name is taken. This is the default.
+	LocalNamingCamelCase LocalNaming = "camel"
+	// LocalNamingOutputSuffix names locals in camelCase with an Output suffix, e.g. vpcCidrOutput.
+	LocalNamingOutputSuffix LocalNaming = "output"
+	// LocalNamingUnderscore names locals in camelCase with a trailing underscore, e.g. vpcCidr_.
+	LocalNamingUnderscore LocalNaming = "underscore"
+	// LocalNamingOriginal names locals as they're named in terraform, e.g. vpc_cidr.
+	LocalNamingOriginal LocalNaming = "original"
+)
+
+// WithLocalNaming sets how the variables locals are converted to are named. Most locals are outputs of resources,
+// or computed from them, and large programs convert to hundreds of them, so this lets them match the naming
+// conventions of the code they're added to. Names that are taken get a number appended.
+func WithLocalNaming(naming LocalNaming) TranslateOption {
+	return func(o *translateOptions) {
+		o.localNaming = naming
+	}
+}
+
+// checkLocalNaming returns an error if naming isn't one of the local namings.
+func checkLocalNaming(naming LocalNaming) hcl.Diagnostics {
+	switch naming {
+	case "", LocalNamingCamelCase, LocalNamingOutputSuffix, LocalNamingUnderscore, LocalNamingOriginal:
+		return nil
+	}
+	return hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Unknown local naming",
+		Detail: fmt.Sprintf("Unknown local naming %q, expected %s, %s, %s or %s", naming, LocalNamingCamelCase,
+			LocalNamingOutputSuffix, LocalNamingUnderscore, LocalNamingOriginal),
+	}}
+}
+
+// addLocalName returns the unique name of the variable the local called name is converted to, adding it to scopes
+// if it hasn't been named yet.
+func addLocalName(scopes *scopes, naming LocalNaming, name string) string {
+	key := "local." + name
+	if root, has := scopes.roots[key]; has {
+		return root.Name
+	}
+	var pulumiName string
+	switch naming {
+	case LocalNamingOutputSuffix:
+		pulumiName = camelCaseName(name) + "Output"
+	case LocalNamingUnderscore:
+		pulumiName = camelCaseName(name) + "_"
+	case LocalNamingOriginal:
+		// Terraform names can contain dashes, which most languages' identifiers can't.
+		pulumiName = strings.ReplaceAll(name, "-", "_")
+	default:
+		return scopes.getOrAddPulumiName(key, "my", "")
+	}
+	pulumiName = scopes.generateUniqueName(pulumiName, "", "")
+	scopes.roots[key] = PathInfo{Name: pulumiName}
+	return pulumiName
+}
@@ -0,0 +1,47 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// WithLogger logs the progress of the conversion to logger. Modules being converted and fetched are logged at info
+// level, and each file and item converted and provider mapping looked up at debug level, to help find what a long
+// running conversion is stuck on. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) TranslateOption {
+	return func(o *translateOptions) {
+		o.logger = logger
+	}
+}
+
+// discardHandler is a slog.Handler that drops every record, so that nothing is logged unless a logger is given.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// moduleLogger returns the logger for the module being translated, which adds the module's address to every record.
+func moduleLogger(options translateOptions) *slog.Logger {
+	if options.manifestKey == "" {
+		return options.logger
+	}
+	return options.logger.With("module", "module."+strings.ReplaceAll(options.manifestKey, ".", ".module."))
+}
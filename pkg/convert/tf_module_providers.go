@@ -0,0 +1,148 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Modules that create resources in more than one region, like S3 replication, DynamoDB global tables or KMS replica
+// keys, declare the aliased providers they use in configuration_aliases, or with an empty provider block, and their
+// callers pass them in with the module's providers argument:
+//
+//	module "replication" {
+//	  source    = "./replication"
+//	  providers = {
+//	    aws.replica = aws.west
+//	  }
+//	}
+//
+// Components have no equivalent of providers, so each aliased provider a module is passed becomes a config
+// variable of its component that the resources using it set as their provider, and the caller sets it to the
+// provider resource it passes. A default provider passed to the module is set as the component's provider, which
+// its resources inherit.
+
+// findProviderInputs returns the aliased providers that module is passed by its callers, keyed by providerKey, and
+// the providers to add to its items for those that are only declared in configuration_aliases. The root module has
+// no callers, so has none.
+func findProviderInputs(module *configs.Module, root bool) (map[string]bool, []*configs.Provider) {
+	if root {
+		return nil, nil
+	}
+	inputs := map[string]bool{}
+	for _, provider := range module.ProviderConfigs {
+		if provider.Alias == "" {
+			continue
+		}
+		content := bodyContent(provider.Config)
+		if len(content.Attributes) == 0 && len(content.Blocks) == 0 {
+			inputs[providerKey(provider.Name, provider.Alias)] = true
+		}
+	}
+
+	var declared []*configs.Provider
+	if module.ProviderRequirements != nil {
+		for _, required := range module.ProviderRequirements.RequiredProviders {
+			for _, alias := range required.Aliases {
+				key := providerKey(alias.LocalName, alias.Alias)
+				if _, has := module.ProviderConfigs[alias.StringCompact()]; has || inputs[key] {
+					continue
+				}
+				inputs[key] = true
+				rng := emptyRange(required.DeclRange)
+				declared = append(declared, &configs.Provider{
+					Name:      alias.LocalName,
+					NameRange: rng,
+					Alias:     alias.Alias,
+					Config:    &hclsyntax.Body{SrcRange: rng, EndRange: rng},
+					DeclRange: rng,
+				})
+			}
+		}
+	}
+	return inputs, declared
+}
+
+// convertProviderInput returns the config variable that provider, an aliased provider the module is passed, is
+// converted to. It defaults to null for callers that pass the default provider, which resources use when their
+// provider is null.
+func convertProviderInput(
+	state *convertState, scopes *scopes, provider *configs.Provider,
+) (hclwrite.Tokens, *hclwrite.Block, hclwrite.Tokens) {
+	name := scopes.roots[providerKey(provider.Name, provider.Alias)].Name
+	block := hclwrite.NewBlock("config", []string{name})
+	block.Body().SetAttributeValue("default", cty.NilVal)
+	block.Body().SetAttributeValue("description",
+		cty.StringVal(fmt.Sprintf("The %s.%s provider", provider.Name, provider.Alias)))
+	leading, trailing := getTrivia(state.sources, provider.DeclRange, false)
+	return leading, block, trailing
+}
+
+// passedProvider is an aliased provider passed to a module, and the argument of its component it's set as.
+type passedProvider struct {
+	name  string
+	value hcl.Traversal
+}
+
+// convertPassedProviders returns the provider the component that moduleCall is converted to is given for the
+// default provider it passes the module, if any, and the arguments it's given for the aliased providers it passes.
+// Only one provider can be set on a component, so passing more than one default provider is reported.
+func convertPassedProviders(
+	state *convertState, scopes *scopes, moduleCall *configs.ModuleCall,
+) (hcl.Traversal, []passedProvider) {
+	var provider hcl.Traversal
+	var passed []passedProvider
+	for _, config := range moduleCall.Providers {
+		parent, child := config.InParent, config.InChild
+		root, has := scopes.roots[providerKey(parent.Name, parent.Alias)]
+		if !has {
+			if parent.Alias != "" {
+				state.appendDiagnostic(&hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Provider not found",
+					Detail: fmt.Sprintf("module.%s is passed the provider %s.%s which isn't configured in this "+
+						"module, it will use the default %s provider instead", moduleCall.Name, parent.Name,
+						parent.Alias, parent.Name),
+					Subject: parent.NameRange.Ptr(),
+				})
+			}
+			// The default provider is already the one the module's resources use without a provider.
+			continue
+		}
+		value := hcl.Traversal{hcl.TraverseRoot{Name: root.Name}}
+
+		if child.Alias != "" {
+			passed = append(passed, passedProvider{name: camelCaseName(child.Alias), value: value})
+		} else if provider == nil {
+			provider = value
+		} else {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Provider not passed",
+				Detail: fmt.Sprintf("module.%s is passed more than one default provider, but a component can only "+
+					"be given one, so its %s resources will use the default %s provider", moduleCall.Name,
+					child.Name, child.Name),
+				Subject: child.NameRange.Ptr(),
+			})
+		}
+	}
+	return provider, passed
+}
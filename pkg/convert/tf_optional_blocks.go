@@ -0,0 +1,203 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// optionalBlock is a dynamic block whose for_each adds the block at most once, like
+// `for_each = var.log_bucket != null ? [var.log_bucket] : []` or `for_each = compact([var.log_bucket])`. These are
+// how terraform modules make a block optional, and are converted to setting the property to the block if it's
+// present and null otherwise, rather than to a list that may be empty, which providers can treat differently from
+// not setting the property.
+type optionalBlock struct {
+	// The condition the block is present under, or nil if it's present when element isn't null or empty, for
+	// compact.
+	condition hclsyntax.Expression
+	negated   bool
+	// The single element of the for_each, which the content of the block refers to as the iterator's value.
+	element hclsyntax.Expression
+}
+
+// isEmptyTuple returns true if expr is the literal [].
+func isEmptyTuple(expr hclsyntax.Expression) bool {
+	tuple, ok := unwrapParentheses(expr).(*hclsyntax.TupleConsExpr)
+	return ok && len(tuple.Exprs) == 0
+}
+
+// singleElement returns the element of expr if it's a literal list of one element.
+func singleElement(expr hclsyntax.Expression) (hclsyntax.Expression, bool) {
+	tuple, ok := unwrapParentheses(expr).(*hclsyntax.TupleConsExpr)
+	if !ok || len(tuple.Exprs) != 1 {
+		return nil, false
+	}
+	return tuple.Exprs[0], true
+}
+
+// matchOptionalBlock returns the optionalBlock for the for_each of a dynamic block if it's one of:
+//
+//   - `condition ? [element] : []`, or `condition ? [] : [element]`
+//   - `compact([element])`
+//   - one of the above concatenated with empty lists, like `concat(condition ? [element] : [], [])`
+//   - one of the above wrapped in `try(..., [])`
+func matchOptionalBlock(forEach hcl.Expression) (optionalBlock, bool) {
+	expr, ok := forEach.(hclsyntax.Expression)
+	if !ok {
+		return optionalBlock{}, false
+	}
+
+	switch expr := unwrapParentheses(expr).(type) {
+	case *hclsyntax.ConditionalExpr:
+		if element, ok := singleElement(expr.TrueResult); ok && isEmptyTuple(expr.FalseResult) {
+			return optionalBlock{condition: expr.Condition, element: element}, true
+		}
+		if element, ok := singleElement(expr.FalseResult); ok && isEmptyTuple(expr.TrueResult) {
+			return optionalBlock{condition: expr.Condition, negated: true, element: element}, true
+		}
+	case *hclsyntax.FunctionCallExpr:
+		switch {
+		case expr.Name == "compact" && len(expr.Args) == 1:
+			if element, ok := singleElement(expr.Args[0]); ok {
+				return optionalBlock{element: element}, true
+			}
+		case expr.Name == "concat":
+			var list hclsyntax.Expression
+			for _, arg := range expr.Args {
+				if isEmptyTuple(arg) {
+					continue
+				}
+				if list != nil {
+					return optionalBlock{}, false
+				}
+				list = arg
+			}
+			if list != nil {
+				return matchOptionalBlock(list)
+			}
+		case expr.Name == "try" && len(expr.Args) == 2 && isEmptyTuple(expr.Args[1]):
+			// The fallback is only for errors evaluating the list, like a missing attribute of an object
+			// variable, which the converted program doesn't have.
+			return matchOptionalBlock(expr.Args[0])
+		}
+	}
+	return optionalBlock{}, false
+}
+
+// convertOptionalBlockCondition converts the condition the optional block is present under.
+func convertOptionalBlockCondition(state *convertState, scopes *scopes, block optionalBlock) hclwrite.Tokens {
+	if block.condition == nil {
+		// compact removes null and empty strings.
+		element := convertExpression(state, false, scopes, "", block.element)
+		tokens := append(hclwrite.Tokens{}, element...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenNotEqual, "!="), makeToken(hclsyntax.TokenIdent, "null"))
+		tokens = append(tokens, makeToken(hclsyntax.TokenAnd, "&&"))
+		tokens = append(tokens, convertExpression(state, false, scopes, "", block.element)...)
+		tokens = append(tokens, makeToken(hclsyntax.TokenNotEqual, "!="))
+		return append(tokens, hclwrite.TokensForValue(cty.StringVal(""))...)
+	}
+	if block.negated {
+		return convertNegation(state, false, scopes, "", block.condition)
+	}
+	return convertExpression(state, false, scopes, "", block.condition)
+}
+
+// tokensForOptional returns `condition ? value : otherwise`.
+func tokensForOptional(condition, value, otherwise hclwrite.Tokens) hclwrite.Tokens {
+	tokens := append(hclwrite.Tokens{}, condition...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenQuestion, "?"))
+	tokens = append(tokens, value...)
+	tokens = append(tokens, makeToken(hclsyntax.TokenColon, ":"))
+	return append(tokens, otherwise...)
+}
+
+// rewriteOptionalBlockIterator rewrites a reference to the iterator of an optional block, like logging.value.bucket,
+// to the element of its for_each. It returns false if the traversal isn't a reference to an optional block's
+// iterator.
+func rewriteOptionalBlockIterator(scopes *scopes, traversal hcl.Traversal) (hclwrite.Tokens, bool) {
+	element, has := scopes.optionalBlockValues[traversal.RootName()]
+	if !has || len(traversal) < 2 {
+		return nil, false
+	}
+	attr, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return nil, false
+	}
+	switch attr.Name {
+	case "key":
+		// The for_each is a list, so the key of its only element is 0.
+		return hclwrite.Tokens{makeToken(hclsyntax.TokenNumberLit, "0")}, true
+	case "value":
+		rest := rewriteRelativeTraversal(scopes, "", traversal[2:])
+		tokens := hclwrite.Tokens{}
+		for _, token := range element {
+			copied := *token
+			tokens = append(tokens, &copied)
+		}
+		if len(rest) == 0 {
+			return tokens, true
+		}
+		if !isSimpleTokens(tokens) {
+			tokens = append(hclwrite.Tokens{makeToken(hclsyntax.TokenOParen, "(")}, tokens...)
+			tokens = append(tokens, makeToken(hclsyntax.TokenCParen, ")"))
+		}
+		return append(tokens, hclwrite.TokensForTraversal(rest)...), true
+	}
+	return nil, false
+}
+
+// isSimpleTokens returns true if tokens are a single identifier or a traversal, which can be followed by attribute
+// accesses without parentheses.
+func isSimpleTokens(tokens hclwrite.Tokens) bool {
+	for _, token := range tokens {
+		switch token.Type {
+		case hclsyntax.TokenIdent, hclsyntax.TokenDot, hclsyntax.TokenOBrack, hclsyntax.TokenCBrack,
+			hclsyntax.TokenNumberLit, hclsyntax.TokenOQuote, hclsyntax.TokenCQuote, hclsyntax.TokenQuotedLit:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// convertOptionalBlockContent converts the content of the optional dynamic block body at blockPath to an object,
+// with references to its iterator converted to the element of its for_each.
+func convertOptionalBlockContent(state *convertState, scopes *scopes, blockPath, iterator string,
+	body *hclsyntax.Body, block optionalBlock,
+) hclwrite.Tokens {
+	element := convertExpression(state, false, scopes, "", block.element)
+	previous, hadPrevious := scopes.optionalBlockValues[iterator]
+	if scopes.optionalBlockValues == nil {
+		scopes.optionalBlockValues = map[string]hclwrite.Tokens{}
+	}
+	scopes.optionalBlockValues[iterator] = element
+	defer func() {
+		if hadPrevious {
+			scopes.optionalBlockValues[iterator] = previous
+		} else {
+			delete(scopes.optionalBlockValues, iterator)
+		}
+	}()
+
+	for _, innerBlock := range body.Blocks {
+		if innerBlock.Type == "content" {
+			return tokensForObject(convertBody(state, scopes, blockPath, innerBlock.Body))
+		}
+	}
+	return hclwrite.Tokens{makeToken(hclsyntax.TokenIdent, "{}")}
+}
@@ -0,0 +1,89 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+// WithSingleFile writes each converted module to a single main.pp, rather than a .pp file for each of its .tf files.
+// Everything in the file is ordered after what it refers to, as it is within each file by default.
+func WithSingleFile() TranslateOption {
+	return func(o *translateOptions) {
+		o.singleFile = true
+	}
+}
+
+// orderKey returns the key that items refer to item by, like itemKey, and for providers the key of the provider
+// that resources using it refer to.
+func (item terraformItem) orderKey() string {
+	if item.provider != nil {
+		return providerKey(item.provider.Name, item.provider.Alias)
+	}
+	return item.itemKey()
+}
+
+// orderDependencies returns the keys of the items that item refers to, including the provider of a resource.
+func (item terraformItem) orderDependencies() []string {
+	var keys []string
+	for _, traversal := range item.references() {
+		if key := referenceKey(traversal); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if item.resource != nil {
+		providerName, providerAlias := impliedProvider(item.resource.Type), ""
+		if ref := item.resource.ProviderConfigRef; ref != nil {
+			providerName, providerAlias = ref.Name, ref.Alias
+		}
+		keys = append(keys, providerKey(providerName, providerAlias))
+	}
+	return keys
+}
+
+// orderItems returns items, which are in source order, ordered so that each item comes after the items it refers to
+// that are written to the same file, as given by path. Languages without hoisting, like Go and Python, need variables
+// to be defined before they're used, which terraform doesn't. Items are kept in source order otherwise, and items in
+// a reference cycle are left in source order, as they can't be ordered.
+func orderItems(items terraformItems, path func(terraformItem) string) terraformItems {
+	byKey := map[string]int{}
+	for i, item := range items {
+		if key := item.orderKey(); key != "" {
+			byKey[key] = i
+		}
+	}
+
+	ordered := make(terraformItems, 0, len(items))
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(items))
+	var visit func(i int)
+	visit = func(i int) {
+		if states[i] != unvisited {
+			return
+		}
+		states[i] = visiting
+		for _, key := range items[i].orderDependencies() {
+			if j, has := byKey[key]; has && j != i && path(items[j]) == path(items[i]) {
+				visit(j)
+			}
+		}
+		states[i] = visited
+		ordered = append(ordered, items[i])
+	}
+	for i := range items {
+		visit(i)
+	}
+	return ordered
+}
@@ -0,0 +1,122 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Modules often re-export one of their inputs as an output, directly or through the outputs of the modules they
+// call, like an `environment` variable passed down through every level of a program. A reference to such an output
+// is converted to the argument the module was given for the input, rather than to the output of the component, so
+// the value doesn't have to pass through the chain of components to be used. The outputs are still converted, other
+// programs may use them.
+
+// moduleOutputKey returns "module.name.output" if traversal is a reference to an output of a module without an
+// index, like module.network.vpc_id, or "" otherwise.
+func moduleOutputKey(traversal hcl.Traversal) string {
+	if len(traversal) != 3 || traversal.RootName() != "module" {
+		return ""
+	}
+	name, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return ""
+	}
+	output, ok := traversal[2].(hcl.TraverseAttr)
+	if !ok {
+		return ""
+	}
+	return "module." + name.Name + "." + output.Name
+}
+
+// passthroughOutputs returns the name of the variable each output in items passes through, keyed by the output's
+// name. Outputs that are marked sensitive or depend on anything else don't pass through a value as it is.
+func passthroughOutputs(state *convertState, items terraformItems) map[string]string {
+	passthroughs := map[string]string{}
+	for _, item := range items {
+		output := item.output
+		if output == nil || output.Sensitive || len(output.DependsOn) > 0 {
+			continue
+		}
+		expr, ok := output.Expr.(hclsyntax.Expression)
+		if !ok {
+			continue
+		}
+		seen := map[string]bool{}
+		for {
+			traversal, ok := unwrapParentheses(expr).(*hclsyntax.ScopeTraversalExpr)
+			if !ok {
+				break
+			}
+			if len(traversal.Traversal) == 2 && traversal.Traversal.RootName() == "var" {
+				if attr, ok := traversal.Traversal[1].(hcl.TraverseAttr); ok {
+					passthroughs[output.Name] = attr.Name
+				}
+				break
+			}
+			// Follow outputs of modules this module calls that pass through one of its own variables.
+			key := moduleOutputKey(traversal.Traversal)
+			forwarded, has := state.forwardedOutputs[key]
+			if key == "" || !has || seen[key] {
+				break
+			}
+			seen[key] = true
+			expr = forwarded
+		}
+	}
+	return passthroughs
+}
+
+// forwardedOutputs returns the arguments in items that the outputs of the modules they're passed to pass through,
+// keyed by the reference to the output, e.g. "module.network.environment". passthroughs are the results of
+// passthroughOutputs for each module that's been converted, keyed by the path it was written to. Modules using
+// count or for_each are left out, as their outputs are referenced by instance.
+func forwardedOutputs(
+	items terraformItems, modules map[moduleKey]string, passthroughs map[string]map[string]string,
+) map[string]hclsyntax.Expression {
+	forwarded := map[string]hclsyntax.Expression{}
+	for _, item := range items {
+		call := item.moduleCall
+		if call == nil || call.Count != nil || call.ForEach != nil {
+			continue
+		}
+		path, has := modules[makeModuleKey(call)]
+		if !has {
+			continue
+		}
+		arguments := bodyContent(call.Config).Attributes
+		for output, variable := range passthroughs[path] {
+			if argument, has := arguments[variable]; has {
+				if expr, ok := argument.Expr.(hclsyntax.Expression); ok {
+					forwarded["module."+call.Name+"."+output] = expr
+				}
+			}
+		}
+	}
+	return forwarded
+}
+
+// forwardedOutput returns the argument that a reference to a module output passes through, or false if the output
+// isn't a passthrough of one of the module's variables or the argument is already being converted, which can only
+// happen for modules that depend on each other's outputs.
+func forwardedOutput(state *convertState, traversal hcl.Traversal) (string, hclsyntax.Expression, bool) {
+	key := moduleOutputKey(traversal)
+	expr, has := state.forwardedOutputs[key]
+	if key == "" || !has || state.forwarding[key] {
+		return "", nil, false
+	}
+	return key, expr, true
+}
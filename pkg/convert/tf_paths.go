@@ -0,0 +1,84 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The functions that take paths, and how many of their leading arguments are paths. fileset's pattern is a path
+// too, terraform matches it with forward slashes whatever the platform.
+var pathFunctions = map[string]int{
+	"abspath":          1,
+	"file":             1,
+	"filebase64":       1,
+	"filebase64sha256": 1,
+	"filebase64sha512": 1,
+	"fileexists":       1,
+	"filemd5":          1,
+	"fileset":          2,
+	"filesha1":         1,
+	"filesha256":       1,
+	"filesha512":       1,
+	"templatefile":     1,
+}
+
+// portablePath returns a path from a program or its .terraform directory, which may have been written on Windows,
+// as a path on this platform.
+func portablePath(path string) string {
+	return filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+}
+
+// normalizePathArguments returns call with backslashes in the literal parts of its path arguments replaced by forward
+// slashes. Programs written on Windows often use backslashes, like file("${path.module}\\policy.json"), which only
+// work on Windows, but forward slashes work on every platform the converted program can run on.
+func normalizePathArguments(call *hclsyntax.FunctionCallExpr) *hclsyntax.FunctionCallExpr {
+	count, isPathFunction := pathFunctions[call.Name]
+	if !isPathFunction {
+		return call
+	}
+
+	normalized := *call
+	normalized.Args = append([]hclsyntax.Expression{}, call.Args...)
+	for i := 0; i < count && i < len(normalized.Args); i++ {
+		normalized.Args[i] = normalizePathSeparators(normalized.Args[i])
+	}
+	return &normalized
+}
+
+// normalizePathSeparators returns expr with backslashes in its string literals replaced by forward slashes.
+func normalizePathSeparators(expr hclsyntax.Expression) hclsyntax.Expression {
+	switch expr := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		if expr.Val.Type() != cty.String || expr.Val.IsNull() || !strings.Contains(expr.Val.AsString(), `\`) {
+			return expr
+		}
+		normalized := *expr
+		normalized.Val = cty.StringVal(strings.ReplaceAll(expr.Val.AsString(), `\`, "/"))
+		return &normalized
+	case *hclsyntax.TemplateExpr:
+		normalized := *expr
+		normalized.Parts = make([]hclsyntax.Expression, len(expr.Parts))
+		for i, part := range expr.Parts {
+			normalized.Parts[i] = normalizePathSeparators(part)
+		}
+		return &normalized
+	}
+	return expr
+}
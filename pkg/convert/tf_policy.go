@@ -0,0 +1,120 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/spf13/afero"
+)
+
+var (
+	// Sentinel rules are declared as `name = rule { ... }`.
+	sentinelRuleRegexp = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*=\s*rule\b`)
+	// Conftest looks for rules named deny, violation and warn, optionally suffixed (e.g. deny_public_acl).
+	regoRuleRegexp = regexp.MustCompile(`^\s*((?:deny|violation|warn)(?:_[A-Za-z0-9_]+)?)\b`)
+)
+
+// findPolicyRules looks through the given policy source for rule declarations, returning each rule's name and
+// the range it was declared at.
+func findPolicyRules(path string, src []byte, ruleRegexp *regexp.Regexp) ([]string, []hcl.Range) {
+	var names []string
+	var ranges []hcl.Range
+	seen := map[string]bool{}
+
+	offset := 0
+	for i, text := range strings.SplitAfter(string(src), "\n") {
+		lineStart := offset
+		offset += len(text)
+
+		match := ruleRegexp.FindStringSubmatchIndex(text)
+		if match == nil {
+			continue
+		}
+		name := text[match[2]:match[3]]
+		// Rego rules are often defined incrementally over multiple bodies, only report each name once.
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		names = append(names, name)
+		ranges = append(ranges, hcl.Range{
+			Filename: path,
+			Start:    hcl.Pos{Line: i + 1, Column: match[2] + 1, Byte: lineStart + match[2]},
+			End:      hcl.Pos{Line: i + 1, Column: match[3] + 1, Byte: lineStart + match[3]},
+		})
+	}
+	return names, ranges
+}
+
+// checkPolicyFiles looks for Sentinel policies and OPA/conftest rego files next to the terraform source and adds a
+// warning for each rule found. These can't be converted automatically and need to be ported to a Pulumi CrossGuard
+// policy pack.
+func checkPolicyFiles(source afero.Fs, sourceDirectory string) hcl.Diagnostics {
+	var diagnostics hcl.Diagnostics
+	err := afero.Walk(source, sourceDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// Don't look at the policies of downloaded modules.
+			if info.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var kind string
+		var ruleRegexp *regexp.Regexp
+		switch filepath.Ext(path) {
+		case ".sentinel":
+			kind, ruleRegexp = "Sentinel", sentinelRuleRegexp
+		case ".rego":
+			kind, ruleRegexp = "OPA", regoRuleRegexp
+		default:
+			return nil
+		}
+
+		src, err := afero.ReadFile(source, path)
+		if err != nil {
+			return err
+		}
+		names, ranges := findPolicyRules(path, src, ruleRegexp)
+		for i, name := range names {
+			subject := ranges[i]
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Subject:  &subject,
+				Summary:  "Policy rule not converted",
+				Detail: fmt.Sprintf(
+					"The %s rule %q needs to be ported to a Pulumi CrossGuard policy pack", kind, name),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		diagnostics = append(diagnostics, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("could not search for policy files: %s", err),
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,110 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Operators are converted by writing out the tokens of their converted operands either side of them, so the meaning
+// of the result depends on how it's parsed again. An operand can be converted to an expression of lower precedence
+// than the one it came from, for example a reference to a module output that's converted to the argument the output
+// passes through, which would then bind to the wrong operator. The languages PCL is converted to also differ from
+// terraform: Python chains comparisons, so `a < b == c` means `a < b and b == c`, and its `not` binds more loosely
+// than comparisons. Operands are parenthesized wherever the result could be read differently.
+
+// The precedence of terraform expressions, from the loosest to the tightest binding.
+const (
+	precedenceConditional = iota
+	precedenceOr
+	precedenceAnd
+	precedenceEquality
+	precedenceComparison
+	precedenceAdditive
+	precedenceMultiplicative
+	precedenceUnary
+	precedencePrimary
+)
+
+// binaryPrecedence returns the precedence of a binary operator.
+func binaryPrecedence(op *hclsyntax.Operation) int {
+	switch op {
+	case hclsyntax.OpLogicalOr:
+		return precedenceOr
+	case hclsyntax.OpLogicalAnd:
+		return precedenceAnd
+	case hclsyntax.OpEqual, hclsyntax.OpNotEqual:
+		return precedenceEquality
+	case hclsyntax.OpGreaterThan, hclsyntax.OpGreaterThanOrEqual, hclsyntax.OpLessThan, hclsyntax.OpLessThanOrEqual:
+		return precedenceComparison
+	case hclsyntax.OpAdd, hclsyntax.OpSubtract:
+		return precedenceAdditive
+	case hclsyntax.OpMultiply, hclsyntax.OpDivide, hclsyntax.OpModulo:
+		return precedenceMultiplicative
+	}
+	return precedencePrimary
+}
+
+// expressionPrecedence returns the precedence of the operator at the top of expr, and whether it's a logical not.
+// Anything that isn't an operator, including expressions in parentheses, is primary.
+func expressionPrecedence(expr hclsyntax.Expression) (int, bool) {
+	switch expr := expr.(type) {
+	case *hclsyntax.ConditionalExpr:
+		return precedenceConditional, false
+	case *hclsyntax.BinaryOpExpr:
+		return binaryPrecedence(expr.Op), false
+	case *hclsyntax.UnaryOpExpr:
+		return precedenceUnary, expr.Op == hclsyntax.OpLogicalNot
+	}
+	return precedencePrimary, false
+}
+
+// isComparison returns true for the precedences of operators that Python chains.
+func isComparison(precedence int) bool {
+	return precedence == precedenceEquality || precedence == precedenceComparison
+}
+
+// parenthesizeOperand returns the converted tokens of an operand, in parentheses if they would otherwise be read as
+// something else as an operand of an operator of the given precedence. right is set for the right hand operand of
+// a binary operator, which are all left associative, and the condition and true result of a conditional.
+func parenthesizeOperand(tokens hclwrite.Tokens, precedence int, right bool) hclwrite.Tokens {
+	if len(tokens) <= 1 {
+		return tokens
+	}
+	// Converted tokens don't have the spaces between them that they'd be formatted with, which identifiers and
+	// keywords need to be read apart.
+	var source bytes.Buffer
+	for _, token := range tokens {
+		source.Write(token.Bytes)
+		source.WriteByte(' ')
+	}
+	expr, diags := hclsyntax.ParseExpression(source.Bytes(), "", hcl.InitialPos)
+	if !diags.HasErrors() {
+		operand, not := expressionPrecedence(expr)
+		looser := operand < precedence || (right && operand == precedence)
+		chained := isComparison(precedence) && (isComparison(operand) || not)
+		if !looser && !chained {
+			return tokens
+		}
+	}
+	// Tokens that can't be parsed on their own are parenthesized to be safe, they're still valid in parentheses.
+	result := hclwrite.Tokens{makeToken(hclsyntax.TokenOParen, "(")}
+	result = append(result, tokens...)
+	return append(result, makeToken(hclsyntax.TokenCParen, ")"))
+}
@@ -0,0 +1,222 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+)
+
+var testOperators = []struct {
+	text  string
+	token hclsyntax.TokenType
+	op    *hclsyntax.Operation
+}{
+	{"||", hclsyntax.TokenOr, hclsyntax.OpLogicalOr},
+	{"&&", hclsyntax.TokenAnd, hclsyntax.OpLogicalAnd},
+	{"==", hclsyntax.TokenEqualOp, hclsyntax.OpEqual},
+	{"!=", hclsyntax.TokenNotEqual, hclsyntax.OpNotEqual},
+	{">", hclsyntax.TokenGreaterThan, hclsyntax.OpGreaterThan},
+	{">=", hclsyntax.TokenGreaterThanEq, hclsyntax.OpGreaterThanOrEqual},
+	{"<", hclsyntax.TokenLessThan, hclsyntax.OpLessThan},
+	{"<=", hclsyntax.TokenLessThanEq, hclsyntax.OpLessThanOrEqual},
+	{"+", hclsyntax.TokenPlus, hclsyntax.OpAdd},
+	{"-", hclsyntax.TokenMinus, hclsyntax.OpSubtract},
+	{"*", hclsyntax.TokenStar, hclsyntax.OpMultiply},
+	{"/", hclsyntax.TokenSlash, hclsyntax.OpDivide},
+	{"%", hclsyntax.TokenPercent, hclsyntax.OpModulo},
+}
+
+func parseTokens(t *testing.T, tokens hclwrite.Tokens) hclsyntax.Expression {
+	var source bytes.Buffer
+	for _, token := range tokens {
+		source.Write(token.Bytes)
+		source.WriteByte(' ')
+	}
+	expr, diags := hclsyntax.ParseExpression(source.Bytes(), "", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), "%s: %v", source.String(), diags)
+	return expr
+}
+
+// TestParenthesizeOperand checks that every binary operator written as either operand of every other one is read
+// back as that operand, and that comparisons are never written directly as the operands of comparisons.
+func TestParenthesizeOperand(t *testing.T) {
+	t.Parallel()
+
+	ident := func(name string) hclwrite.Tokens {
+		return hclwrite.Tokens{makeToken(hclsyntax.TokenIdent, name)}
+	}
+
+	for _, parent := range testOperators {
+		for _, child := range testOperators {
+			for _, right := range []bool{false, true} {
+				operand := binary(ident("c"), child.token, child.text, ident("d"))
+				operand = parenthesizeOperand(operand, binaryPrecedence(parent.op), right)
+				var tokens hclwrite.Tokens
+				if right {
+					tokens = binary(ident("a"), parent.token, parent.text, operand)
+				} else {
+					tokens = binary(operand, parent.token, parent.text, ident("b"))
+				}
+
+				expr, ok := parseTokens(t, tokens).(*hclsyntax.BinaryOpExpr)
+				require.True(t, ok)
+				assert.Equal(t, parent.op, expr.Op, "%s", tokens.Bytes())
+				side := expr.LHS
+				if right {
+					side = expr.RHS
+				}
+				_, parenthesized := side.(*hclsyntax.ParenthesesExpr)
+				inner, ok := unwrapParentheses(side).(*hclsyntax.BinaryOpExpr)
+				require.True(t, ok, "%s", tokens.Bytes())
+				assert.Equal(t, child.op, inner.Op, "%s", tokens.Bytes())
+				if isComparison(binaryPrecedence(parent.op)) && isComparison(binaryPrecedence(inner.Op)) {
+					assert.True(t, parenthesized, "%s", tokens.Bytes())
+				}
+			}
+		}
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		t.Parallel()
+
+		for _, child := range testOperators {
+			operand := parenthesizeOperand(
+				binary(ident("a"), child.token, child.text, ident("b")), precedenceUnary, false)
+			tokens := append(hclwrite.Tokens{makeToken(hclsyntax.TokenBang, "!")}, operand...)
+			expr, ok := parseTokens(t, tokens).(*hclsyntax.UnaryOpExpr)
+			require.True(t, ok, "%s", tokens.Bytes())
+			_, ok = expr.Val.(*hclsyntax.ParenthesesExpr)
+			assert.True(t, ok, "%s", tokens.Bytes())
+		}
+
+		// Python's not binds more loosely than comparisons.
+		not := hclwrite.Tokens{makeToken(hclsyntax.TokenBang, "!"), makeToken(hclsyntax.TokenIdent, "a")}
+		assert.Equal(t, "(!a)", string(parenthesizeOperand(not, precedenceEquality, false).Bytes()))
+		assert.Equal(t, "!a", string(parenthesizeOperand(not, precedenceAnd, false).Bytes()))
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		t.Parallel()
+
+		conditional := hclwrite.Tokens{
+			makeToken(hclsyntax.TokenIdent, "a"),
+			makeToken(hclsyntax.TokenQuestion, "?"),
+			makeToken(hclsyntax.TokenIdent, "b"),
+			makeToken(hclsyntax.TokenColon, ":"),
+			makeToken(hclsyntax.TokenIdent, "c"),
+		}
+		assert.Equal(t, "(a?b:c)", string(parenthesizeOperand(conditional, precedenceOr, false).Bytes()))
+		assert.Equal(t, "(a?b:c)", string(parenthesizeOperand(conditional, precedenceConditional, true).Bytes()))
+		assert.Equal(t, "a?b:c", string(parenthesizeOperand(conditional, precedenceConditional, false).Bytes()))
+	})
+}
+
+func TestConvertExpressionPrecedence(t *testing.T) {
+	t.Parallel()
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	info := il.NewMapperProviderInfoSource(mapper)
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"var.a + var.b * var.c", "a + b * c"},
+		{"(var.a + var.b) * var.c", "(a + b) * c"},
+		{"var.a - (var.b - var.c)", "a - (b - c)"},
+		{"var.a % var.b * var.c", "a % b * c"},
+		{"-(var.a % var.b)", "-(a % b)"},
+		{"!var.a == var.b", "(!a) == b"},
+		{"!(var.a == var.b)", "!(a == b)"},
+		{"var.a < var.b == var.c", "(a < b) == c"},
+		{"var.a == var.b != var.c", "(a == b) != c"},
+		{"var.a || var.b && var.c", "a || b && c"},
+		{"var.a ? var.b : var.c ? 1 : 2", "a ? b : c ? 1 : 2"},
+		{"(var.a ? var.b : var.c) ? 1 : 2", "(a ? b : c) ? 1 : 2"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			result, diagnostics := ConvertExpression(tt.input, info)
+			require.Empty(t, diagnostics)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestTranslatePassthroughPrecedence(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"/prog/main.tf": `
+variable "a" {
+    type = number
+}
+
+variable "b" {
+    type = number
+}
+
+module "sum" {
+    source = "./sum"
+    value = var.a + var.b
+}
+
+output "doubled" {
+    value = module.sum.value * 2
+}
+`,
+		"/prog/sum/main.tf": `
+variable "value" {
+    type = number
+}
+
+output "value" {
+    value = var.value
+}
+`,
+	}
+	src := afero.NewMemMapFs()
+	for path, content := range files {
+		err := afero.WriteFile(src, path, []byte(content), 0o600)
+		require.NoError(t, err)
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	main, err := afero.ReadFile(dst, "/main.pp")
+	require.NoError(t, err)
+	pcl := strings.Join(strings.Fields(string(main)), " ")
+	// The argument the output passes through is added before it's doubled, as it was in the module.
+	assert.Contains(t, pcl, `output "doubled" { value = (a + b) * 2 }`)
+}
@@ -0,0 +1,396 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"golang.org/x/exp/maps"
+)
+
+// Provider config attributes that were renamed in newer versions of the terraform provider, and so are missing from
+// the mappings of current Pulumi providers. These are mostly the flags used to run against local emulators like
+// LocalStack, e.g. s3_force_path_style became s3_use_path_style in version 4 of the aws provider.
+var renamedProviderConfig = map[string]map[string]string{
+	"aws": {
+		"s3_force_path_style": "s3UsePathStyle",
+	},
+}
+
+// providerKey returns the key of the provider resource in scopes, e.g. "provider.aws.west" for an aliased provider,
+// or "provider.kubernetes" for a default provider that's converted to a provider resource.
+func providerKey(name, alias string) string {
+	if alias == "" {
+		return "provider." + name
+	}
+	return "provider." + name + "." + alias
+}
+
+// referencesResources returns true if any of the traversals refer to resources, data sources or modules, either
+// directly or through locals. Expressions that do can't be evaluated when converting.
+func referencesResources(scopes *scopes, traversals []hcl.Traversal, seen map[string]bool) bool {
+	for _, traversal := range traversals {
+		switch traversal.RootName() {
+		case "var", "path", "terraform", "count", "each", "self":
+		case "local":
+			if len(traversal) < 2 {
+				continue
+			}
+			attr, ok := traversal[1].(hcl.TraverseAttr)
+			if !ok || seen[attr.Name] {
+				continue
+			}
+			seen[attr.Name] = true
+			root, has := scopes.roots["local."+attr.Name]
+			if has && root.Expression != nil && referencesResources(scopes, (*root.Expression).Variables(), seen) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// dataSourceProvider returns the name of the provider a data source uses.
+func dataSourceProvider(dataResource *configs.Resource) string {
+	if dataResource.ProviderConfigRef != nil {
+		return dataResource.ProviderConfigRef.Name
+	}
+	return impliedProvider(dataResource.Type)
+}
+
+// isExplicitProvider returns true if provider is converted to an explicit provider resource rather than stack
+// config. Aliased providers always are, and so are default providers whose config refers to resources or data
+// sources, like a kubernetes provider set from the endpoint of an EKS cluster, as stack config can't be set from
+// them. Resources that use the provider set the provider resource option to it.
+func isExplicitProvider(scopes *scopes, provider *configs.Provider) bool {
+	return provider.Alias != "" || referencesResources(scopes, bodyReferences(provider.Config), map[string]bool{})
+}
+
+// providerConfigSchemas returns the schemas and infos of the config of the provider, which are nil if the provider
+// has no mapping.
+func providerConfigSchemas(
+	providerInfo *tfbridge.ProviderInfo,
+) (shim.SchemaMap, map[string]*tfbridge.SchemaInfo) {
+	if providerInfo == nil {
+		return nil, nil
+	}
+	var schemas shim.SchemaMap
+	if providerInfo.P != nil {
+		schemas = providerInfo.P.Schema()
+	}
+	return schemas, providerInfo.Config
+}
+
+// providerConfigName returns the Pulumi name of the provider config attribute attrKey, and its info if it has any.
+func providerConfigName(
+	provider, attrKey string, infos map[string]*tfbridge.SchemaInfo,
+) (string, *tfbridge.SchemaInfo) {
+	if info, has := infos[attrKey]; has {
+		if info.Name != "" {
+			return info.Name, info
+		}
+		return camelCaseName(attrKey), info
+	}
+	if renamed, has := renamedProviderConfig[provider][attrKey]; has {
+		return renamed, nil
+	}
+	return camelCaseName(attrKey), nil
+}
+
+// convertProviderResource converts an aliased provider to an explicit provider resource, configured with the
+// attributes and blocks of the provider. Resources that use the aliased provider set the provider resource option
+// to it.
+func convertProviderResource(
+	state *convertState, info il.ProviderInfoSource, scopes *scopes, provider *configs.Provider,
+) (hclwrite.Tokens, *hclwrite.Block, hclwrite.Tokens) {
+	pulumiName := scopes.roots[providerKey(provider.Name, provider.Alias)].Name
+	providerName := baseProvider(provider.Name)
+	providerToken := "pulumi:providers:" + il.GetPulumiProviderName(providerName)
+	block := hclwrite.NewBlock("resource", []string{pulumiName, providerToken})
+	blockBody := block.Body()
+
+	providerInfo, err := state.getProviderInfo(info, providerName)
+	if err != nil {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Subject:  &provider.DeclRange,
+			Severity: hcl.DiagWarning,
+			Summary:  "Failed to get provider info",
+			Detail:   fmt.Sprintf("Failed to get provider info for %q: %v", provider.Name, err),
+		})
+	}
+	schemas, infos := providerConfigSchemas(providerInfo)
+
+	content, connection := splitKubeconfig(providerName, bodyContent(provider.Config), state.kubeconfigTemplate)
+	if root, has := scopes.roots[kubeconfigTemplateKey(provider)]; has {
+		blockBody.SetAttributeRaw("kubeconfig", hclwrite.TokensForIdentifier(root.Name))
+	} else if connection != nil {
+		blockBody.SetAttributeRaw("kubeconfig", convertKubeconfig(state, scopes, connection))
+	}
+	attrs := make([]*hcl.Attribute, 0, len(content.Attributes))
+	for _, attr := range content.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Range.Start.Byte < attrs[j].Range.Start.Byte
+	})
+	for _, attr := range attrs {
+		name, _ := providerConfigName(providerName, attr.Name, infos)
+		blockBody.SetAttributeRaw(name, convertExpression(state, false, scopes, "", attr.Expr))
+	}
+
+	// Blocks are evaluated like stack config, unless they refer to resources, like the kubernetes block of the helm
+	// provider set from an EKS cluster, in which case they're converted to expressions.
+	var staticBlocks hcl.Blocks
+	var types []string
+	byType := map[string][]*hcl.Block{}
+	for _, block := range content.Blocks {
+		if block.Type == "dynamic" || !referencesResources(scopes, bodyReferences(block.Body), map[string]bool{}) {
+			staticBlocks = append(staticBlocks, block)
+			continue
+		}
+		if _, has := byType[block.Type]; !has {
+			types = append(types, block.Type)
+		}
+		byType[block.Type] = append(byType[block.Type], block)
+	}
+	blockTokens := map[string]hclwrite.Tokens{}
+	for _, typ := range types {
+		name, value := convertProviderBlockExpressions(state, scopes, typ, byType[typ], schemas, infos)
+		blockTokens[name] = value
+	}
+
+	blockValues := convertProviderConfigBlocks(state, scopes, provider, staticBlocks, schemas, infos)
+	blockNames := append(maps.Keys(blockValues), maps.Keys(blockTokens)...)
+	sort.Strings(blockNames)
+	for _, name := range blockNames {
+		if value, has := blockTokens[name]; has {
+			blockBody.SetAttributeRaw(name, value)
+		} else {
+			blockBody.SetAttributeValue(name, blockValues[name])
+		}
+	}
+
+	leading, trailing := getTrivia(state.sources, provider.DeclRange, false)
+	return leading, block, trailing
+}
+
+// providerConfigValue returns val as a YAML like value for Pulumi config.
+func providerConfigValue(val cty.Value) (interface{}, error) {
+	// Simplest way to get a cty type into YAML is to roundtrip it through JSON
+	buffer, err := json.Marshal(ctyjson.SimpleJSONValue{Value: val})
+	if err != nil {
+		return nil, err
+	}
+	var yamlValue interface{}
+	err = json.Unmarshal(buffer, &yamlValue)
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue, nil
+}
+
+// convertProviderConfigBlocks returns the config values for the blocks in a provider's config, keyed by their
+// Pulumi names. For example the endpoints block of the aws provider, used to point it at LocalStack:
+//
+//	endpoints {
+//	  s3 = "http://localhost:4566"
+//	}
+//
+// is converted to aws:endpoints with the value [{s3: http://localhost:4566}]. Blocks are converted to a list of
+// objects, or a single object if the provider only allows one of them. Dynamic blocks can't be evaluated so are
+// skipped with a warning.
+//
+// schemas and infos describe the object the blocks are in, starting with the provider's config.
+func convertProviderConfigBlocks(
+	state *convertState, scopes *scopes,
+	provider *configs.Provider, blocks hcl.Blocks,
+	schemas shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo,
+) map[string]cty.Value {
+	// Group the blocks by type, keeping them in source order.
+	var types []string
+	byType := map[string][]*hcl.Block{}
+	for _, block := range blocks {
+		if block.Type == "dynamic" {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Subject:  &block.DefRange,
+				Severity: hcl.DiagWarning,
+				Summary:  "Provider config not supported",
+				Detail: fmt.Sprintf("Blocks in provider config are not supported, ignoring %s:%s",
+					provider.Name, block.Type),
+			})
+			continue
+		}
+		if _, has := byType[block.Type]; !has {
+			types = append(types, block.Type)
+		}
+		byType[block.Type] = append(byType[block.Type], block)
+	}
+
+	values := make(map[string]cty.Value, len(types))
+	for _, typ := range types {
+		name, value := convertProviderConfigBlockList(state, scopes, provider, typ, byType[typ], schemas, infos)
+		values[name] = value
+	}
+	return values
+}
+
+// convertProviderConfigBlockList returns the Pulumi name and value of the blocks of the given type, which are
+// described by the schemas and infos of the object they're in.
+func convertProviderConfigBlockList(
+	state *convertState, scopes *scopes,
+	provider *configs.Provider, typ string, blocks []*hcl.Block,
+	schemas shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo,
+) (string, cty.Value) {
+	name, elemSchemas, elemInfos, maxItemsOne := providerConfigBlockSchema(typ, schemas, infos)
+
+	objects := make([]cty.Value, 0, len(blocks))
+	for _, block := range blocks {
+		content := bodyContent(block.Body)
+		attributes := make(map[string]cty.Value, len(content.Attributes))
+		// Attributes are converted in a stable order so diagnostics are reported in the same order
+		attrKeys := make([]string, 0, len(content.Attributes))
+		for key := range content.Attributes {
+			attrKeys = append(attrKeys, key)
+		}
+		sort.Strings(attrKeys)
+		for _, key := range attrKeys {
+			attr := content.Attributes[key]
+			attrName, _ := providerConfigName("", key, elemInfos)
+
+			val, diags := scopes.EvalExpr(attr.Expr)
+			if diags.HasErrors() {
+				state.appendDiagnostic(&hcl.Diagnostic{
+					Subject:  &provider.DeclRange,
+					Severity: hcl.DiagWarning,
+					Summary:  "Failed to evaluate provider config",
+					Detail:   fmt.Sprintf("Could not evaluate expression for %s:%s.%s", provider.Name, typ, key),
+				})
+				// If we couldn't eval the config we'll emit an obvious TODO to the config for it
+				val = cty.StringVal("TODO: " + state.sourceCode(attr.Expr.Range()))
+			}
+			attributes[attrName] = val
+		}
+
+		nested := convertProviderConfigBlocks(state, scopes, provider, content.Blocks, elemSchemas, elemInfos)
+		for nestedName, value := range nested {
+			attributes[nestedName] = value
+		}
+
+		objects = append(objects, cty.ObjectVal(attributes))
+	}
+
+	if maxItemsOne && len(objects) == 1 {
+		return name, objects[0]
+	}
+	return name, cty.TupleVal(objects)
+}
+
+// providerConfigBlockSchema returns the Pulumi name of the blocks of the given type in a provider's config, the
+// schemas and infos of their attributes, and whether they're a single object rather than a list, from the schemas
+// and infos of the object they're in.
+func providerConfigBlockSchema(typ string, schemas shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) (
+	string, shim.SchemaMap, map[string]*tfbridge.SchemaInfo, bool,
+) {
+	name := camelCaseName(typ)
+	var schema shim.Schema
+	var info *tfbridge.SchemaInfo
+	if schemas != nil {
+		schema = schemas.Get(typ)
+	}
+	if infos != nil {
+		info = infos[typ]
+	}
+	if info != nil && info.Name != "" {
+		name = info.Name
+	}
+
+	var elemSchemas shim.SchemaMap
+	if schema != nil {
+		if elem, ok := schema.Elem().(shim.Resource); ok {
+			elemSchemas = elem.Schema()
+		}
+	}
+	var elemInfos map[string]*tfbridge.SchemaInfo
+	if info != nil {
+		elemInfos = info.Fields
+	}
+
+	maxItemsOne := schema != nil && schema.MaxItems() == 1
+	if info != nil && info.MaxItemsOne != nil {
+		maxItemsOne = *info.MaxItemsOne
+	}
+	return name, elemSchemas, elemInfos, maxItemsOne
+}
+
+// convertProviderBlockExpressions returns the Pulumi name and value of the blocks of the given type in the config of
+// an explicit provider resource, converting their attributes to expressions rather than evaluating them.
+func convertProviderBlockExpressions(
+	state *convertState, scopes *scopes, typ string, blocks []*hcl.Block,
+	schemas shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo,
+) (string, hclwrite.Tokens) {
+	name, elemSchemas, elemInfos, maxItemsOne := providerConfigBlockSchema(typ, schemas, infos)
+
+	objects := make([]hclwrite.Tokens, 0, len(blocks))
+	for _, block := range blocks {
+		content := bodyContent(block.Body)
+		var attributes bodyAttrsTokens
+		for _, attr := range content.Attributes {
+			attrName, _ := providerConfigName("", attr.Name, elemInfos)
+			attributes = append(attributes, bodyAttrTokens{
+				Line:  attr.Range.Start.Line,
+				Name:  attrName,
+				Value: convertExpression(state, false, scopes, "", attr.Expr),
+			})
+		}
+
+		var types []string
+		byType := map[string][]*hcl.Block{}
+		for _, nested := range content.Blocks {
+			if _, has := byType[nested.Type]; !has {
+				types = append(types, nested.Type)
+			}
+			byType[nested.Type] = append(byType[nested.Type], nested)
+		}
+		for _, nestedType := range types {
+			nestedName, value := convertProviderBlockExpressions(
+				state, scopes, nestedType, byType[nestedType], elemSchemas, elemInfos)
+			attributes = append(attributes, bodyAttrTokens{
+				Line:  byType[nestedType][0].DefRange.Start.Line,
+				Name:  nestedName,
+				Value: value,
+			})
+		}
+
+		sort.Stable(attributes)
+		objects = append(objects, tokensForObject(attributes))
+	}
+
+	if maxItemsOne && len(objects) == 1 {
+		return name, objects[0]
+	}
+	return name, hclwrite.TokensForTuple(objects)
+}
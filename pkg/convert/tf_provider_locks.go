@@ -0,0 +1,130 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/terraform/pkg/addrs"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// providerLockFilename is the name of the dependency lockfile `terraform init` writes next to the root module.
+const providerLockFilename = ".terraform.lock.hcl"
+
+var providerLockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "provider", LabelNames: []string{"source"}}},
+}
+
+var providerLockBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "version", Required: true}},
+}
+
+// readProviderLocks reads the exact provider versions from the dependency lockfile at path, keyed by the provider's
+// type name (e.g. "aws"). It returns nil if the lockfile doesn't exist.
+func readProviderLocks(source afero.Fs, path string) (map[string]string, hcl.Diagnostics) {
+	src, err := afero.ReadFile(source, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to read provider lockfile",
+			Detail:   fmt.Sprintf("Failed to read provider lockfile %s: %v", path, err),
+		}}
+	}
+
+	file, diagnostics := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	// The lockfile may gain new attributes and blocks in later versions of terraform, we only need the versions.
+	content, _, diags := file.Body.PartialContent(providerLockSchema)
+	diagnostics = append(diagnostics, diags...)
+
+	versions := make(map[string]string)
+	for _, block := range content.Blocks {
+		provider, sourceDiags := addrs.ParseProviderSourceString(block.Labels[0])
+		if sourceDiags.HasErrors() {
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Invalid provider source in lockfile",
+				Detail:   fmt.Sprintf("Failed to parse provider source %q: %s", block.Labels[0], sourceDiags.Err()),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+
+		blockContent, _, diags := block.Body.PartialContent(providerLockBlockSchema)
+		diagnostics = append(diagnostics, diags...)
+		attr, has := blockContent.Attributes["version"]
+		if !has {
+			continue
+		}
+		value, diags := attr.Expr.Value(nil)
+		diagnostics = append(diagnostics, diags...)
+		if diags.HasErrors() || value.IsNull() || !value.Type().Equals(cty.String) {
+			continue
+		}
+		versions[provider.Type] = value.AsString()
+	}
+	return versions, diagnostics
+}
+
+// getProviderInfo returns the mapping for the terraform provider, at the version locked in the dependency lockfile
+// if there is one. If the mapping is for exactly the locked version the Pulumi version of the provider is recorded
+// to pin resources to, otherwise a warning is reported that the program may convert differently to what was
+// deployed.
+func (state *convertState) getProviderInfo(
+	info il.ProviderInfoSource, provider string,
+) (*tfbridge.ProviderInfo, error) {
+	locked := state.providerLocks[provider]
+	state.logger.Debug("looking up provider mapping", "provider", provider, "version", locked)
+	start := time.Now()
+	providerInfo, err := info.GetProviderInfo("", "", provider, locked)
+	if err != nil {
+		state.logger.Warn("failed to look up provider mapping", "provider", provider, "error", err)
+	} else {
+		state.logger.Debug("looked up provider mapping", "provider", provider, "duration", time.Since(start))
+	}
+	if err != nil || providerInfo == nil || locked == "" || providerInfo.TFProviderVersion == "" {
+		return providerInfo, err
+	}
+	if _, checked := state.pinnedVersions[provider]; checked {
+		return providerInfo, nil
+	}
+
+	if providerInfo.TFProviderVersion == locked {
+		state.pinnedVersions[provider] = providerInfo.Version
+	} else {
+		state.pinnedVersions[provider] = ""
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Provider version differs from lockfile",
+			Detail: fmt.Sprintf("The mapping for provider %q is for version %s of the terraform provider, but "+
+				"version %s is locked in %s. Resources may be converted differently to how they were deployed.",
+				provider, providerInfo.TFProviderVersion, locked, providerLockFilename),
+		})
+	}
+	return providerInfo, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+)
+
+const simpleLockfile = `# This file is maintained automatically by "terraform init".
+provider "registry.terraform.io/pulumi/simple" {
+  version     = "%s"
+  constraints = "~> 1.0"
+  hashes = [
+    "h1:aaaa",
+  ]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.5.1"
+}
+`
+
+// versionedProviderInfoSource returns the mappings of info as if they were for version 0.1.0 of the Pulumi provider,
+// bridging version 1.2.3 of the terraform provider.
+type versionedProviderInfoSource struct {
+	info il.ProviderInfoSource
+}
+
+func (s versionedProviderInfoSource) GetProviderInfo(
+	registryName, namespace, name, version string,
+) (*tfbridge.ProviderInfo, error) {
+	info, err := s.info.GetProviderInfo(registryName, namespace, name, version)
+	if err != nil || info == nil {
+		return info, err
+	}
+	versioned := *info
+	versioned.Version = "0.1.0"
+	versioned.TFProviderVersion = "1.2.3"
+	return &versioned, nil
+}
+
+func TestReadProviderLocks(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/.terraform.lock.hcl", []byte(fmt.Sprintf(simpleLockfile, "1.2.3")), 0o600)
+	require.NoError(t, err)
+
+	versions, diagnostics := readProviderLocks(src, "/prog/.terraform.lock.hcl")
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	assert.Equal(t, map[string]string{"simple": "1.2.3", "random": "3.5.1"}, versions)
+
+	versions, diagnostics = readProviderLocks(src, "/other/.terraform.lock.hcl")
+	assert.Empty(t, diagnostics)
+	assert.Nil(t, versions)
+}
+
+func TestTranslateWithProviderLockfile(t *testing.T) {
+	t.Parallel()
+
+	program := []byte(`
+resource "simple_resource" "main" {
+  input_one = "hello"
+}
+`)
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	info := versionedProviderInfoSource{info: il.NewMapperProviderInfoSource(mapper)}
+
+	t.Run("matching version", func(t *testing.T) {
+		t.Parallel()
+
+		src := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(src, "/prog/main.tf", program, 0o600))
+		require.NoError(t, afero.WriteFile(src, "/prog/.terraform.lock.hcl",
+			[]byte(fmt.Sprintf(simpleLockfile, "1.2.3")), 0o600))
+
+		dst := afero.NewMemMapFs()
+		diagnostics := TranslateModule(src, "/prog", dst, info)
+		require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+		assert.Empty(t, diagnostics)
+
+		pcl, err := afero.ReadFile(dst, "/main.pp")
+		require.NoError(t, err)
+		assert.Equal(t, `resource "main" "simple:index:resource" {
+  options {
+    version = "0.1.0"
+  }
+  inputOne = "hello"
+}
+`, string(pcl))
+	})
+
+	t.Run("different version", func(t *testing.T) {
+		t.Parallel()
+
+		src := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(src, "/prog/main.tf", program, 0o600))
+		require.NoError(t, afero.WriteFile(src, "/prog/.terraform.lock.hcl",
+			[]byte(fmt.Sprintf(simpleLockfile, "1.0.0")), 0o600))
+
+		dst := afero.NewMemMapFs()
+		diagnostics := TranslateModule(src, "/prog", dst, info)
+		require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+		require.Len(t, diagnostics, 1)
+		assert.Equal(t, "Provider version differs from lockfile", diagnostics[0].Summary)
+		assert.Contains(t, diagnostics[0].Detail, "version 1.2.3 of the terraform provider")
+
+		pcl, err := afero.ReadFile(dst, "/main.pp")
+		require.NoError(t, err)
+		assert.Equal(t, `resource "main" "simple:index:resource" {
+  inputOne = "hello"
+}
+`, string(pcl))
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// Terraform providers that are variants of another provider, and are converted to the Pulumi provider of that
+// provider. The Pulumi gcp provider is built from google-beta, so it has the beta fields and resources as well as the
+// generally available ones, and programs often use both providers for the same resources.
+var providerVariants = map[string]string{
+	"google-beta": "google",
+}
+
+// baseProvider returns the name of the terraform provider that name is converted as, e.g. "google" for
+// "google-beta".
+func baseProvider(name string) string {
+	if base, has := providerVariants[name]; has {
+		return base
+	}
+	return name
+}
+
+// setProviderConfig sets key to value in the stack config cfg. Variants of a provider share the config of the
+// provider they're converted to, so if they both set a key the first value is kept, and a warning is reported if
+// they differ, e.g. if the google and google-beta providers are given different projects.
+func setProviderConfig(state *convertState, cfg map[string]workspace.ProjectConfigType,
+	provider *configs.Provider, key string, value interface{},
+) {
+	if existing, has := cfg[key]; has {
+		if !reflect.DeepEqual(existing.Value, value) {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Conflicting provider config",
+				Detail: fmt.Sprintf("The %s provider sets %s to a different value than another provider that's "+
+					"converted to the same Pulumi provider, the first value is kept", provider.Name, key),
+				Subject: provider.DeclRange.Ptr(),
+			})
+		}
+		return
+	}
+	cfg[key] = workspace.ProjectConfigType{Value: value}
+}
+
+// providerVariantComment returns comment lines to write above a converted resource that used a variant of its
+// provider, like google-beta, saying which provider it used. Fields the resource sets that the mapping of the
+// provider it's converted to doesn't know about are listed, and reported as a warning, as they may be beta only
+// fields that the Pulumi provider doesn't have yet. It returns nil if the resource uses its default provider.
+func providerVariantComment(state *convertState, resource *configs.Resource, root PathInfo) hclwrite.Tokens {
+	ref := resource.ProviderConfigRef
+	if ref == nil || baseProvider(ref.Name) == ref.Name {
+		return nil
+	}
+
+	var unknown []string
+	if root.Resource != nil {
+		schemas := root.Resource.Schema()
+		for _, name := range resourceFields(resource) {
+			if _, has := schemas.GetOk(name); !has {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("This resource used the %s provider and is converted to use the %s provider.",
+			ref.Name, baseProvider(ref.Name)),
+	}
+	if len(unknown) > 0 {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Fields not supported by provider",
+			Detail: fmt.Sprintf("%s.%s used the %s provider and sets %s, which the %s provider doesn't support",
+				resource.Type, resource.Name, ref.Name, strings.Join(unknown, ", "), baseProvider(ref.Name)),
+			Subject: ref.NameRange.Ptr(),
+		})
+		lines = append(lines, fmt.Sprintf("The %s provider doesn't support these fields, which may be beta "+
+			"only: %s.", baseProvider(ref.Name), strings.Join(unknown, ", ")))
+	}
+
+	tokens := hclwrite.Tokens{}
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
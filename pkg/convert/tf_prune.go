@@ -0,0 +1,125 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// WithRemoveUnused removes locals, data sources and variables that aren't used by any resource, module, provider or
+// output, reporting a warning for each one removed. Modules vendored from elsewhere often carry a lot of these that
+// just clutter the converted program.
+//
+// Variables are only removed from the root program, the variables of modules are the inputs of their components so
+// are always kept. To keep the variables of the root program too use WithKeepVariables.
+func WithRemoveUnused() TranslateOption {
+	return func(o *translateOptions) {
+		o.removeUnused = true
+	}
+}
+
+// WithKeepVariables guarantees every variable is converted to config, even if it's unused and WithRemoveUnused is
+// set. This is for programs whose variables are a contract with the stacks that configure them, so config that is
+// set shouldn't become an error because the program stopped using it.
+func WithKeepVariables() TranslateOption {
+	return func(o *translateOptions) {
+		o.keepVariables = true
+	}
+}
+
+// itemKey returns the key that references to item use, e.g. "local.name" or "data.type.name", or "" for items that
+// can't be referenced.
+func (item terraformItem) itemKey() string {
+	switch {
+	case item.variable != nil:
+		return "var." + item.variable.Name
+	case item.local != nil:
+		return "local." + item.local.Name
+	case item.data != nil:
+		return "data." + item.data.Type + "." + item.data.Name
+	case item.moduleCall != nil:
+		return "module." + item.moduleCall.Name
+	case item.resource != nil:
+		return item.resource.Type + "." + item.resource.Name
+	}
+	return ""
+}
+
+// removeUnusedItems returns items without the locals, data sources and, if removeVariables is set, variables that
+// nothing else in items uses.
+func removeUnusedItems(state *convertState, items terraformItems, removeVariables bool) terraformItems {
+	removable := func(item terraformItem) bool {
+		return item.local != nil || item.data != nil || (removeVariables && item.variable != nil)
+	}
+
+	byKey := make(map[string]terraformItem, len(items))
+	for _, item := range items {
+		if key := item.itemKey(); key != "" {
+			byKey[key] = item
+		}
+	}
+
+	// Walk everything used from the items that are always kept.
+	used := map[string]bool{}
+	var queue []terraformItem
+	for _, item := range items {
+		if !removable(item) {
+			queue = append(queue, item)
+		}
+	}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		for _, traversal := range item.references() {
+			key := referenceKey(traversal)
+			if used[key] {
+				continue
+			}
+			if dependency, has := byKey[key]; has {
+				used[key] = true
+				queue = append(queue, dependency)
+			}
+		}
+	}
+
+	kept := make(terraformItems, 0, len(items))
+	for _, item := range items {
+		if !removable(item) || used[item.itemKey()] {
+			kept = append(kept, item)
+			continue
+		}
+
+		var kind string
+		var subject hcl.Range
+		switch {
+		case item.variable != nil:
+			kind, subject = "variable", item.variable.DeclRange
+		case item.local != nil:
+			kind, subject = "local", item.local.DeclRange
+		case item.data != nil:
+			kind, subject = "data source", item.data.DeclRange
+		}
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Removed unused " + kind,
+			Detail: fmt.Sprintf("%s%s is not used by any resource, module, provider or output so has been removed",
+				state.modulePrefix, item.itemKey()),
+			Subject: &subject,
+		})
+	}
+	return kept
+}
@@ -0,0 +1,88 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// convertItemSafely calls convert to convert item into body, recovering from any panic so that one item the
+// converter can't handle doesn't stop the rest of the program being converted. A panic is reported as a warning with
+// its stack trace, so it can be reported as a bug, and the item is replaced by a notImplemented placeholder.
+func convertItemSafely(
+	state *convertState, scopes *scopes, item terraformItem, body *hclwrite.Body, convert func(),
+) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		// The panic may have happened part way through converting an expression, so reset anything that's only
+		// set while converting one.
+		state.rewriteObjectKeys = true
+		state.inComponentArguments = false
+		scopes.locals = scopes.locals[:0]
+		scopes.countIndex, scopes.countList, scopes.countValue = nil, nil, nil
+		scopes.eachKey, scopes.eachValue = nil, nil
+		scopes.useVariableDefaults = false
+
+		address := state.modulePrefix + item.itemKey()
+		if item.itemKey() == "" {
+			address = state.sourceCode(item.DeclRange())
+		}
+		stack := string(debug.Stack())
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Internal error",
+			Detail: fmt.Sprintf("The converter failed converting %s, so it has been replaced with notImplemented. "+
+				"Please report this as a bug: %v\n\n%s", address, r, stack),
+			Subject: item.DeclRange().Ptr(),
+		})
+		state.recordInternalError(item, address, fmt.Sprint(r), stack)
+		state.countNotImplemented("internal error")
+		appendPlaceholder(state, scopes, item, body)
+	}()
+	convert()
+}
+
+// appendPlaceholder appends a placeholder for an item that couldn't be converted to body. Locals, data sources and
+// outputs are converted to notImplemented so references to them still bind, anything else is left as a comment.
+func appendPlaceholder(state *convertState, scopes *scopes, item terraformItem, body *hclwrite.Body) {
+	switch {
+	case item.local != nil:
+		name := scopes.roots["local."+item.local.Name].Name
+		body.SetAttributeRaw(name, notImplemented(state, item.local.Expr.Range()))
+	case item.data != nil && !isRemoteState(item.data):
+		name := scopes.roots["data."+item.data.Type+"."+item.data.Name].Name
+		body.SetAttributeRaw(name, notImplemented(state, item.data.DeclRange))
+	case item.output != nil:
+		block := body.AppendNewBlock("output", []string{scopes.roots["output."+item.output.Name].Name})
+		block.Body().SetAttributeRaw("value", notImplemented(state, item.output.Expr.Range()))
+	default:
+		// The declaration is quoted as it's written, the tokens of sourceCode lose their spacing.
+		declRange := item.DeclRange()
+		declaration := declRange.SliceBytes(state.sources[declRange.Filename])
+		body.AppendUnstructuredTokens(hclwrite.Tokens{&hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte(fmt.Sprintf("// The converter failed converting %s\n", declaration)),
+		}})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// std has no regex or regexall, but its replace takes the same RE2 patterns as terraform when the search is
+// wrapped in slashes, and can write any of the pattern's capture groups in the replacement. A search that matches
+// the whole string around the first match of the pattern replaces the string with just the group asked for, so
+// regex("arn:aws:iam::(\\d+):role/(.*)", var.arn) is converted to a tuple of two replaces of arn, with the search
+// "/^(?s:.*?)(?:arn:aws:iam::(\\d+):role/(.*))(?s:.*)$/" and the replacements "${1}" and "${2}".
+//
+// A string that doesn't match is left as it is, so replacing it with two different strings only gives different
+// results if it matches, which is how can(regex(...)) and length(regexall(...)) > 0 are converted. Terraform fails
+// instead of returning strings that don't match, and returns null rather than "" for groups that don't match.
+
+// staticRegex returns the pattern expr evaluates to, compiled, if it's known at conversion time.
+func staticRegex(scopes *scopes, expr hclsyntax.Expression) (*regexp.Regexp, bool) {
+	if !isStaticExpr(scopes, expr, map[string]bool{}) {
+		return nil, false
+	}
+	value, diags := scopes.EvalExpr(expr)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() || value.Type() != cty.String {
+		return nil, false
+	}
+	re, err := regexp.Compile(value.AsString())
+	if err != nil {
+		return nil, false
+	}
+	return re, true
+}
+
+// regexSearch returns the search of a std replace that matches the whole of any string with a match of re,
+// keeping re's capture groups. If re has none the match is captured as group 1.
+func regexSearch(re *regexp.Regexp) string {
+	pattern := "(?:" + re.String() + ")"
+	if re.NumSubexp() == 0 {
+		pattern = "(" + re.String() + ")"
+	}
+	// The (?s) flag is only set outside the pattern, which keeps its own flags as the group scopes them.
+	return "/^(?s:.*?)" + pattern + "(?s:.*)$/"
+}
+
+// convertRegexReplace returns the tokens of a std replace of text, whose search matches the whole of text if it
+// contains a match of re, with replacement.
+func convertRegexReplace(
+	state *convertState, scopes *scopes, re *regexp.Regexp, text hclsyntax.Expression, replacement string,
+) hclwrite.Tokens {
+	rng := emptyRange(text.Range())
+	call := &hclsyntax.FunctionCallExpr{
+		Name: "replace",
+		Args: []hclsyntax.Expression{
+			text,
+			&hclsyntax.LiteralValueExpr{Val: cty.StringVal(regexSearch(re)), SrcRange: rng},
+			&hclsyntax.LiteralValueExpr{Val: cty.StringVal(replacement), SrcRange: rng},
+		},
+		NameRange:       rng,
+		OpenParenRange:  rng,
+		CloseParenRange: rng,
+	}
+	return convertExpression(state, false, scopes, "", call)
+}
+
+// convertRegexMatch returns a comparison that's true if text contains a match of re, or false if negate is set.
+func convertRegexMatch(
+	state *convertState, scopes *scopes, re *regexp.Regexp, text hclsyntax.Expression, negate bool,
+) hclwrite.Tokens {
+	op, opText := hclsyntax.TokenNotEqual, "!="
+	if negate {
+		op, opText = hclsyntax.TokenEqualOp, "=="
+	}
+	return parenthesize(binary(
+		convertRegexReplace(state, scopes, re, text, "0"), op, opText,
+		convertRegexReplace(state, scopes, re, text, "1")))
+}
+
+// matchRegexCall returns the compiled pattern and the text of call if it's a regex or regexall call, named name,
+// whose pattern is known at conversion time.
+func matchRegexCall(
+	scopes *scopes, expr hclsyntax.Expression, name string,
+) (*regexp.Regexp, hclsyntax.Expression, bool) {
+	call, ok := unwrapParentheses(expr).(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != name || len(call.Args) != 2 || call.ExpandFinal {
+		return nil, nil, false
+	}
+	re, ok := staticRegex(scopes, call.Args[0])
+	if !ok {
+		return nil, nil, false
+	}
+	return re, call.Args[1], true
+}
+
+// convertRegexFunction returns call, a regex or regexall call, converted to its value if its arguments are known at
+// conversion time, or for regex with a known pattern to std replaces of each capture group. It returns false if the
+// call can't be converted like this.
+func convertRegexFunction(
+	state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if (call.Name != "regex" && call.Name != "regexall") || len(call.Args) != 2 || call.ExpandFinal {
+		return nil, false
+	}
+	if isStaticExpr(scopes, call, map[string]bool{}) {
+		value, diags := scopes.EvalExpr(call)
+		if diags.HasErrors() || !value.IsWhollyKnown() {
+			// A string that doesn't match is an error in terraform too.
+			return nil, false
+		}
+		return hclwrite.TokensForValue(value), true
+	}
+
+	re, text, ok := matchRegexCall(scopes, call, "regex")
+	if !ok {
+		return nil, false
+	}
+	var named []string
+	unnamed := 0
+	for _, name := range re.SubexpNames()[1:] {
+		if name == "" {
+			unnamed++
+		} else {
+			named = append(named, name)
+		}
+	}
+	switch {
+	case len(named) > 0 && unnamed > 0:
+		// Terraform doesn't allow mixing named and unnamed groups.
+		return nil, false
+	case len(named) > 0:
+		items := make([]hclwrite.ObjectAttrTokens, len(named))
+		for i, name := range named {
+			items[i] = hclwrite.ObjectAttrTokens{
+				Name:  hclwrite.TokensForValue(cty.StringVal(name)),
+				Value: convertRegexReplace(state, scopes, re, text, "${"+name+"}"),
+			}
+		}
+		return hclwrite.TokensForObject(items), true
+	case unnamed > 0:
+		elems := make([]hclwrite.Tokens, unnamed)
+		for i := range elems {
+			elems[i] = convertRegexReplace(state, scopes, re, text, "${"+strconv.Itoa(i+1)+"}")
+		}
+		return multilineTuple(elems), true
+	}
+	return convertRegexReplace(state, scopes, re, text, "${1}"), true
+}
+
+// convertRegexallMatch returns expr converted to whether a string matches a pattern if it compares the number of
+// matches of regexall to 0, e.g. length(regexall("^prod-", var.name)) > 0. It returns false otherwise.
+func convertRegexallMatch(
+	state *convertState, scopes *scopes, expr *hclsyntax.BinaryOpExpr,
+) (hclwrite.Tokens, bool) {
+	var negate bool
+	switch expr.Op {
+	case hclsyntax.OpGreaterThan, hclsyntax.OpNotEqual:
+	case hclsyntax.OpEqual, hclsyntax.OpLessThanOrEqual:
+		negate = true
+	default:
+		return nil, false
+	}
+	length, ok := unwrapParentheses(expr.LHS).(*hclsyntax.FunctionCallExpr)
+	if !ok || length.Name != "length" || len(length.Args) != 1 || length.ExpandFinal ||
+		!isNumberLiteral(expr.RHS, 0) {
+		return nil, false
+	}
+	if isStaticExpr(scopes, length, map[string]bool{}) {
+		// The matches are known, so are left to be folded.
+		return nil, false
+	}
+	re, text, ok := matchRegexCall(scopes, length.Args[0], "regexall")
+	if !ok {
+		return nil, false
+	}
+	return convertRegexMatch(state, scopes, re, text, negate), true
+}
@@ -0,0 +1,71 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// TestRegexSearch checks that std replaces with the search regex is converted to give the same results as terraform's
+// regex, using the same RE2 implementation std does.
+func TestRegexSearch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		text    string
+	}{
+		{`[a-z]+`, "53453453.345345aaabbbccc23454"},
+		{`(\d\d\d\d)-(\d\d)`, "from 2019-02 to 2020-03"},
+		{`^(?:(?P<scheme>[^:/?#]+):)?(?://(?P<authority>[^/?#]*))?`, "https://terraform.io/docs/"},
+		{`(?i)role/(.*)`, "arn:aws:iam::123456789012:ROLE/admin\nsecond line"},
+		// The pattern's . doesn't match newlines, even though the search around it does.
+		{`a.b`, "a\nb axb"},
+		{`^b`, "ab b"},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(tt.pattern)
+		search := regexSearch(re)
+		replace := regexp.MustCompile(search[1 : len(search)-1])
+
+		expected, err := stdlib.Regex(cty.StringVal(tt.pattern), cty.StringVal(tt.text))
+		if err != nil {
+			// Strings that don't match are left as they are.
+			assert.Equal(t, tt.text, replace.ReplaceAllString(tt.text, "0"), tt.pattern)
+			continue
+		}
+		switch {
+		case expected.Type() == cty.String:
+			assert.Equal(t, expected.AsString(), replace.ReplaceAllString(tt.text, "${1}"), tt.pattern)
+		case expected.Type().IsTupleType():
+			for i, group := range expected.AsValueSlice() {
+				assert.Equal(t, group.AsString(), replace.ReplaceAllString(tt.text, fmt.Sprintf("${%d}", i+1)),
+					tt.pattern)
+			}
+		default:
+			require.True(t, expected.Type().IsObjectType())
+			for name, group := range expected.AsValueMap() {
+				assert.Equal(t, group.AsString(), replace.ReplaceAllString(tt.text, "${"+name+"}"), tt.pattern)
+			}
+		}
+	}
+}
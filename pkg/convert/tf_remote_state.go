@@ -0,0 +1,166 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StackDependency is a dependency of the converted program on another stack.
+type StackDependency struct {
+	// The kind of dependency, "remote_state" for a terraform_remote_state data source converted to a stack
+	// reference, or "run_trigger" for a Terraform Cloud run trigger.
+	Kind string `json:"kind"`
+	// The address of the terraform_remote_state data source, including the module it's in, or the
+	// "organization/workspace" of the workspace whose runs trigger the converted workspace.
+	Source string `json:"source"`
+	// The name of the stack that's depended on. If this depends on the program's config, such as the environment
+	// being deployed, it's the template of the name in the converted program, e.g. "acme/network-${environment}".
+	Stack string `json:"stack"`
+}
+
+// WithStackDependencies calls the given function with the stacks the converted program depends on once the
+// conversion is finished. Together with the run triggers of a Terraform Cloud workspace this gives the order stacks
+// need to be deployed in.
+func WithStackDependencies(callback func([]StackDependency)) TranslateOption {
+	return func(o *translateOptions) {
+		o.onStackDependencies = callback
+	}
+}
+
+// isRemoteState returns true if the data source is a terraform_remote_state that can be converted to a stack
+// reference. Remote state with count or for_each is converted to invokes like any other data source.
+func isRemoteState(dataResource *configs.Resource) bool {
+	return dataResource.Type == "terraform_remote_state" && dataResource.Count == nil && dataResource.ForEach == nil
+}
+
+// remoteStateStackName returns the parts of the name of the stack that a terraform_remote_state data source reads
+// from, as a template. Terraform Cloud workspaces, read with the "remote" backend, are assumed to have been converted
+// to a project and stack both named after the workspace. The organization and workspace can be expressions, such as
+// "network-${var.environment}", so that a chain of workspaces per environment is converted to stack references that
+// follow the stack being deployed. Other backends don't name their state, so false is returned.
+func remoteStateStackName(dataResource *configs.Resource) ([]hclsyntax.Expression, bool) {
+	backend, ok := staticStringAttribute(dataResource.Config, "backend")
+	if !ok || backend != "remote" {
+		return nil, false
+	}
+
+	attr, has := bodyContent(dataResource.Config).Attributes["config"]
+	if !has {
+		return nil, false
+	}
+	organization := objectConsItem(attr.Expr, "organization")
+	name := objectConsItem(objectConsItem(attr.Expr, "workspaces"), "name")
+	if organization == nil || name == nil {
+		return nil, false
+	}
+
+	var parts []hclsyntax.Expression
+	for _, part := range []hclsyntax.Expression{organization, nil, name, nil, name} {
+		if part == nil {
+			parts = append(parts, &hclsyntax.LiteralValueExpr{Val: cty.StringVal("/")})
+		} else if template, ok := part.(*hclsyntax.TemplateExpr); ok {
+			parts = append(parts, template.Parts...)
+		} else {
+			parts = append(parts, part)
+		}
+	}
+	return parts, true
+}
+
+// objectConsItem returns the value of the named item of expr if it's an object constructor, or nil.
+func objectConsItem(expr hcl.Expression, name string) hclsyntax.Expression {
+	object, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil
+	}
+	for _, item := range object.Items {
+		if hcl.ExprAsKeyword(item.KeyExpr) == name {
+			return item.ValueExpr
+		}
+	}
+	return nil
+}
+
+// convertRemoteState converts a terraform_remote_state data source to a stack reference. The outputs of the data
+// source are the outputs of the stack reference, and Pulumi style output names are used to match the names of the
+// outputs of the converted stack.
+func convertRemoteState(state *convertState, scopes *scopes,
+	dataResource *configs.Resource,
+) (hclwrite.Tokens, *hclwrite.Block, hclwrite.Tokens) {
+	path := "data." + dataResource.Type + "." + dataResource.Name
+	pulumiName := scopes.roots[path].Name
+
+	// The stack name is written as a literal string where it can be, which is also what's recorded in the stack
+	// dependencies. Otherwise that's the template of the name in the converted program.
+	var name hclwrite.Tokens
+	stack := pulumiName
+	if parts, ok := remoteStateStackName(dataResource); ok {
+		literal := &strings.Builder{}
+		for _, part := range parts {
+			if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+				literal.WriteString(lit.Val.AsString())
+				continue
+			}
+			name = convertTemplateExpr(state, scopes, "", &hclsyntax.TemplateExpr{Parts: parts})
+			literal = nil
+			break
+		}
+		if literal != nil {
+			stack = literal.String()
+		} else {
+			stack = strings.Trim(string(name.Bytes()), `"`)
+		}
+	} else {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Unknown stack for remote state",
+			Detail: fmt.Sprintf("%s has been converted to a reference to the stack %q, change this to the "+
+				"name of the stack that its state was converted to", path, stack),
+			Subject: dataResource.DeclRange.Ptr(),
+		})
+	}
+	if _, has := bodyContent(dataResource.Config).Attributes["defaults"]; has {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Remote state defaults not supported",
+			Detail:   fmt.Sprintf("The defaults of %s are ignored, stack outputs that don't exist will be null", path),
+			Subject:  bodyContent(dataResource.Config).Attributes["defaults"].NameRange.Ptr(),
+		})
+	}
+	if state.stackDependencies != nil {
+		*state.stackDependencies = append(*state.stackDependencies, StackDependency{
+			Kind:   "remote_state",
+			Source: state.modulePrefix + path,
+			Stack:  stack,
+		})
+	}
+
+	block := hclwrite.NewBlock("resource", []string{pulumiName, "pulumi:pulumi:StackReference"})
+	if name != nil {
+		block.Body().SetAttributeRaw("name", name)
+	} else {
+		block.Body().SetAttributeValue("name", cty.StringVal(stack))
+	}
+	leading, trailing := getTrivia(state.sources, dataResource.DeclRange, false)
+	return leading, block, trailing
+}
@@ -0,0 +1,261 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Rule rewrites the resources it matches during conversion.
+type Rule struct {
+	Match RuleMatch `yaml:"match"`
+
+	// The name of the resource in the converted program.
+	Name string `yaml:"name,omitempty"`
+	// The name of the resource in Pulumi state, by default this is the Terraform name of the resource.
+	LogicalName string `yaml:"logicalName,omitempty"`
+	// The Pulumi type token to convert the resource to, rather than the type it's mapped to.
+	Type string `yaml:"type,omitempty"`
+	// The protect and retainOnDelete resource options to set.
+	Protect        *bool `yaml:"protect,omitempty"`
+	RetainOnDelete *bool `yaml:"retainOnDelete,omitempty"`
+	// Leave the resource out of the converted program.
+	Exclude bool `yaml:"exclude,omitempty"`
+}
+
+// RuleMatch selects the resources a Rule applies to, a resource must match all of the fields that are set.
+type RuleMatch struct {
+	// A glob matching the address of the resource, e.g. "module.network.aws_subnet.*".
+	Address string `yaml:"address,omitempty"`
+	// A glob matching the Terraform type of the resource, e.g. "aws_s3_bucket*".
+	Type string `yaml:"type,omitempty"`
+	// Tags the resource must have, with a literal value, in its tags attribute.
+	Tags map[string]string `yaml:"tags,omitempty"`
+}
+
+// ParseRules parses a rules file, which is YAML (or JSON) with a list of rules:
+//
+//	rules:
+//	  - match:
+//	      type: aws_s3_bucket
+//	      tags:
+//	        team: data
+//	    protect: true
+//	  - match:
+//	      address: aws_instance.legacy
+//	    exclude: true
+func ParseRules(data []byte) ([]Rule, error) {
+	var file struct {
+		Rules []Rule `yaml:"rules"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range file.Rules {
+		match := rule.Match
+		if match.Address == "" && match.Type == "" && len(match.Tags) == 0 {
+			return nil, fmt.Errorf("rule %d: match must set at least one of address, type or tags", i)
+		}
+		for _, pattern := range []string{match.Address, match.Type} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+		if rule.Exclude && (rule.Name != "" || rule.LogicalName != "" || rule.Type != "" ||
+			rule.Protect != nil || rule.RetainOnDelete != nil) {
+			return nil, fmt.Errorf("rule %d: exclude can't be combined with other changes", i)
+		}
+		if rule.Name != "" && !hclsyntax.ValidIdentifier(rule.Name) {
+			return nil, fmt.Errorf("rule %d: invalid name %q", i, rule.Name)
+		}
+	}
+	return file.Rules, nil
+}
+
+// WithRules applies the given rules to the resources of the program and its modules. Where several rules match a
+// resource they're all applied in order, so later rules override earlier ones.
+func WithRules(rules []Rule) TranslateOption {
+	return func(o *translateOptions) {
+		o.rules = rules
+	}
+}
+
+// DefaultRetainOnDeleteTypes are the types of stateful resources, like databases, buckets and keys, that
+// WithRetainOnDelete protects by default.
+var DefaultRetainOnDeleteTypes = []string{
+	"aws_db_instance",
+	"aws_docdb_cluster",
+	"aws_dynamodb_table",
+	"aws_ebs_volume",
+	"aws_efs_file_system",
+	"aws_elasticache_replication_group",
+	"aws_kms_key",
+	"aws_rds_cluster",
+	"aws_s3_bucket",
+	"azurerm_cosmosdb_account",
+	"azurerm_key_vault",
+	"azurerm_mssql_database",
+	"azurerm_postgresql_flexible_server",
+	"azurerm_storage_account",
+	"google_bigquery_dataset",
+	"google_kms_crypto_key",
+	"google_spanner_database",
+	"google_sql_database_instance",
+	"google_storage_bucket",
+}
+
+// WithRetainOnDelete sets the retainOnDelete option on all resources whose type matches one of the given globs, so
+// that deleting them from the converted program while it's being migrated to doesn't delete the cloud resources.
+// Rules can still set retainOnDelete to false for particular resources.
+func WithRetainOnDelete(types []string) TranslateOption {
+	return func(o *translateOptions) {
+		o.retainOnDeleteTypes = types
+	}
+}
+
+// matches returns true if the rule matches the given resource in the module at modulePrefix, e.g. "module.network.".
+func (match RuleMatch) matches(modulePrefix string, resource *configs.Resource) bool {
+	if match.Address != "" {
+		if ok, _ := path.Match(match.Address, modulePrefix+resource.Type+"."+resource.Name); !ok {
+			return false
+		}
+	}
+	if match.Type != "" {
+		if ok, _ := path.Match(match.Type, resource.Type); !ok {
+			return false
+		}
+	}
+	if len(match.Tags) > 0 {
+		tags := staticTags(resource)
+		for key, value := range match.Tags {
+			if tag, has := tags[key]; !has || tag != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// staticTags returns the tags of a resource that have literal keys and values. Other tags, for example set from
+// variables, are ignored.
+func staticTags(resource *configs.Resource) map[string]string {
+	attr, has := bodyContent(resource.Config).Attributes["tags"]
+	if !has {
+		return nil
+	}
+	object, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil
+	}
+	tags := map[string]string{}
+	for _, item := range object.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+			continue
+		}
+		value, diags := item.ValueExpr.Value(nil)
+		if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+			continue
+		}
+		tags[key.AsString()] = value.AsString()
+	}
+	return tags
+}
+
+// resourceRules applies options.rules to the resources in items. It returns the merged rule for each resource matched
+// by any rule, keyed by "type.name", and items without the excluded resources.
+func resourceRules(
+	state *convertState, options translateOptions, items terraformItems,
+) (map[string]Rule, terraformItems) {
+	// Retaining stateful resources is applied first so that rules can override it.
+	retain := true
+	allRules := make([]Rule, 0, len(options.retainOnDeleteTypes)+len(options.rules))
+	for _, typ := range options.retainOnDeleteTypes {
+		allRules = append(allRules, Rule{Match: RuleMatch{Type: typ}, RetainOnDelete: &retain})
+	}
+	allRules = append(allRules, options.rules...)
+	if len(allRules) == 0 {
+		return nil, items
+	}
+
+	modulePrefix := state.modulePrefix
+
+	rules := map[string]Rule{}
+	for _, item := range items {
+		if item.resource == nil {
+			continue
+		}
+		key := item.resource.Type + "." + item.resource.Name
+		for _, rule := range allRules {
+			if !rule.Match.matches(modulePrefix, item.resource) {
+				continue
+			}
+			merged := rules[key]
+			merged.Exclude = merged.Exclude || rule.Exclude
+			if rule.Name != "" {
+				merged.Name = rule.Name
+			}
+			if rule.LogicalName != "" {
+				merged.LogicalName = rule.LogicalName
+			}
+			if rule.Type != "" {
+				merged.Type = rule.Type
+			}
+			if rule.Protect != nil {
+				merged.Protect = rule.Protect
+			}
+			if rule.RetainOnDelete != nil {
+				merged.RetainOnDelete = rule.RetainOnDelete
+			}
+			rules[key] = merged
+		}
+	}
+
+	kept := make(terraformItems, 0, len(items))
+	for _, item := range items {
+		if item.resource == nil || !rules[item.resource.Type+"."+item.resource.Name].Exclude {
+			kept = append(kept, item)
+		}
+	}
+	// Anything still using an excluded resource won't bind, so say where that is.
+	for _, item := range kept {
+		for _, traversal := range item.references() {
+			key := referenceKey(traversal)
+			if !rules[key].Exclude {
+				continue
+			}
+			subject := traversal.SourceRange()
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Excluded resource is used",
+				Detail: fmt.Sprintf("%s%s has been excluded by a rule but is still used, remove this use of it",
+					modulePrefix, key),
+				Subject: &subject,
+			})
+		}
+	}
+	return rules, kept
+}
@@ -0,0 +1,260 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules([]byte(`
+rules:
+  - match:
+      type: simple_*
+      tags:
+        team: data
+    protect: true
+  - match:
+      address: module.legacy.*
+    exclude: true
+`))
+	require.NoError(t, err)
+	protect := true
+	assert.Equal(t, []Rule{
+		{Match: RuleMatch{Type: "simple_*", Tags: map[string]string{"team": "data"}}, Protect: &protect},
+		{Match: RuleMatch{Address: "module.legacy.*"}, Exclude: true},
+	}, rules)
+
+	// JSON is YAML too.
+	rules, err = ParseRules([]byte(`{"rules": [{"match": {"type": "simple_resource"}, "name": "main"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Match: RuleMatch{Type: "simple_resource"}, Name: "main"}}, rules)
+
+	cases := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{
+			name:  "empty match",
+			rules: "rules: [{match: {}, protect: true}]",
+			err:   "rule 0: match must set at least one of address, type or tags",
+		},
+		{
+			name:  "invalid pattern",
+			rules: "rules: [{match: {type: '['}, protect: true}]",
+			err:   `rule 0: invalid pattern "["`,
+		},
+		{
+			name:  "exclude and rename",
+			rules: "rules: [{match: {type: a}, exclude: true, name: b}]",
+			err:   "rule 0: exclude can't be combined with other changes",
+		},
+		{
+			name:  "invalid name",
+			rules: "rules: [{match: {type: a}, name: 1st}]",
+			err:   `rule 0: invalid name "1st"`,
+		},
+		{
+			name:  "unknown field",
+			rules: "rules: [{match: {type: a}, protected: true}]",
+			err:   "field protected not found",
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseRules([]byte(tt.rules))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestTranslateWithRules(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	files := map[string]string{
+		"/prog/main.tf": `
+resource "simple_resource" "data" {
+  input_one = "hello"
+  tags = {
+    team = "data"
+  }
+}
+
+resource "simple_resource" "web" {
+  input_one = "world"
+  tags = {
+    team = "web"
+  }
+}
+
+resource "simple_resource" "legacy" {
+  input_one = "legacy"
+}
+
+module "child" {
+  source = "./child"
+}
+
+output "legacy" {
+  value = simple_resource.legacy.result
+}
+`,
+		"/prog/child/main.tf": `
+resource "simple_resource" "a_resource" {
+  input_one = "child"
+}
+`,
+	}
+	for path, source := range files {
+		err := afero.WriteFile(src, path, []byte(source), 0o600)
+		require.NoError(t, err)
+	}
+
+	rules, err := ParseRules([]byte(`
+rules:
+  - match:
+      tags:
+        team: data
+    protect: true
+    retainOnDelete: true
+  - match:
+      address: simple_resource.web
+    name: webServer
+    logicalName: web-server
+  - match:
+      address: simple_resource.legacy
+    exclude: true
+  - match:
+      address: module.child.simple_resource.*
+    type: acme:index:Thing
+`))
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper), WithRules(rules))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	// The other two are for the tags, which simple_resource doesn't have in its schema.
+	require.Len(t, diagnostics, 3)
+	assert.Equal(t, "Excluded resource is used", diagnostics[0].Summary)
+	assert.Equal(t, 25, diagnostics[0].Subject.Start.Line)
+
+	program, err := afero.ReadFile(dst, "/main.pp")
+	require.NoError(t, err)
+	assert.Equal(t, `resource "data" "simple:index:resource" {
+  options {
+    protect        = true
+    retainOnDelete = true
+  }
+  inputOne = "hello"
+  tags = {
+    team = "data"
+  }
+}
+
+resource "webServer" "simple:index:resource" {
+  __logicalName = "web-server"
+  inputOne      = "world"
+  tags = {
+    team = "web"
+  }
+}
+
+component "child" "./child" {
+}
+
+output "legacy" {
+  value = legacySimpleResource.result
+}
+`, string(program))
+
+	child, err := afero.ReadFile(dst, "/child/main.pp")
+	require.NoError(t, err)
+	assert.Equal(t, `resource "aResource" "acme:index:Thing" {
+  __logicalName = "a_resource"
+  inputOne      = "child"
+}
+`, string(child))
+}
+
+func TestTranslateWithRetainOnDelete(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+resource "simple_resource" "database" {
+  input_one = "hello"
+}
+
+resource "simple_resource" "scratch" {
+  input_one = "world"
+}
+
+resource "simple_another_resource" "other" {
+  input_one = "other"
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	// Rules take precedence over the retained types.
+	rules, err := ParseRules([]byte(`
+rules:
+  - match:
+      address: simple_resource.scratch
+    retainOnDelete: false
+`))
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithRetainOnDelete([]string{"simple_resource"}), WithRules(rules))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	program, err := afero.ReadFile(dst, "/main.pp")
+	require.NoError(t, err)
+	assert.Equal(t, `resource "database" "simple:index:resource" {
+  options {
+    retainOnDelete = true
+  }
+  inputOne = "hello"
+}
+
+resource "scratch" "simple:index:resource" {
+  options {
+    retainOnDelete = false
+  }
+  inputOne = "world"
+}
+
+resource "other" "simple:index:anotherResource" {
+  inputOne = "other"
+}
+`, string(program))
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
 	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
@@ -50,6 +51,22 @@ type PathInfo struct {
 
 	// The expression for a local variable
 	Expression *hcl.Expression
+	// The type constraint for an input variable
+	VariableType cty.Type
+	// The default value of an input variable, this is left unset for sensitive variables so they're never folded
+	// into the program
+	VariableDefault cty.Value
+	// Set for locals that are used as maps, so their keys shouldn't be renamed
+	UsedAsMap bool
+	// Set for data sources with a count of `condition ? 1 : 0`, which are converted to the invoke or null rather than
+	// a list
+	ConditionalCount bool
+	// Set for variables without a type that are used as objects or lists, but whose type couldn't be inferred, so
+	// they're converted to config of any type
+	DynamicConfig bool
+	// Set for data sources whose arguments refer to resources, so they're only read once those are created and
+	// their results are outputs
+	ReadAfterApply bool
 }
 
 type scopes struct {
@@ -63,9 +80,20 @@ type scopes struct {
 
 	// Set non-nil if "count.index" can be mapped
 	countIndex hcl.Traversal
+	// Set non-nil if count is the length of this list, so that indexing it by "count.index" can be mapped to
+	// countValue
+	countList  hcl.Traversal
+	countValue hcl.Traversal
 	eachKey    hcl.Traversal
 	eachValue  hcl.Traversal
 
+	// The converted element of the for_each of optional dynamic blocks, keyed by the name of their iterator, see
+	// optionalBlock
+	optionalBlockValues map[string]hclwrite.Tokens
+
+	// Set while folding constants so that variables evaluate to their defaults
+	useVariableDefaults bool
+
 	scope *lang.Scope
 }
 
@@ -337,6 +365,31 @@ func (s *scopes) pulumiName(fullyQualifiedPath string) string {
 	return camelCaseName(info.Name)
 }
 
+// Given a fully typed path (e.g. data.simple_data_source.my_data.a_field) returns true if the schema of the
+// containing object is known but does not contain the final part of the path. If we don't know the schema of the
+// containing object this returns false.
+func (s *scopes) isUnknownProperty(fullyQualifiedPath string) bool {
+	dot := strings.LastIndex(fullyQualifiedPath, ".")
+	contract.Assertf(dot != -1, "path passed into isUnknownProperty has no parent: %s", fullyQualifiedPath)
+	parentPath, name := fullyQualifiedPath[:dot], fullyQualifiedPath[dot+1:]
+
+	parent := s.getInfo(parentPath)
+	var sch shim.SchemaMap
+	if parent.Resource != nil {
+		sch = parent.Resource.Schema()
+	} else if parent.Schema != nil {
+		if res, ok := parent.Schema.Elem().(shim.Resource); ok {
+			sch = res.Schema()
+		}
+	}
+	if sch == nil {
+		return false
+	}
+
+	_, has := sch.GetOk(name)
+	return !has
+}
+
 // Given a fully typed path (e.g. data.simple_data_source.my_data.a_field) returns if the schema says it's a map.
 func (s *scopes) isMap(fullyQualifiedPath string) *bool {
 	info := s.getInfo(fullyQualifiedPath)
@@ -494,6 +547,11 @@ func (s *scopes) GetTerraformAttr(_ addrs.TerraformAttr, src tfdiags.SourceRange
 	return cty.NilVal, makeErrorDiagnostic("GetTerraformAttr is not supported", src)
 }
 
-func (s *scopes) GetInputVariable(_ addrs.InputVariable, src tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+func (s *scopes) GetInputVariable(addr addrs.InputVariable, src tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+	if s.useVariableDefaults {
+		if root, has := s.roots[addr.String()]; has && root.VariableDefault != cty.NilVal && !root.VariableDefault.IsNull() {
+			return root.VariableDefault, nil
+		}
+	}
 	return cty.NilVal, makeErrorDiagnostic("GetInputVariable is not supported", src)
 }
@@ -0,0 +1,78 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Attributes of data sources that read secrets. These are always secret, even when the provider mapping can't be
+// loaded or doesn't mark them as sensitive.
+var secretDataSourceAttributes = map[string]map[string]struct{}{
+	"aws_secretsmanager_secret_version": {
+		"secret_string": {},
+		"secret_binary": {},
+	},
+	"aws_ssm_parameter": {
+		"value": {},
+	},
+}
+
+// isSecretDataSourceAttribute returns true if traversal reads a secret attribute of a data source, e.g.
+// data.aws_secretsmanager_secret_version.db.secret_string. Invokes with plain arguments return plain values, so
+// unless these are marked as secrets they would be shown in the preview and the outputs of the stack.
+func isSecretDataSourceAttribute(scopes *scopes, traversal hcl.Traversal) bool {
+	if len(traversal) < 4 || traversal.RootName() != "data" {
+		return false
+	}
+	dataType, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+	dataName, ok := traversal[2].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+
+	// Skip over any index into a data source with count or for_each to find the attribute that's read.
+	var attr *hcl.TraverseAttr
+	for _, step := range traversal[3:] {
+		if a, ok := step.(hcl.TraverseAttr); ok {
+			attr = &a
+			break
+		}
+	}
+	if attr == nil {
+		return false
+	}
+
+	if _, has := secretDataSourceAttributes[dataType.Name][attr.Name]; has {
+		return true
+	}
+	if _, has := scopes.roots["data."+dataType.Name+"."+dataName.Name]; !has {
+		return false
+	}
+	info := scopes.getInfo("data." + dataType.Name + "." + dataName.Name + "." + attr.Name)
+	if info.SchemaInfo != nil && info.SchemaInfo.Secret != nil {
+		return *info.SchemaInfo.Secret
+	}
+	return info.Schema != nil && info.Schema.Sensitive()
+}
+
+// tokensForSecret wraps tokens in a call to secret.
+func tokensForSecret(tokens hclwrite.Tokens) hclwrite.Tokens {
+	return hclwrite.TokensForFunctionCall("secret", tokens)
+}
@@ -0,0 +1,103 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// std has no setproduct, so setproduct(a, b) is converted to a for expression per set, nested in the order of the
+// sets, whose innermost body is the tuple of their elements. The outer ones are concatenated to a single list, e.g.
+// setproduct(var.roles, var.policies) is converted to
+// invoke("std:index:concat", {input = [for item in roles : [for item2 in policies : [item, item2]]]}).result.
+// Sets are converted to lists, so the products are in the order of the sets' elements.
+
+// newForVariable returns a name starting with base for a variable of a for expression a function is converted to,
+// which isn't a variable of any enclosing for expression, so the function's arguments can still refer to those.
+func newForVariable(scopes *scopes, base string, taken map[string]bool) string {
+	inScope := func(name string) bool {
+		if taken[name] {
+			return true
+		}
+		for _, locals := range scopes.locals {
+			if _, has := locals[name]; has {
+				return true
+			}
+		}
+		return false
+	}
+	name := base
+	for counter := 2; inScope(name); counter++ {
+		name = fmt.Sprintf("%s%d", base, counter)
+	}
+	return name
+}
+
+// convertSetProduct returns call, a setproduct call, converted to its value if its arguments are known at conversion
+// time, or otherwise to nested for expressions over its sets. It returns false if the call can't be converted.
+func convertSetProduct(
+	state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if call.Name != "setproduct" || len(call.Args) == 0 || call.ExpandFinal {
+		return nil, false
+	}
+	if isStaticExpr(scopes, call, map[string]bool{}) {
+		value, diags := scopes.EvalExpr(call)
+		if diags.HasErrors() || !value.IsWhollyKnown() {
+			return nil, false
+		}
+		return hclwrite.TokensForValue(value), true
+	}
+
+	rng := emptyRange(call.Range())
+	taken := map[string]bool{}
+	names := make([]string, len(call.Args))
+	elems := make([]hclsyntax.Expression, len(call.Args))
+	for i := range call.Args {
+		names[i] = newForVariable(scopes, "item", taken)
+		taken[names[i]] = true
+		elems[i] = &hclsyntax.ScopeTraversalExpr{
+			Traversal: hcl.Traversal{hcl.TraverseRoot{Name: names[i], SrcRange: rng}},
+			SrcRange:  rng,
+		}
+	}
+
+	var product hclsyntax.Expression = &hclsyntax.TupleConsExpr{Exprs: elems, SrcRange: rng, OpenRange: rng}
+	for i := len(call.Args) - 1; i >= 0; i-- {
+		product = &hclsyntax.ForExpr{
+			ValVar:     names[i],
+			CollExpr:   call.Args[i],
+			ValExpr:    product,
+			SrcRange:   rng,
+			OpenRange:  rng,
+			CloseRange: rng,
+		}
+		if i < len(call.Args)-1 {
+			// Each element of the for over all but the last set is a list of products, which are concatenated.
+			product = &hclsyntax.FunctionCallExpr{
+				Name:            "concat",
+				Args:            []hclsyntax.Expression{product},
+				NameRange:       rng,
+				OpenParenRange:  rng,
+				CloseParenRange: rng,
+			}
+		}
+	}
+	return convertExpression(state, false, scopes, "", product), true
+}
@@ -0,0 +1,189 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Terraform has no boolean count, so modules are full of idioms that turn conditions into numbers and back, like
+// `var.enabled ? 1 : 0`, `signum(length(var.list))` and `var.enabled == true`. The functions here recognize them so
+// they're converted to the condition they stand for rather than literally.
+
+// boolLiteral returns the value of expr if it's a literal true or false.
+func boolLiteral(expr hclsyntax.Expression) (bool, bool) {
+	literal, ok := unwrapParentheses(expr).(*hclsyntax.LiteralValueExpr)
+	if !ok || literal.Val.Type() != cty.Bool || literal.Val.IsNull() {
+		return false, false
+	}
+	return literal.Val.True(), true
+}
+
+// isNumberLiteral returns true if expr is the literal number n.
+func isNumberLiteral(expr hclsyntax.Expression, n int64) bool {
+	literal, ok := unwrapParentheses(expr).(*hclsyntax.LiteralValueExpr)
+	return ok && literal.Val.Type() == cty.Number && !literal.Val.IsNull() &&
+		literal.Val.Equals(cty.NumberIntVal(n)).True()
+}
+
+// unwrapParentheses returns the expression inside any parentheses around expr.
+func unwrapParentheses(expr hclsyntax.Expression) hclsyntax.Expression {
+	for {
+		parens, ok := expr.(*hclsyntax.ParenthesesExpr)
+		if !ok {
+			return expr
+		}
+		expr = parens.Expression
+	}
+}
+
+// isBoolExpr returns true if expr is known to be a bool without evaluating it, so it can stand in for a comparison
+// of it with true. Expressions that terraform would only convert to a bool, like the string "true", aren't.
+func isBoolExpr(scopes *scopes, expr hclsyntax.Expression) bool {
+	switch expr := unwrapParentheses(expr).(type) {
+	case *hclsyntax.BinaryOpExpr:
+		return expr.Op.Type.Equals(cty.Bool)
+	case *hclsyntax.UnaryOpExpr:
+		return expr.Op == hclsyntax.OpLogicalNot
+	}
+	return staticType(scopes, expr).Equals(cty.Bool)
+}
+
+// countCondition returns the condition of expr if it's `condition ? 1 : 0`, and whether the condition is negated,
+// for `condition ? 0 : 1`.
+func countCondition(expr hclsyntax.Expression) (hclsyntax.Expression, bool, bool) {
+	conditional, ok := unwrapParentheses(expr).(*hclsyntax.ConditionalExpr)
+	if !ok {
+		return nil, false, false
+	}
+	switch {
+	case isNumberLiteral(conditional.TrueResult, 1) && isNumberLiteral(conditional.FalseResult, 0):
+		return conditional.Condition, false, true
+	case isNumberLiteral(conditional.TrueResult, 0) && isNumberLiteral(conditional.FalseResult, 1):
+		return conditional.Condition, true, true
+	}
+	return nil, false, false
+}
+
+// simplifiedCondition returns the condition that expr is equivalent to, and whether it's negated, for the idioms:
+//
+//   - `x == true` and `x != false` are x, and `x == false` and `x != true` are !x, if x is a bool.
+//   - `(c ? 1 : 0) == 1`, `(c ? 1 : 0) != 0` and `(c ? 1 : 0) > 0` are c, and `(c ? 1 : 0) == 0` and
+//     `(c ? 1 : 0) != 1` are !c, and the reverse for `c ? 0 : 1`, if c is a bool.
+//   - `c ? true : false` is c and `c ? false : true` is !c, if c is a bool.
+func simplifiedCondition(scopes *scopes, expr hclsyntax.Expression) (hclsyntax.Expression, bool, bool) {
+	switch expr := expr.(type) {
+	case *hclsyntax.BinaryOpExpr:
+		for _, sides := range [][2]hclsyntax.Expression{{expr.LHS, expr.RHS}, {expr.RHS, expr.LHS}} {
+			operand, other := sides[0], sides[1]
+			if value, ok := boolLiteral(other); ok && isBoolExpr(scopes, operand) {
+				switch expr.Op {
+				case hclsyntax.OpEqual:
+					return operand, !value, true
+				case hclsyntax.OpNotEqual:
+					return operand, value, true
+				}
+			}
+		}
+
+		// Only `count > 0` makes sense for counts, `0 < count` is rare enough to not be worth matching.
+		condition, negated, ok := countCondition(expr.LHS)
+		if !ok || !isBoolExpr(scopes, condition) {
+			return nil, false, false
+		}
+		switch {
+		case expr.Op == hclsyntax.OpEqual && isNumberLiteral(expr.RHS, 1),
+			expr.Op == hclsyntax.OpNotEqual && isNumberLiteral(expr.RHS, 0),
+			expr.Op == hclsyntax.OpGreaterThan && isNumberLiteral(expr.RHS, 0):
+			return condition, negated, true
+		case expr.Op == hclsyntax.OpEqual && isNumberLiteral(expr.RHS, 0),
+			expr.Op == hclsyntax.OpNotEqual && isNumberLiteral(expr.RHS, 1):
+			return condition, !negated, true
+		}
+	case *hclsyntax.ConditionalExpr:
+		whenTrue, ok := boolLiteral(expr.TrueResult)
+		if !ok {
+			break
+		}
+		whenFalse, ok := boolLiteral(expr.FalseResult)
+		if !ok || whenTrue == whenFalse || !isBoolExpr(scopes, expr.Condition) {
+			break
+		}
+		return expr.Condition, !whenTrue, true
+	}
+	return nil, false, false
+}
+
+// convertSimplifiedCondition converts expr as the condition it's equivalent to, see simplifiedCondition, or returns
+// false if it isn't one of the idioms.
+func convertSimplifiedCondition(state *convertState, inBlock bool, scopes *scopes,
+	fullyQualifiedPath string, expr hclsyntax.Expression,
+) (hclwrite.Tokens, bool) {
+	condition, negated, ok := simplifiedCondition(scopes, expr)
+	if !ok {
+		return nil, false
+	}
+	// The condition may be an idiom itself, like `(var.enabled == true) == true`.
+	if inner, innerNegated, ok := simplifiedCondition(scopes, unwrapParentheses(condition)); ok {
+		condition, negated = inner, negated != innerNegated
+	}
+	if !negated {
+		return convertExpression(state, inBlock, scopes, fullyQualifiedPath, condition), true
+	}
+	return convertNegation(state, inBlock, scopes, fullyQualifiedPath, condition), true
+}
+
+// convertNegation converts !expr, without the double negation if expr is itself a negation and with parentheses
+// around expr if it's an operator.
+func convertNegation(state *convertState, inBlock bool, scopes *scopes,
+	fullyQualifiedPath string, expr hclsyntax.Expression,
+) hclwrite.Tokens {
+	if not, ok := unwrapParentheses(expr).(*hclsyntax.UnaryOpExpr); ok && not.Op == hclsyntax.OpLogicalNot {
+		return convertExpression(state, inBlock, scopes, fullyQualifiedPath, not.Val)
+	}
+	tokens := hclwrite.Tokens{makeToken(hclsyntax.TokenBang, "!")}
+	switch expr.(type) {
+	case *hclsyntax.BinaryOpExpr, *hclsyntax.ConditionalExpr:
+		tokens = append(tokens, makeToken(hclsyntax.TokenOParen, "("))
+		tokens = append(tokens, convertExpression(state, inBlock, scopes, fullyQualifiedPath, expr)...)
+		return append(tokens, makeToken(hclsyntax.TokenCParen, ")"))
+	}
+	return append(tokens, convertExpression(state, inBlock, scopes, fullyQualifiedPath, expr)...)
+}
+
+// convertSignumOfLength converts signum(length(x)), which is 1 if x isn't empty and 0 otherwise, to
+// `length(x) > 0 ? 1 : 0` rather than calling signum, or returns false if call isn't signum(length(x)).
+func convertSignumOfLength(state *convertState, scopes *scopes,
+	fullyQualifiedPath string, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if call.Name != "signum" || len(call.Args) != 1 {
+		return nil, false
+	}
+	length, ok := unwrapParentheses(call.Args[0]).(*hclsyntax.FunctionCallExpr)
+	if !ok || length.Name != "length" || len(length.Args) != 1 {
+		return nil, false
+	}
+	tokens := convertExpression(state, false, scopes, fullyQualifiedPath, length)
+	tokens = append(tokens,
+		makeToken(hclsyntax.TokenGreaterThan, ">"),
+		makeToken(hclsyntax.TokenNumberLit, "0"),
+		makeToken(hclsyntax.TokenQuestion, "?"),
+		makeToken(hclsyntax.TokenNumberLit, "1"),
+		makeToken(hclsyntax.TokenColon, ":"),
+		makeToken(hclsyntax.TokenNumberLit, "0"))
+	return tokens, true
+}
@@ -0,0 +1,193 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// NameStabilization is what WithStableNames replaces timestamps in resource names with.
+type NameStabilization string
+
+const (
+	// NameStabilizationConfig replaces timestamps with a config value for each resource.
+	NameStabilizationConfig NameStabilization = "config"
+	// NameStabilizationRandom replaces timestamps with the hex of a random_id for each resource, which only changes
+	// when the random_id is replaced.
+	NameStabilizationRandom NameStabilization = "random"
+)
+
+// WithStableNames replaces the timestamps in the names of resources, like
+// `"snapshot-${formatdate("YYYYMMDD", timestamp())}"`, with a stable suffix. These names change, and so replace the
+// resource, on every update. Names whose changes are ignored are left as they are, as they only use the timestamp
+// when the resource is created. By default every name is converted as it is, with a warning.
+func WithStableNames(how NameStabilization) TranslateOption {
+	return func(o *translateOptions) {
+		o.stableNames = how
+	}
+}
+
+// Matches the names of attributes that name a resource, e.g. name, name_prefix, bucket and
+// final_snapshot_identifier.
+var nameAttributeRegexp = regexp.MustCompile(`(^|_)(name|identifier|bucket)(_prefix)?$`)
+
+// isTimestamp returns true if expr is the current time, or a time formatted or computed from it.
+func isTimestamp(expr hclsyntax.Expression) bool {
+	call, ok := unwrapParentheses(expr).(*hclsyntax.FunctionCallExpr)
+	if !ok {
+		return false
+	}
+	switch call.Name {
+	case "timestamp", "plantimestamp":
+		return true
+	case "formatdate", "timeadd":
+		for _, arg := range call.Args {
+			if isTimestamp(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timestampNames returns the names of the attributes of resource that name it and are built from timestamps, sorted.
+// Attributes whose changes are ignored only use the timestamp when the resource is created, so aren't included.
+func timestampNames(resource *configs.Resource) []string {
+	ignored := map[string]bool{}
+	if resource.Managed != nil {
+		if resource.Managed.IgnoreAllChanges {
+			return nil
+		}
+		for _, traversal := range resource.Managed.IgnoreChanges {
+			if len(traversal) == 1 {
+				if attr, ok := traversal[0].(hcl.TraverseAttr); ok {
+					ignored[attr.Name] = true
+				}
+			}
+		}
+	}
+
+	var names []string
+	for name, attr := range bodyContent(resource.Config).Attributes {
+		expr, ok := attr.Expr.(hclsyntax.Expression)
+		if !ok || !nameAttributeRegexp.MatchString(name) || ignored[name] {
+			continue
+		}
+		found := false
+		hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if expr, ok := node.(hclsyntax.Expression); ok && isTimestamp(expr) {
+				found = true
+			}
+			return nil
+		})
+		if found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nameSuffixKey returns the key of the config or random_id that replaces the timestamps in the names of the resource
+// with the given key in scopes.roots.
+func nameSuffixKey(key string) string {
+	return "nameSuffix." + key
+}
+
+// addNameSuffixName reserves the name of the config or random_id that replaces the timestamps in the names of
+// resource, converted to a resource named name, if names are being stabilized and it has any.
+func addNameSuffixName(state *convertState, scopes *scopes, resource *configs.Resource, name string) {
+	if state.stableNames == "" || len(timestampNames(resource)) == 0 {
+		return
+	}
+	scopes.roots[nameSuffixKey(resource.Type+"."+resource.Name)] = PathInfo{
+		Name: scopes.generateUniqueName(name+"NameSuffix", "", ""),
+	}
+}
+
+// convertNameSuffix warns about each name of resource that's built from a timestamp. If names are being stabilized
+// it returns the config or random_id block to write before the resource, and records the expression that
+// timestamps in those names are converted to in state.nameSuffixes.
+func convertNameSuffix(state *convertState, scopes *scopes, resource *configs.Resource) *hclwrite.Block {
+	names := timestampNames(resource)
+	if len(names) == 0 {
+		return nil
+	}
+	key := resource.Type + "." + resource.Name
+	root, has := scopes.roots[nameSuffixKey(key)]
+
+	var block *hclwrite.Block
+	var suffix hclwrite.Tokens
+	switch {
+	case has && state.stableNames == NameStabilizationConfig:
+		block = hclwrite.NewBlock("config", []string{root.Name, "string"})
+		block.Body().SetAttributeValue("description",
+			cty.StringVal(fmt.Sprintf("The suffix of the name of %s, which terraform built from a timestamp", key)))
+		suffix = hclwrite.TokensForTraversal(hcl.Traversal{hcl.TraverseRoot{Name: root.Name}})
+	case has && state.stableNames == NameStabilizationRandom:
+		block = hclwrite.NewBlock("resource", []string{root.Name, "random:index/randomId:RandomId"})
+		block.Body().SetAttributeValue("byteLength", cty.NumberIntVal(4))
+		suffix = hclwrite.TokensForTraversal(hcl.Traversal{
+			hcl.TraverseRoot{Name: root.Name},
+			hcl.TraverseAttr{Name: "hex"},
+		})
+	}
+
+	attributes := bodyContent(resource.Config).Attributes
+	for _, name := range names {
+		attr := attributes[name]
+		if suffix == nil {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Timestamp in resource name",
+				Detail: fmt.Sprintf("%s of %s is built from the current time, so it will change and replace the "+
+					"resource on every update. Use a suffix from config or a random_id instead", name, key),
+				Subject: attr.Expr.Range().Ptr(),
+			})
+			continue
+		}
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Timestamp in resource name replaced",
+			Detail: fmt.Sprintf("The timestamp in %s of %s has been replaced with %s, so the name doesn't change "+
+				"on every update", name, key, root.Name),
+			Subject: attr.Expr.Range().Ptr(),
+		})
+		state.nameSuffixes[key+"."+name] = suffix
+	}
+	return block
+}
+
+// checkNameStabilization returns an error if how isn't a NameStabilization.
+func checkNameStabilization(how NameStabilization) hcl.Diagnostics {
+	switch how {
+	case "", NameStabilizationConfig, NameStabilizationRandom:
+		return nil
+	}
+	return hcl.Diagnostics{&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Unknown name stabilization",
+		Detail: fmt.Sprintf("Unknown name stabilization %q, expected %s or %s",
+			how, NameStabilizationConfig, NameStabilizationRandom),
+	}}
+}
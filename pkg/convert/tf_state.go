@@ -15,18 +15,21 @@
 package convert
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"math/big"
-	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
-	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/terraform/pkg/addrs"
+	"github.com/pulumi/terraform/pkg/states"
 	"github.com/pulumi/terraform/pkg/states/statefile"
+	"github.com/spf13/afero"
 )
 
 // Looks up a given attribute and returns it as a string. If the attribute is not found, or is not a string, an error is
@@ -43,11 +46,97 @@ func getString(addr addrs.Resource, obj map[string]interface{}, key string) (str
 	return str, nil
 }
 
-func TranslateState(info il.ProviderInfoSource, path string) (*plugin.ConvertStateResponse, error) {
-	stateFile, err := os.Open(path)
+// InstanceNaming is how the instances of resources using count or for_each are named when imported.
+type InstanceNaming string
+
+const (
+	// InstanceNamingKey suffixes the name with the instance key, e.g. "a_resource-0" or "a_resource-hello".
+	InstanceNamingKey InstanceNaming = "key"
+	// InstanceNamingIndex suffixes the name with the index of the instance when sorted by key, e.g. "a_resource-0".
+	InstanceNamingIndex InstanceNaming = "index"
+	// InstanceNamingHash suffixes the name with a short hash of the instance key, e.g. "a_resource-2cf24dba".
+	InstanceNamingHash InstanceNaming = "hash"
+)
+
+type translateStateOptions struct {
+	instanceNaming InstanceNaming
+	// The filesystem the state file is read from.
+	source afero.Fs
+}
+
+// TranslateStateOption is an option that can be passed to TranslateState.
+type TranslateStateOption func(*translateStateOptions)
+
+// WithInstanceNaming sets how the instances of resources using count or for_each are named, the default is
+// InstanceNamingKey.
+func WithInstanceNaming(naming InstanceNaming) TranslateStateOption {
+	return func(o *translateStateOptions) {
+		o.instanceNaming = naming
+	}
+}
+
+// WithStateSource reads the state file from source rather than the OS filesystem, so that state can be converted
+// from memory, for example in a web service.
+func WithStateSource(source afero.Fs) TranslateStateOption {
+	return func(o *translateStateOptions) {
+		o.source = source
+	}
+}
+
+// instanceKeyLess orders instance keys, ints by value and strings lexically.
+func instanceKeyLess(a, b addrs.InstanceKey) bool {
+	ai, aIsInt := a.(addrs.IntKey)
+	bi, bIsInt := b.(addrs.IntKey)
+	if aIsInt && bIsInt {
+		return ai < bi
+	}
+	if aIsInt != bIsInt {
+		return aIsInt
+	}
+	return a.String() < b.String()
+}
+
+// instanceName returns the name to import the instance of a resource with the given key as.
+func instanceName(name string, key addrs.InstanceKey, index int, naming InstanceNaming) string {
+	var suffix string
+	switch key := key.(type) {
+	case addrs.IntKey:
+		suffix = strconv.Itoa(int(key))
+	case addrs.StringKey:
+		suffix = string(key)
+	default:
+		// Resources without count or for_each just have the one instance, there's no need for a suffix.
+		return name
+	}
+
+	switch naming {
+	case InstanceNamingIndex:
+		suffix = strconv.Itoa(index)
+	case InstanceNamingHash:
+		hash := sha256.Sum256([]byte(suffix))
+		suffix = hex.EncodeToString(hash[:4])
+	}
+	return fmt.Sprintf("%s-%s", name, suffix)
+}
+
+func TranslateState(
+	info il.ProviderInfoSource, path string, opts ...TranslateStateOption,
+) (*plugin.ConvertStateResponse, error) {
+	options := translateStateOptions{instanceNaming: InstanceNamingKey, source: afero.NewOsFs()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	switch options.instanceNaming {
+	case InstanceNamingKey, InstanceNamingIndex, InstanceNamingHash:
+	default:
+		return nil, fmt.Errorf("unknown instance naming %q, expected key, index or hash", options.instanceNaming)
+	}
+
+	stateFile, err := options.source.Open(path)
 	if err != nil {
 		return nil, err
 	}
+	defer stateFile.Close()
 	file, err := statefile.Read(stateFile)
 	if err != nil {
 		return nil, err
@@ -57,143 +146,168 @@ func TranslateState(info il.ProviderInfoSource, path string) (*plugin.ConvertSta
 
 	state := file.State
 	var resources []plugin.ResourceImport
+	// The terraform address of each resource that's been given a name, keyed by type and name.
+	names := map[string]string{}
+	// Modules and resources are stored in maps, sort them by address so we report any name clashes consistently.
+	var stateResources []*states.Resource
 	for _, mod := range state.Modules {
 		for _, resource := range mod.Resources {
-			// We only care about managed resources, we can't import data sources
-			if resource.Addr.Resource.Mode != addrs.ManagedResourceMode {
-				continue
-			}
+			stateResources = append(stateResources, resource)
+		}
+	}
+	sort.Slice(stateResources, func(i, j int) bool {
+		return stateResources[i].Addr.String() < stateResources[j].Addr.String()
+	})
+
+	for _, resource := range stateResources {
+		// We only care about managed resources, we can't import data sources
+		if resource.Addr.Resource.Mode != addrs.ManagedResourceMode {
+			continue
+		}
+
+		// Go through the instances in key order, this keeps the output stable and is the order indexes are given
+		// out in for InstanceNamingIndex.
+		instanceKeys := make([]addrs.InstanceKey, 0, len(resource.Instances))
+		for instanceAddr := range resource.Instances {
+			instanceKeys = append(instanceKeys, instanceAddr)
+		}
+		sort.Slice(instanceKeys, func(i, j int) bool {
+			return instanceKeyLess(instanceKeys[i], instanceKeys[j])
+		})
 
-			// TODO: Currently we just expect one instance
-			for instanceAddr, instance := range resource.Instances {
-				if instance.HasCurrent() {
-					current := instance.Current
+		for index, instanceAddr := range instanceKeys {
+			instance := resource.Instances[instanceAddr]
+			if instance.HasCurrent() {
+				current := instance.Current
 
-					// We assume AttrsJSON is set, this will be true for all recent tfstate files
-					var obj map[string]interface{}
-					err := json.Unmarshal(current.AttrsJSON, &obj)
+				// We assume AttrsJSON is set, this will be true for all recent tfstate files
+				var obj map[string]interface{}
+				err := json.Unmarshal(current.AttrsJSON, &obj)
+				if err != nil {
+					return nil, err
+				}
+				var id string
+				// Most resources can be imported by passing their `id`, but a few need to be imported using some
+				// other property of the resource.  This table includes any of these exceptions.  If you get errors
+				// or warnings about resources not being able to be found or the format of resource ids being
+				// incorrect, add a mapping here that constructs the correct id format based on the property values
+				// in the Terraform state file.
+				//
+				// TODO(https://github.com/pulumi/pulumi-terraform-bridge/issues/1406): This table should somehow be
+				// expressed via the mapping file, rather than hardcoding for each provider here.
+				switch resource.Addr.Resource.Type {
+				case "aws_ecs_cluster":
+					id, err = getString(resource.Addr.Resource, obj, "name")
 					if err != nil {
 						return nil, err
 					}
-					var id string
-					// Most resources can be imported by passing their `id`, but a few need to be imported using some
-					// other property of the resource.  This table includes any of these exceptions.  If you get errors
-					// or warnings about resources not being able to be found or the format of resource ids being
-					// incorrect, add a mapping here that constructs the correct id format based on the property values
-					// in the Terraform state file.
-					//
-					// TODO(https://github.com/pulumi/pulumi-terraform-bridge/issues/1406): This table should somehow be
-					// expressed via the mapping file, rather than hardcoding for each provider here.
-					switch resource.Addr.Resource.Type {
-					case "aws_ecs_cluster":
-						id, err = getString(resource.Addr.Resource, obj, "name")
-						if err != nil {
-							return nil, err
-						}
-					case "aws_ecs_service":
-						cluster, err := getString(resource.Addr.Resource, obj, "cluster")
-						if err != nil {
-							return nil, err
-						}
-						name, err := getString(resource.Addr.Resource, obj, "name")
-						if err != nil {
-							return nil, err
-						}
-
-						parts := strings.Split(cluster, "/")
-						id = fmt.Sprintf("%s/%s", parts[len(parts)-1], name)
-					case "aws_ecs_task_definition":
-						id, err = getString(resource.Addr.Resource, obj, "arn")
-						if err != nil {
-							return nil, err
-						}
-					case "aws_route":
-						routeTable, err := getString(resource.Addr.Resource, obj, "route_table_id")
-						if err != nil {
-							return nil, err
-						}
-						destinationCidr, err := getString(resource.Addr.Resource, obj, "destination_cidr_block")
-						if err != nil {
-							return nil, err
-						}
-
-						id = fmt.Sprintf("%s_%s", routeTable, destinationCidr)
-					case "aws_route_table_association":
-						subnet, err := getString(resource.Addr.Resource, obj, "subnet_id")
-						if err != nil {
-							return nil, err
-						}
-						routeTable, err := getString(resource.Addr.Resource, obj, "route_table_id")
-						if err != nil {
-							return nil, err
-						}
-
-						id = fmt.Sprintf("%s/%s", subnet, routeTable)
-					case "aws_iam_role_policy_attachment":
-						role, err := getString(resource.Addr.Resource, obj, "role")
-						if err != nil {
-							return nil, err
-						}
-						policy, err := getString(resource.Addr.Resource, obj, "policy_arn")
-						if err != nil {
-							return nil, err
-						}
-
-						id = fmt.Sprintf("%s/%s", role, policy)
-					default:
-						// We only care about the id value
-						id, err = getString(resource.Addr.Resource, obj, "id")
-						if err != nil {
-							return nil, err
-						}
+				case "aws_ecs_service":
+					cluster, err := getString(resource.Addr.Resource, obj, "cluster")
+					if err != nil {
+						return nil, err
+					}
+					name, err := getString(resource.Addr.Resource, obj, "name")
+					if err != nil {
+						return nil, err
 					}
 
-					// Try to grab the info for this resource type
-					tfType := resource.Addr.Resource.Type
-					provider := impliedProvider(tfType)
-					providerInfo, err := info.GetProviderInfo("", "", provider, "")
+					parts := strings.Split(cluster, "/")
+					id = fmt.Sprintf("%s/%s", parts[len(parts)-1], name)
+				case "aws_ecs_task_definition":
+					id, err = getString(resource.Addr.Resource, obj, "arn")
 					if err != nil {
-						// Don't fail the import, just warn
+						return nil, err
+					}
+				case "aws_route":
+					routeTable, err := getString(resource.Addr.Resource, obj, "route_table_id")
+					if err != nil {
+						return nil, err
+					}
+					destinationCidr, err := getString(resource.Addr.Resource, obj, "destination_cidr_block")
+					if err != nil {
+						return nil, err
+					}
+
+					id = fmt.Sprintf("%s_%s", routeTable, destinationCidr)
+				case "aws_route_table_association":
+					subnet, err := getString(resource.Addr.Resource, obj, "subnet_id")
+					if err != nil {
+						return nil, err
+					}
+					routeTable, err := getString(resource.Addr.Resource, obj, "route_table_id")
+					if err != nil {
+						return nil, err
+					}
+
+					id = fmt.Sprintf("%s/%s", subnet, routeTable)
+				case "aws_iam_role_policy_attachment":
+					role, err := getString(resource.Addr.Resource, obj, "role")
+					if err != nil {
+						return nil, err
+					}
+					policy, err := getString(resource.Addr.Resource, obj, "policy_arn")
+					if err != nil {
+						return nil, err
+					}
+
+					id = fmt.Sprintf("%s/%s", role, policy)
+				default:
+					// We only care about the id value
+					id, err = getString(resource.Addr.Resource, obj, "id")
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				// Try to grab the info for this resource type
+				tfType := resource.Addr.Resource.Type
+				provider := impliedProvider(tfType)
+				providerInfo, err := info.GetProviderInfo("", "", provider, "")
+				if err != nil {
+					// Don't fail the import, just warn
+					diagnostics = append(diagnostics, &hcl.Diagnostic{
+						Severity: hcl.DiagWarning,
+						Summary:  "Failed to get provider info",
+						Detail:   fmt.Sprintf("Failed to get provider info for %q: %v", tfType, err),
+					})
+				}
+
+				// Get the pulumi type of this resource
+				pulumiType := impliedToken(tfType)
+				if providerInfo != nil {
+					resourceInfo := providerInfo.Resources[tfType]
+					if resourceInfo != nil {
+						pulumiType = resourceInfo.Tok.String()
+					} else {
 						diagnostics = append(diagnostics, &hcl.Diagnostic{
 							Severity: hcl.DiagWarning,
 							Summary:  "Failed to get provider info",
-							Detail:   fmt.Sprintf("Failed to get provider info for %q: %v", tfType, err),
+							Detail:   fmt.Sprintf("Failed to get resource info for %q", tfType),
 						})
 					}
+				}
 
-					// Get the pulumi type of this resource
-					pulumiType := impliedToken(tfType)
-					if providerInfo != nil {
-						resourceInfo := providerInfo.Resources[tfType]
-						if resourceInfo != nil {
-							pulumiType = resourceInfo.Tok.String()
-						} else {
-							diagnostics = append(diagnostics, &hcl.Diagnostic{
-								Severity: hcl.DiagWarning,
-								Summary:  "Failed to get provider info",
-								Detail:   fmt.Sprintf("Failed to get resource info for %q", tfType),
-							})
-						}
-					}
-
-					// Add a suffix to the name if there is more than one instance
-					name := resource.Addr.Resource.Name
-					switch instanceAddr.(type) {
-					case addrs.IntKey:
-						flt := instanceAddr.Value().AsBigFloat()
-						i, a := flt.Int64()
-						contract.Assertf(a == big.Exact, "expected exact conversion to int64")
-						name = fmt.Sprintf("%s-%d", name, i)
-					case addrs.StringKey:
-						name = fmt.Sprintf("%s-%s", name, instanceAddr.Value().AsString())
-					}
+				// Add a suffix to the name if there is more than one instance
+				name := instanceName(resource.Addr.Resource.Name, instanceAddr, index, options.instanceNaming)
 
-					resources = append(resources, plugin.ResourceImport{
-						Type: pulumiType,
-						Name: name,
-						ID:   id,
+				// Pulumi needs every resource of a type to have a unique name, which might not be the case if
+				// the same name is used in multiple modules or the instance suffix clashes with another resource.
+				if other, has := names[pulumiType+"::"+name]; has {
+					diagnostics = append(diagnostics, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Duplicate resource name",
+						Detail: fmt.Sprintf("%s and %s would both be imported as %s %q",
+							other, resource.Addr.Instance(instanceAddr), pulumiType, name),
 					})
+					continue
 				}
+				names[pulumiType+"::"+name] = resource.Addr.Instance(instanceAddr).String()
+
+				resources = append(resources, plugin.ResourceImport{
+					Type: pulumiType,
+					Name: name,
+					ID:   id,
+				})
 			}
 		}
 	}
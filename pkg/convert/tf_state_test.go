@@ -26,6 +26,7 @@ import (
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -114,3 +115,67 @@ func TestTranslateState(t *testing.T) {
 		})
 	}
 }
+
+func TestTranslateStateInstanceNaming(t *testing.T) {
+	t.Parallel()
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	info := il.NewMapperProviderInfoSource(mapper)
+
+	tests := []struct {
+		naming   InstanceNaming
+		state    string
+		expected []string
+	}{
+		{InstanceNamingKey, "range", []string{"a_resource-hello", "a_resource-goodbye"}},
+		{InstanceNamingKey, "count", []string{"a_resource-0", "a_resource-1"}},
+		{InstanceNamingIndex, "range", []string{"a_resource-1", "a_resource-0"}},
+		{InstanceNamingIndex, "count", []string{"a_resource-0", "a_resource-1"}},
+		{InstanceNamingHash, "range", []string{"a_resource-2cf24dba", "a_resource-82e35a63"}},
+		{InstanceNamingHash, "simple", []string{"a_resource"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.naming)+"/"+tt.state, func(t *testing.T) {
+			t.Parallel()
+
+			statePath := filepath.Join("testdata", "states", tt.state, "tfstate.json")
+			actualImport, err := TranslateState(info, statePath, WithInstanceNaming(tt.naming))
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, resource := range actualImport.Resources {
+				names = append(names, resource.Name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+
+		statePath := filepath.Join("testdata", "states", "simple", "tfstate.json")
+		_, err := TranslateState(info, statePath, WithInstanceNaming("uuid"))
+		assert.EqualError(t, err, `unknown instance naming "uuid", expected key, index or hash`)
+	})
+}
+
+func TestTranslateStateFromMemory(t *testing.T) {
+	t.Parallel()
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	info := il.NewMapperProviderInfoSource(mapper)
+
+	statePath := filepath.Join("testdata", "states", "simple", "tfstate.json")
+	state, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	source := afero.NewMemMapFs()
+	err = afero.WriteFile(source, "/terraform.tfstate", state, 0o600)
+	require.NoError(t, err)
+
+	expected, err := TranslateState(info, statePath)
+	require.NoError(t, err)
+	actual, err := TranslateState(info, "/terraform.tfstate", WithStateSource(source))
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,140 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Statistics are counts of what a conversion couldn't convert. They only contain the kinds of construct (e.g.
+// function and resource type names), never names or values from the program being converted.
+type Statistics struct {
+	// The number of resources and data sources converted.
+	Resources int `json:"resources"`
+	// The number of notImplemented calls emitted for each kind of construct, e.g. "function:templatefile" or
+	// "reference:path.cwd".
+	NotImplemented map[string]int `json:"notImplemented"`
+	// The number of resources and data sources of each type that had no mapping to a Pulumi type.
+	UnmappedResources map[string]int `json:"unmappedResources"`
+	// The number of waits for eventual consistency of each kind, e.g. "time_sleep" or "local-exec:retry".
+	Waits map[string]int `json:"waits,omitempty"`
+}
+
+// WithStatistics calls the given function with the statistics for the conversion once it's finished. This is opt-in
+// so that tools can record which gaps in the converter matter most in practice.
+func WithStatistics(callback func(Statistics)) TranslateOption {
+	return func(o *translateOptions) {
+		o.onStatistics = callback
+	}
+}
+
+func newStatistics() *Statistics {
+	return &Statistics{
+		NotImplemented:    map[string]int{},
+		UnmappedResources: map[string]int{},
+		Waits:             map[string]int{},
+	}
+}
+
+// Records that a notImplemented call was emitted for the given kind of construct.
+func (s *convertState) countNotImplemented(kind string) {
+	if s.statistics != nil {
+		s.statistics.NotImplemented[kind]++
+	}
+}
+
+// Records that a resource waits for eventual consistency in the given way.
+func (s *convertState) countWait(kind string) {
+	if s.statistics != nil {
+		s.statistics.Waits[kind]++
+	}
+}
+
+// Coverage returns the percentage of resources and data sources that had a mapping to a Pulumi type.
+func (s Statistics) Coverage() float64 {
+	if s.Resources == 0 {
+		return 100
+	}
+	unmapped := 0
+	for _, count := range s.UnmappedResources {
+		unmapped += count
+	}
+	return 100 * float64(s.Resources-unmapped) / float64(s.Resources)
+}
+
+// Records that a resource or data source was converted.
+func (s *convertState) countResource() {
+	if s.statistics != nil {
+		s.statistics.Resources++
+	}
+}
+
+// Records that the given resource or data source type had no mapping.
+func (s *convertState) countUnmappedResource(typ string) {
+	if s.statistics != nil {
+		s.statistics.UnmappedResources[typ]++
+	}
+}
+
+// WithSummary replaces the warnings from a conversion with a single summary of how many resources were converted,
+// how many warnings and errors there were and the percentage of resources that had a mapping. Errors are still
+// returned.
+func WithSummary() TranslateOption {
+	return func(o *translateOptions) {
+		o.summary = true
+	}
+}
+
+// WithMinimumCoverage adds an error if the percentage of resources that had a mapping to a Pulumi type is below the
+// given percentage. This allows conversions to be used as a quality gate.
+func WithMinimumCoverage(percent float64) TranslateOption {
+	return func(o *translateOptions) {
+		o.minimumCoverage = percent
+	}
+}
+
+// summarizeDiagnostics applies the summary and minimum coverage options to the diagnostics of a conversion.
+func summarizeDiagnostics(
+	options translateOptions, statistics Statistics, diagnostics hcl.Diagnostics,
+) hcl.Diagnostics {
+	coverage := statistics.Coverage()
+	if coverage < options.minimumCoverage {
+		diagnostics = append(diagnostics, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Coverage below minimum",
+			Detail: fmt.Sprintf("%.1f%% of resources were mapped to Pulumi types, the minimum is %.1f%%",
+				coverage, options.minimumCoverage),
+		})
+	}
+	if !options.summary {
+		return diagnostics
+	}
+
+	errors := diagnostics.Errs()
+	summarized := hcl.Diagnostics{}
+	for _, diag := range diagnostics {
+		if diag.Severity == hcl.DiagError {
+			summarized = append(summarized, diag)
+		}
+	}
+	return append(summarized, &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Conversion summary",
+		Detail: fmt.Sprintf("%d resources converted, %d warnings, %d errors, %.1f%% coverage",
+			statistics.Resources, len(diagnostics)-len(errors), len(errors), coverage),
+	})
+}
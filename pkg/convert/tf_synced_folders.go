@@ -0,0 +1,270 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Static sites are usually deployed with an aws_s3_object for each file in a directory, from
+// `for_each = fileset(dir, "**")`, with a content_type looked up from the file's extension in a map of MIME types.
+// fileset has no Pulumi equivalent, so these uploads are converted to a loop over readDir if they only upload the
+// files at the top of the directory, with the content type from mimeType. WithSyncedFolders converts them to a
+// synced folder component instead, which uploads every file in the directory and sets their content types itself.
+
+// WithSyncedFolders converts the aws_s3_object resources that upload every file in a directory, with for_each over
+// fileset, to a synced-folder S3BucketFolder component.
+func WithSyncedFolders() TranslateOption {
+	return func(o *translateOptions) {
+		o.syncedFolders = true
+	}
+}
+
+// The token of the synced folder component that uploads a directory to an S3 bucket.
+const syncedFolderToken = "synced-folder:index:S3BucketFolder"
+
+// The attributes of an upload that a synced folder sets itself, from the directory it uploads. Uploads that set any
+// other attributes, like cache_control or tags, aren't converted to a synced folder.
+var syncedFolderAttributes = map[string]bool{
+	"acl":          true,
+	"bucket":       true,
+	"content_type": true,
+	"etag":         true,
+	"key":          true,
+	"source":       true,
+	"source_hash":  true,
+}
+
+// folderUpload is an aws_s3_object that uploads the files in a directory, with for_each over fileset.
+type folderUpload struct {
+	// The directory and pattern given to fileset.
+	dir     hclsyntax.Expression
+	pattern string
+	// Set if the upload is converted to a synced folder.
+	synced bool
+}
+
+// isEachReference returns true if expr is each.key or each.value, which are the same for a set.
+func isEachReference(expr hcl.Expression) bool {
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != "each" {
+		return false
+	}
+	attr, ok := traversal.Traversal[1].(hcl.TraverseAttr)
+	return ok && (attr.Name == "key" || attr.Name == "value")
+}
+
+// findFolderUpload returns the directory resource uploads, if it's an aws_s3_object with for_each over fileset of a
+// literal pattern that's keyed by the file's path.
+func findFolderUpload(resource *configs.Resource) *folderUpload {
+	if (resource.Type != "aws_s3_object" && resource.Type != "aws_s3_bucket_object") || resource.Count != nil {
+		return nil
+	}
+	forEach, ok := resource.ForEach.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+	call, ok := unwrapParentheses(forEach).(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "fileset" || len(call.Args) != 2 || call.ExpandFinal {
+		return nil
+	}
+	call = normalizePathArguments(call)
+	pattern, ok := staticString(call.Args[1])
+	if !ok {
+		return nil
+	}
+	attributes := bodyContent(resource.Config).Attributes
+	if key, has := attributes["key"]; !has || !isEachReference(key.Expr) {
+		return nil
+	}
+	if _, has := attributes["source"]; !has {
+		return nil
+	}
+	return &folderUpload{dir: call.Args[0], pattern: pattern}
+}
+
+// isSyncedFolder returns true if resource, which uploads upload's directory, can be converted to a synced folder: it
+// uploads every file in the directory, sets no attributes the synced folder doesn't, and has no provisioners.
+func isSyncedFolder(resource *configs.Resource, upload *folderUpload) bool {
+	if upload.pattern != "**" && upload.pattern != "**/*" {
+		return false
+	}
+	if resource.Managed != nil && len(resource.Managed.Provisioners) > 0 {
+		return false
+	}
+	content := bodyContent(resource.Config)
+	if len(content.Blocks) > 0 {
+		return false
+	}
+	for name := range content.Attributes {
+		if !syncedFolderAttributes[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// findFolderUploads returns the folder uploads in items, keyed by their address. If synced folders are being
+// converted, those that nothing refers to and can be are marked as synced, as the component has none of the
+// attributes of the objects it uploads.
+func findFolderUploads(state *convertState, items terraformItems) map[string]*folderUpload {
+	uploads := map[string]*folderUpload{}
+	for _, item := range items {
+		if item.resource == nil || item.resource.Managed == nil {
+			continue
+		}
+		if upload := findFolderUpload(item.resource); upload != nil {
+			uploads[item.itemKey()] = upload
+		}
+	}
+	if len(uploads) == 0 || !state.syncedFolders {
+		return uploads
+	}
+
+	referenced := map[string]bool{}
+	for _, item := range items {
+		for _, traversal := range item.references() {
+			referenced[referenceKey(traversal)] = true
+		}
+	}
+	for _, item := range items {
+		if upload, has := uploads[item.itemKey()]; has && item.resource != nil && !referenced[item.itemKey()] {
+			upload.synced = isSyncedFolder(item.resource, upload)
+		}
+	}
+	return uploads
+}
+
+// convertFolderRange returns the range of an upload of the files at the top of its directory, as a map from each
+// file's name to itself like the range of other sets, or false if the upload's pattern matches any other files.
+func convertFolderRange(state *convertState, scopes *scopes, upload *folderUpload) (hclwrite.Tokens, bool) {
+	if upload.pattern != "*" {
+		return nil, false
+	}
+	files := hclwrite.TokensForFunctionCall("readDir", convertExpression(state, false, scopes, "", upload.dir))
+	return tokensForSetRange(files), true
+}
+
+// isContentTypeLookup returns true if expr looks up a content type for each file, like
+// lookup(local.mime_types, regex("\\.[^.]+$", each.value), null) or local.mime_types[...each.value...].
+func isContentTypeLookup(expr hcl.Expression) bool {
+	syntax, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return false
+	}
+	var key hclsyntax.Expression
+	switch expr := unwrapParentheses(syntax).(type) {
+	case *hclsyntax.FunctionCallExpr:
+		if expr.Name != "lookup" || len(expr.Args) < 2 {
+			return false
+		}
+		key = expr.Args[1]
+	case *hclsyntax.IndexExpr:
+		key = expr.Key
+	default:
+		return false
+	}
+	for _, traversal := range key.Variables() {
+		if traversal.RootName() == "each" {
+			return true
+		}
+	}
+	return false
+}
+
+// convertFolderContentType returns the content type of each file of an upload as mimeType of its source, if its
+// content_type looks it up from the file's name. Terraform has no function for it, so programs look it up from a
+// map of extensions which is converted to expressions that have no equivalent in most languages.
+func convertFolderContentType(state *convertState, scopes *scopes, resource *configs.Resource) (hclwrite.Tokens, bool) {
+	attributes := bodyContent(resource.Config).Attributes
+	contentType, has := attributes["content_type"]
+	if !has || !isContentTypeLookup(contentType.Expr) {
+		return nil, false
+	}
+	source := convertExpression(state, false, scopes, "", attributes["source"].Expr)
+	return hclwrite.TokensForFunctionCall("mimeType", source), true
+}
+
+// convertSyncedFolder sets the inputs of the synced folder that upload is converted to on body.
+func convertSyncedFolder(
+	state *convertState, scopes *scopes, resource *configs.Resource, upload *folderUpload, body *hclwrite.Body,
+) {
+	attributes := bodyContent(resource.Config).Attributes
+	body.SetAttributeRaw("path", convertExpression(state, false, scopes, "", upload.dir))
+	if bucket, has := attributes["bucket"]; has {
+		body.SetAttributeRaw("bucketName", convertExpression(state, false, scopes, "", bucket.Expr))
+	}
+	if acl, has := attributes["acl"]; has {
+		body.SetAttributeRaw("acl", convertExpression(state, false, scopes, "", acl.Expr))
+	} else {
+		body.SetAttributeValue("acl", cty.StringVal("private"))
+	}
+}
+
+// folderUploadComment returns comment lines to write above a converted folder upload, saying what it was converted
+// from, and warns about uploads that couldn't be converted. It returns nil if resource isn't a folder upload.
+func folderUploadComment(
+	state *convertState, resource *configs.Resource, upload *folderUpload, ranged bool,
+) hclwrite.Tokens {
+	if upload == nil {
+		return nil
+	}
+	fileset := strings.TrimSpace(state.sourceCode(resource.ForEach.Range()))
+	var lines []string
+	switch {
+	case upload.synced:
+		var dropped []string
+		for name := range bodyContent(resource.Config).Attributes {
+			if name != "acl" && name != "bucket" {
+				dropped = append(dropped, name)
+			}
+		}
+		sort.Strings(dropped)
+		lines = append(lines,
+			fmt.Sprintf("Converted from an %s for each file in %s.", resource.Type, fileset),
+			fmt.Sprintf("The synced folder sets %s of each object itself.", strings.Join(dropped, ", ")))
+	case ranged:
+		lines = append(lines, fmt.Sprintf("Converted from an %s for each file in %s.", resource.Type, fileset))
+	default:
+		detail := fmt.Sprintf("%s.%s uploads each file in %s, which has no Pulumi equivalent", resource.Type,
+			resource.Name, fileset)
+		if !state.syncedFolders {
+			detail += ", convert it to a synced folder to upload the directory"
+		}
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Folder upload not converted",
+			Detail:   detail,
+			Subject:  resource.ForEach.Range().Ptr(),
+		})
+	}
+
+	var tokens hclwrite.Tokens
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
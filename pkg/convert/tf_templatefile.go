@@ -0,0 +1,162 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// templatefile(path, vars) renders a template file, like a user-data script, with the given variables. The
+// template is written in the same syntax as terraform string templates, so when the file can be found at conversion
+// time it's read and converted like a string template in the program, with references to its variables replaced by
+// the expressions given for them.
+
+// templateVariables are the variables of the template file being converted.
+type templateVariables struct {
+	// The expression for each variable, if the variables are given as an object.
+	values map[string]hclsyntax.Expression
+	// The expression the variables are given as, if they aren't an object.
+	object hclsyntax.Expression
+	// The number of scopes.locals outside the template, locals pushed after these are the template's own for
+	// expression variables.
+	depth int
+}
+
+// templateFilePath returns the path of the template file a templatefile call reads, relative to the module, if it's
+// a literal path or a literal path under path.module.
+func templateFilePath(expr hclsyntax.Expression) (string, bool) {
+	if str, ok := staticString(expr); ok {
+		return str, !filepath.IsAbs(str)
+	}
+	template, ok := expr.(*hclsyntax.TemplateExpr)
+	if !ok || len(template.Parts) != 2 {
+		return "", false
+	}
+	module, ok := template.Parts[0].(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(module.Traversal) != 2 || module.Traversal.RootName() != "path" {
+		return "", false
+	}
+	if attr, ok := module.Traversal[1].(hcl.TraverseAttr); !ok || attr.Name != "module" {
+		return "", false
+	}
+	rest, ok := staticString(template.Parts[1])
+	if !ok || !strings.HasPrefix(rest, "/") {
+		return "", false
+	}
+	return path.Clean(rest[1:]), true
+}
+
+// convertTemplateFile returns call, a templatefile call, converted to a string template of the template file, or
+// false if the file can't be read and parsed.
+func convertTemplateFile(
+	state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if call.Name != "templatefile" || len(call.Args) != 2 || call.ExpandFinal || state.sourceRoot == nil {
+		return nil, false
+	}
+	relative, ok := templateFilePath(call.Args[0])
+	if !ok {
+		return nil, false
+	}
+
+	filename := filepath.Join(state.sourceDirectory, filepath.FromSlash(relative))
+	// Templates that can't be read are left as not implemented, which is already reported.
+	source, err := afero.ReadFile(state.sourceRoot, filename)
+	if err != nil {
+		return nil, false
+	}
+	template, diags := hclsyntax.ParseTemplate(source, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Template file not converted",
+			Detail:   fmt.Sprintf("Could not parse the template file %s: %s", relative, diags.Error()),
+			Subject:  call.Args[0].Range().Ptr(),
+		})
+		return nil, false
+	}
+	// The template's trivia is read from its source like the program's.
+	state.sources[filename] = source
+
+	variables := &templateVariables{depth: len(scopes.locals)}
+	if object, ok := call.Args[1].(*hclsyntax.ObjectConsExpr); ok {
+		variables.values = map[string]hclsyntax.Expression{}
+		for _, item := range object.Items {
+			name, _ := matchStaticString(item.KeyExpr)
+			if name == nil {
+				variables.values = nil
+				break
+			}
+			variables.values[*name] = item.ValueExpr
+		}
+	}
+	if variables.values == nil {
+		variables.object = call.Args[1]
+	}
+
+	outer := state.templateVariables
+	state.templateVariables = variables
+	defer func() { state.templateVariables = outer }()
+
+	return convertExpression(state, false, scopes, "", template), true
+}
+
+// templateVariable returns the expression a reference to a variable of the template file being converted is
+// converted to, the expression given for the variable followed by the rest of the traversal. It returns false if
+// no template file is being converted or the reference is to a variable of one of the template's for directives.
+func templateVariable(state *convertState, scopes *scopes, traversal hcl.Traversal) (hclsyntax.Expression, bool) {
+	variables := state.templateVariables
+	if variables == nil {
+		return nil, false
+	}
+	name := traversal.RootName()
+	for _, locals := range scopes.locals[variables.depth:] {
+		if locals[name] != "" {
+			return nil, false
+		}
+	}
+
+	rng := traversal.SourceRange()
+	rest := traversal[1:]
+	var value hclsyntax.Expression
+	if variables.values != nil {
+		value = variables.values[name]
+		if value == nil {
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Unknown template variable",
+				Detail:   fmt.Sprintf("The template refers to %s but templatefile wasn't given a value for it", name),
+				Subject:  &rng,
+			})
+			return &hclsyntax.LiteralValueExpr{Val: cty.NullVal(cty.DynamicPseudoType), SrcRange: rng}, true
+		}
+	} else {
+		value = variables.object
+		rest = append(hcl.Traversal{hcl.TraverseAttr{Name: name, SrcRange: rng}}, rest...)
+	}
+	if len(rest) == 0 {
+		return value, true
+	}
+	return &hclsyntax.RelativeTraversalExpr{Source: value, Traversal: rest, SrcRange: rng}, true
+}
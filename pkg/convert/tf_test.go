@@ -15,11 +15,25 @@
 package convert
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/slog"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
 )
 
 func TestProjectListToSingleton(t *testing.T) {
@@ -65,3 +79,926 @@ func TestProjectListToSingleton(t *testing.T) {
 		})
 	}
 }
+
+// translateTestSource translates the given terraform source using the testdata mappings, returning the
+// destination filesystem and the diagnostics.
+func translateTestSource(t *testing.T, source string, opts ...TranslateOption) (afero.Fs, hcl.Diagnostics) {
+	t.Helper()
+
+	return translateTestFiles(t, map[string]string{"/main.tf": source}, opts...)
+}
+
+// translateTestFiles is like translateTestSource but allows multiple source files to be given, keyed by path.
+func translateTestFiles(t *testing.T, files map[string]string, opts ...TranslateOption) (afero.Fs, hcl.Diagnostics) {
+	t.Helper()
+
+	return translateTestDirectory(t, files, "/", opts...)
+}
+
+// translateTestDirectory is like translateTestFiles but translates the module in the given directory of the source
+// files, with the given options.
+func translateTestDirectory(
+	t *testing.T, files map[string]string, directory string, opts ...TranslateOption,
+) (afero.Fs, hcl.Diagnostics) {
+	t.Helper()
+
+	src := afero.NewMemMapFs()
+	for path, source := range files {
+		err := afero.WriteFile(src, path, []byte(source), 0o600)
+		require.NoError(t, err)
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, directory, dst, il.NewMapperProviderInfoSource(mapper), opts...)
+	return dst, diagnostics
+}
+
+func TestUnknownProperties(t *testing.T) {
+	t.Parallel()
+
+	_, diagnostics := translateTestSource(t, `
+resource "renames_resource" "a_resource" {
+    a_number = 1
+    not_a_number = 2
+    a_resource {
+        inner_string = "hello"
+        not_inner_string = "world"
+    }
+    not_a_block {
+        value = "hello"
+    }
+}
+
+resource "complex_resource" "a_resource" {
+    inner_object = {
+        inner_string = "hello"
+        not_inner_string = "world"
+    }
+}
+
+resource "unknown_resource" "a_resource" {
+    not_known = true
+}
+`)
+
+	details := []string{}
+	for _, diag := range diagnostics {
+		if diag.Summary == "Unknown property" {
+			details = append(details, diag.Detail)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		`The property "renames_resource.a_resource.not_a_number" is not defined in the provider schema`,
+		`The property "renames_resource.a_resource.a_resource.not_inner_string" is not defined in the provider schema`,
+		`The property "renames_resource.a_resource.not_a_block" is not defined in the provider schema`,
+		`The property "complex_resource.a_resource.inner_object.not_inner_string" is not defined in the provider schema`,
+	}, details)
+}
+
+func TestDataSourceToken(t *testing.T) {
+	t.Parallel()
+
+	// Without provider info we should still get the right tokens for well known data sources
+	assert.Equal(t, "aws:index/getCallerIdentity:getCallerIdentity", dataSourceToken("aws_caller_identity", nil))
+	assert.Equal(t, "aws:index/getPartition:getPartition", dataSourceToken("aws_partition", nil))
+	assert.Equal(t, "aws:index/getRegion:getRegion", dataSourceToken("aws_region", nil))
+	// and fallback to a best guess for everything else
+	assert.Equal(t, "simple:index:dataSource", dataSourceToken("simple_data_source", nil))
+
+	info := &tfbridge.DataSourceInfo{Tok: "aws:index/getRegion:getRegionV2"}
+	assert.Equal(t, "aws:index/getRegion:getRegionV2", dataSourceToken("aws_region", info))
+}
+
+// TestRequiredVersion checks that modules which fail to load report the unsupported version first, the warning for
+// modules that do load is in the required_version_unsupported program.
+func TestRequiredVersion(t *testing.T) {
+	t.Parallel()
+
+	// removed blocks are newer than the converter, so fail to load
+	_, diagnostics := translateTestSource(t, `
+terraform {
+    required_version = ">= 1.7.0"
+}
+
+removed {
+    from = simple_resource.a_resource
+}
+`)
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, hcl.DiagError, diagnostics[0].Severity)
+	assert.Equal(t, "Unsupported Terraform version", diagnostics[0].Summary)
+	assert.Greater(t, len(diagnostics), 1)
+}
+
+func TestTranslateWithRoot(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"/repo/stacks/prod/main.tf": `
+module "bucket" {
+    source = "../../shared/bucket"
+    input = "hello"
+}
+
+module "local" {
+    source = "./mod"
+}
+`,
+		"/repo/stacks/prod/mod/main.tf": `
+module "nested" {
+    source = "../../../shared/network"
+}
+`,
+		"/repo/shared/bucket/main.tf": `
+variable "input" {}
+
+module "network" {
+    source = "../network"
+}
+`,
+		"/repo/shared/network/main.tf": `
+output "text" {
+    value = "hello"
+}
+`,
+	}
+
+	t.Run("modules inside root", func(t *testing.T) {
+		t.Parallel()
+
+		dst, diagnostics := translateTestDirectory(t, files, "/repo/stacks/prod", WithRoot("/repo"))
+		require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+		main, err := afero.ReadFile(dst, "/main.pp")
+		require.NoError(t, err)
+		assert.Contains(t, string(main), `component "bucket" "./shared/bucket"`)
+		assert.Contains(t, string(main), `component "local" "./mod"`)
+
+		bucket, err := afero.ReadFile(dst, "/shared/bucket/main.pp")
+		require.NoError(t, err)
+		assert.Contains(t, string(bucket), `component "network" "../network"`)
+
+		local, err := afero.ReadFile(dst, "/mod/main.pp")
+		require.NoError(t, err)
+		assert.Contains(t, string(local), `component "nested" "../shared/network"`)
+
+		_, err = afero.ReadFile(dst, "/shared/network/main.pp")
+		require.NoError(t, err)
+	})
+
+	t.Run("modules outside root", func(t *testing.T) {
+		t.Parallel()
+
+		_, diagnostics := translateTestDirectory(t, files, "/repo/stacks/prod", WithRoot("/repo/stacks"))
+		require.True(t, diagnostics.HasErrors())
+		assert.Equal(t, "Module outside of root", diagnostics[0].Summary)
+		assert.Equal(t,
+			`The module source "../../shared/bucket" resolves to "/repo/shared/bucket" `+
+				`which is outside of the root directory "/repo/stacks"`,
+			diagnostics[0].Detail)
+	})
+
+	t.Run("source outside root", func(t *testing.T) {
+		t.Parallel()
+
+		_, diagnostics := translateTestDirectory(t, files, "/repo/stacks/prod", WithRoot("/repo/shared"))
+		require.True(t, diagnostics.HasErrors())
+		assert.Equal(t, "Source directory outside of root", diagnostics[0].Summary)
+	})
+}
+
+// TestTranslateWithModuleLayout checks that invalid layouts are rejected, a valid layout is converted in the
+// module_layout program.
+func TestTranslateWithModuleLayout(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(`
+module "vpc" {
+    source = "./modules/vpc"
+}
+`), 0o600)
+	require.NoError(t, err)
+	err = afero.WriteFile(src, "/modules/vpc/main.tf", []byte(`
+output "text" {
+    value = "hello"
+}
+`), 0o600)
+	require.NoError(t, err)
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+
+	for _, layout := range []string{"infra", "../{module}", "/infra/{module}"} {
+		layout := layout
+		t.Run(layout, func(t *testing.T) {
+			t.Parallel()
+
+			dst := afero.NewMemMapFs()
+			diagnostics := TranslateModule(src, "/", dst,
+				il.NewMapperProviderInfoSource(mapper), WithModuleLayout(layout))
+			require.True(t, diagnostics.HasErrors())
+			assert.Equal(t, "Invalid module layout", diagnostics[0].Summary)
+		})
+	}
+}
+
+func TestTranslateWithLogger(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(`
+resource "simple_resource" "a_resource" {
+    input_one = "hello"
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/", dst, il.NewMapperProviderInfoSource(mapper), WithLogger(logger))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	assert.Contains(t, logs.String(), `level=INFO msg="converting module" source=/`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="converting file" file=/main.tf`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="converting item" address=simple_resource.a_resource`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="looking up provider mapping" provider=simple`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="wrote file" path=/main.pp`)
+}
+
+func TestTranslateRecoversFromPanics(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+resource "acme_database" "main" {
+  engine_version = "15"
+}
+
+resource "simple_resource" "a_resource" {
+  input_one = "hello"
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	hook := func(*HookedResource) hcl.Diagnostics {
+		panic("gnarly expression")
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithResourceHook("acme_database", "acme:index:Database", hook))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "Internal error", diagnostics[0].Summary)
+	assert.Contains(t, diagnostics[0].Detail, "failed converting acme_database.main")
+	assert.Contains(t, diagnostics[0].Detail, "gnarly expression")
+	assert.Contains(t, diagnostics[0].Detail, "convertItemSafely")
+
+	// The rest of the program is still converted.
+	program, err := afero.ReadFile(dst, "/main.pp")
+	require.NoError(t, err)
+	assert.Contains(t, string(program), `// The converter failed converting resource "acme_database" "main"`)
+	assert.Contains(t, string(program), `resource "aResource" "simple:index:resource" {`)
+}
+
+func TestTranslateWithBugReport(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+resource "acme_database" "main" {
+  # the production password
+  password = "hunter2"
+  engine   = "postgres-${var.version}"
+  replicas = 3
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	hook := func(*HookedResource) hcl.Diagnostics {
+		panic("gnarly expression")
+	}
+
+	var report BugReport
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithResourceHook("acme_database", "acme:index:Database", hook),
+		WithBugReport(func(r BugReport) { report = r }))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	require.Len(t, report.Errors, 1)
+	assert.NotEmpty(t, report.ConverterVersion)
+	assert.Equal(t, "acme_database.main", report.Errors[0].Address)
+	assert.Equal(t, "gnarly expression", report.Errors[0].Error)
+	assert.Contains(t, report.Errors[0].Stack, "convertItemSafely")
+	// The structure of the block is kept, but not its strings or comments.
+	assert.Equal(t, `resource "acme_database" "main" {
+
+  password = "REDACTED"
+  engine   = "REDACTED${var.version}"
+  replicas = 3
+}`, report.Errors[0].Source)
+}
+
+func TestTranslateWithContext(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+resource "simple_resource" "a_resource" {
+  input_one = "hello"
+}
+`), 0o600)
+	require.NoError(t, err)
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper), WithContext(ctx))
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, "Conversion cancelled", diagnostics[0].Summary)
+	// Nothing is written for a module that wasn't fully converted.
+	exists, err := afero.Exists(dst, "/main.pp")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	diagnostics = TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper), WithContext(ctx))
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, "Conversion timed out", diagnostics[0].Summary)
+}
+
+func TestStatistics(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(`
+resource "unknown_resource" "a" {
+    input = plantimestamp()
+}
+
+resource "unknown_resource" "b" {
+    input = "${path.cwd}-${plantimestamp()}"
+}
+
+data "unknown_data" "c" {
+}
+
+resource "simple_resource" "d" {
+    input_one = "hello"
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	var statistics *Statistics
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/", dst, il.NewMapperProviderInfoSource(mapper),
+		WithStatistics(func(s Statistics) {
+			statistics = &s
+		}))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	require.NotNil(t, statistics)
+	assert.Equal(t, 4, statistics.Resources)
+	assert.Equal(t, 25.0, statistics.Coverage())
+	assert.Equal(t, map[string]int{
+		"function:plantimestamp": 2,
+		"reference:path.cwd":     1,
+	}, statistics.NotImplemented)
+	assert.Equal(t, map[string]int{
+		"unknown_resource": 2,
+		"unknown_data":     1,
+	}, statistics.UnmappedResources)
+}
+
+func TestGroupDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	source := "locals {\n"
+	for i := 0; i < 5; i++ {
+		source += fmt.Sprintf("    a%d = plantimestamp()\n", i)
+	}
+	source += "    b = path.cwd\n}\n"
+
+	summaries := func(diagnostics hcl.Diagnostics) []string {
+		result := []string{}
+		for _, diag := range diagnostics {
+			result = append(result, fmt.Sprintf("%d:%s:%s", diag.Subject.Start.Line, diag.Summary, diag.Detail))
+		}
+		return result
+	}
+
+	_, diagnostics := translateTestSource(t, source)
+	assert.Equal(t, []string{
+		"2:Function not yet implemented:Function plantimestamp not yet implemented",
+		"3:Function not yet implemented:Function plantimestamp not yet implemented",
+		"4:Function not yet implemented:Function plantimestamp not yet implemented (2 more occurrences not shown)",
+		"7:Terraform input not yet implemented:path",
+	}, summaries(diagnostics))
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(source), 0o600)
+	require.NoError(t, err)
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	diagnostics = TranslateModule(src, "/", afero.NewMemMapFs(), il.NewMapperProviderInfoSource(mapper),
+		WithVerboseDiagnostics())
+	assert.Len(t, diagnostics, 6)
+}
+
+func TestSummary(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(`
+resource "unknown_resource" "a" {
+    input = plantimestamp()
+}
+
+resource "simple_resource" "b" {
+    input_one = plantimestamp()
+}
+
+resource "simple_resource" "c" {
+    input_one = "hello"
+}
+`), 0o600)
+	require.NoError(t, err)
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	translate := func(opts ...TranslateOption) hcl.Diagnostics {
+		return TranslateModule(src, "/", afero.NewMemMapFs(), il.NewMapperProviderInfoSource(mapper), opts...)
+	}
+
+	t.Run("summary", func(t *testing.T) {
+		t.Parallel()
+
+		diagnostics := translate(WithSummary())
+		require.Len(t, diagnostics, 1)
+		assert.Equal(t, hcl.DiagWarning, diagnostics[0].Severity)
+		assert.Equal(t, "Conversion summary", diagnostics[0].Summary)
+		assert.Equal(t, "3 resources converted, 3 warnings, 0 errors, 66.7% coverage", diagnostics[0].Detail)
+	})
+
+	t.Run("coverage met", func(t *testing.T) {
+		t.Parallel()
+
+		diagnostics := translate(WithMinimumCoverage(60))
+		assert.False(t, diagnostics.HasErrors())
+	})
+
+	t.Run("coverage not met", func(t *testing.T) {
+		t.Parallel()
+
+		diagnostics := translate(WithSummary(), WithMinimumCoverage(95))
+		require.Len(t, diagnostics, 2)
+		assert.Equal(t, hcl.DiagError, diagnostics[0].Severity)
+		assert.Equal(t, "Coverage below minimum", diagnostics[0].Summary)
+		assert.Equal(t, "66.7% of resources were mapped to Pulumi types, the minimum is 95.0%", diagnostics[0].Detail)
+		assert.Equal(t, "3 resources converted, 3 warnings, 1 errors, 66.7% coverage", diagnostics[1].Detail)
+	})
+}
+
+func TestTranslateWithUseLockfileOutOfDate(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	files := map[string]string{
+		"/prog/main.tf": `
+module "vpc" {
+    source = "terraform-aws-modules/vpc/aws"
+    version = "~> 6.0"
+}
+`,
+		"/prog/.terraform/modules/modules.json": `{"Modules":[
+    {"Key":"vpc","Source":"registry.terraform.io/terraform-aws-modules/vpc/aws","Version":"5.1.0",
+     "Dir":".terraform/modules/vpc"}
+]}`,
+	}
+	for path, source := range files {
+		err := afero.WriteFile(src, path, []byte(source), 0o600)
+		require.NoError(t, err)
+	}
+
+	state := &convertState{}
+	manifest, err := readModuleManifest(src, "/prog/.terraform/modules/modules.json")
+	require.NoError(t, err)
+	_, module, diagnostics := loadConfigDir(src, "/prog")
+	require.Empty(t, diagnostics)
+
+	options := translateOptions{manifest: manifest, manifestDirectory: "/prog", manifestKey: "vpc"}
+	_, _, ok := vendoredModule(state, options, module.ModuleCalls["vpc"])
+	assert.False(t, ok)
+	require.Len(t, state.diagnostics, 1)
+	assert.Equal(t, "Module lockfile out of date", state.diagnostics[0].Summary)
+
+	options.manifestKey = "other"
+	_, _, ok = vendoredModule(state, options, module.ModuleCalls["vpc"])
+	assert.False(t, ok)
+	require.Len(t, state.diagnostics, 2)
+	assert.Equal(t, "Module not installed", state.diagnostics[1].Summary)
+}
+
+func TestModuleCycle(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	files := map[string]string{
+		"/prog/main.tf": `
+module "a" {
+    source = "./a"
+}
+`,
+		"/prog/a/main.tf": `
+module "b" {
+    source = "../b"
+}
+`,
+		"/prog/b/main.tf": `
+module "a" {
+    source = "../a"
+}
+`,
+	}
+	for path, source := range files {
+		err := afero.WriteFile(src, path, []byte(source), 0o600)
+		require.NoError(t, err)
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper))
+	require.True(t, diagnostics.HasErrors())
+	errs := hcl.Diagnostics{}
+	for _, diag := range diagnostics {
+		if diag.Severity == hcl.DiagError {
+			errs = append(errs, diag)
+		}
+	}
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Module cycle", errs[0].Summary)
+	assert.Equal(t, "module.a calls itself through module.b -> module.a, modules can't be converted to "+
+		"components that call themselves", errs[0].Detail)
+	assert.Equal(t, "/prog/b/main.tf", errs[0].Subject.Filename)
+
+	// Nothing is written for modules in the cycle.
+	_, err := afero.ReadFile(dst, "/b/main.pp")
+	assert.Error(t, err)
+}
+
+// TestTranslateWindowsAuthoredProgram checks that CRLF line endings aren't written to the converted program, which
+// the test programs can't check as their output is compared ignoring line endings. The Windows paths are converted
+// in the windows_paths program.
+func TestTranslateWindowsAuthoredProgram(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"/main.tf": `
+# A module with a Windows style path
+module "network" {
+    source = ".\\modules\\network"
+}
+
+locals {
+    # Policies are read with Windows separators
+    policy = file("policies\\bucket.json")
+}
+`,
+		"/modules/network/main.tf": `
+variable "cidr" {
+    default = "10.0.0.0/16"
+}
+`,
+	}
+	for path, source := range files {
+		files[path] = strings.ReplaceAll(source, "\n", "\r\n")
+	}
+
+	dst, diagnostics := translateTestFiles(t, files)
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	main, err := afero.ReadFile(dst, "/main.pp")
+	require.NoError(t, err)
+	assert.NotContains(t, string(main), "\r")
+	assert.Contains(t, string(main), "# Policies are read with Windows separators\n")
+}
+
+func TestTranslateWithGraph(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+variable "prefix" {
+    type = string
+}
+
+resource "simple_resource" "first" {
+    input_one = var.prefix
+}
+
+module "child" {
+    source = "./mod"
+    input = simple_resource.first.result
+}
+
+output "result" {
+    value = module.child.result
+}
+`), 0o600)
+	require.NoError(t, err)
+	err = afero.WriteFile(src, "/prog/mod/main.tf", []byte(`
+variable "input" {
+    type = string
+}
+
+resource "simple_resource" "second" {
+    input_one = var.input
+}
+
+output "result" {
+    value = simple_resource.second.result
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	var graph Graph
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithGraph(func(g Graph) {
+			graph = g
+		}))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+
+	assert.ElementsMatch(t, []GraphModule{
+		{Path: "/", Address: ""},
+		{Path: "/mod", Address: "module.child"},
+	}, graph.Modules)
+	assert.Contains(t, graph.Nodes, GraphNode{
+		Module: "/", Address: "module.child", Name: "child", Kind: "module", Calls: "/mod",
+	})
+	assert.Contains(t, graph.Nodes, GraphNode{
+		Module: "/mod", Address: "simple_resource.second", Name: "second", Kind: "resource",
+	})
+	assert.ElementsMatch(t, []GraphEdge{
+		{Module: "/mod", From: "simple_resource.second", To: "var.input"},
+		{Module: "/mod", From: "output.result", To: "simple_resource.second"},
+		{Module: "/", From: "simple_resource.first", To: "var.prefix"},
+		{Module: "/", From: "module.child", To: "simple_resource.first"},
+		{Module: "/", From: "output.result", To: "module.child"},
+	}, graph.Edges)
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, `label = "/mod (module.child)";`)
+	assert.Contains(t, dot, `"/:module.child" [label = "child\nmodule.child"];`)
+	assert.Contains(t, dot, `"/:module.child" -> "/:simple_resource.first";`)
+	assert.Contains(t, dot, `"/:module.child" -> "/mod:var.input" [lhead = "cluster_1", style = "dashed"];`)
+
+	mermaid := graph.Mermaid()
+	assert.Contains(t, mermaid, `subgraph module1["/mod (module.child)"]`)
+	assert.Contains(t, mermaid, `node1["first<br/>simple_resource.first"]`)
+	assert.Contains(t, mermaid, `node2 --> node1`)
+	assert.Contains(t, mermaid, `node2 -.-> module1`)
+}
+
+func TestTranslateInlineFunctions(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+output "encoded" {
+    value = base64encode("hello")
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	diagnostics := TranslateModule(src, "/prog", afero.NewMemMapFs(), il.NewMapperProviderInfoSource(mapper),
+		WithInlineFunctions("numbers"))
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, "Unknown function category", diagnostics[0].Summary)
+}
+
+// TestTranslateWaits checks the statistics of waits, how they're converted is in the partial_waits and
+// waits_converted programs.
+func TestTranslateWaits(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(`
+resource "simple_resource" "role" {
+    input_one = "role"
+
+    provisioner "local-exec" {
+        command = "sleep 10"
+    }
+}
+
+resource "time_sleep" "wait" {
+    create_duration = "1m30s"
+    depends_on = [simple_resource.role]
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	var statistics *Statistics
+	diagnostics := TranslateModule(src, "/", afero.NewMemMapFs(), il.NewMapperProviderInfoSource(mapper),
+		WithStatistics(func(s Statistics) {
+			statistics = &s
+		}))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.NotNil(t, statistics)
+	assert.Equal(t, map[string]int{"time_sleep": 1, "local-exec:sleep": 1}, statistics.Waits)
+}
+
+func TestTranslateStableNames(t *testing.T) {
+	t.Parallel()
+
+	// The random and config name stabilizations are checked by the stable_names golden programs.
+	_, diagnostics := translateTestSource(t, `
+resource "simple_resource" "a_resource" {
+    name = "snapshot-${formatdate("YYYYMMDD", timestamp())}"
+}
+`, WithStableNames("never"))
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, "Unknown name stabilization", diagnostics[0].Summary)
+}
+
+// TestTranslateResourceAlternatives checks resources converted to an alternative aren't counted as unmapped, the
+// conversion itself is checked by the resource_alternatives program.
+func TestTranslateResourceAlternatives(t *testing.T) {
+	t.Parallel()
+
+	var statistics Statistics
+	_, diagnostics := translateTestSource(t, `
+resource "aws_db_security_group" "legacy" {
+    name = "legacy"
+}
+`, WithStatistics(func(s Statistics) { statistics = s }))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	assert.Empty(t, statistics.UnmappedResources)
+}
+
+func TestTranslateLocalNaming(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/main.tf", []byte(`
+locals {
+    name = "app"
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	diagnostics := TranslateModule(src, "/", afero.NewMemMapFs(), il.NewMapperProviderInfoSource(mapper),
+		WithLocalNaming("snake"))
+	require.True(t, diagnostics.HasErrors())
+	assert.Equal(t, "Unknown local naming", diagnostics[0].Summary)
+}
+
+// TestTranslateWithBootstrapProject checks a program without a backend, whose warning has no source range so it
+// can't be in a test program, the other cases are in the bootstrap_project programs.
+func TestTranslateWithBootstrapProject(t *testing.T) {
+	t.Parallel()
+
+	_, diagnostics := translateTestDirectory(t, map[string]string{"/main.tf": `
+resource "aws_s3_bucket" "state" {
+    bucket = "my-state"
+}
+`}, "/", WithBootstrapProject())
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "No state backend to bootstrap", diagnostics[0].Summary)
+}
+
+func TestTranslateWithStackDependencies(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+data "terraform_remote_state" "network" {
+  backend = "remote"
+  config = {
+    organization = "acme"
+    workspaces = {
+      name = "networking"
+    }
+  }
+}
+
+data "terraform_remote_state" "database" {
+  backend = "s3"
+  config = {
+    bucket = "acme-state"
+    key    = "database/terraform.tfstate"
+  }
+}
+
+output "vpc_id" {
+  value = data.terraform_remote_state.network.outputs.vpc_id
+}
+
+module "app" {
+  source      = "./app"
+  environment = "prod"
+}
+`), 0o600)
+	require.NoError(t, err)
+	err = afero.WriteFile(src, "/prog/app/main.tf", []byte(`
+variable "environment" {
+  type = string
+}
+
+data "terraform_remote_state" "platform" {
+  backend = "remote"
+  config = {
+    organization = "acme"
+    workspaces = {
+      name = "platform-${var.environment}"
+    }
+  }
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	var dependencies []StackDependency
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithStackDependencies(func(d []StackDependency) {
+			dependencies = d
+		}))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "Unknown stack for remote state", diagnostics[0].Summary)
+
+	// Modules are converted before the program that uses them.
+	assert.Equal(t, []StackDependency{
+		{
+			Kind:   "remote_state",
+			Source: "module.app.data.terraform_remote_state.platform",
+			Stack:  "acme/platform-${environment}/platform-${environment}",
+		},
+		{Kind: "remote_state", Source: "data.terraform_remote_state.network", Stack: "acme/networking/networking"},
+		{Kind: "remote_state", Source: "data.terraform_remote_state.database", Stack: "database"},
+	}, dependencies)
+}
+
+func TestTranslateWithResourceHook(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+resource "acme_database" "main" {
+  engine_version = "15"
+  size           = "small"
+}
+
+output "endpoint" {
+  value = acme_database.main.endpoint
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	// acme_database is from an internal provider that has no mappings, so it's mapped to a component.
+	var hooked []string
+	hook := func(resource *HookedResource) hcl.Diagnostics {
+		hooked = append(hooked, resource.Type+"."+resource.Name)
+		body := resource.Block.Body()
+		body.RemoveAttribute("size")
+		body.SetAttributeValue("instanceClass", cty.StringVal("db.t3.small"))
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagWarning,
+			Summary:  "Converted size to instanceClass",
+		}}
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithResourceHook("acme_database", "acme:index:Database", hook))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "Converted size to instanceClass", diagnostics[0].Summary)
+	assert.Equal(t, "/prog/main.tf", diagnostics[0].Subject.Filename)
+	assert.Equal(t, []string{"acme_database.main"}, hooked)
+
+	program, err := afero.ReadFile(dst, "/main.pp")
+	require.NoError(t, err)
+	assert.Equal(t, `resource "main" "acme:index:Database" {
+  engineVersion = "15"
+  instanceClass = "db.t3.small"
+}
+
+output "endpoint" {
+  value = main.endpoint
+}
+`, string(program))
+}
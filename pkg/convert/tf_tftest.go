@@ -0,0 +1,128 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var (
+	tftestFileSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "run", LabelNames: []string{"name"}},
+		},
+	}
+	tftestRunSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "assert"},
+		},
+	}
+	tftestAssertSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "condition"},
+			{Name: "error_message"},
+		},
+	}
+)
+
+// checkTestFile reads the run blocks of a terraform test file, returning a warning for each assertion found.
+func checkTestFile(path string, src []byte) hcl.Diagnostics {
+	file, diagnostics := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	if diagnostics.HasErrors() {
+		// We don't want to fail the conversion just because a test file doesn't parse, but report why we
+		// couldn't look at it.
+		for _, diag := range diagnostics {
+			diag.Severity = hcl.DiagWarning
+		}
+		return diagnostics
+	}
+
+	// We only look at run and assert blocks, everything else (variables, providers, etc) is ignored.
+	content, _, _ := file.Body.PartialContent(tftestFileSchema)
+	diagnostics = hcl.Diagnostics{}
+	for _, run := range content.Blocks {
+		runContent, _, _ := run.Body.PartialContent(tftestRunSchema)
+		for _, assert := range runContent.Blocks {
+			assertContent, _, _ := assert.Body.PartialContent(tftestAssertSchema)
+
+			subject := assert.DefRange
+			condition := ""
+			if attr, has := assertContent.Attributes["condition"]; has {
+				subject = attr.Expr.Range()
+				condition = strings.TrimSpace(string(subject.SliceBytes(src)))
+			}
+			detail := fmt.Sprintf(
+				"The assertion %q in test run %q needs to be ported to a Pulumi test", condition, run.Labels[0])
+			if attr, has := assertContent.Attributes["error_message"]; has {
+				msg, diags := attr.Expr.Value(nil)
+				if !diags.HasErrors() && msg.Type() == cty.String && msg.IsKnown() && !msg.IsNull() {
+					detail = fmt.Sprintf("%s: %s", detail, msg.AsString())
+				}
+			}
+
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Subject:  &subject,
+				Summary:  "Test assertion not converted",
+				Detail:   detail,
+			})
+		}
+	}
+	return diagnostics
+}
+
+// checkTestFiles looks for terraform test files (*.tftest.hcl) in the source directory and its tests directory
+// and adds a warning for each assertion they make. These aren't converted and need porting to Pulumi tests.
+func checkTestFiles(source afero.Fs, sourceDirectory string) hcl.Diagnostics {
+	var diagnostics hcl.Diagnostics
+	for _, dir := range []string{sourceDirectory, filepath.Join(sourceDirectory, "tests")} {
+		infos, err := afero.ReadDir(source, dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			diagnostics = append(diagnostics, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("could not search for test files: %s", err),
+			})
+			continue
+		}
+
+		for _, info := range infos {
+			if info.IsDir() || !strings.HasSuffix(info.Name(), ".tftest.hcl") {
+				continue
+			}
+			path := filepath.Join(dir, info.Name())
+			src, err := afero.ReadFile(source, path)
+			if err != nil {
+				diagnostics = append(diagnostics, &hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  fmt.Sprintf("could not read test file %s: %s", path, err),
+				})
+				continue
+			}
+			diagnostics = append(diagnostics, checkTestFile(path, src)...)
+		}
+	}
+	return diagnostics
+}
@@ -0,0 +1,115 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/terraform/pkg/configs"
+)
+
+// The operations a timeouts block can set, in the order they're listed in comments.
+var timeoutOperations = []string{"create", "read", "update", "delete", "default"}
+
+// timeoutsComment returns comment lines to write above a converted resource whose terraform timeouts block has been
+// dropped, saying what the timeouts were and the provider's own defaults that will be used instead, if the bridge
+// knows them. It returns nil if the resource has no timeouts block.
+func timeoutsComment(state *convertState, resource *configs.Resource, root PathInfo) hclwrite.Tokens {
+	var timeouts *hcl.Block
+	for _, block := range bodyContent(resource.Config).Blocks {
+		if block.Type == "timeouts" {
+			timeouts = block
+			break
+		}
+	}
+	if timeouts == nil {
+		return nil
+	}
+
+	// List the timeouts in the usual order of operations, then anything else sorted so the comment is stable.
+	attributes := bodyContent(timeouts.Body).Attributes
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	order := func(name string) int {
+		for i, operation := range timeoutOperations {
+			if operation == name {
+				return i
+			}
+		}
+		return len(timeoutOperations)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if oi, oj := order(names[i]), order(names[j]); oi != oj {
+			return oi < oj
+		}
+		return names[i] < names[j]
+	})
+	set := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.TrimSpace(state.sourceCode(attributes[name].Expr.Range()))
+		set = append(set, fmt.Sprintf("%s = %s", name, value))
+	}
+
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Resource timeouts not converted",
+		Detail: fmt.Sprintf("The timeouts of %s.%s aren't converted, set the customTimeouts resource option "+
+			"to keep them", resource.Type, resource.Name),
+		Subject: timeouts.DefRange.Ptr(),
+	})
+
+	lines := []string{
+		fmt.Sprintf("The terraform timeouts of this resource (%s) aren't converted.", strings.Join(set, ", ")),
+	}
+	if root.Resource != nil {
+		if defaults := formatTimeouts(root.Resource.Timeouts()); defaults != "" {
+			lines = append(lines, fmt.Sprintf("Without them the provider's default timeouts (%s) are used.", defaults))
+		}
+	}
+	lines = append(lines, "Set the customTimeouts resource option to keep them.")
+
+	tokens := hclwrite.Tokens{}
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
+
+// formatTimeouts returns the timeouts that are set, e.g. "create 10m0s, delete 20m0s", or "" if none are.
+func formatTimeouts(timeouts *shim.ResourceTimeout) string {
+	if timeouts == nil {
+		return ""
+	}
+	durations := []*time.Duration{timeouts.Create, timeouts.Read, timeouts.Update, timeouts.Delete, timeouts.Default}
+	var parts []string
+	for i, duration := range durations {
+		if duration != nil {
+			parts = append(parts, fmt.Sprintf("%s %s", timeoutOperations[i], duration))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,317 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PCL has no equivalent of try and can, which catch the errors of evaluating their arguments. They're mostly used
+// to guard against missing attributes, like try(local.settings.timeout, 30), so they're converted by working out
+// which arguments fail at conversion time where their values are known, and otherwise by looking the attributes up
+// with a default: lookup(mySettings, "timeout", 30). PCL's lookup only takes maps, so this is only done where the
+// values being looked up in are known to be maps, which is only the case for input variables with a map type.
+
+// isStaticExpr returns true if expr only refers to locals that only refer to other locals, so evaluating it at
+// conversion time gives the same result as terraform would, errors included.
+func isStaticExpr(scopes *scopes, expr hcl.Expression, seen map[string]bool) bool {
+	if syntax, ok := expr.(hclsyntax.Expression); ok {
+		static := true
+		hclsyntax.VisitAll(syntax, func(node hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+				if _, unfoldable := unfoldableFunctions[call.Name]; unfoldable {
+					static = false
+				}
+			}
+			return nil
+		})
+		if !static {
+			return false
+		}
+	}
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			return false
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			return false
+		}
+		key := "local." + attr.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		root, has := scopes.roots[key]
+		if !has {
+			// References to undeclared locals always fail.
+			continue
+		}
+		if root.Expression == nil || !isStaticExpr(scopes, *root.Expression, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// staticSuccess returns whether evaluating expr succeeds, and true if that's known at conversion time.
+func staticSuccess(scopes *scopes, expr hclsyntax.Expression) (succeeds bool, known bool) {
+	if !isStaticExpr(scopes, expr, map[string]bool{}) {
+		return false, false
+	}
+	value, diags := scopes.EvalExpr(expr)
+	if diags.HasErrors() {
+		return false, true
+	}
+	return true, value.IsWhollyKnown()
+}
+
+// traversalBase returns the number of steps of traversal that refer to something that always exists, like var.name
+// or aws_s3_bucket.name.arn, rather than to attributes or keys of its value that might not.
+func traversalBase(scopes *scopes, traversal hcl.Traversal) int {
+	switch traversal.RootName() {
+	case "var", "local", "each", "count", "path", "terraform":
+		return 2
+	case "module":
+		return 3
+	case "data":
+		return 4
+	}
+	if len(traversal) > 1 {
+		if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+			if _, has := scopes.roots[traversal.RootName()+"."+attr.Name]; has {
+				return 3
+			}
+		}
+	}
+	return 1
+}
+
+// unmappedLookup returns the part of traversal that the next step is looked up in if it isn't known to be a map, e.g.
+// var.settings for var.settings.network.port if settings doesn't have a map type, or nil if every step is looked up
+// in a map.
+func unmappedLookup(scopes *scopes, traversal hcl.Traversal) hcl.Traversal {
+	base := traversalBase(scopes, traversal)
+	if len(traversal) <= base {
+		return nil
+	}
+	for _, step := range traversal[base:] {
+		// Only attributes and string keys are looked up, see convertTraversalLookup.
+		if index, ok := step.(hcl.TraverseIndex); ok &&
+			(index.Key.Type() != cty.String || !index.Key.IsKnown() || index.Key.IsNull()) {
+			return nil
+		}
+	}
+	typ := cty.DynamicPseudoType
+	if attr, ok := traversal[1].(hcl.TraverseAttr); ok && traversal.RootName() == "var" {
+		typ = scopes.roots["var."+attr.Name].VariableType
+	}
+	for i := base; i < len(traversal); i++ {
+		if typ == cty.NilType || !typ.IsMapType() {
+			return traversal[:i]
+		}
+		typ = typ.ElementType()
+	}
+	return nil
+}
+
+// convertUnmappedLookup warns that call guards against missing attributes of a value that isn't known to be a map,
+// so can't be converted to lookups, and returns a notImplemented call for it.
+func convertUnmappedLookup(
+	state *convertState, call *hclsyntax.FunctionCallExpr, value hcl.Traversal,
+) hclwrite.Tokens {
+	callRange := call.Range()
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Subject:  &callRange,
+		Severity: hcl.DiagWarning,
+		Summary:  "Function not supported",
+		Detail: fmt.Sprintf("Function %s is converted to lookups of the attributes it guards against missing, "+
+			"which needs %s to be a map, but it isn't known to be one, give its variable a map type",
+			call.Name, state.sourceCode(value.SourceRange())),
+	})
+	state.countNotImplemented("function:" + call.Name)
+	return notImplemented(state, callRange)
+}
+
+// convertTraversalLookup returns the tokens of traversal with the steps that might fail written as lookups with the
+// given default, e.g. lookup(lookup(mySettings, "network", {}), "port", 80) for local.settings.network.port. It
+// returns false if any of those steps isn't an attribute or a string key.
+func convertTraversalLookup(
+	state *convertState, scopes *scopes, expr *hclsyntax.ScopeTraversalExpr, fallback hclwrite.Tokens,
+) (hclwrite.Tokens, bool) {
+	traversal := expr.Traversal
+	base := traversalBase(scopes, traversal)
+	if len(traversal) <= base {
+		return nil, false
+	}
+	steps := traversal[base:]
+	for _, step := range steps {
+		switch step := step.(type) {
+		case hcl.TraverseAttr:
+		case hcl.TraverseIndex:
+			if step.Key.Type() != cty.String || !step.Key.IsKnown() || step.Key.IsNull() {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	// Convert the whole traversal, so attributes are renamed as they'd be anywhere else, then read the steps back
+	// off the end of it.
+	tokens := convertExpression(state, false, scopes, "", expr)
+	keys := make([]string, len(steps))
+	end := len(tokens)
+	for i := len(steps) - 1; i >= 0; i-- {
+		switch step := steps[i].(type) {
+		case hcl.TraverseAttr:
+			if end < 2 || tokens[end-1].Type != hclsyntax.TokenIdent || tokens[end-2].Type != hclsyntax.TokenDot {
+				return nil, false
+			}
+			keys[i] = string(tokens[end-1].Bytes)
+			end -= 2
+		case hcl.TraverseIndex:
+			if end < 1 || tokens[end-1].Type != hclsyntax.TokenCBrack {
+				return nil, false
+			}
+			depth := 0
+			for end > 0 {
+				end--
+				if tokens[end].Type == hclsyntax.TokenCBrack {
+					depth++
+				} else if tokens[end].Type == hclsyntax.TokenOBrack {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if depth != 0 {
+				return nil, false
+			}
+			keys[i] = step.Key.AsString()
+		}
+	}
+	if end == 0 {
+		return nil, false
+	}
+
+	result := tokens[:end]
+	for i, key := range keys {
+		value := hclwrite.Tokens{makeToken(hclsyntax.TokenOBrace, "{"), makeToken(hclsyntax.TokenCBrace, "}")}
+		if i == len(keys)-1 {
+			value = fallback
+		}
+		result = hclwrite.TokensForFunctionCall("lookup", result, hclwrite.TokensForValue(cty.StringVal(key)), value)
+	}
+	return result, true
+}
+
+// convertTry returns a try call converted to the first of its arguments that doesn't fail at conversion time, or to
+// lookups of the attributes of its first argument that might be missing with the rest as the default. It returns
+// false if the call can't be converted like this.
+func convertTry(state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr) (hclwrite.Tokens, bool) {
+	args := call.Args
+	for len(args) > 0 {
+		succeeds, known := staticSuccess(scopes, args[0])
+		if !known {
+			break
+		}
+		if succeeds {
+			return convertExpression(state, false, scopes, "", args[0]), true
+		}
+		args = args[1:]
+	}
+	switch {
+	case len(args) == 0:
+		// Every argument fails, which is an error in terraform too.
+		return nil, false
+	case len(args) == 1:
+		return convertExpression(state, false, scopes, "", args[0]), true
+	}
+
+	traversal, ok := args[0].(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil, false
+	}
+	// Each argument but the last is looked up in turn, so they all need to be looked up in maps.
+	for _, arg := range args[:len(args)-1] {
+		if traversal, ok := arg.(*hclsyntax.ScopeTraversalExpr); ok {
+			if value := unmappedLookup(scopes, traversal.Traversal); value != nil {
+				return convertUnmappedLookup(state, call, value), true
+			}
+		}
+	}
+	rest := *call
+	rest.Args = args[1:]
+	fallback, ok := convertTry(state, scopes, &rest)
+	if !ok {
+		return nil, false
+	}
+	return convertTraversalLookup(state, scopes, traversal, fallback)
+}
+
+// convertCan returns a can call converted to true or false if its argument's success is known at conversion time,
+// or for a reference to attributes that might be missing to a check that they're not null. It returns false if the
+// call can't be converted like this.
+//
+// The check that an attribute isn't null differs from terraform for attributes that are present but set to null:
+// can(var.settings.debug) is true if settings has a debug key whose value is null, but the lookup is null so the
+// converted check is false. Map values are rarely null, so this is only documented rather than converted to a much
+// longer check of the map's keys.
+func convertCan(state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr) (hclwrite.Tokens, bool) {
+	if len(call.Args) != 1 {
+		return nil, false
+	}
+	if succeeds, known := staticSuccess(scopes, call.Args[0]); known {
+		return hclwrite.TokensForValue(cty.BoolVal(succeeds)), true
+	}
+	// regex fails if the string doesn't match, so can(regex(...)) is whether it matches.
+	if re, text, ok := matchRegexCall(scopes, call.Args[0], "regex"); ok {
+		return convertRegexMatch(state, scopes, re, text, false), true
+	}
+	traversal, ok := call.Args[0].(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil, false
+	}
+	if value := unmappedLookup(scopes, traversal.Traversal); value != nil {
+		return convertUnmappedLookup(state, call, value), true
+	}
+	lookup, ok := convertTraversalLookup(state, scopes, traversal, null())
+	if !ok {
+		return nil, false
+	}
+	return parenthesize(binary(lookup, hclsyntax.TokenNotEqual, "!=", null())), true
+}
+
+// convertTryOrCan returns call converted if it's a try or can call that can be converted, see convertTry and
+// convertCan.
+func convertTryOrCan(state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr) (hclwrite.Tokens, bool) {
+	if call.ExpandFinal {
+		return nil, false
+	}
+	switch call.Name {
+	case "try":
+		return convertTry(state, scopes, call)
+	case "can":
+		return convertCan(state, scopes, call)
+	}
+	return nil, false
+}
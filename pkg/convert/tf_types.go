@@ -0,0 +1,298 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// schemaPrimitiveType returns the cty type for a primitive provider schema type, or cty.NilType if the schema isn't
+// known or isn't for a primitive.
+func schemaPrimitiveType(sch shim.Schema) cty.Type {
+	if sch == nil {
+		return cty.NilType
+	}
+	switch sch.Type() {
+	case shim.TypeBool:
+		return cty.Bool
+	case shim.TypeInt, shim.TypeFloat:
+		return cty.Number
+	case shim.TypeString:
+		return cty.String
+	}
+	return cty.NilType
+}
+
+// variableReference returns the name of the variable if the expression is just a reference to an input variable.
+func variableReference(expr hcl.Expression) (string, bool) {
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != "var" {
+		return "", false
+	}
+	attr, ok := traversal.Traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+	return attr.Name, true
+}
+
+// walkResourceAttributes calls f with the fully qualified path of every attribute of the resources and data sources
+// in items, including those nested in blocks.
+func walkResourceAttributes(scopes *scopes, items terraformItems, f func(string, *hcl.Attribute)) {
+	var walkBody func(fullyQualifiedPath string, body hcl.Body)
+	walkBody = func(fullyQualifiedPath string, body hcl.Body) {
+		content := bodyContent(body)
+		for _, attr := range content.Attributes {
+			f(appendPath(fullyQualifiedPath, attr.Name), attr)
+		}
+		for _, block := range content.Blocks {
+			// Dynamic blocks and timeouts aren't part of the resource schema.
+			if block.Type == "dynamic" || block.Type == "timeouts" {
+				continue
+			}
+			blockPath := appendPath(fullyQualifiedPath, block.Type)
+			if !scopes.maxItemsOne(blockPath) {
+				blockPath = appendPathArray(blockPath)
+			}
+			walkBody(blockPath, block.Body)
+		}
+	}
+
+	for _, item := range items {
+		if item.resource != nil {
+			walkBody(item.resource.Type+"."+item.resource.Name, item.resource.Config)
+		}
+		if item.data != nil {
+			walkBody("data."+item.data.Type+"."+item.data.Name, item.data.Config)
+		}
+	}
+}
+
+// inferVariableTypes fills in the types of input variables that don't have a type constraint or a default value.
+// Without a type these would be converted to config of any type, which most languages then have to treat as
+// dynamic. If every place a variable is passed directly to a resource or data source attribute expects the same
+// primitive type then that's the type it must have been given. Variables used as objects or lists, including those
+// with type any, get the type of the attributes and elements that are used, see variableShape. If the uses of a
+// variable disagree about its type we add a warning saying so.
+func inferVariableTypes(state *convertState, scopes *scopes, items terraformItems) {
+	uses := map[string]map[string]cty.Type{}
+	walkResourceAttributes(scopes, items, func(fullyQualifiedPath string, attr *hcl.Attribute) {
+		name, ok := variableReference(attr.Expr)
+		if !ok {
+			return
+		}
+		typ := schemaPrimitiveType(scopes.getInfo(fullyQualifiedPath).Schema)
+		if typ == cty.NilType {
+			// We don't know what this use expects, so we can't infer a type at all.
+			typ = cty.DynamicPseudoType
+		}
+		if uses[name] == nil {
+			uses[name] = map[string]cty.Type{}
+		}
+		uses[name][typ.FriendlyName()] = typ
+	})
+
+	shapes := inferVariableShapes(scopes, items)
+	for _, item := range items {
+		variable := item.variable
+		if variable == nil || variable.Type != cty.DynamicPseudoType {
+			continue
+		}
+
+		// Variables used as objects or lists may have a type from the attributes and elements that are used.
+		if shape := shapes[variable.Name]; shape != nil && shape.structured() {
+			key := "var." + variable.Name
+			root := scopes.roots[key]
+			if typ := shape.typ(); typ != cty.DynamicPseudoType {
+				root.VariableType = typ
+			} else {
+				root.DynamicConfig = true
+				reportUntypedVariable(state, variable,
+					"it's used as a whole as well as by its attributes or elements, so they may not be all it has")
+			}
+			scopes.roots[key] = root
+			continue
+		}
+
+		// Variables with no type constraint are parsed as literals, whereas "any" is parsed as HCL.
+		if variable.ParsingMode != configs.VariableParseLiteral || !variable.Default.IsNull() {
+			continue
+		}
+
+		// A variable passed to attributes of different primitive types is ambiguous, any type we picked would be
+		// wrong for some of its uses.
+		types := uses[variable.Name]
+		if len(types) > 1 {
+			names := make([]string, 0, len(types))
+			for name, typ := range types {
+				if typ != cty.DynamicPseudoType {
+					names = append(names, name)
+				}
+			}
+			if len(names) > 1 {
+				sort.Strings(names)
+				reportUntypedVariable(state, variable,
+					fmt.Sprintf("it's passed to attributes of different types (%s)", strings.Join(names, ", ")))
+			}
+			continue
+		}
+		for _, typ := range types {
+			if typ == cty.DynamicPseudoType {
+				continue
+			}
+			key := "var." + variable.Name
+			root := scopes.roots[key]
+			root.VariableType = typ
+			scopes.roots[key] = root
+		}
+	}
+}
+
+// reportUntypedVariable adds a warning that the type of variable couldn't be inferred for the given reason. These
+// point users at the variables whose uses are ambiguous, where adding a type constraint would make the converted
+// program more precise.
+func reportUntypedVariable(state *convertState, variable *configs.Variable, reason string) {
+	state.appendDiagnostic(&hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Untyped variable",
+		Detail: fmt.Sprintf("The type of variable %q couldn't be inferred because %s, so it will be any. "+
+			"Add a type constraint to the variable to give it a more precise type.", variable.Name, reason),
+		Subject: variable.DeclRange.Ptr(),
+	})
+}
+
+// markMapLocals finds the locals that are used as maps, either directly or merged into map typed resource and data
+// source attributes, like the common pattern of `tags = merge(local.common_tags, {...})`. The keys of these locals
+// must be kept as they are rather than renamed to Pulumi style property names.
+func markMapLocals(scopes *scopes, items terraformItems) {
+	// The number of locals marked so far.
+	marked := 0
+	var mark func(expr hcl.Expression)
+	mark = func(expr hcl.Expression) {
+		switch expr := expr.(type) {
+		case *hclsyntax.ParenthesesExpr:
+			mark(expr.Expression)
+		case *hclsyntax.ConditionalExpr:
+			mark(expr.TrueResult)
+			mark(expr.FalseResult)
+		case *hclsyntax.FunctionCallExpr:
+			if expr.Name == "merge" {
+				for _, arg := range expr.Args {
+					mark(arg)
+				}
+			}
+		case *hclsyntax.ScopeTraversalExpr:
+			if len(expr.Traversal) != 2 || expr.Traversal.RootName() != "local" {
+				return
+			}
+			attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+			if !ok {
+				return
+			}
+			key := "local." + attr.Name
+			root, has := scopes.roots[key]
+			if !has || root.UsedAsMap {
+				return
+			}
+			root.UsedAsMap = true
+			scopes.roots[key] = root
+			marked++
+			// Anything this local is built from is also used as a map.
+			if root.Expression != nil {
+				mark(*root.Expression)
+			}
+		}
+	}
+
+	walkResourceAttributes(scopes, items, func(fullyQualifiedPath string, attr *hcl.Attribute) {
+		if isMap := scopes.isMap(fullyQualifiedPath); isMap != nil && *isMap {
+			mark(attr.Expr)
+		}
+	})
+
+	// Merging anything with a map gives a map, so the locals merged with a map variable, a local used as a map or a
+	// map literal are used as maps too, e.g. local.common_tags in merge(local.common_tags, var.extra_tags), as is a
+	// local that is such a merge. Marking a local can make it the map that another merge is merged with, so this is
+	// repeated until nothing changes.
+	var isMap func(expr hclsyntax.Expression) bool
+	isMap = func(expr hclsyntax.Expression) bool {
+		switch expr := expr.(type) {
+		case *hclsyntax.ParenthesesExpr:
+			return isMap(expr.Expression)
+		case *hclsyntax.FunctionCallExpr:
+			if expr.Name == "merge" {
+				for _, arg := range expr.Args {
+					if isMap(arg) {
+						return true
+					}
+				}
+			}
+			return expr.Name == "tomap"
+		case *hclsyntax.ObjectConsExpr:
+			for _, item := range expr.Items {
+				if _, isIdentifier := matchStaticString(item.KeyExpr); !isIdentifier {
+					return true
+				}
+			}
+		case *hclsyntax.ScopeTraversalExpr:
+			if len(expr.Traversal) != 2 {
+				return false
+			}
+			attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+			if !ok {
+				return false
+			}
+			root := scopes.roots[expr.Traversal.RootName()+"."+attr.Name]
+			switch expr.Traversal.RootName() {
+			case "var":
+				return root.VariableType != cty.NilType && root.VariableType.IsMapType()
+			case "local":
+				return root.UsedAsMap
+			}
+		}
+		return false
+	}
+	for previous := -1; previous != marked; {
+		previous = marked
+		for key, root := range scopes.roots {
+			if !strings.HasPrefix(key, "local.") || root.Expression == nil {
+				continue
+			}
+			expr, ok := (*root.Expression).(hclsyntax.Expression)
+			if !ok {
+				continue
+			}
+			if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "merge" && isMap(call) {
+				mark(&hclsyntax.ScopeTraversalExpr{Traversal: hcl.Traversal{
+					hcl.TraverseRoot{Name: "local"}, hcl.TraverseAttr{Name: strings.TrimPrefix(key, "local.")},
+				}})
+			}
+			hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+				if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && call.Name == "merge" && isMap(call) {
+					mark(call)
+				}
+				return nil
+			})
+		}
+	}
+}
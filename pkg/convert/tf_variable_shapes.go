@@ -0,0 +1,311 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// variableShape is what the uses of a variable without a type, or with type any, say about the structure of its
+// value. A variable that's only used as `var.settings.name` and `var.settings.subnets[count.index].id` must be an
+// object with a name and a list of subnets with ids.
+type variableShape struct {
+	attributes map[string]*variableShape
+	element    *variableShape
+	// Set if the value is used as a whole, e.g. passed to a function or output, so it may have more to it than the
+	// attributes and elements that are used.
+	whole bool
+	// The types of the resource and data source attributes the value is passed to, keyed by their friendly name.
+	types map[string]cty.Type
+}
+
+func (s *variableShape) attribute(name string) *variableShape {
+	if s.attributes == nil {
+		s.attributes = map[string]*variableShape{}
+	}
+	if s.attributes[name] == nil {
+		s.attributes[name] = &variableShape{}
+	}
+	return s.attributes[name]
+}
+
+func (s *variableShape) elem() *variableShape {
+	if s.element == nil {
+		s.element = &variableShape{}
+	}
+	return s.element
+}
+
+// structured returns true if the value is used as an object or list.
+func (s *variableShape) structured() bool {
+	return len(s.attributes) > 0 || s.element != nil
+}
+
+// typ returns the type of the value, or cty.DynamicPseudoType if its uses don't determine it. Objects and lists are
+// only inferred if they're never used as a whole, as the attributes that are used may not be all of them.
+func (s *variableShape) typ() cty.Type {
+	if s.whole && s.structured() || len(s.attributes) > 0 && s.element != nil {
+		return cty.DynamicPseudoType
+	}
+	if len(s.attributes) > 0 {
+		attributes := map[string]cty.Type{}
+		for name, attribute := range s.attributes {
+			attributes[name] = attribute.typ()
+		}
+		return cty.Object(attributes)
+	}
+	if s.element != nil {
+		return cty.List(s.element.typ())
+	}
+	if len(s.types) == 1 {
+		for _, typ := range s.types {
+			return typ
+		}
+	}
+	return cty.DynamicPseudoType
+}
+
+// resolveShape returns the shape of the part of a variable in shapes that expr refers to, like var.settings.name,
+// and the nodes of expr that make up the reference. If only part of expr can be followed, like the collection of an
+// index by a variable key, it returns the shape of that part and false.
+func resolveShape(shapes map[string]*variableShape, expr hclsyntax.Expression) (
+	*variableShape, []hclsyntax.Node, bool,
+) {
+	follow := func(shape *variableShape, traversal hcl.Traversal) (*variableShape, bool) {
+		for _, part := range traversal {
+			switch part := part.(type) {
+			case hcl.TraverseAttr:
+				shape = shape.attribute(part.Name)
+			case hcl.TraverseIndex:
+				if part.Key.Type() != cty.Number {
+					// Values indexed by strings are as likely to be maps as objects.
+					return shape, false
+				}
+				shape = shape.elem()
+			default:
+				return shape, false
+			}
+		}
+		return shape, true
+	}
+
+	switch expr := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		if len(expr.Traversal) < 2 || expr.Traversal.RootName() != "var" {
+			return nil, nil, false
+		}
+		attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+		if !ok || shapes[attr.Name] == nil {
+			return nil, nil, false
+		}
+		shape, complete := follow(shapes[attr.Name], expr.Traversal[2:])
+		return shape, []hclsyntax.Node{expr}, complete
+	case *hclsyntax.RelativeTraversalExpr:
+		shape, nodes, complete := resolveShape(shapes, expr.Source)
+		if shape == nil || !complete {
+			return shape, nodes, complete
+		}
+		shape, complete = follow(shape, expr.Traversal)
+		return shape, append(nodes, expr), complete
+	case *hclsyntax.IndexExpr:
+		shape, nodes, complete := resolveShape(shapes, expr.Collection)
+		if shape == nil || !complete {
+			return shape, nodes, complete
+		}
+		nodes = append(nodes, expr)
+		if literal, ok := expr.Key.(*hclsyntax.LiteralValueExpr); ok && !literal.Val.IsNull() {
+			shape, complete = follow(shape, hcl.Traversal{hcl.TraverseIndex{Key: literal.Val}})
+			return shape, nodes, complete
+		}
+		if key, ok := expr.Key.(*hclsyntax.ScopeTraversalExpr); ok && sameTraversal(key.Traversal, hcl.Traversal{
+			hcl.TraverseRoot{Name: "count"}, hcl.TraverseAttr{Name: "index"},
+		}) {
+			return shape.elem(), nodes, true
+		}
+		// Indexed by something else, which could be a key of a map or an index of a list.
+		return shape, nodes, false
+	case *hclsyntax.SplatExpr:
+		shape, nodes, complete := resolveShape(shapes, expr.Source)
+		if shape == nil || !complete {
+			return shape, nodes, complete
+		}
+		shape = shape.elem()
+		nodes = append(nodes, expr, expr.Item)
+		switch each := expr.Each.(type) {
+		case *hclsyntax.AnonSymbolExpr:
+			return shape, append(nodes, each), true
+		case *hclsyntax.RelativeTraversalExpr:
+			if _, ok := each.Source.(*hclsyntax.AnonSymbolExpr); ok {
+				shape, complete = follow(shape, each.Traversal)
+				return shape, append(nodes, each, each.Source), complete
+			}
+		}
+		return shape, nodes, false
+	}
+	return nil, nil, false
+}
+
+// inferVariableShapes returns the shapes of the variables in items that have no type constraint, or type any, and
+// no default value, from how they're used by every expression in items.
+func inferVariableShapes(scopes *scopes, items terraformItems) map[string]*variableShape {
+	shapes := map[string]*variableShape{}
+	for _, item := range items {
+		if item.variable != nil && item.variable.Type == cty.DynamicPseudoType && item.variable.Default.IsNull() {
+			shapes[item.variable.Name] = &variableShape{}
+		}
+	}
+	if len(shapes) == 0 {
+		return shapes
+	}
+
+	consumed := map[hclsyntax.Node]bool{}
+	consume := func(nodes []hclsyntax.Node) {
+		for _, node := range nodes {
+			consumed[node] = true
+		}
+	}
+	for _, item := range items {
+		for _, expr := range item.expressions() {
+			node, ok := expr.(hclsyntax.Node)
+			if !ok {
+				continue
+			}
+			hclsyntax.VisitAll(node, func(node hclsyntax.Node) hcl.Diagnostics {
+				// The scopes of for expressions aren't comparable, and the expressions in them are visited anyway.
+				if _, ok := node.(hclsyntax.ChildScope); ok || consumed[node] {
+					return nil
+				}
+				switch node := node.(type) {
+				case *hclsyntax.BinaryOpExpr:
+					// Comparing a value to null doesn't use it as a whole.
+					if node.Op == hclsyntax.OpEqual || node.Op == hclsyntax.OpNotEqual {
+						for _, sides := range [][2]hclsyntax.Expression{{node.LHS, node.RHS}, {node.RHS, node.LHS}} {
+							literal, ok := sides[1].(*hclsyntax.LiteralValueExpr)
+							if ok && literal.Val.IsNull() {
+								if _, nodes, complete := resolveShape(shapes, sides[0]); complete {
+									consume(nodes)
+								}
+							}
+						}
+					}
+				case *hclsyntax.FunctionCallExpr:
+					// Neither does taking its length.
+					if node.Name == "length" && len(node.Args) == 1 {
+						if _, nodes, complete := resolveShape(shapes, node.Args[0]); complete {
+							consume(nodes)
+						}
+					}
+				}
+				if expr, ok := node.(hclsyntax.Expression); ok && !consumed[node] {
+					if shape, nodes, _ := resolveShape(shapes, expr); shape != nil {
+						shape.whole = true
+						consume(nodes)
+					}
+				}
+				return nil
+			})
+		}
+	}
+
+	// Values passed directly to primitive attributes have the type of the attribute.
+	walkResourceAttributes(scopes, items, func(fullyQualifiedPath string, attr *hcl.Attribute) {
+		expr, ok := attr.Expr.(hclsyntax.Expression)
+		if !ok {
+			return
+		}
+		shape, _, complete := resolveShape(shapes, expr)
+		if shape == nil || !complete {
+			return
+		}
+		typ := schemaPrimitiveType(scopes.getInfo(fullyQualifiedPath).Schema)
+		if typ == cty.NilType {
+			typ = cty.DynamicPseudoType
+		}
+		if shape.types == nil {
+			shape.types = map[string]cty.Type{}
+		}
+		shape.types[typ.FriendlyName()] = typ
+	})
+	return shapes
+}
+
+// expressions returns all the expressions of the item, including those nested in blocks.
+func (item terraformItem) expressions() []hcl.Expression {
+	var exprs []hcl.Expression
+	var body func(hcl.Body)
+	body = func(b hcl.Body) {
+		content := bodyContent(b)
+		for _, attr := range content.Attributes {
+			exprs = append(exprs, attr.Expr)
+		}
+		for _, block := range content.Blocks {
+			body(block.Body)
+		}
+	}
+	expressions := func(es ...hcl.Expression) {
+		for _, expr := range es {
+			if expr != nil {
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+	switch {
+	case item.variable != nil:
+		for _, validation := range item.variable.Validations {
+			expressions(validation.Condition)
+		}
+	case item.local != nil:
+		expressions(item.local.Expr)
+	case item.data != nil:
+		body(item.data.Config)
+		expressions(item.data.Count, item.data.ForEach)
+	case item.resource != nil:
+		body(item.resource.Config)
+		expressions(item.resource.Count, item.resource.ForEach)
+		if item.resource.Managed != nil {
+			for _, provisioner := range item.resource.Managed.Provisioners {
+				body(provisioner.Config)
+			}
+		}
+	case item.moduleCall != nil:
+		body(item.moduleCall.Config)
+		expressions(item.moduleCall.Count, item.moduleCall.ForEach)
+	case item.output != nil:
+		expressions(item.output.Expr)
+	case item.provider != nil:
+		body(item.provider.Config)
+	}
+	return exprs
+}
+
+// dynamicConfigComment returns the comment written above config that's used as an object or list but whose type
+// couldn't be inferred, saying how it's read.
+func dynamicConfigComment() hclwrite.Tokens {
+	lines := []string{
+		"The type of this config couldn't be inferred from how it's used, so it's read as a dynamic value.",
+		"In typed languages its attributes and elements have to be accessed dynamically, or give the variable a type.",
+	}
+	tokens := hclwrite.Tokens{}
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
@@ -0,0 +1,442 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/terraform/pkg/addrs"
+)
+
+// PlanComparison is the result of comparing the resources a `terraform plan` of a program would have, with those a
+// `pulumi preview` of its conversion would have.
+type PlanComparison struct {
+	// The number of resource instances that are in both the plan and the preview.
+	Resources int `json:"resources"`
+	// What the conversion changed, in the order of the plan's resources.
+	Differences []PlanDifference `json:"differences"`
+}
+
+// PlanDifference is a resource that's only in the plan or the preview, or a property of a resource that's in both
+// whose value differs.
+type PlanDifference struct {
+	// The Terraform address of the resource instance, e.g. "module.vpc.aws_subnet.private[0]", or "" if the resource
+	// is only in the preview.
+	Address string `json:"address,omitempty"`
+	// The URN of the resource in the preview, or "" if the resource is only in the plan.
+	URN string `json:"urn,omitempty"`
+	// The path of the Pulumi property whose value differs, e.g. `tags["Name"]`, or "" if the resource is only in the
+	// plan or the preview.
+	Property string `json:"property,omitempty"`
+	// The values of the property in the plan and the preview, nil if it isn't set.
+	Terraform interface{} `json:"terraform,omitempty"`
+	Pulumi    interface{} `json:"pulumi,omitempty"`
+}
+
+// The parts of `terraform show -json` of a plan file that are compared.
+type terraformPlan struct {
+	ResourceChanges []struct {
+		Address string      `json:"address"`
+		Mode    string      `json:"mode"`
+		Type    string      `json:"type"`
+		Name    string      `json:"name"`
+		Index   interface{} `json:"index"`
+		Change  struct {
+			Actions        []string               `json:"actions"`
+			After          map[string]interface{} `json:"after"`
+			AfterUnknown   interface{}            `json:"after_unknown"`
+			AfterSensitive interface{}            `json:"after_sensitive"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// The parts of `pulumi preview --json` that are compared.
+type pulumiPreview struct {
+	Steps []struct {
+		Op       string `json:"op"`
+		URN      string `json:"urn"`
+		NewState *struct {
+			Type   string                 `json:"type"`
+			Custom bool                   `json:"custom"`
+			Inputs map[string]interface{} `json:"inputs"`
+		} `json:"newState"`
+	} `json:"steps"`
+}
+
+// The value the Pulumi CLI shows for properties that are unknown during a preview.
+const previewUnknown = "04da6b54-80e4-46f7-96ec-b56ff0331ba9"
+
+// ComparePlan compares the resources in plan, the output of `terraform show -json` for a plan of a Terraform
+// program, with those in preview, the output of `pulumi preview --json` for its conversion, to find what the
+// conversion changed. Resources are matched by their Pulumi type and name, which is the Terraform name with the
+// instance key of resources using count or for_each, as TranslateState names them.
+//
+// Only the attributes that are set in the plan are compared, and values that are unknown or secret on either side
+// are skipped, as are computed attributes.
+func ComparePlan(info il.ProviderInfoSource, plan, preview io.Reader) (*PlanComparison, error) {
+	var tfPlan terraformPlan
+	if err := json.NewDecoder(plan).Decode(&tfPlan); err != nil {
+		return nil, fmt.Errorf("read terraform plan: %w", err)
+	}
+	var pulumiPlan pulumiPreview
+	if err := json.NewDecoder(preview).Decode(&pulumiPlan); err != nil {
+		return nil, fmt.Errorf("read pulumi preview: %w", err)
+	}
+
+	c := &planComparer{info: info, providers: map[string]*tfbridge.ProviderInfo{}}
+
+	// The steps of the preview that create or keep custom resources, keyed by type and name.
+	type previewResource struct {
+		urn    string
+		inputs map[string]interface{}
+	}
+	previewResources := map[string][]previewResource{}
+	var previewKeys []string
+	for _, step := range pulumiPlan.Steps {
+		state := step.NewState
+		if state == nil || !state.Custom || strings.HasPrefix(state.Type, "pulumi:providers:") {
+			continue
+		}
+		name := step.URN[strings.LastIndex(step.URN, "::")+2:]
+		key := state.Type + "::" + name
+		if _, has := previewResources[key]; !has {
+			previewKeys = append(previewKeys, key)
+		}
+		previewResources[key] = append(previewResources[key], previewResource{
+			urn:    step.URN,
+			inputs: state.Inputs,
+		})
+	}
+
+	for _, change := range tfPlan.ResourceChanges {
+		if change.Mode != "managed" || change.Change.After == nil {
+			continue
+		}
+
+		var key addrs.InstanceKey = addrs.NoKey
+		switch index := change.Index.(type) {
+		case float64:
+			key = addrs.IntKey(int(index))
+		case string:
+			key = addrs.StringKey(index)
+		}
+		schemas, infos := c.resourceSchema(change.Type)
+		name := instanceName(change.Name, key, 0, InstanceNamingKey)
+		previewKey := c.pulumiType(change.Type) + "::" + name
+
+		// Resources with the same name in different modules have the same type and name in Pulumi too, so these
+		// are matched in order.
+		candidates := previewResources[previewKey]
+		if len(candidates) == 0 {
+			c.differences = append(c.differences, PlanDifference{Address: change.Address})
+			continue
+		}
+		resource := candidates[0]
+		previewResources[previewKey] = candidates[1:]
+		c.resources++
+
+		c.compareObject(change.Address, resource.urn, "", false,
+			change.Change.After, change.Change.AfterUnknown, change.Change.AfterSensitive,
+			resource.inputs, schemas, infos)
+	}
+
+	for _, key := range previewKeys {
+		for _, resource := range previewResources[key] {
+			c.differences = append(c.differences, PlanDifference{URN: resource.urn})
+		}
+	}
+
+	return &PlanComparison{Resources: c.resources, Differences: c.differences}, nil
+}
+
+type planComparer struct {
+	info        il.ProviderInfoSource
+	providers   map[string]*tfbridge.ProviderInfo
+	resources   int
+	differences []PlanDifference
+}
+
+// providerInfo returns the info of the provider of the given resource type, or nil if there isn't a mapping for it.
+func (c *planComparer) providerInfo(tfType string) *tfbridge.ProviderInfo {
+	provider := impliedProvider(tfType)
+	providerInfo, has := c.providers[provider]
+	if !has {
+		// Like TranslateState this doesn't fail for types without a mapping, they're compared without a schema.
+		providerInfo, _ = c.info.GetProviderInfo("", "", provider, "")
+		c.providers[provider] = providerInfo
+	}
+	return providerInfo
+}
+
+// pulumiType returns the token of the given resource type.
+func (c *planComparer) pulumiType(tfType string) string {
+	if providerInfo := c.providerInfo(tfType); providerInfo != nil {
+		if resourceInfo := providerInfo.Resources[tfType]; resourceInfo != nil {
+			return resourceInfo.Tok.String()
+		}
+	}
+	return impliedToken(tfType)
+}
+
+// resourceSchema returns the schemas and infos of the attributes of the given resource type.
+func (c *planComparer) resourceSchema(tfType string) (shim.SchemaMap, map[string]*tfbridge.SchemaInfo) {
+	providerInfo := c.providerInfo(tfType)
+	if providerInfo == nil {
+		return nil, nil
+	}
+	var schemas shim.SchemaMap
+	if resource := providerInfo.P.ResourcesMap().Get(tfType); resource != nil {
+		schemas = resource.Schema()
+	}
+	var infos map[string]*tfbridge.SchemaInfo
+	if resourceInfo := providerInfo.Resources[tfType]; resourceInfo != nil {
+		infos = resourceInfo.Fields
+	}
+	return schemas, infos
+}
+
+func (c *planComparer) difference(address, urn, path string, tf, pulumi interface{}) {
+	c.differences = append(c.differences, PlanDifference{
+		Address:   address,
+		URN:       urn,
+		Property:  path,
+		Terraform: tf,
+		Pulumi:    pulumi,
+	})
+}
+
+// isZero returns true for values Terraform plans for attributes that aren't set, which are left out of the inputs
+// of the converted resource rather than set to the zero value.
+func isZero(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case bool:
+		return !value
+	case float64:
+		return value == 0
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+// planElement returns the part of the after_unknown or after_sensitive of a plan for the attribute or element at
+// key, which mirror the structure of the value they're for.
+func planElement(marks interface{}, key interface{}) interface{} {
+	switch marks := marks.(type) {
+	case map[string]interface{}:
+		if key, ok := key.(string); ok {
+			return marks[key]
+		}
+	case []interface{}:
+		if key, ok := key.(int); ok && key < len(marks) {
+			return marks[key]
+		}
+	}
+	return nil
+}
+
+// hasMark returns true if any part of the after_unknown or after_sensitive of a value is true.
+func hasMark(marks interface{}) bool {
+	switch marks := marks.(type) {
+	case bool:
+		return marks
+	case []interface{}:
+		for _, mark := range marks {
+			if hasMark(mark) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, mark := range marks {
+			if hasMark(mark) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareObject compares the attributes of an object in a plan with the properties of the object they're converted
+// to. Keys are converted to Pulumi names from the schemas of the attributes, unless mapKeys is set for the keys of
+// map attributes, which are kept as they are.
+func (c *planComparer) compareObject(
+	address, urn, path string, mapKeys bool,
+	tf map[string]interface{}, unknown, sensitive interface{}, pulumi map[string]interface{},
+	schemas shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo,
+) {
+	keys := make([]string, 0, len(tf))
+	for key := range tf {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := tf[key]
+		valueUnknown, valueSensitive := planElement(unknown, key), planElement(sensitive, key)
+		if valueUnknown == true || valueSensitive == true {
+			continue
+		}
+
+		name, propertyPath := key, fmt.Sprintf("%s[%q]", path, key)
+		var schema shim.Schema
+		var info *tfbridge.SchemaInfo
+		if !mapKeys {
+			if schemas != nil {
+				schema = schemas.Get(key)
+				// Attributes the provider doesn't have in its schema, like timeouts, have no properties.
+				if schema == nil || schema.Computed() && !schema.Optional() && !schema.Required() {
+					continue
+				}
+			} else if key == "id" {
+				continue
+			}
+			if infos != nil {
+				info = infos[key]
+			}
+			name = tfbridge.TerraformToPulumiNameV2(key, schemas, infos)
+			propertyPath = name
+			if path != "" {
+				propertyPath = path + "." + name
+			}
+		}
+
+		// Blocks and lists that are limited to one item are converted to a single object.
+		maxItemsOne := schema != nil && schema.MaxItems() == 1
+		if info != nil && info.MaxItemsOne != nil {
+			maxItemsOne = *info.MaxItemsOne
+		}
+		if list, ok := value.([]interface{}); ok && maxItemsOne && len(list) == 1 {
+			value, valueUnknown, valueSensitive = list[0], planElement(valueUnknown, 0), planElement(valueSensitive, 0)
+		}
+
+		property, has := pulumi[name]
+		if !has || property == nil {
+			if !isZero(value) {
+				c.difference(address, urn, propertyPath, value, nil)
+			}
+			continue
+		}
+		c.compareValue(address, urn, propertyPath, value, valueUnknown, valueSensitive, property, schema, info)
+	}
+}
+
+// compareValue compares the value of an attribute in a plan with the value of the property it's converted to.
+func (c *planComparer) compareValue(
+	address, urn, path string, tf, unknown, sensitive, pulumi interface{},
+	schema shim.Schema, info *tfbridge.SchemaInfo,
+) {
+	// Secrets are shown as objects with a signature key, and their value if --show-secrets was passed.
+	if secret, ok := pulumi.(map[string]interface{}); ok {
+		if _, isSecret := secret["4dabf18193072939515e22adb298388d"]; isSecret {
+			value, has := secret["value"]
+			if !has {
+				return
+			}
+			pulumi = value
+		}
+	}
+	if pulumi == previewUnknown || pulumi == "[secret]" {
+		return
+	}
+
+	var elemSchema shim.Schema
+	var elemSchemas shim.SchemaMap
+	var elemInfo *tfbridge.SchemaInfo
+	var elemInfos map[string]*tfbridge.SchemaInfo
+	if schema != nil {
+		switch elem := schema.Elem().(type) {
+		case shim.Resource:
+			elemSchemas = elem.Schema()
+		case shim.Schema:
+			elemSchema = elem
+		}
+	}
+	if info != nil {
+		elemInfo, elemInfos = info.Elem, info.Fields
+	}
+
+	switch tf := tf.(type) {
+	case map[string]interface{}:
+		pulumiObject, ok := pulumi.(map[string]interface{})
+		if !ok {
+			c.difference(address, urn, path, tf, pulumi)
+			return
+		}
+		// Maps keep their keys, objects of nested blocks have their attributes renamed.
+		if schema == nil || schema.Type() == shim.TypeMap {
+			c.compareObject(address, urn, path, true, tf, unknown, sensitive, pulumiObject, nil, nil)
+		} else {
+			c.compareObject(address, urn, path, false, tf, unknown, sensitive, pulumiObject, elemSchemas, elemInfos)
+		}
+	case []interface{}:
+		pulumiList, ok := pulumi.([]interface{})
+		if !ok || len(pulumiList) != len(tf) {
+			c.difference(address, urn, path, tf, pulumi)
+			return
+		}
+		if schema != nil && schema.Type() == shim.TypeSet {
+			// Sets are unordered, so only their scalar elements are compared, as sorted strings.
+			tfElements, pulumiElements := make([]string, len(tf)), make([]string, len(pulumiList))
+			for i := range tf {
+				tfElements[i], pulumiElements[i] = fmt.Sprint(tf[i]), fmt.Sprint(pulumiList[i])
+			}
+			if elemSchemas == nil && !hasMark(unknown) && !hasMark(sensitive) {
+				sort.Strings(tfElements)
+				sort.Strings(pulumiElements)
+				if strings.Join(tfElements, "\n") != strings.Join(pulumiElements, "\n") {
+					c.difference(address, urn, path, tf, pulumi)
+				}
+			}
+			return
+		}
+		for i := range tf {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			elemUnknown, elemSensitive := planElement(unknown, i), planElement(sensitive, i)
+			if elemUnknown == true || elemSensitive == true {
+				continue
+			}
+			if object, ok := tf[i].(map[string]interface{}); ok && elemSchemas != nil {
+				pulumiObject, ok := pulumiList[i].(map[string]interface{})
+				if !ok {
+					c.difference(address, urn, elemPath, tf[i], pulumiList[i])
+					continue
+				}
+				c.compareObject(address, urn, elemPath, false,
+					object, elemUnknown, elemSensitive, pulumiObject, elemSchemas, elemInfos)
+				continue
+			}
+			c.compareValue(address, urn, elemPath, tf[i], elemUnknown, elemSensitive, pulumiList[i],
+				elemSchema, elemInfo)
+		}
+	default:
+		// Numbers and bools may be strings on one side, as Terraform converts between them as needed.
+		if fmt.Sprint(tf) != fmt.Sprint(pulumi) {
+			c.difference(address, urn, path, tf, pulumi)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparePlan(t *testing.T) {
+	t.Parallel()
+
+	plan := `{
+  "resource_changes": [
+    {
+      "address": "simple_resource.a_resource[0]",
+      "mode": "managed", "type": "simple_resource", "name": "a_resource", "index": 0,
+      "change": {
+        "actions": ["create"],
+        "after": {"input_one": "hello", "input_two": true, "result": null},
+        "after_unknown": {"result": true}
+      }
+    },
+    {
+      "address": "simple_resource.a_resource[1]",
+      "mode": "managed", "type": "simple_resource", "name": "a_resource", "index": 1,
+      "change": {
+        "actions": ["create"],
+        "after": {"input_one": "world", "input_two": false, "result": null},
+        "after_unknown": {"result": true}
+      }
+    },
+    {
+      "address": "module.child.simple_another_resource.other",
+      "mode": "managed", "type": "simple_another_resource", "name": "other",
+      "change": {
+        "actions": ["create"],
+        "after": {"input_one": "unchanged", "result": null},
+        "after_unknown": {"result": true}
+      }
+    },
+    {
+      "address": "data.simple_data_source.a_data_source",
+      "mode": "data", "type": "simple_data_source", "name": "a_data_source",
+      "change": {"actions": ["read"], "after": {"input_one": "ignored"}}
+    }
+  ]
+}`
+	preview := `{
+  "steps": [
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::prog::pulumi:pulumi:Stack::prog-dev",
+      "newState": {"type": "pulumi:pulumi:Stack", "custom": false, "inputs": {}}
+    },
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::prog::pulumi:providers:simple::default",
+      "newState": {"type": "pulumi:providers:simple", "custom": true, "inputs": {}}
+    },
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::prog::simple:index:resource::a_resource-0",
+      "newState": {"type": "simple:index:resource", "custom": true, "inputs": {
+        "inputOne": "hello", "inputTwo": true
+      }}
+    },
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::prog::simple:index:resource::a_resource-1",
+      "newState": {"type": "simple:index:resource", "custom": true, "inputs": {
+        "inputOne": "04da6b54-80e4-46f7-96ec-b56ff0331ba9"
+      }}
+    },
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::prog::simple:index:resource::extra",
+      "newState": {"type": "simple:index:resource", "custom": true, "inputs": {}}
+    }
+  ]
+}`
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	comparison, err := ComparePlan(il.NewMapperProviderInfoSource(mapper),
+		strings.NewReader(plan), strings.NewReader(preview))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, comparison.Resources)
+	assert.Equal(t, []PlanDifference{
+		{Address: "module.child.simple_another_resource.other"},
+		{URN: "urn:pulumi:dev::prog::simple:index:resource::extra"},
+	}, comparison.Differences)
+
+	t.Run("property differences", func(t *testing.T) {
+		t.Parallel()
+
+		preview := `{"steps": [{
+  "op": "create",
+  "urn": "urn:pulumi:dev::prog::simple:index:resource::a_resource-0",
+  "newState": {"type": "simple:index:resource", "custom": true, "inputs": {"inputOne": "goodbye"}}
+}]}`
+		comparison, err := ComparePlan(il.NewMapperProviderInfoSource(mapper),
+			strings.NewReader(plan), strings.NewReader(preview))
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, comparison.Resources)
+		assert.Equal(t, []PlanDifference{
+			{
+				Address:   "simple_resource.a_resource[0]",
+				URN:       "urn:pulumi:dev::prog::simple:index:resource::a_resource-0",
+				Property:  "inputOne",
+				Terraform: "hello",
+				Pulumi:    "goodbye",
+			},
+			{
+				Address:   "simple_resource.a_resource[0]",
+				URN:       "urn:pulumi:dev::prog::simple:index:resource::a_resource-0",
+				Property:  "inputTwo",
+				Terraform: true,
+			},
+			{Address: "simple_resource.a_resource[1]"},
+			{Address: "module.child.simple_another_resource.other"},
+		}, comparison.Differences)
+	})
+}
@@ -0,0 +1,241 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Programs often wait for eventually consistent APIs, like IAM, before using what they've created: with a time_sleep
+// resource that others depend on, or a local-exec provisioner that sleeps or retries until something is ready. In
+// terraform a resource isn't created until its provisioners have run, but the Command a provisioner is converted to
+// is a separate resource that nothing else depends on. Each wait is described in a comment above the resource and
+// counted in the statistics, and WithWaits converts them so that the converted program still waits.
+
+// WithWaits converts time_sleep resources that have no Pulumi mapping to a Command that sleeps for as long, and adds
+// the Command of a local-exec provisioner that sleeps or retries to the dependsOn of the resources that refer to the
+// resource it provisions.
+func WithWaits() TranslateOption {
+	return func(o *translateOptions) {
+		o.waits = true
+	}
+}
+
+var (
+	sleepCommand = regexp.MustCompile(`(?i)\b(sleep|start-sleep)\b|\btimeout\s+/t\b`)
+	// Shell until and while loops, PowerShell do-until and do-while loops, and the retry command run as a command
+	// of its own rather than, say, the --retry flag of curl.
+	retryCommand = regexp.MustCompile(`(?im)\b(until|while)\b[^;\n]*[;\n]\s*do\b|` +
+		`\bdo\s*\{[\s\S]*\}\s*(until|while)\s*\(|(^|["';&|(]|<<-?\w+)\s*retry\s`)
+)
+
+// provisionerWait returns "retry" or "sleep" if provisioner is a local-exec provisioner that runs when the resource
+// is created and whose command retries or sleeps, or "" otherwise.
+func provisionerWait(state *convertState, provisioner *configs.Provisioner) string {
+	if provisioner.Type != "local-exec" || provisioner.When == configs.ProvisionerWhenDestroy {
+		return ""
+	}
+	command, has := bodyContent(provisioner.Config).Attributes["command"]
+	if !has {
+		return ""
+	}
+	return commandWait(state.sourceCode(command.Expr.Range()))
+}
+
+// commandWait returns "retry" if the source of a local-exec command loops or retries, "sleep" if it sleeps, or ""
+// otherwise.
+func commandWait(source string) string {
+	switch {
+	case retryCommand.MatchString(source):
+		return "retry"
+	case sleepCommand.MatchString(source):
+		return "sleep"
+	}
+	return ""
+}
+
+// findWaitingProvisioners returns the index of the last provisioner that sleeps or retries of each resource in
+// items that has one, keyed by the resource's address. Resources using count or for_each aren't included, as
+// their provisioners aren't converted to a single Command.
+func findWaitingProvisioners(state *convertState, items terraformItems) map[string]int {
+	waiting := map[string]int{}
+	for _, item := range items {
+		resource := item.resource
+		if resource == nil || resource.Managed == nil || resource.Count != nil || resource.ForEach != nil {
+			continue
+		}
+		for idx, provisioner := range resource.Managed.Provisioners {
+			if provisionerWait(state, provisioner) != "" {
+				waiting[item.itemKey()] = idx
+			}
+		}
+	}
+	return waiting
+}
+
+// waitDependencies returns the names of the provisioner Commands that resource should depend on to wait for the
+// resources it refers to, if waits are being converted.
+func waitDependencies(state *convertState, scopes *scopes, resource *configs.Resource) []string {
+	if !state.waits {
+		return nil
+	}
+	item := terraformItem{resource: resource}
+	self := item.itemKey()
+	seen := map[string]bool{}
+	var names []string
+	for _, traversal := range item.references() {
+		key := referenceKey(traversal)
+		idx, has := state.waitingProvisioners[key]
+		if !has || key == self || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if root, has := scopes.roots[key]; has {
+			names = append(names, fmt.Sprintf("%sProvisioner%d", root.Name, idx))
+		}
+	}
+	return names
+}
+
+// sleepDurations returns the seconds a time_sleep resource waits for after it's created and before it's destroyed,
+// or false if they aren't literal durations or it has triggers, which other resources refer to its attributes for.
+func sleepDurations(resource *configs.Resource) (create, destroy int, ok bool) {
+	attributes := bodyContent(resource.Config).Attributes
+	if _, has := attributes["triggers"]; has {
+		return 0, 0, false
+	}
+	seconds := func(name string) (int, bool) {
+		attr, has := attributes[name]
+		if !has {
+			return 0, true
+		}
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+			return 0, false
+		}
+		duration, err := time.ParseDuration(value.AsString())
+		if err != nil || duration < 0 {
+			return 0, false
+		}
+		return int(math.Ceil(duration.Seconds())), true
+	}
+	create, createOk := seconds("create_duration")
+	destroy, destroyOk := seconds("destroy_duration")
+	return create, destroy, createOk && destroyOk
+}
+
+// sleepMapped returns true if a time_sleep resource is converted to a Pulumi type, from the provider mappings, a
+// rule or a resource hook, rather than to an unmapped type.
+func sleepMapped(state *convertState, resource *configs.Resource, root PathInfo) bool {
+	return root.ResourceInfo != nil || state.rules[resource.Type+"."+resource.Name].Type != "" ||
+		state.resourceHooks[resource.Type].token != ""
+}
+
+// convertSleep returns the commands a time_sleep resource is converted to, to sleep when it's created and destroyed,
+// or false if it isn't converted. destroy is "" if the resource doesn't sleep when it's destroyed.
+func convertSleep(state *convertState, resource *configs.Resource, root PathInfo) (create, destroy string, ok bool) {
+	if !state.waits || resource.Type != "time_sleep" || sleepMapped(state, resource, root) {
+		return "", "", false
+	}
+	createSeconds, destroySeconds, ok := sleepDurations(resource)
+	if !ok {
+		return "", "", false
+	}
+	create = "true"
+	if createSeconds > 0 {
+		create = fmt.Sprintf("sleep %d", createSeconds)
+	}
+	if destroySeconds > 0 {
+		destroy = fmt.Sprintf("sleep %d", destroySeconds)
+	}
+	return create, destroy, true
+}
+
+// waitComment returns comment lines to write above a converted resource that waits, saying how it waited in
+// terraform and whether the converted program still does. It returns nil if the resource doesn't wait.
+func waitComment(state *convertState, resource *configs.Resource, root PathInfo, converted bool) hclwrite.Tokens {
+	var lines []string
+	if resource.Type == "time_sleep" {
+		attributes := bodyContent(resource.Config).Attributes
+		var set []string
+		for _, name := range []string{"create_duration", "destroy_duration"} {
+			if attr, has := attributes[name]; has {
+				value := strings.TrimSpace(state.sourceCode(attr.Expr.Range()))
+				set = append(set, fmt.Sprintf("%s = %s", name, value))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("Wait: time_sleep (%s).", strings.Join(set, ", ")))
+		state.countWait("time_sleep")
+
+		switch {
+		case converted:
+			lines = append(lines, "Converted to a command that sleeps for as long.")
+		case !sleepMapped(state, resource, root):
+			lines = append(lines, "Resources that depend on this only wait for it if the time provider is available.")
+			state.appendDiagnostic(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Wait not converted",
+				Detail: fmt.Sprintf("%s.%s waits for eventual consistency but the time provider has no "+
+					"mapping, convert it to a command that sleeps to keep the wait", resource.Type, resource.Name),
+				Subject: resource.DeclRange.Ptr(),
+			})
+		}
+	}
+
+	if resource.Managed != nil {
+		for idx, provisioner := range resource.Managed.Provisioners {
+			kind := provisionerWait(state, provisioner)
+			if kind == "" {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("Wait: local-exec %s in provisioner %d.", kind, idx))
+			state.countWait("local-exec:" + kind)
+		}
+		if _, waits := state.waitingProvisioners[resource.Type+"."+resource.Name]; waits {
+			if state.waits {
+				lines = append(lines, "Resources that refer to this one depend on its provisioner so they still wait.")
+			} else {
+				lines = append(lines, "Resources that refer to this one don't wait for its provisioner, add it to "+
+					"their dependsOn to keep the wait.")
+				state.appendDiagnostic(&hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Wait not converted",
+					Detail: fmt.Sprintf("A provisioner of %s.%s waits for eventual consistency but resources that "+
+						"refer to it won't wait for the command it's converted to", resource.Type, resource.Name),
+					Subject: resource.DeclRange.Ptr(),
+				})
+			}
+		}
+	}
+
+	var tokens hclwrite.Tokens
+	for _, line := range lines {
+		tokens = append(tokens, &hclwrite.Token{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte("// " + line + "\n"),
+		})
+	}
+	return tokens
+}
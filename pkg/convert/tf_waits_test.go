@@ -0,0 +1,47 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandWait(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source string
+		wait   string
+	}{
+		{`"sleep 30"`, "sleep"},
+		{`"Start-Sleep -Seconds 30"`, "sleep"},
+		{`"timeout /t 30"`, "sleep"},
+		{`"until curl -sf http://localhost; do sleep 5; done"`, "retry"},
+		{"<<EOT\nwhile ! nc -z localhost 80\ndo\n  sleep 1\ndone\nEOT", "retry"},
+		{`"do { $ok = Test-Path x } until ($ok)"`, "retry"},
+		{`"retry -t 5 -- aws iam get-role --role-name app"`, "retry"},
+		{`"set -e; retry aws s3 ls"`, "retry"},
+		// Flags, arguments and names that mention retries aren't loops.
+		{`"curl --retry 3 https://example.com"`, ""},
+		{`"aws s3 cp x y --cli-read-timeout 10 # retries handled by the cli"`, ""},
+		{`"echo retry"`, ""},
+		{`"echo done"`, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.wait, commandWait(tt.source), tt.source)
+	}
+}
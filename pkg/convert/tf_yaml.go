@@ -0,0 +1,112 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PCL has no YAML functions, and neither does std. Kubernetes modules mostly decode manifests that are literals or
+// files next to the module, like yamldecode(file("${path.module}/crd.yaml")), so yamldecode is converted by
+// decoding the YAML at conversion time, the same way terraform does, and writing its value. yamlencode of a value
+// known at conversion time is converted to the string terraform would encode it to. Otherwise it's converted to
+// toJSON with a warning: JSON decodes to the same value, but it isn't the string terraform wrote, so properties that
+// compare strings, like user_data, ConfigMap data and helm values, show a diff on the first update.
+
+// yamlFileSource returns the path of the file read by expr, relative to the module, and its contents if it's a file
+// call with a literal path, or a literal path under path.module, that can be read at conversion time.
+func yamlFileSource(state *convertState, expr hclsyntax.Expression) (string, string, bool) {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "file" || len(call.Args) != 1 || call.ExpandFinal || state.sourceRoot == nil {
+		return "", "", false
+	}
+	relative, ok := templateFilePath(normalizePathSeparators(call.Args[0]))
+	if !ok {
+		return "", "", false
+	}
+	filename := filepath.Join(state.sourceDirectory, filepath.FromSlash(relative))
+	source, err := afero.ReadFile(state.sourceRoot, filename)
+	if err != nil {
+		return "", "", false
+	}
+	return relative, string(source), true
+}
+
+// evalYAMLCall returns the value of a yamldecode or yamlencode call of value, evaluated like terraform would.
+func evalYAMLCall(scopes *scopes, call *hclsyntax.FunctionCallExpr, value hclsyntax.Expression) (cty.Value, bool) {
+	evaluated := *call
+	evaluated.Args = []hclsyntax.Expression{value}
+	result, diags := scopes.EvalExpr(&evaluated)
+	if diags.HasErrors() || !result.IsWhollyKnown() || result.ContainsMarked() {
+		return cty.NilVal, false
+	}
+	return result, true
+}
+
+// convertYAMLFunction returns call, a yamldecode or yamlencode call, converted to its value if its argument is
+// known at conversion time, or is a YAML file that can be read at conversion time. It returns false otherwise, in
+// which case yamlencode is converted to toJSON and a warning is added.
+func convertYAMLFunction(
+	state *convertState, scopes *scopes, call *hclsyntax.FunctionCallExpr,
+) (hclwrite.Tokens, bool) {
+	if (call.Name != "yamldecode" && call.Name != "yamlencode") || len(call.Args) != 1 || call.ExpandFinal {
+		return nil, false
+	}
+	arg := call.Args[0]
+
+	if call.Name == "yamldecode" {
+		if relative, source, ok := yamlFileSource(state, arg); ok {
+			value, ok := evalYAMLCall(scopes, call, &hclsyntax.LiteralValueExpr{
+				Val:      cty.StringVal(source),
+				SrcRange: arg.Range(),
+			})
+			if !ok {
+				state.appendDiagnostic(&hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "YAML file not converted",
+					Detail:   fmt.Sprintf("Could not decode the YAML file %s", relative),
+					Subject:  arg.Range().Ptr(),
+				})
+				return nil, false
+			}
+			return hclwrite.TokensForValue(value), true
+		}
+	}
+
+	if isStaticExpr(scopes, arg, map[string]bool{}) {
+		if value, ok := evalYAMLCall(scopes, call, arg); ok {
+			return hclwrite.TokensForValue(value), true
+		}
+	}
+
+	if call.Name == "yamlencode" {
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "yamlencode converted to toJSON",
+			Detail: "The value isn't known when converting so it's encoded as JSON rather than YAML. This decodes to " +
+				"the same value, but properties that compare strings, like user_data, ConfigMap data and helm " +
+				"values, will show a diff from the YAML terraform wrote",
+			Subject: call.Range().Ptr(),
+		})
+	}
+	return nil, false
+}
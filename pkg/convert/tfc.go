@@ -0,0 +1,340 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pulumi/terraform/pkg/configs"
+	"github.com/zclconf/go-cty/cty"
+	ctyconvert "github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// TerraformCloudVariable is a variable of a Terraform Cloud workspace, either set on the workspace itself or by a
+// variable set applied to it.
+type TerraformCloudVariable struct {
+	Key string `json:"key"`
+	// The value of the variable, this is always empty for sensitive variables.
+	Value string `json:"value"`
+	// Either "terraform" for input variables or "env" for environment variables.
+	Category  string `json:"category"`
+	HCL       bool   `json:"hcl"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// A page of a Terraform Cloud API response, with just the fields we need.
+type tfcResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+type tfcResource struct {
+	ID         string                 `json:"id"`
+	Attributes TerraformCloudVariable `json:"attributes"`
+}
+
+// tfcGet requests path from the Terraform Cloud API at address and calls f with the data of each page of the
+// response.
+func tfcGet(
+	ctx context.Context, client *http.Client, address, token, path string, f func(json.RawMessage) error,
+) error {
+	next := strings.TrimSuffix(address, "/") + "/api/v2" + path
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/vnd.api+json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		var page tfcResponse
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("GET %s: %s", path, resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("GET %s: %w", path, err)
+		}
+		if err := f(page.Data); err != nil {
+			return fmt.Errorf("GET %s: %w", path, err)
+		}
+		next = page.Links.Next
+	}
+	return nil
+}
+
+// A Terraform Cloud workspace, as read by tfcReadWorkspace.
+type tfcWorkspace struct {
+	id           string
+	organization string
+}
+
+// tfcReadWorkspace reads the ID of the given "organization/workspace".
+func tfcReadWorkspace(
+	ctx context.Context, client *http.Client, address, token, workspace string,
+) (tfcWorkspace, error) {
+	organization, name, ok := strings.Cut(workspace, "/")
+	if !ok || organization == "" || name == "" || strings.Contains(name, "/") {
+		return tfcWorkspace{}, fmt.Errorf("invalid workspace %q, expected organization/workspace", workspace)
+	}
+
+	var ws tfcResource
+	err := tfcGet(ctx, client, address, token,
+		fmt.Sprintf("/organizations/%s/workspaces/%s", url.PathEscape(organization), url.PathEscape(name)),
+		func(data json.RawMessage) error { return json.Unmarshal(data, &ws) })
+	if err != nil {
+		return tfcWorkspace{}, fmt.Errorf("read workspace: %w", err)
+	}
+	return tfcWorkspace{id: ws.ID, organization: organization}, nil
+}
+
+// ReadTerraformCloudVariables reads the variables of the given "organization/workspace" from the Terraform Cloud (or
+// Enterprise) API at address. Variables set on the workspace take precedence over those from variable sets.
+func ReadTerraformCloudVariables(
+	ctx context.Context, client *http.Client, address, token, workspace string,
+) ([]TerraformCloudVariable, error) {
+	ws, err := tfcReadWorkspace(ctx, client, address, token, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]TerraformCloudVariable{}
+	addVariables := func(data json.RawMessage) error {
+		var resources []tfcResource
+		if err := json.Unmarshal(data, &resources); err != nil {
+			return err
+		}
+		for _, resource := range resources {
+			variables[resource.Attributes.Category+"."+resource.Attributes.Key] = resource.Attributes
+		}
+		return nil
+	}
+
+	var varsets []tfcResource
+	err = tfcGet(ctx, client, address, token, fmt.Sprintf("/workspaces/%s/varsets", url.PathEscape(ws.id)),
+		func(data json.RawMessage) error {
+			var page []tfcResource
+			if err := json.Unmarshal(data, &page); err != nil {
+				return err
+			}
+			varsets = append(varsets, page...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("read variable sets: %w", err)
+	}
+	for _, varset := range varsets {
+		err = tfcGet(ctx, client, address, token,
+			fmt.Sprintf("/varsets/%s/relationships/vars", url.PathEscape(varset.ID)), addVariables)
+		if err != nil {
+			return nil, fmt.Errorf("read variable set variables: %w", err)
+		}
+	}
+	// Read the workspace's own variables last so they override the variable sets.
+	err = tfcGet(ctx, client, address, token, fmt.Sprintf("/workspaces/%s/vars", url.PathEscape(ws.id)),
+		addVariables)
+	if err != nil {
+		return nil, fmt.Errorf("read workspace variables: %w", err)
+	}
+
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]TerraformCloudVariable, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, variables[key])
+	}
+	return result, nil
+}
+
+// ReadTerraformCloudRunTriggers reads the workspaces whose runs trigger runs of the given "organization/workspace",
+// returning each as "organization/workspace".
+func ReadTerraformCloudRunTriggers(
+	ctx context.Context, client *http.Client, address, token, workspace string,
+) ([]string, error) {
+	ws, err := tfcReadWorkspace(ctx, client, address, token, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	query := url.Values{"filter[run-trigger][type]": []string{"inbound"}}
+	err = tfcGet(ctx, client, address, token,
+		fmt.Sprintf("/workspaces/%s/run-triggers?%s", url.PathEscape(ws.id), query.Encode()),
+		func(data json.RawMessage) error {
+			var page []struct {
+				Attributes struct {
+					SourceableName string `json:"sourceable-name"`
+				} `json:"attributes"`
+			}
+			if err := json.Unmarshal(data, &page); err != nil {
+				return err
+			}
+			for _, trigger := range page {
+				// Run triggers can only be between workspaces of the same organization.
+				sources = append(sources, ws.organization+"/"+trigger.Attributes.SourceableName)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("read run triggers: %w", err)
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// WithTerraformCloudVariables writes a stack config file, Pulumi.<workspace>.yaml, setting the config of the
+// converted program from the variables of the given "organization/workspace". Sensitive and environment variables
+// can't be written to stack config, they're listed in a comment at the top of the file for users to set by hand.
+func WithTerraformCloudVariables(workspace string, variables []TerraformCloudVariable) TranslateOption {
+	return func(o *translateOptions) {
+		o.tfcWorkspace = workspace
+		o.tfcVariables = variables
+	}
+}
+
+// tfcStackConfig returns the name and contents of the stack config file for the Terraform Cloud variables in
+// options, which set the config of the given module.
+func tfcStackConfig(
+	state *convertState, scopes *scopes, module *configs.Module, projectName string, options translateOptions,
+) (string, []byte, error) {
+	stack := options.tfcWorkspace[strings.LastIndex(options.tfcWorkspace, "/")+1:]
+
+	config := &yaml.Node{Kind: yaml.MappingNode}
+	var sensitive, environment, unknown []string
+	for _, variable := range options.tfcVariables {
+		if variable.Category == "env" {
+			environment = append(environment, variable.Key)
+			continue
+		}
+		moduleVariable, has := module.Variables[variable.Key]
+		if !has {
+			unknown = append(unknown, variable.Key)
+			continue
+		}
+		name := scopes.roots["var."+variable.Key].Name
+		if variable.Sensitive {
+			sensitive = append(sensitive, name)
+			continue
+		}
+
+		var value interface{} = variable.Value
+		if variable.HCL {
+			val, diags := evaluateTerraformCloudVariable(variable.Value, moduleVariable.Type)
+			if diags.HasErrors() {
+				state.appendDiagnostic(&hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Failed to evaluate Terraform Cloud variable",
+					Detail: fmt.Sprintf("Could not evaluate the HCL value of %s in %s: %s",
+						variable.Key, options.tfcWorkspace, diags.Error()),
+				})
+				value = "TODO: " + variable.Value
+			} else {
+				// Simplest way to get a cty type into YAML is to roundtrip it through JSON
+				buffer, err := json.Marshal(ctyjson.SimpleJSONValue{Value: camelCaseObjectAttributes(val)})
+				if err != nil {
+					return "", nil, err
+				}
+				if err := json.Unmarshal(buffer, &value); err != nil {
+					return "", nil, err
+				}
+			}
+		}
+
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return "", nil, err
+		}
+		config.Content = append(config.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: projectName + ":" + name}, &valueNode)
+	}
+
+	comments := []string{fmt.Sprintf("Config for the %s stack, from the Terraform Cloud workspace %s.",
+		stack, options.tfcWorkspace)}
+	if len(sensitive) > 0 {
+		comments = append(comments, "Sensitive variables can't be read from Terraform Cloud, set them with:")
+		for _, name := range sensitive {
+			comments = append(comments, fmt.Sprintf("  pulumi config set --secret %s", name))
+		}
+		state.appendDiagnostic(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Sensitive Terraform Cloud variables",
+			Detail: fmt.Sprintf("The values of sensitive variables can't be read from Terraform Cloud, set %s "+
+				"with `pulumi config set --secret`", strings.Join(sensitive, ", ")),
+		})
+	}
+	if len(environment) > 0 {
+		comments = append(comments, "Environment variables aren't stack config, set them in the stack's "+
+			"environment instead: "+strings.Join(environment, ", "))
+	}
+	if len(unknown) > 0 {
+		comments = append(comments, "Variables that aren't used by the program: "+strings.Join(unknown, ", "))
+	}
+
+	document := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "config"}, config,
+		},
+		HeadComment: strings.Join(comments, "\n"),
+	}
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return "", nil, err
+	}
+	return "Pulumi." + stack + ".yaml", data, nil
+}
+
+// evaluateTerraformCloudVariable evaluates the value of a Terraform Cloud variable that's written in HCL, converting
+// it to the type of the variable so that maps aren't treated as objects.
+func evaluateTerraformCloudVariable(value string, typ cty.Type) (cty.Value, hcl.Diagnostics) {
+	expr, diags := hclsyntax.ParseExpression([]byte(value), "variable", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	converted, err := ctyconvert.Convert(val, typ)
+	if err != nil {
+		return cty.NilVal, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid variable value",
+			Detail:   err.Error(),
+		}}
+	}
+	return converted, nil
+}
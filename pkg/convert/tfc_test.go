@@ -0,0 +1,174 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bridgetesting "github.com/pulumi/pulumi-converter-terraform/pkg/testing"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/il"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTerraformCloudVariables(t *testing.T) {
+	t.Parallel()
+
+	responses := map[string]string{
+		"/api/v2/organizations/acme/workspaces/prod": `{"data": {"id": "ws-1"}}`,
+		"/api/v2/workspaces/ws-1/varsets":            `{"data": [{"id": "varset-1"}]}`,
+		"/api/v2/varsets/varset-1/relationships/vars": `{"data": [
+			{"id": "var-1", "attributes": {"key": "region", "value": "us-east-1", "category": "terraform"}},
+			{"id": "var-2", "attributes": {"key": "AWS_ACCESS_KEY_ID", "value": "", "category": "env",
+				"sensitive": true}}
+		]}`,
+		// The workspace variables are split over two pages.
+		"/api/v2/workspaces/ws-1/vars": `{"data": [
+			{"id": "var-3", "attributes": {"key": "region", "value": "us-west-2", "category": "terraform"}}
+		], "links": {"next": "NEXT/api/v2/workspaces/ws-1/vars/2"}}`,
+		"/api/v2/workspaces/ws-1/vars/2": `{"data": [
+			{"id": "var-4", "attributes": {"key": "tags", "value": "{ team = \"web\" }", "category": "terraform",
+				"hcl": true}}
+		]}`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		response, has := responses[r.URL.Path]
+		if !has {
+			http.NotFound(w, r)
+			return
+		}
+		_, err := w.Write([]byte(strings.ReplaceAll(response, "NEXT", server.URL)))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	variables, err := ReadTerraformCloudVariables(ctx, server.Client(), server.URL, "token", "acme/prod")
+	require.NoError(t, err)
+	assert.Equal(t, []TerraformCloudVariable{
+		{Key: "AWS_ACCESS_KEY_ID", Category: "env", Sensitive: true},
+		{Key: "region", Value: "us-west-2", Category: "terraform"},
+		{Key: "tags", Value: `{ team = "web" }`, Category: "terraform", HCL: true},
+	}, variables)
+
+	_, err = ReadTerraformCloudVariables(ctx, server.Client(), server.URL, "wrong", "acme/prod")
+	assert.ErrorContains(t, err, "401 Unauthorized")
+
+	_, err = ReadTerraformCloudVariables(ctx, server.Client(), server.URL, "token", "prod")
+	assert.ErrorContains(t, err, "expected organization/workspace")
+}
+
+func TestReadTerraformCloudRunTriggers(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response string
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/workspaces/app":
+			response = `{"data": {"id": "ws-1"}}`
+		case "/api/v2/workspaces/ws-1/run-triggers":
+			if r.URL.Query().Get("filter[run-trigger][type]") != "inbound" {
+				http.Error(w, "missing filter", http.StatusBadRequest)
+				return
+			}
+			response = `{"data": [
+				{"id": "rt-1", "attributes": {"sourceable-name": "networking"}},
+				{"id": "rt-2", "attributes": {"sourceable-name": "database"}}
+			]}`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_, err := w.Write([]byte(response))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	triggers, err := ReadTerraformCloudRunTriggers(ctx, server.Client(), server.URL, "token", "acme/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme/database", "acme/networking"}, triggers)
+
+	_, err = ReadTerraformCloudRunTriggers(ctx, server.Client(), server.URL, "token", "acme/missing")
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestTranslateWithTerraformCloudVariables(t *testing.T) {
+	t.Parallel()
+
+	src := afero.NewMemMapFs()
+	err := afero.WriteFile(src, "/prog/main.tf", []byte(`
+variable "region" {
+    type = string
+}
+
+variable "db_password" {
+    type = string
+    sensitive = true
+}
+
+variable "tags" {
+    type = map(string)
+}
+
+variable "server_config" {
+    type = object({ instance_type = string })
+}
+`), 0o600)
+	require.NoError(t, err)
+
+	variables := []TerraformCloudVariable{
+		{Key: "AWS_ACCESS_KEY_ID", Category: "env", Sensitive: true},
+		{Key: "db_password", Category: "terraform", Sensitive: true},
+		{Key: "old_setting", Value: "unused", Category: "terraform"},
+		{Key: "region", Value: "us-west-2", Category: "terraform"},
+		{Key: "server_config", Value: `{ instance_type = "t3.micro" }`, Category: "terraform", HCL: true},
+		{Key: "tags", Value: `{ cost_center = "web" }`, Category: "terraform", HCL: true},
+	}
+
+	mapper := &bridgetesting.TestFileMapper{Path: filepath.Join("testdata", "mappings")}
+	dst := afero.NewMemMapFs()
+	diagnostics := TranslateModule(src, "/prog", dst, il.NewMapperProviderInfoSource(mapper),
+		WithTerraformCloudVariables("acme/prod", variables))
+	require.False(t, diagnostics.HasErrors(), "%v", diagnostics)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "Sensitive Terraform Cloud variables", diagnostics[0].Summary)
+
+	config, err := afero.ReadFile(dst, "/Pulumi.prod.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `# Config for the prod stack, from the Terraform Cloud workspace acme/prod.
+# Sensitive variables can't be read from Terraform Cloud, set them with:
+#   pulumi config set --secret dbPassword
+# Environment variables aren't stack config, set them in the stack's environment instead: AWS_ACCESS_KEY_ID
+# Variables that aren't used by the program: old_setting
+config:
+    prog:region: us-west-2
+    prog:serverConfig:
+        instanceType: t3.micro
+    prog:tags:
+        cost_center: web
+`, string(config))
+}
@@ -77,6 +77,11 @@ func (l *testLoader) LoadPackage(pkg string, version *semver.Version) (*schema.P
 }
 
 func (l *testLoader) LoadPackageReference(pkg string, version *semver.Version) (schema.PackageReference, error) {
+	// Like the plugin loader the pulumi package, for stack references, is built in.
+	if pkg == "pulumi" {
+		return schema.DefaultPulumiPackage.Reference(), nil
+	}
+
 	schemaPackage, err := l.LoadPackage(pkg, version)
 	if err != nil {
 		return nil, err
@@ -84,6 +89,31 @@ func (l *testLoader) LoadPackageReference(pkg string, version *semver.Version) (
 	return schemaPackage.Reference(), nil
 }
 
+// programOptions are the options that the test programs of optional features are converted with, keyed by the name
+// of the program. Every program is converted with WithVerboseDiagnostics.
+var programOptions = map[string][]TranslateOption{
+	"module_layout":                   {WithModuleLayout("infra/{module}")},
+	"interface_only":                  {WithInterfaceOnly()},
+	"constant_folding":                {WithConstantFolding()},
+	"remove_unused":                   {WithRemoveUnused()},
+	"keep_variables":                  {WithRemoveUnused(), WithKeepVariables()},
+	"use_lockfile":                    {WithUseLockfile()},
+	"extract_files":                   {WithExtractFiles()},
+	"partial_kubeconfig_template":     {WithKubeconfigTemplate()},
+	"order_references_single_file":    {WithSingleFile()},
+	"inline_functions":                {WithInlineFunctions(FunctionCategoryEncoding, FunctionCategoryNumeric)},
+	"waits_converted":                 {WithWaits()},
+	"stable_names_config":             {WithStableNames(NameStabilizationConfig)},
+	"local_naming_output_suffix":      {WithLocalNaming(LocalNamingOutputSuffix)},
+	"local_naming_underscore":         {WithLocalNaming(LocalNamingUnderscore)},
+	"local_naming_original":           {WithLocalNaming(LocalNamingOriginal)},
+	"bootstrap_project":               {WithBootstrapProject()},
+	"bootstrap_project_used":          {WithBootstrapProject()},
+	"bootstrap_project_local_backend": {WithBootstrapProject()},
+	"stable_names_random":             {WithStableNames(NameStabilizationRandom)},
+	"folder_uploads_synced":           {WithSyncedFolders()},
+}
+
 // TestTranslate runs through all the folders in testdata (except for "schemas" and "mappings") and tries to
 // convert all the .tf files in that folder into PCL.
 //
@@ -143,6 +173,11 @@ func TestTranslate(t *testing.T) {
 						return err
 					}
 
+					// The expected results of a program aren't part of its source.
+					if d.IsDir() && path == filepath.Join(srcDirectory, "pcl") {
+						return filepath.SkipDir
+					}
+
 					if !d.IsDir() && (strings.HasSuffix(d.Name(), suffix) || suffix == "") {
 						src, err := os.Open(path)
 						if err != nil {
@@ -179,14 +214,18 @@ func TestTranslate(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			copy(tt.path, hclPath, ".tf")
+			// Programs may have other files next to their .tf files, like templates, YAML or policy files.
+			copy(tt.path, hclPath, "")
 			copy(filepath.Join(testDir, "modules"), modulePath, ".tf")
 
 			osFs := afero.NewOsFs()
 			pclFs := afero.NewBasePathFs(osFs, pclPath)
 
 			providerInfoSource := il.NewMapperProviderInfoSource(mapper)
-			diagnostics := TranslateModule(osFs, hclPath, pclFs, providerInfoSource)
+			// Snapshot every diagnostic so we see each construct we fail to convert.
+			options := []TranslateOption{WithVerboseDiagnostics(), WithSchemaLoader(loader)}
+			options = append(options, programOptions[tt.name]...)
+			diagnostics := TranslateModule(osFs, hclPath, pclFs, providerInfoSource, options...)
 
 			// If PULUMI_ACCEPT is set then clear the PCL folder and copy the generated files out. Note we
 			// copy these out even if this returned errors, this makes it easy in the local dev loop to see
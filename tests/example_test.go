@@ -97,11 +97,12 @@ func (ss stringSet) Equal(other stringSet) bool {
 const (
 	csharp     = "c#"
 	golang     = "go"
+	java       = "java"
 	python     = "python"
 	typescript = "typescript"
 )
 
-var allLanguages = newStringSet(csharp, golang, python, typescript)
+var allLanguages = newStringSet(csharp, golang, java, python, typescript)
 
 func TestExample(t *testing.T) {
 	t.Parallel()
@@ -111,6 +112,7 @@ func TestExample(t *testing.T) {
 	languages := []string{
 		csharp,
 		golang,
+		java,
 		python,
 		typescript,
 	}